@@ -0,0 +1,178 @@
+// Command importfoods fills the food catalogue from a USDA FoodData Central download or an Open Food Facts dump.
+//
+//	importfoods -format usda -path ./FoodData_Central_csv_2024-10-31
+//	importfoods -format off -path openfoodfacts-products.jsonl.gz -errors rejected.txt
+//
+// The FoodData Central files are merged on fdc_id as they are read, so each must list its rows by ascending fdc_id.
+// Food items are upserted by source ID and barcode, so a newer dump can be imported over an older one. The
+// database is configured like the app, through PG_URL, PG_POOL_MAX and LOG_LEVEL.
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/terrnit/rebound/backend/config"
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+	"github.com/terrnit/rebound/backend/pkg/fooddata"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+	"github.com/terrnit/rebound/backend/pkg/postgres"
+)
+
+func main() {
+	format := flag.String("format", "", "format of the dump: usda for a FoodData Central CSV download, off for an Open Food Facts JSON lines dump")
+	path := flag.String("path", "", "directory of the FoodData Central CSV files, or Open Food Facts dump file, gzipped or not, - for stdin")
+	dataTypes := flag.String("data-types", strings.Join(fooddata.DefaultDataTypes, ","), "comma separated FoodData Central data types to import")
+	batchSize := flag.Int("batch-size", 1000, "number of food items written per transaction")
+	progress := flag.Duration("progress", 10*time.Second, "interval between progress reports")
+	errorsPath := flag.String("errors", "", "file to list rejected records in, instead of logging them at debug level")
+	flag.Parse()
+
+	if *path == "" || (*format != "usda" && *format != "off") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.NewImportConfig()
+	if err != nil {
+		log.Fatalf("Config error: %s", err)
+	}
+	l := logger.New(cfg.Log.Level,
+		logger.Format(cfg.Log.Format),
+		logger.DebugSampling(cfg.Log.DebugSampling),
+	)
+
+	var types []string
+	for _, t := range strings.Split(*dataTypes, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+
+	if err := run(l, cfg, *format, *path, types, *batchSize, *progress, *errorsPath); err != nil {
+		l.Fatal("importfoods - run", "error", err)
+	}
+}
+
+func run(l logger.Interface, cfg *config.Import, format, path string, dataTypes []string, batchSize int, progress time.Duration, errorsPath string) error {
+	pg, err := postgres.New(cfg.PG.URL, postgres.MaxPoolSize(cfg.PG.PoolMax))
+	if err != nil {
+		return err
+	}
+	defer pg.Close()
+
+	r := &reporter{log: l, interval: progress, start: time.Now()}
+	if errorsPath != "" {
+		f, err := os.Create(errorsPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r.errors = bufio.NewWriter(f)
+		defer r.errors.Flush()
+	}
+
+	// Interrupting stops the import between batches; the batches written are committed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	uc := usecase.NewFoodImportUseCase(repository.NewFoodImportRepository(pg), batchSize)
+	var result entity.FoodImportResult
+	switch format {
+	case "usda":
+		result, err = uc.ImportUSDA(ctx, path, dataTypes, r)
+	case "off":
+		dump, openErr := openDump(path)
+		if openErr != nil {
+			return openErr
+		}
+		defer dump.Close()
+		result, err = uc.ImportOpenFoodFacts(ctx, dump, r)
+	}
+
+	r.report("importfoods - done", result)
+	return err
+}
+
+// openDump opens an Open Food Facts dump, decompressing it when its name ends in .gz
+func openDump(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &gzipFile{Reader: gz, file: f}, nil
+}
+
+// gzipFile closes the file under a gzip reader along with it
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+// reporter logs the progress of an import and lists the records it rejects
+type reporter struct {
+	log      logger.Interface
+	interval time.Duration
+	start    time.Time
+	last     time.Time
+	errors   *bufio.Writer
+}
+
+// Progress implements usecase.FoodImportReporter
+func (r *reporter) Progress(result entity.FoodImportResult) {
+	if time.Since(r.last) < r.interval {
+		return
+	}
+	r.last = time.Now()
+	r.report("importfoods - progress", result)
+}
+
+// Reject implements usecase.FoodImportReporter
+func (r *reporter) Reject(err error) {
+	if r.errors != nil {
+		fmt.Fprintln(r.errors, err)
+		return
+	}
+	r.log.Debug("importfoods - rejected", "reason", err.Error())
+}
+
+func (r *reporter) report(message string, result entity.FoodImportResult) {
+	elapsed := time.Since(r.start)
+	r.log.Info(message,
+		"read", result.Read,
+		"inserted", result.Inserted,
+		"updated", result.Updated,
+		"skipped", result.Skipped,
+		"rejected", result.Rejected,
+		"elapsed", elapsed.Round(time.Second).String(),
+		"per_second", int64(float64(result.Read)/max(elapsed.Seconds(), 1)),
+	)
+}
@@ -2,19 +2,37 @@ package config
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v11"
+	"github.com/terrnit/rebound/backend/pkg/ratelimit"
 )
 
 type (
 	// Config -.
 	Config struct {
-		App  App
-		HTTP HTTP
-		Log  Log
-		PG   PG
-		// Metrics Metrics
-		Swagger Swagger
+		App           App
+		HTTP          HTTP
+		Log           Log
+		PG            PG
+		Idempotency   Idempotency
+		Trash         Trash
+		Export        Export
+		Upload        Upload
+		ProgressPhoto ProgressPhoto
+		S3            S3
+		FoodCatalog   FoodCatalog
+		Account       Account
+		Metrics       Metrics
+		Tracing       Tracing
+		RateLimit     RateLimit
+		Login         Login
+		Auth          Auth
+		OAuth         OAuth
+		OIDC          OIDC
+		Swagger       Swagger
 	}
 
 	// App -.
@@ -25,13 +43,16 @@ type (
 
 	// HTTP -.
 	HTTP struct {
-		Port           string `env:"HTTP_PORT,required"`
-		UsePreforkMode bool   `env:"HTTP_USE_PREFORK_MODE" envDefault:"false"`
+		Port           string        `env:"HTTP_PORT,required"`
+		UsePreforkMode bool          `env:"HTTP_USE_PREFORK_MODE" envDefault:"false"`
+		DrainDelay     time.Duration `env:"HTTP_DRAIN_DELAY" envDefault:"5s"`
 	}
 
 	// Log -.
 	Log struct {
-		Level string `env:"LOG_LEVEL,required"`
+		Level         string `env:"LOG_LEVEL,required"`
+		Format        string `env:"LOG_FORMAT" envDefault:"json"`
+		DebugSampling uint32 `env:"LOG_DEBUG_SAMPLING" envDefault:"0"`
 	}
 
 	// PG -.
@@ -40,17 +61,166 @@ type (
 		URL     string `env:"PG_URL,required"`
 	}
 
-	// // Metrics -.
-	// Metrics struct {
-	// 	Enabled bool `env:"METRICS_ENABLED" envDefault:"true"`
-	// }
+	// Idempotency -.
+	Idempotency struct {
+		TTL           time.Duration `env:"IDEMPOTENCY_TTL" envDefault:"24h"`
+		PurgeInterval time.Duration `env:"IDEMPOTENCY_PURGE_INTERVAL" envDefault:"1h"`
+	}
+
+	// Trash -.
+	Trash struct {
+		Retention     time.Duration `env:"TRASH_RETENTION" envDefault:"720h"`
+		PurgeInterval time.Duration `env:"TRASH_PURGE_INTERVAL" envDefault:"1h"`
+	}
+
+	// Export -.
+	Export struct {
+		Dir           string        `env:"EXPORT_DIR" envDefault:"./data/exports"`
+		LinkSecret    string        `env:"EXPORT_LINK_SECRET,required"`
+		TTL           time.Duration `env:"EXPORT_TTL" envDefault:"72h"`
+		PollInterval  time.Duration `env:"EXPORT_POLL_INTERVAL" envDefault:"10s"`
+		StaleAfter    time.Duration `env:"EXPORT_STALE_AFTER" envDefault:"1h"`
+		PurgeInterval time.Duration `env:"EXPORT_PURGE_INTERVAL" envDefault:"1h"`
+	}
+
+	// Upload -.
+	Upload struct {
+		// Storage is local, files in Dir, or s3, objects in the S3 bucket
+		Storage       string `env:"UPLOAD_STORAGE" envDefault:"local"`
+		Dir           string `env:"UPLOAD_DIR" envDefault:"./data/uploads"`
+		MaxBytes      int64  `env:"UPLOAD_MAX_BYTES" envDefault:"10485760"`
+		MaxDimension  int    `env:"UPLOAD_MAX_DIMENSION" envDefault:"2048"`
+		ThumbnailSize int    `env:"UPLOAD_THUMBNAIL_SIZE" envDefault:"256"`
+		// PublicURL is where clients reach GET /api/uploads, image URLs written to records start with it
+		PublicURL     string        `env:"UPLOAD_PUBLIC_URL" envDefault:"http://localhost:8080/api/uploads"`
+		SignedURLTTL  time.Duration `env:"UPLOAD_SIGNED_URL_TTL" envDefault:"1h"`
+		PurgeInterval time.Duration `env:"UPLOAD_PURGE_INTERVAL" envDefault:"1h"`
+	}
+
+	// ProgressPhoto -.
+	ProgressPhoto struct {
+		// Photos are stored in the storage of uploads, under Dir or under S3Prefix in the S3 bucket.
+		// Size limits are those of uploads.
+		Dir           string        `env:"PROGRESS_PHOTO_DIR" envDefault:"./data/progress-photos"`
+		S3Prefix      string        `env:"PROGRESS_PHOTO_S3_PREFIX" envDefault:"progress-photos/"`
+		LinkSecret    string        `env:"PROGRESS_PHOTO_LINK_SECRET,required"`
+		LinkTTL       time.Duration `env:"PROGRESS_PHOTO_LINK_TTL" envDefault:"15m"`
+		PurgeInterval time.Duration `env:"PROGRESS_PHOTO_PURGE_INTERVAL" envDefault:"1h"`
+	}
+
+	// S3 -.
+	S3 struct {
+		Endpoint        string `env:"S3_ENDPOINT"`
+		Region          string `env:"S3_REGION" envDefault:"us-east-1"`
+		Bucket          string `env:"S3_BUCKET"`
+		AccessKeyID     string `env:"S3_ACCESS_KEY_ID"`
+		SecretAccessKey string `env:"S3_SECRET_ACCESS_KEY"`
+		Prefix          string `env:"S3_PREFIX"`
+		PathStyle       bool   `env:"S3_PATH_STYLE" envDefault:"false"`
+	}
+
+	// FoodCatalog -.
+	FoodCatalog struct {
+		// Provider is openfoodfacts, static, products read from File, or none to look barcodes up locally only
+		Provider  string        `env:"FOOD_CATALOG" envDefault:"openfoodfacts"`
+		URL       string        `env:"FOOD_CATALOG_URL" envDefault:"https://world.openfoodfacts.org"`
+		UserAgent string        `env:"FOOD_CATALOG_USER_AGENT" envDefault:"Rebound/1.0"`
+		Timeout   time.Duration `env:"FOOD_CATALOG_TIMEOUT" envDefault:"5s"`
+		// File holds products in the JSON lines format of the Open Food Facts data dumps
+		File string `env:"FOOD_CATALOG_FILE"`
+	}
+
+	// Account -.
+	Account struct {
+		DeletionGracePeriod time.Duration `env:"ACCOUNT_DELETION_GRACE_PERIOD" envDefault:"720h"`
+		ErasureInterval     time.Duration `env:"ACCOUNT_ERASURE_INTERVAL" envDefault:"1h"`
+	}
+
+	// Metrics -.
+	Metrics struct {
+		Enabled bool `env:"METRICS_ENABLED" envDefault:"true"`
+	}
+
+	// RateLimit -.
+	// Limits are written as count/period, such as 600/1m, 0 is unlimited.
+	RateLimit struct {
+		Store         string          `env:"RATE_LIMIT_STORE" envDefault:"memory"`
+		APIPerIP      ratelimit.Limit `env:"RATE_LIMIT_API_PER_IP" envDefault:"600/1m"`
+		APIPerUser    ratelimit.Limit `env:"RATE_LIMIT_API_PER_USER" envDefault:"1200/1m"`
+		AuthPerIP     ratelimit.Limit `env:"RATE_LIMIT_AUTH_PER_IP" envDefault:"10/1m"`
+		AuthPerUser   ratelimit.Limit `env:"RATE_LIMIT_AUTH_PER_USER" envDefault:"0"`
+		IdleTTL       time.Duration   `env:"RATE_LIMIT_IDLE_TTL" envDefault:"1h"`
+		PurgeInterval time.Duration   `env:"RATE_LIMIT_PURGE_INTERVAL" envDefault:"10m"`
+	}
+
+	// Login -.
+	Login struct {
+		LockoutThreshold int           `env:"LOGIN_LOCKOUT_THRESHOLD" envDefault:"5"`
+		LockoutBaseDelay time.Duration `env:"LOGIN_LOCKOUT_BASE_DELAY" envDefault:"1m"`
+		LockoutMaxDelay  time.Duration `env:"LOGIN_LOCKOUT_MAX_DELAY" envDefault:"1h"`
+	}
+
+	// Auth -.
+	Auth struct {
+		JWTSecret         string        `env:"AUTH_JWT_SECRET,required"`
+		AccessTokenTTL    time.Duration `env:"AUTH_ACCESS_TOKEN_TTL" envDefault:"15m"`
+		RefreshTokenTTL   time.Duration `env:"AUTH_REFRESH_TOKEN_TTL" envDefault:"720h"`
+		ChallengeTTL      time.Duration `env:"AUTH_CHALLENGE_TTL" envDefault:"5m"`
+		TOTPIssuer        string        `env:"AUTH_TOTP_ISSUER" envDefault:"Rebound"`
+		TOTPEncryptionKey string        `env:"AUTH_TOTP_ENCRYPTION_KEY,required"`
+		MaxPersonalTokens int           `env:"AUTH_MAX_PERSONAL_TOKENS" envDefault:"20"`
+	}
+
+	// OAuth -.
+	OAuth struct {
+		CodeTTL         time.Duration `env:"OAUTH_CODE_TTL" envDefault:"1m"`
+		AccessTokenTTL  time.Duration `env:"OAUTH_ACCESS_TOKEN_TTL" envDefault:"1h"`
+		RefreshTokenTTL time.Duration `env:"OAUTH_REFRESH_TOKEN_TTL" envDefault:"2160h"`
+		PurgeInterval   time.Duration `env:"OAUTH_PURGE_INTERVAL" envDefault:"1h"`
+	}
+
+	// OIDC -.
+	OIDC struct {
+		// Providers names the sign in providers, each configured by OIDC_<NAME>_* variables
+		Providers     []string      `env:"OIDC_PROVIDERS" envSeparator:","`
+		StateTTL      time.Duration `env:"OIDC_STATE_TTL" envDefault:"10m"`
+		PurgeInterval time.Duration `env:"OIDC_PURGE_INTERVAL" envDefault:"1h"`
+		Clients       map[string]OIDCClient
+	}
+
+	// OIDCClient -.
+	OIDCClient struct {
+		Issuer       string `env:"ISSUER,required"`
+		ClientID     string `env:"CLIENT_ID,required"`
+		ClientSecret string `env:"CLIENT_SECRET,required"`
+		// RedirectURL is the app page the provider sends users back to, which posts the code to the API
+		RedirectURL string   `env:"REDIRECT_URL,required"`
+		Scopes      []string `env:"SCOPES" envSeparator:"," envDefault:"email,profile"`
+	}
+
+	// Tracing -.
+	Tracing struct {
+		Exporter     string  `env:"TRACING_EXPORTER" envDefault:"none"`
+		OTLPEndpoint string  `env:"TRACING_OTLP_ENDPOINT" envDefault:"localhost:4318"`
+		OTLPInsecure bool    `env:"TRACING_OTLP_INSECURE" envDefault:"false"`
+		SampleRatio  float64 `env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
+	}
 
 	// Swagger -.
 	Swagger struct {
 		Enabled bool `env:"SWAGGER_ENABLED" envDefault:"false"`
 	}
+
+	// Import is the config of the importfoods command, which only needs the database
+	Import struct {
+		Log Log
+		PG  PG
+	}
 )
 
+// providerName is the form of OIDC provider names, which appear in the API paths and env variable names
+var providerName = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
 // NewConfig returns app config.
 func NewConfig() (*Config, error) {
 	cfg := &Config{}
@@ -58,5 +228,28 @@ func NewConfig() (*Config, error) {
 		return nil, fmt.Errorf("config error: %w", err)
 	}
 
+	cfg.OIDC.Clients = make(map[string]OIDCClient, len(cfg.OIDC.Providers))
+	for _, name := range cfg.OIDC.Providers {
+		name = strings.TrimSpace(name)
+		if !providerName.MatchString(name) {
+			return nil, fmt.Errorf("config error: invalid OIDC provider name %q", name)
+		}
+		var client OIDCClient
+		if err := env.ParseWithOptions(&client, env.Options{Prefix: "OIDC_" + strings.ToUpper(name) + "_"}); err != nil {
+			return nil, fmt.Errorf("config error: %w", err)
+		}
+		cfg.OIDC.Clients[name] = client
+	}
+
+	return cfg, nil
+}
+
+// NewImportConfig returns the config of the importfoods command.
+func NewImportConfig() (*Import, error) {
+	cfg := &Import{}
+	if err := env.Parse(cfg); err != nil {
+		return nil, fmt.Errorf("config error: %w", err)
+	}
+
 	return cfg, nil
 }
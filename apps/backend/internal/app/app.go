@@ -2,60 +2,317 @@
 package app
 
 import (
-	"fmt"
+	"context"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/terrnit/rebound/backend/config"
 	"github.com/terrnit/rebound/backend/internal/controller/router"
+	"github.com/terrnit/rebound/backend/internal/metrics"
 	repo "github.com/terrnit/rebound/backend/internal/repository"
 	"github.com/terrnit/rebound/backend/internal/usecase"
+	"github.com/terrnit/rebound/backend/pkg/health"
 	"github.com/terrnit/rebound/backend/pkg/httpserver"
 	"github.com/terrnit/rebound/backend/pkg/logger"
+	"github.com/terrnit/rebound/backend/pkg/oidc"
+	"github.com/terrnit/rebound/backend/pkg/openfoodfacts"
 	pgpkg "github.com/terrnit/rebound/backend/pkg/postgres"
+	"github.com/terrnit/rebound/backend/pkg/ratelimit"
+	"github.com/terrnit/rebound/backend/pkg/secretbox"
+	"github.com/terrnit/rebound/backend/pkg/storage"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
 )
 
 // Run creates objects via constructors.
 func Run(cfg *config.Config) {
-	l := logger.New(cfg.Log.Level)
+	l := logger.New(cfg.Log.Level,
+		logger.Format(cfg.Log.Format),
+		logger.DebugSampling(cfg.Log.DebugSampling),
+	)
+
+	// Tracing
+	tr, err := tracing.New(cfg.App.Name, cfg.App.Version,
+		tracing.Exporter(cfg.Tracing.Exporter),
+		tracing.Endpoint(cfg.Tracing.OTLPEndpoint),
+		tracing.Insecure(cfg.Tracing.OTLPInsecure),
+		tracing.SampleRatio(cfg.Tracing.SampleRatio),
+	)
+	if err != nil {
+		l.Fatal("app - Run - tracing.New", "error", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := tr.Shutdown(ctx); err != nil {
+			l.Error("app - Run - tracing.Shutdown", "error", err)
+		}
+	}()
 
 	// Repository
 	pg, err := pgpkg.New(cfg.PG.URL, pgpkg.MaxPoolSize(cfg.PG.PoolMax))
 	if err != nil {
-		l.Fatal(fmt.Errorf("app - Run - postgres.New: %w", err))
+		l.Fatal("app - Run - postgres.New", "error", err)
 	}
 	defer pg.Close()
 
 	// Initialize repositories
 	foodItemRepo := repo.NewFoodItemRepository(pg)
 	userRepo := repo.NewUserRepository(pg)
-	// exerciseRepo := repo.NewExerciseRepository(pg)
-	// mealRepo := repo.NewMealRepository(pg)
+	exerciseRepo := repo.NewExerciseRepository(pg)
+	mealRepo := repo.NewMealRepository(pg)
 	nutritionRepo := repo.NewNutritionRepository(pg)
-	// workoutPlanRepo := repo.NewWorkoutPlanRepository(pg)
+	workoutPlanRepo := repo.NewWorkoutPlanRepository(pg)
 	workoutSessionRepo := repo.NewWorkoutSessionRepository(pg)
+	syncRepo := repo.NewSyncRepository(pg)
+	trashRepo := repo.NewTrashRepository(pg)
+	roleRepo := repo.NewRoleRepository(pg)
+	auditRepo := repo.NewAuditLogRepository(pg)
+	exportRepo := repo.NewDataExportRepository(pg)
+	authTokenRepo := repo.NewAuthTokenRepository(pg)
+	twoFactorRepo := repo.NewTwoFactorRepository(pg)
+	personalTokenRepo := repo.NewPersonalTokenRepository(pg)
+	oauthRepo := repo.NewOAuthRepository(pg)
+	identityRepo := repo.NewIdentityRepository(pg)
+	uploadRepo := repo.NewUploadRepository(pg)
+	progressPhotoRepo := repo.NewProgressPhotoRepository(pg)
+	coachRepo := repo.NewCoachRepository(pg)
+	idempotencyRepo := repo.NewIdempotencyRepository(pg)
+
+	// File storage
+	exportFiles, err := storage.NewLocal(cfg.Export.Dir)
+	if err != nil {
+		l.Fatal("app - Run - storage.NewLocal", "error", err)
+	}
+	uploadFiles, err := newFileStorage(cfg, cfg.Upload.Dir, cfg.S3.Prefix)
+	if err != nil {
+		l.Fatal("app - Run - newFileStorage", "error", err)
+	}
+	progressPhotoFiles, err := newFileStorage(cfg, cfg.ProgressPhoto.Dir, cfg.ProgressPhoto.S3Prefix)
+	if err != nil {
+		l.Fatal("app - Run - newFileStorage", "error", err)
+	}
+
+	// TOTP secrets are encrypted at rest
+	totpSecrets, err := secretbox.New(cfg.Auth.TOTPEncryptionKey)
+	if err != nil {
+		l.Fatal("app - Run - secretbox.New", "error", err)
+	}
+
+	// Rate limiting
+	var rateLimitStore ratelimit.Store
+	switch cfg.RateLimit.Store {
+	case "memory":
+		rateLimitStore = ratelimit.NewMemory()
+	case "postgres":
+		rateLimitStore = ratelimit.NewPostgres(pg)
+	default:
+		l.Fatal("app - Run - unknown rate limit store", "store", cfg.RateLimit.Store)
+	}
+
+	// Readiness checks
+	healthChecks := health.New()
+	healthChecks.Register("postgres", pg.Pool.Ping)
+	healthChecks.Register("export_storage", exportFiles.Check)
+	healthChecks.Register("upload_storage", uploadFiles.Check)
+	healthChecks.Register("progress_photo_storage", progressPhotoFiles.Check)
+	if version, err := latestMigration(_migrationsDir); err != nil {
+		l.Warn("app - Run - latestMigration, the migration check is disabled", "error", err)
+	} else {
+		healthChecks.Register("migrations", migrationCheck(pg, version))
+	}
+
+	// Metrics
+	var registry *prometheus.Registry
+	if cfg.Metrics.Enabled {
+		registry = prometheus.NewRegistry()
+		registry.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+			pg.Collector(),
+		)
+		if err = metrics.Register(registry); err != nil {
+			l.Fatal("app - Run - metrics.Register", "error", err)
+		}
+	}
+
+	// External food catalog for barcodes missing locally
+	var foodCatalog usecase.FoodCatalog
+	switch cfg.FoodCatalog.Provider {
+	case "openfoodfacts":
+		foodCatalog = openfoodfacts.New(openfoodfacts.Config{
+			BaseURL:    cfg.FoodCatalog.URL,
+			UserAgent:  cfg.FoodCatalog.UserAgent,
+			HTTPClient: &http.Client{Timeout: cfg.FoodCatalog.Timeout},
+		})
+	case "static":
+		foodCatalog, err = openfoodfacts.LoadStatic(cfg.FoodCatalog.File)
+		if err != nil {
+			l.Fatal("app - Run - openfoodfacts.LoadStatic", "error", err)
+		}
+	case "none":
+	default:
+		l.Fatal("app - Run - unknown food catalog", "catalog", cfg.FoodCatalog.Provider)
+	}
 
 	// Initialize use cases
-	foodItemUC := usecase.NewFoodItemUseCase(foodItemRepo, *&usecase.Config{MaxPageSize: 100, DefaultPageSize: 10})
-	userUC := usecase.NewUserUseCase(userRepo, *&usecase.UserConfig{MaxPageSize: 100, DefaultPageSize: 10})
+	foodItemUC := usecase.NewFoodItemUseCase(foodItemRepo, roleRepo, foodCatalog, auditRepo, *&usecase.Config{MaxPageSize: 100, DefaultPageSize: 10})
+	userUC := usecase.NewUserUseCase(userRepo, roleRepo, authTokenRepo, auditRepo, exportFiles, usecase.UserConfig{
+		MaxPageSize:         100,
+		DefaultPageSize:     10,
+		DeletionGracePeriod: cfg.Account.DeletionGracePeriod,
+		Lockout: repo.Lockout{
+			Threshold: cfg.Login.LockoutThreshold,
+			BaseDelay: cfg.Login.LockoutBaseDelay,
+			MaxDelay:  cfg.Login.LockoutMaxDelay,
+		},
+	})
+	authUC := usecase.NewAuthUseCase(userRepo, authTokenRepo, twoFactorRepo, auditRepo, totpSecrets, usecase.AuthConfig{
+		JWTSecret:       []byte(cfg.Auth.JWTSecret),
+		AccessTokenTTL:  cfg.Auth.AccessTokenTTL,
+		RefreshTokenTTL: cfg.Auth.RefreshTokenTTL,
+		ChallengeTTL:    cfg.Auth.ChallengeTTL,
+		TOTPIssuer:      cfg.Auth.TOTPIssuer,
+		Lockout: repo.Lockout{
+			Threshold: cfg.Login.LockoutThreshold,
+			BaseDelay: cfg.Login.LockoutBaseDelay,
+			MaxDelay:  cfg.Login.LockoutMaxDelay,
+		},
+	})
+	personalTokenUC := usecase.NewPersonalTokenUseCase(userRepo, personalTokenRepo, auditRepo, usecase.PersonalTokenConfig{
+		MaxPerUser: cfg.Auth.MaxPersonalTokens,
+	})
+	oauthUC := usecase.NewOAuthUseCase(userRepo, oauthRepo, authTokenRepo, auditRepo, usecase.OAuthConfig{
+		CodeTTL:         cfg.OAuth.CodeTTL,
+		AccessTokenTTL:  cfg.OAuth.AccessTokenTTL,
+		RefreshTokenTTL: cfg.OAuth.RefreshTokenTTL,
+	})
+	oidcProviders := make(map[string]usecase.OIDCProvider, len(cfg.OIDC.Clients))
+	for name, client := range cfg.OIDC.Clients {
+		oidcProviders[name] = oidc.New(oidc.Config{
+			Issuer:       client.Issuer,
+			ClientID:     client.ClientID,
+			ClientSecret: client.ClientSecret,
+			RedirectURL:  client.RedirectURL,
+			Scopes:       client.Scopes,
+		})
+	}
+	oidcUC := usecase.NewOIDCUseCase(authUC, userRepo, identityRepo, auditRepo, oidcProviders, usecase.OIDCConfig{
+		StateTTL: cfg.OIDC.StateTTL,
+	})
 	// exerciseUC := usecase.NewExerciseUseCase(exerciseRepo, usecase.Config{})
-	// mealUC := usecase.NewMealUseCase(mealRepo)
-	nutritionUC := usecase.NewNutritionUseCase(nutritionRepo)
+	mealUC := usecase.NewMealUseCase(mealRepo, usecase.Config{MaxPageSize: 100, DefaultPageSize: 10})
+	nutritionUC := usecase.NewNutritionUseCase(nutritionRepo, usecase.Config{MaxPageSize: 100, DefaultPageSize: 10})
 	// workoutPlanUC := usecase.NewWorkoutPlanUseCase(workoutPlanRepo, usecase.Config{})
-	workoutSessionUC := usecase.NewWorkoutSessionUseCase(workoutSessionRepo, usecase.Config{})
+	workoutSessionUC := usecase.NewWorkoutSessionUseCase(workoutSessionRepo, usecase.Config{MaxPageSize: 100, DefaultPageSize: 10})
+	syncUC := usecase.NewSyncUseCase(userRepo, workoutSessionRepo, mealRepo, nutritionRepo, syncRepo, trashRepo, usecase.Config{MaxPageSize: 500, DefaultPageSize: 100})
+	trashUC := usecase.NewTrashUseCase(userRepo, trashRepo, usecase.TrashConfig{Retention: cfg.Trash.Retention})
+	auditUC := usecase.NewAuditUseCase(auditRepo, usecase.Config{MaxPageSize: 200, DefaultPageSize: 50})
+	exportUC := usecase.NewDataExportUseCase(userRepo, exportRepo, exportFiles, usecase.DataExportConfig{
+		TTL:        cfg.Export.TTL,
+		StaleAfter: cfg.Export.StaleAfter,
+		LinkSecret: []byte(cfg.Export.LinkSecret),
+	})
+	uploadUC := usecase.NewUploadUseCase(userRepo, exerciseRepo, workoutPlanRepo, uploadRepo, uploadFiles, usecase.UploadConfig{
+		MaxBytes:      cfg.Upload.MaxBytes,
+		MaxDimension:  cfg.Upload.MaxDimension,
+		ThumbnailSize: cfg.Upload.ThumbnailSize,
+		PublicURL:     cfg.Upload.PublicURL,
+		SignedURLTTL:  cfg.Upload.SignedURLTTL,
+	})
+	progressPhotoUC := usecase.NewProgressPhotoUseCase(nutritionRepo, progressPhotoRepo, coachRepo, progressPhotoFiles, usecase.ProgressPhotoConfig{
+		MaxBytes:      cfg.Upload.MaxBytes,
+		MaxDimension:  cfg.Upload.MaxDimension,
+		ThumbnailSize: cfg.Upload.ThumbnailSize,
+		LinkSecret:    []byte(cfg.ProgressPhoto.LinkSecret),
+		LinkTTL:       cfg.ProgressPhoto.LinkTTL,
+	})
+	coachUC := usecase.NewCoachUseCase(userRepo, coachRepo, auditRepo)
+	idempotencyUC := usecase.NewIdempotencyUseCase(idempotencyRepo, usecase.IdempotencyConfig{TTL: cfg.Idempotency.TTL})
+
+	// Background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go runPeriodically(jobsCtx, l, "idempotency purge", cfg.Idempotency.PurgeInterval, func(ctx context.Context) error {
+		_, err := idempotencyUC.PurgeExpired(ctx)
+		return err
+	})
+	go runPeriodically(jobsCtx, l, "data export", cfg.Export.PollInterval, exportUC.ProcessPending)
+	go runPeriodically(jobsCtx, l, "data export purge", cfg.Export.PurgeInterval, func(ctx context.Context) error {
+		_, err := exportUC.PurgeExpired(ctx)
+		return err
+	})
+	go runPeriodically(jobsCtx, l, "upload purge", cfg.Upload.PurgeInterval, func(ctx context.Context) error {
+		_, err := uploadUC.PurgeOrphans(ctx)
+		return err
+	})
+	go runPeriodically(jobsCtx, l, "progress photo purge", cfg.ProgressPhoto.PurgeInterval, func(ctx context.Context) error {
+		_, err := progressPhotoUC.PurgeOrphans(ctx)
+		return err
+	})
+	go runPeriodically(jobsCtx, l, "account erasure", cfg.Account.ErasureInterval, func(ctx context.Context) error {
+		_, err := userUC.EraseDueAccounts(ctx)
+		return err
+	})
+	go runPeriodically(jobsCtx, l, "rate limit purge", cfg.RateLimit.PurgeInterval, func(ctx context.Context) error {
+		_, err := rateLimitStore.Purge(ctx, time.Now().Add(-cfg.RateLimit.IdleTTL))
+		return err
+	})
+	go runPeriodically(jobsCtx, l, "oauth code purge", cfg.OAuth.PurgeInterval, func(ctx context.Context) error {
+		_, err := oauthUC.PurgeExpiredCodes(ctx)
+		return err
+	})
+	go runPeriodically(jobsCtx, l, "oidc state purge", cfg.OIDC.PurgeInterval, func(ctx context.Context) error {
+		_, err := oidcUC.PurgeExpiredStates(ctx)
+		return err
+	})
+	go runPeriodically(jobsCtx, l, "trash purge", cfg.Trash.PurgeInterval, func(ctx context.Context) error {
+		_, err := trashUC.PurgeExpired(ctx)
+		return err
+	})
 
 	// HTTP Server
-	httpServer := httpserver.New(httpserver.Port(cfg.HTTP.Port), httpserver.Prefork(cfg.HTTP.UsePreforkMode))
+	httpServer := httpserver.New(
+		httpserver.Port(cfg.HTTP.Port),
+		httpserver.Prefork(cfg.HTTP.UsePreforkMode),
+		// Room for the multipart encoding around an upload of the largest size
+		httpserver.BodyLimit(int(cfg.Upload.MaxBytes)+1<<20),
+		httpserver.ErrorHandler(router.ErrorHandler(l)),
+		httpserver.DrainDelay(cfg.HTTP.DrainDelay),
+		httpserver.OnShutdown(healthChecks.ShutDown),
+	)
 
 	router.NewRouter(
 		httpServer.App,
+		authUC,
+		personalTokenUC,
+		oauthUC,
+		oidcUC,
 		userUC,
 		foodItemUC,
-		// mealUC,
+		mealUC,
 		workoutSessionUC,
 		// workoutPlanUC,
 		nutritionUC,
+		syncUC,
+		trashUC,
+		auditUC,
+		exportUC,
+		uploadUC,
+		progressPhotoUC,
+		coachUC,
+		idempotencyUC,
+		router.RateLimits{
+			Store: rateLimitStore,
+			API:   router.RateLimitPolicy{Name: "api", PerIP: cfg.RateLimit.APIPerIP, PerUser: cfg.RateLimit.APIPerUser},
+			Auth:  router.RateLimitPolicy{Name: "auth", PerIP: cfg.RateLimit.AuthPerIP, PerUser: cfg.RateLimit.AuthPerUser},
+		},
+		registry,
+		healthChecks,
 		l,
 	)
 
@@ -68,14 +325,14 @@ func Run(cfg *config.Config) {
 
 	select {
 	case s := <-interrupt:
-		l.Info("app - Run - signal: %s", s.String())
+		l.Info("app - Run - signal", "signal", s.String())
 	case err = <-httpServer.Notify():
-		l.Error(fmt.Errorf("app - Run - httpServer.Notify: %w", err))
+		l.Error("app - Run - httpServer.Notify", "error", err)
 	}
 
 	// Shutdown
 	err = httpServer.Shutdown()
 	if err != nil {
-		l.Error(fmt.Errorf("app - Run - httpServer.Shutdown: %w", err))
+		l.Error("app - Run - httpServer.Shutdown", "error", err)
 	}
 }
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/terrnit/rebound/backend/pkg/postgres"
+)
+
+// _migrationsDir is where the migrations applied at start up are read from
+const _migrationsDir = "migrations"
+
+// latestMigration returns the highest version among the up migrations in dir
+func latestMigration(dir string) (uint, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".up.sql") {
+			continue
+		}
+		prefix, _, _ := strings.Cut(e.Name(), "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			// Not a versioned migration
+			continue
+		}
+		latest = max(latest, uint(version))
+	}
+	if latest == 0 {
+		return 0, fmt.Errorf("no migrations in %s", dir)
+	}
+	return latest, nil
+}
+
+// migrationCheck reports the database as unready while it is not at the expected version
+func migrationCheck(pg *postgres.Postgres, expected uint) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		version, dirty, err := pg.MigrationVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("migration %d failed and must be fixed by hand", version)
+		}
+		if version != expected {
+			return fmt.Errorf("database is at migration %d, expected %d", version, expected)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,26 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+// runPeriodically calls job every interval until ctx is cancelled.
+// Failures are logged and the job is tried again on the next tick.
+func runPeriodically(ctx context.Context, l logger.Interface, name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job(ctx); err != nil {
+				l.Error("app - job failed", "job", name, "error", err)
+			}
+		}
+	}
+}
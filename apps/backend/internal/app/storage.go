@@ -0,0 +1,28 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/terrnit/rebound/backend/config"
+	"github.com/terrnit/rebound/backend/pkg/storage"
+)
+
+// newFileStorage opens the storage configured by UPLOAD_STORAGE: dir on local disk or prefix in the S3 bucket
+func newFileStorage(cfg *config.Config, dir, prefix string) (storage.Storage, error) {
+	switch cfg.Upload.Storage {
+	case "local":
+		return storage.NewLocal(dir)
+	case "s3":
+		return storage.NewS3(storage.S3Config{
+			Endpoint:        cfg.S3.Endpoint,
+			Region:          cfg.S3.Region,
+			Bucket:          cfg.S3.Bucket,
+			AccessKeyID:     cfg.S3.AccessKeyID,
+			SecretAccessKey: cfg.S3.SecretAccessKey,
+			Prefix:          prefix,
+			PathStyle:       cfg.S3.PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unknown upload storage %q", cfg.Upload.Storage)
+	}
+}
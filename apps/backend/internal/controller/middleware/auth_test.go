@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+)
+
+// testErrorHandler maps the errors of the authorization middleware like the router does
+func testErrorHandler(c *fiber.Ctx, err error) error {
+	var domainErr *entity.Error
+	if !errors.As(err, &domainErr) {
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	switch domainErr.Kind {
+	case entity.ErrorKindUnauthorized:
+		return c.SendStatus(fiber.StatusUnauthorized)
+	case entity.ErrorKindForbidden:
+		return c.SendStatus(fiber.StatusForbidden)
+	}
+	return c.SendStatus(fiber.StatusInternalServerError)
+}
+
+// serve sends a request as principal, anonymous when nil, through handlers and returns the status
+func serve(t *testing.T, method, route, target string, principal *entity.Principal, handlers ...fiber.Handler) int {
+	t.Helper()
+	app := fiber.New(fiber.Config{ErrorHandler: testErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		if principal != nil {
+			c.Locals(PrincipalKey, principal)
+			c.Locals(UserIDKey, principal.UserID)
+		}
+		return c.Next()
+	})
+	handlers = append(handlers, func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	app.Add(method, route, handlers...)
+
+	resp, err := app.Test(httptest.NewRequest(method, target, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
+
+func TestRequireOwner(t *testing.T) {
+	alice := &entity.Principal{UserID: "alice"}
+
+	tests := []struct {
+		name      string
+		target    string
+		principal *entity.Principal
+		want      int
+	}{
+		{name: "anonymous", target: "/users/alice/meals", want: fiber.StatusUnauthorized},
+		{name: "owner", target: "/users/alice/meals", principal: alice, want: fiber.StatusNoContent},
+		{name: "another user", target: "/users/bob/meals", principal: alice, want: fiber.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := serve(t, fiber.MethodGet, "/users/:userID/meals", tt.target, tt.principal, RequireOwner("userID"))
+			if got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	token := &entity.PersonalToken{}
+
+	tests := []struct {
+		name      string
+		method    string
+		principal *entity.Principal
+		want      int
+	}{
+		{name: "anonymous", method: fiber.MethodGet, want: fiber.StatusUnauthorized},
+		{name: "session", method: fiber.MethodPost, principal: &entity.Principal{UserID: "alice", SessionID: "s"}, want: fiber.StatusNoContent},
+		{name: "read scope reads", method: fiber.MethodGet,
+			principal: &entity.Principal{UserID: "alice", PersonalToken: token, Scopes: []entity.Scope{entity.ScopeReadMeals}}, want: fiber.StatusNoContent},
+		{name: "read scope writes", method: fiber.MethodPost,
+			principal: &entity.Principal{UserID: "alice", PersonalToken: token, Scopes: []entity.Scope{entity.ScopeReadMeals}}, want: fiber.StatusForbidden},
+		{name: "write scope writes", method: fiber.MethodPost,
+			principal: &entity.Principal{UserID: "alice", ClientID: "app", Scopes: []entity.Scope{entity.ScopeWriteMeals}}, want: fiber.StatusNoContent},
+		{name: "other scope", method: fiber.MethodGet,
+			principal: &entity.Principal{UserID: "alice", ClientID: "app", Scopes: []entity.Scope{entity.ScopeReadWorkouts}}, want: fiber.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := serve(t, tt.method, "/meals", "/meals", tt.principal, RequireScope(entity.ScopeReadMeals, entity.ScopeWriteMeals))
+			if got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeRoles holds the roles of each user
+type fakeRoles map[string][]string
+
+func (f fakeRoles) HasRole(_ context.Context, userID, role string) (bool, error) {
+	if userID == "broken" {
+		return false, errors.New("connection refused")
+	}
+	for _, r := range f[userID] {
+		if r == role {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestRequireRole(t *testing.T) {
+	roles := fakeRoles{"root": {"admin"}, "alice": {"coach"}}
+
+	tests := []struct {
+		name      string
+		principal *entity.Principal
+		want      int
+	}{
+		{name: "anonymous", want: fiber.StatusUnauthorized},
+		{name: "without the role", principal: &entity.Principal{UserID: "alice"}, want: fiber.StatusForbidden},
+		{name: "with the role", principal: &entity.Principal{UserID: "root"}, want: fiber.StatusNoContent},
+		{name: "lookup fails", principal: &entity.Principal{UserID: "broken"}, want: fiber.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := serve(t, fiber.MethodGet, "/admin", "/admin", tt.principal, RequireRole(roles, "admin"))
+			if got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
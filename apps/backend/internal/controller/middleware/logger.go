@@ -1,35 +1,102 @@
 package middleware
 
 import (
-	"strconv"
-	"strings"
+	"net/url"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/terrnit/rebound/backend/pkg/logger"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
 )
 
-func buildRequestMessage(ctx *fiber.Ctx) string {
-	var result strings.Builder
+// UserIDKey is the local under which authentication stores the ID of the signed in user
+const UserIDKey = "user_id"
 
-	result.WriteString(ctx.IP())
-	result.WriteString(" - ")
-	result.WriteString(ctx.Method())
-	result.WriteString(" ")
-	result.WriteString(ctx.OriginalURL())
-	result.WriteString(" - ")
-	result.WriteString(strconv.Itoa(ctx.Response().StatusCode()))
-	result.WriteString(" ")
-	result.WriteString(strconv.Itoa(len(ctx.Response().Body())))
+// loggedQueryParams are the query parameters whose values are logged. The others are left out of the logs
+// as they can carry signatures, authorization codes, tokens, search terms or filters on personal data.
+var loggedQueryParams = map[string]bool{
+	"page":      true,
+	"page_size": true,
+	"size":      true,
+	"limit":     true,
+	"sort":      true,
+	"variant":   true,
+	"from":      true,
+	"to":        true,
+	"expires":   true,
+}
 
-	return result.String()
+// requestFields provides the request ID, route, user ID and trace ID of a request to its child loggers.
+// They are read when a child logger is created, once routing and authentication have filled them in.
+type requestFields struct {
+	ctx *fiber.Ctx
+}
+
+// LogFields implements logger.Fielder
+func (f requestFields) LogFields() []interface{} {
+	fields := []interface{}{"request_id", f.ctx.Locals(requestid.ConfigDefault.ContextKey)}
+	if route := f.ctx.Route(); len(route.Handlers) > 0 {
+		fields = append(fields, "route", route.Path)
+	}
+	if userID, ok := f.ctx.Locals(UserIDKey).(string); ok {
+		fields = append(fields, "user_id", userID)
+	}
+	if span, ok := f.ctx.Locals(tracing.ContextKey).(trace.Span); ok && span.SpanContext().HasTraceID() {
+		fields = append(fields, "trace_id", span.SpanContext().TraceID().String())
+	}
+	return fields
 }
 
+// Logger logs one line per request, at error level for server errors and warn level for client errors.
+// Handlers pass c.Context() to the use cases, so the request's fields are stored in its locals under logger.ContextKey.
 func Logger(l logger.Interface) func(c *fiber.Ctx) error {
 	return func(ctx *fiber.Ctx) error {
+		start := time.Now()
+		ctx.Locals(logger.ContextKey, requestFields{ctx})
+
 		err := ctx.Next()
+		if err != nil {
+			if err := ctx.App().ErrorHandler(ctx, err); err != nil {
+				_ = ctx.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
 
-		l.Info(buildRequestMessage(ctx))
+		status := ctx.Response().StatusCode()
+		rl := l.WithContext(ctx.Context())
+		log := rl.Info
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			log = rl.Error
+		case status >= fiber.StatusBadRequest:
+			log = rl.Warn
+		}
+		fields := []interface{}{
+			"method", ctx.Method(),
+			"path", ctx.Path(),
+			"status", status,
+			"latency", time.Since(start),
+			"ip", ctx.IP(),
+			"bytes", len(ctx.Response().Body()),
+		}
+		if query := loggedQuery(ctx); query != "" {
+			fields = append(fields, "query", query)
+		}
+		log("request", fields...)
 
-		return err
+		return nil
 	}
 }
+
+// loggedQuery returns the query parameters of a request that can be logged
+func loggedQuery(ctx *fiber.Ctx) string {
+	query := url.Values{}
+	ctx.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		if loggedQueryParams[string(key)] {
+			query.Add(string(key), string(value))
+		}
+	})
+	return query.Encode()
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+func TestLogger_LeavesSecretsOut(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		wantPath  string
+		wantQuery string
+		secrets   []string
+	}{
+		{
+			name:     "signed download",
+			target:   "/api/exports/1/download?expires=1790000000&signature=c2VjcmV0",
+			wantPath: "/api/exports/1/download", wantQuery: "expires=1790000000",
+			secrets: []string{"c2VjcmV0", "signature"},
+		},
+		{
+			name:     "oauth callback",
+			target:   "/api/auth/oidc/google/callback?code=4%2F0Adeu5B&state=st4te",
+			wantPath: "/api/auth/oidc/google/callback",
+			secrets:  []string{"4/0Adeu5B", "0Adeu5B", "st4te"},
+		},
+		{
+			name:     "filters and paging",
+			target:   "/api/users?filter[email][eq]=alice@example.com&page=2&page_size=20",
+			wantPath: "/api/users", wantQuery: "page=2&page_size=20",
+			secrets: []string{"alice@example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			app := fiber.New()
+			app.Use(Logger(logger.New("info", logger.Output(&out))))
+			app.Get("/*", func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, tt.target, nil)); err != nil {
+				t.Fatal(err)
+			}
+
+			var line map[string]interface{}
+			if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+				t.Fatalf("log line %q: %v", out.String(), err)
+			}
+			if line["path"] != tt.wantPath {
+				t.Errorf("path = %v, want %s", line["path"], tt.wantPath)
+			}
+			if query, _ := line["query"].(string); query != tt.wantQuery {
+				t.Errorf("query = %q, want %q", query, tt.wantQuery)
+			}
+			for _, secret := range tt.secrets {
+				if strings.Contains(out.String(), secret) {
+					t.Errorf("log line %s contains %q", out.String(), secret)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unmatchedRoute labels requests that did not match any route, to keep the label set bounded
+const unmatchedRoute = "unmatched"
+
+// Metrics records the request count, latency and in flight requests labelled by route template and status.
+// Errors are passed to the error handler here, so that the recorded status is the one sent to the client.
+func Metrics(reg prometheus.Registerer) func(c *fiber.Ctx) error {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	inFlight := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests being served.",
+	})
+	reg.MustRegister(requests, duration, inFlight)
+
+	return func(ctx *fiber.Ctx) error {
+		start := time.Now()
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		err := ctx.Next()
+
+		// Without a matching handler the route is the last middleware, or the original path when there is none
+		route := ctx.Route()
+		path := route.Path
+		if len(route.Handlers) == 0 || isNoRoute(ctx, err) {
+			path = unmatchedRoute
+		}
+
+		if err != nil {
+			if err := ctx.App().ErrorHandler(ctx, err); err != nil {
+				_ = ctx.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		labels := prometheus.Labels{
+			"method": ctx.Method(),
+			"route":  path,
+			"status": strconv.Itoa(ctx.Response().StatusCode()),
+		}
+		requests.With(labels).Inc()
+		duration.With(labels).Observe(time.Since(start).Seconds())
+
+		return nil
+	}
+}
+
+// isNoRoute reports whether err is the error fiber returns when no route matches the request
+func isNoRoute(ctx *fiber.Ctx, err error) bool {
+	var fe *fiber.Error
+	return errors.As(err, &fe) && fe.Code == fiber.StatusNotFound &&
+		strings.HasPrefix(fe.Message, "Cannot "+ctx.Method()+" ")
+}
@@ -1,32 +1,22 @@
 package middleware
 
 import (
-	"fmt"
 	"runtime/debug"
-	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	fiberRecover "github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/terrnit/rebound/backend/pkg/logger"
 )
 
-func buildPanicMessage(ctx *fiber.Ctx, err interface{}) string {
-	var result strings.Builder
-
-	result.WriteString(ctx.IP())
-	result.WriteString(" - ")
-	result.WriteString(ctx.Method())
-	result.WriteString(" ")
-	result.WriteString(ctx.OriginalURL())
-	result.WriteString(" PANIC DETECTED: ")
-	result.WriteString(fmt.Sprintf("%v\n%s\n", err, debug.Stack()))
-
-	return result.String()
-}
-
 func logPanic(l logger.Interface) func(c *fiber.Ctx, err interface{}) {
 	return func(ctx *fiber.Ctx, err interface{}) {
-		l.Error(buildPanicMessage(ctx, err))
+		l.WithContext(ctx.Context()).Error("panic recovered",
+			"panic", err,
+			"method", ctx.Method(),
+			"path", ctx.Path(),
+			"ip", ctx.IP(),
+			"stack", string(debug.Stack()),
+		)
 	}
 }
 
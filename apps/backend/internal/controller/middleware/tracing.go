@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/terrnit/rebound/backend/pkg/tracing"
+)
+
+// Tracing starts a server span for each request, continuing the trace of an incoming W3C traceparent header.
+// Handlers pass c.Context() to the use cases, so the span is stored in the request's locals under tracing.ContextKey.
+func Tracing() func(c *fiber.Ctx) error {
+	return func(ctx *fiber.Ctx) error {
+		parent := otel.GetTextMapPropagator().Extract(ctx.UserContext(), propagation.HeaderCarrier(ctx.GetReqHeaders()))
+
+		_, span := tracing.Start(parent, ctx.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(ctx.Method()),
+				semconv.URLPath(ctx.Path()),
+				semconv.ClientAddress(ctx.IP()),
+				semconv.UserAgentOriginal(ctx.Get(fiber.HeaderUserAgent)),
+			),
+		)
+		defer span.End()
+		ctx.Locals(tracing.ContextKey, span)
+
+		err := ctx.Next()
+
+		route := ctx.Route().Path
+		if len(ctx.Route().Handlers) == 0 || isNoRoute(ctx, err) {
+			route = unmatchedRoute
+		}
+
+		if err != nil {
+			if err := ctx.App().ErrorHandler(ctx, err); err != nil {
+				_ = ctx.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := ctx.Response().StatusCode()
+		span.SetName(ctx.Method() + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(status))
+		// Client errors are not failures of the server span
+		if status >= fiber.StatusInternalServerError {
+			if err != nil {
+				span.RecordError(err)
+			}
+			span.SetStatus(codes.Error, fiber.ErrInternalServerError.Message)
+		}
+
+		return nil
+	}
+}
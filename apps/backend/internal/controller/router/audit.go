@@ -0,0 +1,19 @@
+package router
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+)
+
+// AuditSource stores where a request comes from, so that use cases can record it in the audit log.
+// Handlers pass c.Context() to the use cases, whose values are the request's locals.
+func AuditSource() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(usecase.AuditSourceKey, entity.AuditSource{
+			IPAddress: c.IP(),
+			UserAgent: c.Get(fiber.HeaderUserAgent),
+		})
+		return c.Next()
+	}
+}
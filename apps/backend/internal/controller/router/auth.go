@@ -0,0 +1,65 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+)
+
+// clientAuthPaths are the OAuth endpoints at which clients authenticate themselves with HTTP Basic
+var clientAuthPaths = []string{
+	"/api/oauth/token",
+	"/api/oauth/revoke",
+}
+
+// Authenticate signs in requests that carry a Bearer access token, personal access token or OAuth access token,
+// requests without one stay anonymous. It runs after AuditSource, whose entry it completes with the actor.
+func Authenticate(authUC *usecase.AuthUseCase, personalTokenUC *usecase.PersonalTokenUseCase, oauthUC *usecase.OAuthUseCase) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := c.Get(fiber.HeaderAuthorization)
+		if header == "" {
+			return c.Next()
+		}
+
+		scheme, token, found := strings.Cut(header, " ")
+		// OAuth clients send their credentials with Basic, which the OAuth endpoints check themselves.
+		// Anywhere else Basic would leave the request anonymous, so it is refused like any other unknown credential.
+		if found && strings.EqualFold(scheme, "Basic") && underAny(c.Path(), clientAuthPaths) {
+			return c.Next()
+		}
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return usecase.ErrInvalidAccessToken
+		}
+		token = strings.TrimSpace(token)
+
+		var (
+			principal *entity.Principal
+			err       error
+		)
+		switch {
+		case strings.HasPrefix(token, usecase.PersonalTokenPrefix):
+			principal, err = personalTokenUC.Authenticate(c.Context(), token)
+		case strings.HasPrefix(token, usecase.OAuthAccessTokenPrefix):
+			principal, err = oauthUC.AuthenticateAccessToken(c.Context(), token)
+		default:
+			principal, err = authUC.ParseAccessToken(token)
+		}
+		if err != nil {
+			return err
+		}
+
+		c.Locals(middleware.PrincipalKey, principal)
+		c.Locals(middleware.UserIDKey, principal.UserID)
+		if principal.SessionID != "" {
+			c.Locals(middleware.SessionIDKey, principal.SessionID)
+		}
+		if source, ok := c.Locals(usecase.AuditSourceKey).(entity.AuditSource); ok {
+			source.ActorID = principal.UserID
+			c.Locals(usecase.AuditSourceKey, source)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package router
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+func TestAuthenticate_Basic(t *testing.T) {
+	l := logger.New("error", logger.Output(io.Discard))
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(l)})
+	// Requests that are refused never reach the use cases, and Basic credentials are not checked here
+	app.Use(Authenticate(nil, nil, nil))
+	app.All("/*", func(c *fiber.Ctx) error {
+		if middleware.UserID(c) != "" {
+			t.Errorf("%s signed in a user", c.Path())
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{"/api/oauth/token", fiber.StatusNoContent},
+		{"/api/OAuth/Revoke", fiber.StatusNoContent},
+		{"/api/meals", fiber.StatusUnauthorized},
+		{"/api/oauth/tokens", fiber.StatusUnauthorized},
+		{"/api/sync/user/alice/push", fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPost, tt.target, nil)
+			req.SetBasicAuth("client", "secret")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package router
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	v1 "github.com/terrnit/rebound/backend/internal/controller/router/v1"
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+// ErrorHandler maps errors returned by handlers to application/problem+json responses.
+// Domain errors keep their code and fields, anything else is logged and reported as a 500.
+func ErrorHandler(l logger.Interface) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		problem := v1.ErrorResponse{
+			Type:     "about:blank",
+			Instance: c.OriginalURL(),
+		}
+
+		var (
+			domainErr *entity.Error
+			fiberErr  *fiber.Error
+		)
+		switch {
+		case errors.As(err, &domainErr) && domainErr.Kind != entity.ErrorKindInternal:
+			problem.Status = statusFromKind(domainErr.Kind)
+			problem.Code = domainErr.Code
+			problem.Detail = domainErr.Message
+			problem.Errors = domainErr.Fields
+			if domainErr.RetryAfter > 0 {
+				c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(domainErr.RetryAfter.Seconds()))))
+			}
+		case errors.As(err, &fiberErr):
+			problem.Status = fiberErr.Code
+			problem.Code = codeFromStatus(fiberErr.Code)
+			problem.Detail = fiberErr.Message
+		default:
+			problem.Status = fiber.StatusInternalServerError
+			problem.Code = "internal_error"
+		}
+		problem.Title = http.StatusText(problem.Status)
+
+		if problem.Status >= fiber.StatusInternalServerError {
+			problem.RequestID, _ = c.Locals(requestid.ConfigDefault.ContextKey).(string)
+			l.WithContext(c.Context()).Error("router - ErrorHandler", "error", err, "method", c.Method(), "path", c.Path())
+		}
+
+		return c.Status(problem.Status).JSON(problem, "application/problem+json")
+	}
+}
+
+func statusFromKind(kind entity.ErrorKind) int {
+	switch kind {
+	case entity.ErrorKindNotFound:
+		return fiber.StatusNotFound
+	case entity.ErrorKindConflict:
+		return fiber.StatusConflict
+	case entity.ErrorKindValidation:
+		return fiber.StatusBadRequest
+	case entity.ErrorKindUnauthorized:
+		return fiber.StatusUnauthorized
+	case entity.ErrorKindForbidden:
+		return fiber.StatusForbidden
+	case entity.ErrorKindPreconditionFailed:
+		return fiber.StatusPreconditionFailed
+	case entity.ErrorKindTooManyRequests:
+		return fiber.StatusTooManyRequests
+	case entity.ErrorKindPayloadTooLarge:
+		return fiber.StatusRequestEntityTooLarge
+	case entity.ErrorKindUnsupportedMediaType:
+		return fiber.StatusUnsupportedMediaType
+	case entity.ErrorKindBadGateway:
+		return fiber.StatusBadGateway
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// codeFromStatus derives a stable code such as method_not_allowed for errors raised by fiber itself
+func codeFromStatus(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
@@ -0,0 +1,33 @@
+package router
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/pkg/health"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+// Liveness reports that the process is running, it does not check any dependency
+func Liveness() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"status": health.StatusUp})
+	}
+}
+
+// Readiness reports whether the application can serve traffic, with a breakdown per dependency.
+// It answers 503 while a dependency is down or the server is shutting down. The probe is public,
+// so the response only carries the status of each check and the reasons for failures are logged.
+func Readiness(h *health.Health, l logger.Interface) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		report := h.Ready(c.Context())
+		status := fiber.StatusOK
+		if report.Status != health.StatusUp {
+			status = fiber.StatusServiceUnavailable
+		}
+		for name, result := range report.Checks {
+			if result.Status != health.StatusUp {
+				l.WithContext(c.Context()).Warn("router - Readiness - check failed", "check", name, "error", result.Error)
+			}
+		}
+		return c.Status(status).JSON(report)
+	}
+}
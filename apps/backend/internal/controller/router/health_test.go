@@ -0,0 +1,50 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/terrnit/rebound/backend/pkg/health"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+func TestReadiness(t *testing.T) {
+	const detail = "failed to connect to host=db.internal user=rebound"
+
+	checks := health.New()
+	checks.Register("postgres", func(context.Context) error { return errors.New(detail) })
+	checks.Register("storage", func(context.Context) error { return nil })
+
+	var logs bytes.Buffer
+	app := fiber.New()
+	app.Get("/readyz", Readiness(checks, logger.New("info", logger.Output(&logs))))
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/readyz", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", resp.StatusCode, fiber.StatusServiceUnavailable)
+	}
+	if strings.Contains(string(body), "db.internal") {
+		t.Errorf("body %s exposes the error of the check", body)
+	}
+	if !strings.Contains(string(body), `"postgres":{"status":"down"`) || !strings.Contains(string(body), `"storage":{"status":"up"`) {
+		t.Errorf("body %s lacks the status of each check", body)
+	}
+	if !strings.Contains(logs.String(), detail) {
+		t.Errorf("logs %q lack the error of the check", logs.String())
+	}
+}
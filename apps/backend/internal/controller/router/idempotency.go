@@ -0,0 +1,112 @@
+package router
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+const (
+	headerIdempotencyKey     = "Idempotency-Key"
+	headerIdempotentReplayed = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLen = 255
+)
+
+var errInvalidIdempotencyKey = entity.NewValidationError("invalid_idempotency_key", "invalid idempotency key").
+	WithField(headerIdempotencyKey, fmt.Sprintf("must be at most %d characters long", maxIdempotencyKeyLen))
+
+// Idempotency makes POST requests carrying an Idempotency-Key header safe to retry.
+// Keys are scoped to the caller: the signed in user, or the client address for anonymous requests.
+// The first response is stored and replayed for retries by the same caller with the same key, method, path and body.
+// Failed requests and server errors are not stored, so their retries execute again. Requests under the excluded
+// paths are never stored: they issue tokens, secrets and codes that must not be kept in the database.
+func Idempotency(uc *usecase.IdempotencyUseCase, l logger.Interface, excluded ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(headerIdempotencyKey)
+		if c.Method() != fiber.MethodPost || key == "" || underAny(c.Path(), excluded) {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			return errInvalidIdempotencyKey
+		}
+
+		scope := idempotencyScope(c)
+		stored, err := uc.Begin(c.Context(), scope, key, requestHash(c, scope))
+		if err != nil {
+			return err
+		}
+		if stored != nil {
+			c.Set(headerIdempotentReplayed, "true")
+			if stored.ContentType != "" {
+				c.Set(fiber.HeaderContentType, stored.ContentType)
+			}
+			return c.Status(*stored.StatusCode).Send(stored.ResponseBody)
+		}
+
+		if err := c.Next(); err != nil {
+			release(c, uc, l, scope, key)
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			release(c, uc, l, scope, key)
+			return nil
+		}
+
+		body := append([]byte(nil), c.Response().Body()...)
+		contentType := string(c.Response().Header.ContentType())
+		if err := uc.Complete(c.Context(), scope, key, status, contentType, body); err != nil {
+			// The response itself succeeded, only its replay is lost
+			l.WithContext(c.Context()).Error("router - Idempotency - uc.Complete", "error", err, "key", key)
+		}
+		return nil
+	}
+}
+
+func release(c *fiber.Ctx, uc *usecase.IdempotencyUseCase, l logger.Interface, scope, key string) {
+	if err := uc.Release(c.Context(), scope, key); err != nil {
+		l.WithContext(c.Context()).Error("router - Idempotency - uc.Release", "error", err, "key", key)
+	}
+}
+
+// idempotencyScope identifies the caller that owns an idempotency key
+func idempotencyScope(c *fiber.Ctx) string {
+	if userID := middleware.UserID(c); userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + c.IP()
+}
+
+// underAny reports whether path is one of prefixes or below one of them.
+// Routes match regardless of case, and so do the prefixes.
+func underAny(path string, prefixes []string) bool {
+	path = strings.ToLower(path)
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) && (len(path) == len(prefix) || path[len(prefix)] == '/') {
+			return true
+		}
+	}
+	return false
+}
+
+// requestHash identifies a request by its caller, method, URL and body
+func requestHash(c *fiber.Ctx, scope string) string {
+	h := sha256.New()
+	h.Write([]byte(scope))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(c.Method()))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(c.OriginalURL()))
+	h.Write([]byte{'\n'})
+	h.Write(c.Body())
+	return hex.EncodeToString(h.Sum(nil))
+}
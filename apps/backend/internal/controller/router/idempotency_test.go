@@ -0,0 +1,182 @@
+package router
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+// fakeIdempotencyRepository keeps idempotency keys in memory
+type fakeIdempotencyRepository struct {
+	keys map[[2]string]*entity.IdempotencyKey
+}
+
+func (r *fakeIdempotencyRepository) Reserve(_ context.Context, key *entity.IdempotencyKey) (bool, error) {
+	id := [2]string{key.Scope, key.Key}
+	if existing, ok := r.keys[id]; ok && existing.ExpiresAt.After(key.CreatedAt) {
+		return false, nil
+	}
+	clone := *key
+	r.keys[id] = &clone
+	return true, nil
+}
+
+func (r *fakeIdempotencyRepository) GetByKey(_ context.Context, scope, key string) (*entity.IdempotencyKey, error) {
+	if k, ok := r.keys[[2]string{scope, key}]; ok {
+		clone := *k
+		return &clone, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeIdempotencyRepository) Complete(_ context.Context, key *entity.IdempotencyKey) error {
+	k := r.keys[[2]string{key.Scope, key.Key}]
+	k.StatusCode, k.ContentType, k.ResponseBody = key.StatusCode, key.ContentType, key.ResponseBody
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) Delete(_ context.Context, scope, key string) error {
+	delete(r.keys, [2]string{scope, key})
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) DeleteExpired(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+// newIdempotencyApp counts the requests that reach the handlers.
+// The X-User header stands in for the user Authenticate would have signed in.
+func newIdempotencyApp(calls *int) *fiber.App {
+	l := logger.New("error", logger.Output(io.Discard))
+	uc := usecase.NewIdempotencyUseCase(&fakeIdempotencyRepository{keys: map[[2]string]*entity.IdempotencyKey{}}, usecase.IdempotencyConfig{TTL: time.Hour})
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(l)})
+	app.Use(func(c *fiber.Ctx) error {
+		if user := c.Get("X-User"); user != "" {
+			c.Locals(middleware.UserIDKey, user)
+		}
+		return c.Next()
+	})
+	app.Use(Idempotency(uc, l, "/api/auth/login"))
+	handler := func(c *fiber.Ctx) error {
+		*calls++
+		return c.Status(fiber.StatusCreated).SendString(middleware.UserID(c) + ":" + string(c.Body()))
+	}
+	app.Post("/api/meals", handler)
+	app.Post("/api/auth/login", handler)
+	return app
+}
+
+func postIdempotent(t *testing.T, app *fiber.App, path, user, key, body string) (int, string, bool) {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(headerIdempotencyKey, key)
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(data), resp.Header.Get(headerIdempotentReplayed) == "true"
+}
+
+func TestIdempotency_Replay(t *testing.T) {
+	var calls int
+	app := newIdempotencyApp(&calls)
+
+	status, body, replayed := postIdempotent(t, app, "/api/meals", "alice", "k1", "lunch")
+	if status != fiber.StatusCreated || body != "alice:lunch" || replayed {
+		t.Fatalf("first request = %d %q replayed=%v", status, body, replayed)
+	}
+	status, body, replayed = postIdempotent(t, app, "/api/meals", "alice", "k1", "lunch")
+	if status != fiber.StatusCreated || body != "alice:lunch" || !replayed {
+		t.Fatalf("retry = %d %q replayed=%v, want the stored response", status, body, replayed)
+	}
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+
+	status, _, _ = postIdempotent(t, app, "/api/meals", "alice", "k1", "dinner")
+	if status != fiber.StatusConflict {
+		t.Errorf("reused key with another body status = %d, want %d", status, fiber.StatusConflict)
+	}
+}
+
+func TestIdempotency_ScopedToCaller(t *testing.T) {
+	var calls int
+	app := newIdempotencyApp(&calls)
+
+	postIdempotent(t, app, "/api/meals", "alice", "k1", "lunch")
+
+	// Another user sending the same key and request gets their own response, not alice's
+	status, body, replayed := postIdempotent(t, app, "/api/meals", "mallory", "k1", "lunch")
+	if status != fiber.StatusCreated || body != "mallory:lunch" || replayed {
+		t.Errorf("other user = %d %q replayed=%v, want a fresh response", status, body, replayed)
+	}
+	status, body, replayed = postIdempotent(t, app, "/api/meals", "", "k1", "lunch")
+	if status != fiber.StatusCreated || body != ":lunch" || replayed {
+		t.Errorf("anonymous = %d %q replayed=%v, want a fresh response", status, body, replayed)
+	}
+	if calls != 3 {
+		t.Errorf("handler ran %d times, want 3", calls)
+	}
+}
+
+func TestIdempotency_ExcludedPaths(t *testing.T) {
+	var calls int
+	app := newIdempotencyApp(&calls)
+
+	for _, path := range []string{"/api/auth/login", "/API/Auth/Login", "/api/auth/login/2fa"} {
+		postIdempotent(t, app, path, "", "k1", "secret")
+		_, _, replayed := postIdempotent(t, app, path, "", "k1", "secret")
+		if replayed {
+			t.Errorf("%s replayed a stored response", path)
+		}
+	}
+}
+
+func TestRequestHash(t *testing.T) {
+	hash := func(method, url, body, scope string) string {
+		var got string
+		app := fiber.New()
+		app.All("/*", func(c *fiber.Ctx) error {
+			got = requestHash(c, scope)
+			return nil
+		})
+		if _, err := app.Test(httptest.NewRequest(method, url, strings.NewReader(body))); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	base := hash(fiber.MethodPost, "/api/meals?x=1", "{}", "user:1")
+	if again := hash(fiber.MethodPost, "/api/meals?x=1", "{}", "user:1"); again != base {
+		t.Errorf("requestHash() is not stable: %s != %s", again, base)
+	}
+	for name, other := range map[string]string{
+		"scope":  hash(fiber.MethodPost, "/api/meals?x=1", "{}", "user:2"),
+		"method": hash(fiber.MethodPut, "/api/meals?x=1", "{}", "user:1"),
+		"path":   hash(fiber.MethodPost, "/api/users?x=1", "{}", "user:1"),
+		"query":  hash(fiber.MethodPost, "/api/meals?x=2", "{}", "user:1"),
+		"body":   hash(fiber.MethodPost, "/api/meals?x=1", "[]", "user:1"),
+	} {
+		if other == base {
+			t.Errorf("requestHash() ignores the %s", name)
+		}
+	}
+}
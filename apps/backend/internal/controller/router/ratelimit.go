@@ -0,0 +1,64 @@
+package router
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+	"github.com/terrnit/rebound/backend/pkg/ratelimit"
+)
+
+var errRateLimited = entity.NewTooManyRequestsError("rate_limited", "too many requests, slow down")
+
+// RateLimitPolicy limits the requests to a route group per client IP and per signed in user
+type RateLimitPolicy struct {
+	// Name keeps the buckets of route groups apart
+	Name    string
+	PerIP   ratelimit.Limit
+	PerUser ratelimit.Limit
+}
+
+// RateLimits configures rate limiting of the route groups
+type RateLimits struct {
+	Store ratelimit.Store
+	API   RateLimitPolicy
+	Auth  RateLimitPolicy
+}
+
+// RateLimitPerIP answers 429 with a Retry-After header once a client IP used up the tokens of the policy.
+// It runs before authentication, so that requests with bad credentials are limited too.
+func RateLimitPerIP(store ratelimit.Store, policy RateLimitPolicy, l logger.Interface) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := takeToken(c, store, policy.Name, policy.Name+":ip:"+c.IP(), policy.PerIP, l); err != nil {
+			return err
+		}
+		return c.Next()
+	}
+}
+
+// RateLimitPerUser answers 429 with a Retry-After header once the signed in user used up the tokens of the policy.
+// It runs after authentication, anonymous requests are only limited per IP.
+func RateLimitPerUser(store ratelimit.Store, policy RateLimitPolicy, l logger.Interface) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if userID, ok := c.Locals(middleware.UserIDKey).(string); ok {
+			if err := takeToken(c, store, policy.Name, policy.Name+":user:"+userID, policy.PerUser, l); err != nil {
+				return err
+			}
+		}
+		return c.Next()
+	}
+}
+
+// takeToken takes a token from a bucket. When the store fails, requests are let through rather than taking
+// the API down with it.
+func takeToken(c *fiber.Ctx, store ratelimit.Store, policyName, key string, limit ratelimit.Limit, l logger.Interface) error {
+	result, err := store.Take(c.Context(), key, limit)
+	if err != nil {
+		l.WithContext(c.Context()).Error("router - RateLimit - store.Take", "error", err, "policy", policyName)
+		return nil
+	}
+	if !result.Allowed {
+		return errRateLimited.WithRetryAfter(result.RetryAfter)
+	}
+	return nil
+}
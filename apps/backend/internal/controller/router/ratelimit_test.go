@@ -0,0 +1,102 @@
+package router
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+	"github.com/terrnit/rebound/backend/pkg/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	l := logger.New("error", logger.Output(io.Discard))
+	policy := RateLimitPolicy{
+		Name:    "api",
+		PerIP:   ratelimit.Limit{Count: 4, Period: time.Hour},
+		PerUser: ratelimit.Limit{Count: 2, Period: time.Hour},
+	}
+
+	store := ratelimit.NewMemory()
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(l)})
+	// Authentication runs between the two, so per user buckets see the signed in user
+	app.Use(RateLimitPerIP(store, policy, l))
+	app.Use(func(c *fiber.Ctx) error {
+		if user := c.Get("X-User"); user != "" {
+			c.Locals(middleware.UserIDKey, user)
+		}
+		return c.Next()
+	})
+	app.Use(RateLimitPerUser(store, policy, l))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	send := func(user string) (int, string) {
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, resp.Header.Get(fiber.HeaderRetryAfter)
+	}
+
+	// The user runs out before the address does
+	for i := 0; i < 2; i++ {
+		if status, _ := send("alice"); status != fiber.StatusNoContent {
+			t.Fatalf("request %d status = %d, want %d", i+1, status, fiber.StatusNoContent)
+		}
+	}
+	status, retryAfter := send("alice")
+	if status != fiber.StatusTooManyRequests || retryAfter == "" {
+		t.Fatalf("over the user limit = %d Retry-After %q, want %d with Retry-After", status, retryAfter, fiber.StatusTooManyRequests)
+	}
+
+	// Refused requests still took a token of the address, which has one left for everyone behind it
+	if status, _ := send(""); status != fiber.StatusNoContent {
+		t.Errorf("anonymous status = %d, want %d", status, fiber.StatusNoContent)
+	}
+	if status, _ := send("bob"); status != fiber.StatusTooManyRequests {
+		t.Errorf("over the address limit = %d, want %d", status, fiber.StatusTooManyRequests)
+	}
+}
+
+func TestRateLimit_BeforeAuthentication(t *testing.T) {
+	l := logger.New("error", logger.Output(io.Discard))
+	policy := RateLimitPolicy{
+		Name:    "api",
+		PerIP:   ratelimit.Limit{Count: 2, Period: time.Hour},
+		PerUser: ratelimit.Limit{Count: 2, Period: time.Hour},
+	}
+
+	// In the order of the router, a Basic header is refused without a use case to check it
+	store := ratelimit.NewMemory()
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(l)})
+	app.Use(RateLimitPerIP(store, policy, l))
+	app.Use(Authenticate(nil, nil, nil))
+	app.Use(RateLimitPerUser(store, policy, l))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	// Requests with bad credentials use up the address like any other
+	want := []int{fiber.StatusUnauthorized, fiber.StatusUnauthorized, fiber.StatusTooManyRequests}
+	for i, wantStatus := range want {
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Basic Z3Vlc3M6MTIzNA==")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != wantStatus {
+			t.Errorf("request %d status = %d, want %d", i+1, resp.StatusCode, wantStatus)
+		}
+	}
+}
@@ -2,18 +2,34 @@
 package router
 
 import (
-	"net/http"
-
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	fiberlogger "github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/gofiber/swagger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	_ "github.com/terrnit/rebound/backend/docs" // Swagger docs.
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
 	v1 "github.com/terrnit/rebound/backend/internal/controller/router/v1"
 	"github.com/terrnit/rebound/backend/internal/usecase"
+	"github.com/terrnit/rebound/backend/pkg/health"
 	"github.com/terrnit/rebound/backend/pkg/logger"
 )
 
+// credentialPaths issue tokens, client secrets, authorization codes and recovery codes.
+// Their responses are not stored for idempotent replay.
+var credentialPaths = []string{
+	"/api/auth/login",
+	"/api/auth/refresh",
+	"/api/auth/2fa",
+	"/api/auth/tokens",
+	"/api/auth/oidc",
+	"/api/oauth/token",
+	"/api/oauth/authorize",
+	"/api/oauth/clients",
+}
+
 type Router struct {
 	app *fiber.App
 }
@@ -30,33 +46,79 @@ type Router struct {
 // @description Type "Bearer" followed by a space and JWT token.
 func NewRouter(
 	app *fiber.App,
+	authUC *usecase.AuthUseCase,
+	personalTokenUC *usecase.PersonalTokenUseCase,
+	oauthUC *usecase.OAuthUseCase,
+	oidcUC *usecase.OIDCUseCase,
 	userUC *usecase.UserUseCase,
 	foodItemUC *usecase.FoodItemUseCase,
-	// mealUC *usecase.MealUseCase,
+	mealUC *usecase.MealUseCase,
 	// workoutPlanUC *usecase.WorkoutPlanUseCase,
 	workoutSessionUC *usecase.WorkoutSessionUseCase,
 	nutritionUC *usecase.NutritionUseCase,
+	syncUC *usecase.SyncUseCase,
+	trashUC *usecase.TrashUseCase,
+	auditUC *usecase.AuditUseCase,
+	exportUC *usecase.DataExportUseCase,
+	uploadUC *usecase.UploadUseCase,
+	progressPhotoUC *usecase.ProgressPhotoUseCase,
+	coachUC *usecase.CoachUseCase,
+	idempotencyUC *usecase.IdempotencyUseCase,
+	rateLimits RateLimits,
+	registry *prometheus.Registry,
+	healthChecks *health.Health,
 	l logger.Interface,
 ) *Router {
+	app.Use(requestid.New())
+	app.Use(middleware.Tracing())
+	if registry != nil {
+		app.Use(middleware.Metrics(registry))
+	}
 	app.Use(cors.New())
-	app.Use(fiberlogger.New(fiberlogger.Config{
-		Format: "[${time}] ${status} - ${latency} ${method} ${path}\n",
-	}))
+	app.Use(middleware.Logger(l))
 
 	// Swagger documentation
 	app.Get("/swagger/*", swagger.HandlerDefault)
 
-	// K8s probe
-	app.Get("/healthz", func(ctx *fiber.Ctx) error { return ctx.SendStatus(http.StatusOK) })
+	// K8s probes, /healthz is kept for existing deployments
+	app.Get("/healthz", Liveness())
+	app.Get("/livez", Liveness())
+	app.Get("/readyz", Readiness(healthChecks, l))
+
+	// Prometheus metrics, nil registry when disabled
+	if registry != nil {
+		app.Get("/metrics", adaptor.HTTPHandler(promhttp.HandlerFor(registry, promhttp.HandlerOpts{})))
+	}
 
 	// Routers
 	api := app.Group("/api")
+	api.Use(AuditSource())
+	// Addresses are limited before authentication, so that guessing tokens is limited too
+	api.Use(RateLimitPerIP(rateLimits.Store, rateLimits.API, l))
+	api.Use("/auth", RateLimitPerIP(rateLimits.Store, rateLimits.Auth, l))
+	api.Use("/oauth/token", RateLimitPerIP(rateLimits.Store, rateLimits.Auth, l))
+	api.Use(Authenticate(authUC, personalTokenUC, oauthUC))
+	api.Use(RateLimitPerUser(rateLimits.Store, rateLimits.API, l))
+	api.Use("/auth", RateLimitPerUser(rateLimits.Store, rateLimits.Auth, l))
+	api.Use("/oauth/token", RateLimitPerUser(rateLimits.Store, rateLimits.Auth, l))
+	api.Use(Idempotency(idempotencyUC, l, credentialPaths...))
 	{
+		v1.NewAuthRoutes(api, authUC, l)
+		v1.NewPersonalTokenRoutes(api, personalTokenUC, l)
+		v1.NewOAuthRoutes(api, oauthUC, l)
+		v1.NewOIDCRoutes(api, oidcUC, l)
 		v1.NewUserRoutes(api, userUC, l)
 		v1.NewFoodItemRoutes(api, foodItemUC, l)
-		// v1.NewMealRoutes(api, mealUC, l)
+		v1.NewMealRoutes(api, mealUC, l)
 		v1.NewWorkoutSessionRoutes(api, workoutSessionUC, l)
 		v1.NewNutritionRoutes(api, nutritionUC, l)
+		v1.NewSyncRoutes(api, syncUC, l)
+		v1.NewTrashRoutes(api, trashUC, l)
+		v1.NewAuditRoutes(api, auditUC, userUC, l)
+		v1.NewDataExportRoutes(api, exportUC, l)
+		v1.NewUploadRoutes(api, uploadUC, l)
+		v1.NewProgressPhotoRoutes(api, progressPhotoUC, l)
+		v1.NewCoachRoutes(api, coachUC, l)
 		// v1.NewExerciseRoutes()
 	}
 
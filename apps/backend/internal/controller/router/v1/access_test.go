@@ -0,0 +1,237 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+var testLogger = logger.New("error", logger.Output(io.Discard))
+
+// testErrorHandler maps domain errors to their status like the router does
+func testErrorHandler(c *fiber.Ctx, err error) error {
+	var domainErr *entity.Error
+	if !errors.As(err, &domainErr) {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			return c.SendStatus(fiberErr.Code)
+		}
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	switch domainErr.Kind {
+	case entity.ErrorKindUnauthorized:
+		return c.SendStatus(fiber.StatusUnauthorized)
+	case entity.ErrorKindForbidden:
+		return c.SendStatus(fiber.StatusForbidden)
+	case entity.ErrorKindNotFound:
+		return c.SendStatus(fiber.StatusNotFound)
+	case entity.ErrorKindValidation:
+		return c.SendStatus(fiber.StatusBadRequest)
+	case entity.ErrorKindConflict:
+		return c.SendStatus(fiber.StatusConflict)
+	}
+	return c.SendStatus(fiber.StatusInternalServerError)
+}
+
+// newTestApp registers routes on an app that signs requests in as the user named by the X-User header
+func newTestApp(register func(fiber.Router)) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: testErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		if user := c.Get("X-User"); user != "" {
+			c.Locals(middleware.PrincipalKey, &entity.Principal{UserID: user, SessionID: "session"})
+			c.Locals(middleware.UserIDKey, user)
+			c.Locals(middleware.SessionIDKey, "session")
+		}
+		return c.Next()
+	})
+	register(app)
+	return app
+}
+
+// statusAs sends a request as user, anonymous when empty, and returns the response status
+func statusAs(t *testing.T, app *fiber.App, method, target, user string) int {
+	t.Helper()
+	return sendAs(t, app, httptest.NewRequest(method, target, nil), user)
+}
+
+// sendAs sends req as user, anonymous when empty, and returns the response status
+func sendAs(t *testing.T, app *fiber.App, req *http.Request, user string) int {
+	t.Helper()
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
+
+// TestUserDataRequiresOwner checks that routes for a user's data refuse anonymous requests and other users.
+// The use cases are never reached, so they are left nil.
+func TestUserDataRequiresOwner(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(fiber.Router)
+		method   string
+		target   string
+	}{
+		{"list trash", func(h fiber.Router) { NewTrashRoutes(h, (*usecase.TrashUseCase)(nil), testLogger) }, fiber.MethodGet, "/trash/user/alice"},
+		{"restore from trash", func(h fiber.Router) { NewTrashRoutes(h, (*usecase.TrashUseCase)(nil), testLogger) }, fiber.MethodPost, "/trash/user/alice/meal/1/restore"},
+		{"sync push", func(h fiber.Router) { NewSyncRoutes(h, (*usecase.SyncUseCase)(nil), testLogger) }, fiber.MethodPost, "/sync/user/alice/push"},
+		{"sync pull", func(h fiber.Router) { NewSyncRoutes(h, (*usecase.SyncUseCase)(nil), testLogger) }, fiber.MethodGet, "/sync/user/alice/pull"},
+		{"request export", func(h fiber.Router) { NewDataExportRoutes(h, (*usecase.DataExportUseCase)(nil), testLogger) }, fiber.MethodPost, "/exports/user/alice"},
+		{"get export", func(h fiber.Router) { NewDataExportRoutes(h, (*usecase.DataExportUseCase)(nil), testLogger) }, fiber.MethodGet, "/exports/user/alice/1"},
+		{"change password", func(h fiber.Router) { NewUserRoutes(h, (*usecase.UserUseCase)(nil), testLogger) }, fiber.MethodPut, "/users/alice/password"},
+		{"get user", func(h fiber.Router) { NewUserRoutes(h, (*usecase.UserUseCase)(nil), testLogger) }, fiber.MethodGet, "/users/alice"},
+		{"update user", func(h fiber.Router) { NewUserRoutes(h, (*usecase.UserUseCase)(nil), testLogger) }, fiber.MethodPut, "/users/alice"},
+		{"patch user", func(h fiber.Router) { NewUserRoutes(h, (*usecase.UserUseCase)(nil), testLogger) }, fiber.MethodPatch, "/users/alice"},
+		{"delete user", func(h fiber.Router) { NewUserRoutes(h, (*usecase.UserUseCase)(nil), testLogger) }, fiber.MethodDelete, "/users/alice"},
+		{"cancel deletion", func(h fiber.Router) { NewUserRoutes(h, (*usecase.UserUseCase)(nil), testLogger) }, fiber.MethodPost, "/users/alice/cancel-deletion"},
+		{"get roles", func(h fiber.Router) { NewUserRoutes(h, (*usecase.UserUseCase)(nil), testLogger) }, fiber.MethodGet, "/users/alice/roles"},
+		{"list meals", func(h fiber.Router) { NewMealRoutes(h, (*usecase.MealUseCase)(nil), testLogger) }, fiber.MethodGet, "/meals/user/alice"},
+		{"list workout sessions", func(h fiber.Router) {
+			NewWorkoutSessionRoutes(h, (*usecase.WorkoutSessionUseCase)(nil), testLogger)
+		}, fiber.MethodGet, "/workout-sessions/user/alice"},
+		{"active nutrition goals", func(h fiber.Router) { NewNutritionRoutes(h, (*usecase.NutritionUseCase)(nil), testLogger) }, fiber.MethodGet, "/nutrition/goals/user/alice/active"},
+		{"nutrition goals history", func(h fiber.Router) { NewNutritionRoutes(h, (*usecase.NutritionUseCase)(nil), testLogger) }, fiber.MethodGet, "/nutrition/goals/user/alice/history"},
+		{"biometrics history", func(h fiber.Router) { NewNutritionRoutes(h, (*usecase.NutritionUseCase)(nil), testLogger) }, fiber.MethodGet, "/nutrition/biometrics/user/alice/history"},
+		{"latest biometrics", func(h fiber.Router) { NewNutritionRoutes(h, (*usecase.NutritionUseCase)(nil), testLogger) }, fiber.MethodGet, "/nutrition/biometrics/user/alice/latest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(tt.register)
+			if got := statusAs(t, app, tt.method, tt.target, ""); got != fiber.StatusUnauthorized {
+				t.Errorf("anonymous status = %d, want %d", got, fiber.StatusUnauthorized)
+			}
+			if got := statusAs(t, app, tt.method, tt.target, "mallory"); got != fiber.StatusForbidden {
+				t.Errorf("other user status = %d, want %d", got, fiber.StatusForbidden)
+			}
+		})
+	}
+}
+
+// TestScopedRoutesRequireAuth checks that routes limited to token scopes refuse anonymous requests,
+// including those for a single record, whose owner is checked by the use cases
+func TestScopedRoutesRequireAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(fiber.Router)
+		method   string
+		target   string
+	}{
+		{"meal", func(h fiber.Router) { NewMealRoutes(h, (*usecase.MealUseCase)(nil), testLogger) }, fiber.MethodGet, "/meals/1"},
+		{"meal food item", func(h fiber.Router) { NewMealRoutes(h, (*usecase.MealUseCase)(nil), testLogger) }, fiber.MethodDelete, "/meals/food-items/1"},
+		{"workout session", func(h fiber.Router) {
+			NewWorkoutSessionRoutes(h, (*usecase.WorkoutSessionUseCase)(nil), testLogger)
+		}, fiber.MethodDelete, "/workout-sessions/1"},
+		{"nutrition goals", func(h fiber.Router) { NewNutritionRoutes(h, (*usecase.NutritionUseCase)(nil), testLogger) }, fiber.MethodGet, "/nutrition/goals/1"},
+		{"biometrics", func(h fiber.Router) { NewNutritionRoutes(h, (*usecase.NutritionUseCase)(nil), testLogger) }, fiber.MethodGet, "/nutrition/biometrics/1"},
+		{"food item", func(h fiber.Router) { NewFoodItemRoutes(h, (*usecase.FoodItemUseCase)(nil), testLogger) }, fiber.MethodGet, "/food-items/1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(tt.register)
+			if got := statusAs(t, app, tt.method, tt.target, ""); got != fiber.StatusUnauthorized {
+				t.Errorf("anonymous status = %d, want %d", got, fiber.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+// fakeFoodItemRepository holds a food item created by alice and one imported, without a creator
+type fakeFoodItemRepository struct {
+	repository.FoodItemRepository
+}
+
+func (fakeFoodItemRepository) GetByID(_ context.Context, id string) (*entity.FoodItem, error) {
+	alice := "alice"
+	switch id {
+	case "alices":
+		return &entity.FoodItem{ID: id, Name: "Granola", ServingSizeDefaultQty: 100, ServingSizeDefaultUnit: "g", CreatedByUserID: &alice, Source: entity.FoodItemSourceUserCreated}, nil
+	case "imported":
+		return &entity.FoodItem{ID: id, Name: "Apples, raw", ServingSizeDefaultQty: 100, ServingSizeDefaultUnit: "g", Source: entity.FoodItemSourceSystem}, nil
+	}
+	return nil, nil
+}
+
+func (fakeFoodItemRepository) Update(context.Context, *entity.FoodItem) error {
+	return nil
+}
+
+func (fakeFoodItemRepository) Delete(context.Context, string) error {
+	return nil
+}
+
+// discardAuditLogRepository accepts audit log entries
+type discardAuditLogRepository struct {
+	repository.AuditLogRepository
+}
+
+func (discardAuditLogRepository) Create(context.Context, *entity.AuditLog) error {
+	return nil
+}
+
+// TestFoodItemEditsRequireCreatorOrAdmin checks that food items are changed only by their creator or an admin,
+// whatever the scopes of the caller
+func TestFoodItemEditsRequireCreatorOrAdmin(t *testing.T) {
+	uc := usecase.NewFoodItemUseCase(fakeFoodItemRepository{}, fakeRoleRepository{}, nil, discardAuditLogRepository{}, usecase.Config{})
+	app := newTestApp(func(h fiber.Router) { NewFoodItemRoutes(h, uc, testLogger) })
+
+	requests := map[string]func(id string) *http.Request{
+		"replace": func(id string) *http.Request {
+			req := httptest.NewRequest(fiber.MethodPut, "/food-items/"+id, strings.NewReader(`{"name":"Muesli","serving_size_default_qty":100,"serving_size_default_unit":"g"}`))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			return req
+		},
+		"patch": func(id string) *http.Request {
+			req := httptest.NewRequest(fiber.MethodPatch, "/food-items/"+id, strings.NewReader(`{"name":"Muesli"}`))
+			req.Header.Set(fiber.HeaderContentType, "application/merge-patch+json")
+			return req
+		},
+		"delete": func(id string) *http.Request {
+			return httptest.NewRequest(fiber.MethodDelete, "/food-items/"+id, nil)
+		},
+	}
+	tests := []struct {
+		id, user string
+		allowed  bool
+	}{
+		{id: "alices", user: "alice", allowed: true},
+		{id: "alices", user: "mallory"},
+		{id: "alices", user: "root", allowed: true},
+		{id: "imported", user: "alice"},
+		{id: "imported", user: "root", allowed: true},
+	}
+	for name, request := range requests {
+		for _, tt := range tests {
+			got := sendAs(t, app, request(tt.id), tt.user)
+			if allowed := got < fiber.StatusBadRequest; allowed != tt.allowed || (!allowed && got != fiber.StatusForbidden) {
+				t.Errorf("%s %s as %s status = %d, want allowed: %v", name, tt.id, tt.user, got, tt.allowed)
+			}
+		}
+	}
+}
+
+// TestSignedDownloadSkipsAuth checks that the download links of exports work without signing in,
+// their signature being checked instead
+func TestSignedDownloadSkipsAuth(t *testing.T) {
+	uc := usecase.NewDataExportUseCase(nil, nil, nil, usecase.DataExportConfig{LinkSecret: []byte("secret")})
+	app := newTestApp(func(h fiber.Router) { NewDataExportRoutes(h, uc, testLogger) })
+
+	if got := statusAs(t, app, fiber.MethodGet, "/exports/abc/download?expires=1&signature=x", ""); got != fiber.StatusForbidden {
+		t.Errorf("anonymous download status = %d, want the invalid signature's %d", got, fiber.StatusForbidden)
+	}
+}
@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+)
+
+// fakeRoleRepository gives the admin role to the user "root"
+type fakeRoleRepository struct {
+	repository.RoleRepository
+}
+
+func (fakeRoleRepository) ListByUserID(_ context.Context, userID string) ([]*entity.Role, error) {
+	if userID == "root" {
+		return []*entity.Role{{ID: 1, Name: entity.RoleAdmin}}, nil
+	}
+	return []*entity.Role{{ID: 2, Name: "coach"}}, nil
+}
+
+// emptyUserRepository has no users
+type emptyUserRepository struct {
+	repository.UserRepository
+}
+
+func (emptyUserRepository) GetByID(context.Context, string) (*entity.User, error) {
+	return nil, nil
+}
+
+func TestAdminRoutesRequireAdmin(t *testing.T) {
+	userUC := usecase.NewUserUseCase(emptyUserRepository{}, fakeRoleRepository{}, nil, nil, nil, usecase.UserConfig{})
+
+	tests := []struct {
+		name     string
+		register func(fiber.Router)
+		method   string
+		target   string
+		// admin is the status an admin gets, past the authorization
+		admin int
+	}{
+		{"audit log", func(h fiber.Router) {
+			NewAuditRoutes(h, usecase.NewAuditUseCase(nil, usecase.Config{}), userUC, testLogger)
+		}, fiber.MethodGet, "/admin/audit-logs?filter[password][eq]=x", fiber.StatusBadRequest},
+		{"assign role", func(h fiber.Router) { NewUserRoutes(h, userUC, testLogger) }, fiber.MethodPut, "/users/alice/roles/admin", fiber.StatusNotFound},
+		{"revoke role", func(h fiber.Router) { NewUserRoutes(h, userUC, testLogger) }, fiber.MethodDelete, "/users/alice/roles/admin", fiber.StatusNotFound},
+		{"verify email", func(h fiber.Router) { NewUserRoutes(h, userUC, testLogger) }, fiber.MethodPut, "/users/alice/verify-email", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(tt.register)
+			if got := statusAs(t, app, tt.method, tt.target, ""); got != fiber.StatusUnauthorized {
+				t.Errorf("anonymous status = %d, want %d", got, fiber.StatusUnauthorized)
+			}
+			if got := statusAs(t, app, tt.method, tt.target, "alice"); got != fiber.StatusForbidden {
+				t.Errorf("non-admin status = %d, want %d", got, fiber.StatusForbidden)
+			}
+			if got := statusAs(t, app, tt.method, tt.target, "root"); got != tt.admin {
+				t.Errorf("admin status = %d, want %d", got, tt.admin)
+			}
+		})
+	}
+}
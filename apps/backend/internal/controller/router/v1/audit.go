@@ -0,0 +1,64 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+type AuditRoutes struct {
+	auditUC *usecase.AuditUseCase
+	log     logger.Interface
+}
+
+func NewAuditRoutes(handler fiber.Router, uc *usecase.AuditUseCase, roles middleware.RoleChecker, l logger.Interface) {
+	r := &AuditRoutes{
+		auditUC: uc,
+		log:     l,
+	}
+
+	h := handler.Group("/admin/audit-logs", middleware.DenyDelegatedTokens(), middleware.RequireRole(roles, entity.RoleAdmin))
+	{
+		h.Get("/", r.list)
+	}
+}
+
+// @Summary List audit log entries
+// @Description Get a cursor paginated list of audit log entries, most recent first.
+// @Description Filter by actor_id, action, target_type, target_id, ip_address (eq, in) and created_at (eq, in, gte, lte),
+// @Description e.g. filter[action][in]=login,login_failed&filter[created_at][gte]=2026-10-01T00:00:00Z
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param limit query int false "Page size" default(50)
+// @Param cursor query string false "Opaque next_cursor or prev_cursor from a previous response"
+// @Param filter query string false "Filters as filter[field][op]=value"
+// @Success 200 {object} CursorResponse{data=[]entity.AuditLog}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/audit-logs [get]
+func (r *AuditRoutes) list(c *fiber.Ctx) error {
+	spec, err := parseQuerySpec(c, repository.AuditLogFields)
+	if err != nil {
+		return err
+	}
+
+	entries, info, err := r.auditUC.ListAuditLogs(c.Context(), spec, repository.CursorPage{
+		Limit:  c.QueryInt("limit"),
+		Cursor: c.Query("cursor"),
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(CursorResponse{
+		Data:       entries,
+		NextCursor: info.NextCursor,
+		PrevCursor: info.PrevCursor,
+	})
+}
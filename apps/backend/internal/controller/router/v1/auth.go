@@ -0,0 +1,268 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+type AuthRoutes struct {
+	authUC *usecase.AuthUseCase
+	log    logger.Interface
+}
+
+func NewAuthRoutes(handler fiber.Router, uc *usecase.AuthUseCase, l logger.Interface) {
+	r := &AuthRoutes{
+		authUC: uc,
+		log:    l,
+	}
+
+	h := handler.Group("/auth")
+	{
+		h.Post("/login", r.login)
+		h.Post("/login/2fa", r.verifyTwoFactor)
+		h.Post("/refresh", r.refresh)
+
+		twoFactor := h.Group("/2fa", middleware.RequireAuth(), middleware.DenyDelegatedTokens())
+		twoFactor.Post("/enroll", r.enrollTwoFactor)
+		twoFactor.Post("/confirm", r.confirmTwoFactor)
+		twoFactor.Post("/disable", r.disableTwoFactor)
+		twoFactor.Post("/recovery-codes", r.regenerateRecoveryCodes)
+
+		sessions := h.Group("/sessions", middleware.RequireAuth(), middleware.DenyDelegatedTokens())
+		sessions.Get("", r.listSessions)
+		sessions.Post("/revoke-others", r.revokeOtherSessions)
+		sessions.Delete("/:id", r.revokeSession)
+	}
+}
+
+// @Summary Sign in
+// @Description Verify a user's email and password and start a session for the device. Users with two-factor authentication get a challenge token to answer at /auth/login/2fa instead of tokens. After too many failed attempts the account is locked for a growing time.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body loginRequest true "Email and password"
+// @Success 200 {object} entity.LoginResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Header 429 {integer} Retry-After "Seconds to wait before trying again"
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/login [post]
+func (r *AuthRoutes) login(c *fiber.Ctx) error {
+	var req loginRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	result, err := r.authUC.Login(c.Context(), req.Email, req.Password, req.DeviceName)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(result)
+}
+
+// @Summary Verify two-factor code
+// @Description Answer the challenge of the password step with a TOTP code or a recovery code to get the access and refresh tokens. Each code is accepted once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body verifyTwoFactorRequest true "Challenge token and code"
+// @Success 200 {object} entity.TokenPair
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Header 429 {integer} Retry-After "Seconds to wait before trying again"
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/login/2fa [post]
+func (r *AuthRoutes) verifyTwoFactor(c *fiber.Ctx) error {
+	var req verifyTwoFactorRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	pair, err := r.authUC.VerifyTwoFactor(c.Context(), req.ChallengeToken, req.Code)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(pair)
+}
+
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new token pair. Refresh tokens are used once, reusing one signs the user out everywhere.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body refreshRequest true "Refresh token"
+// @Success 200 {object} entity.TokenPair
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/refresh [post]
+func (r *AuthRoutes) refresh(c *fiber.Ctx) error {
+	var req refreshRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	pair, err := r.authUC.Refresh(c.Context(), req.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(pair)
+}
+
+// @Summary Enroll in two-factor authentication
+// @Description Generate a TOTP secret and its otpauth URI for an authenticator app. Two-factor authentication is turned on once a code is confirmed.
+// @Tags auth
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} entity.TwoFactorEnrollment
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/2fa/enroll [post]
+func (r *AuthRoutes) enrollTwoFactor(c *fiber.Ctx) error {
+	enrollment, err := r.authUC.EnrollTwoFactor(c.Context(), middleware.UserID(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(enrollment)
+}
+
+// @Summary Confirm two-factor authentication
+// @Description Turn two-factor authentication on with a code from the authenticator app. The recovery codes are only shown in this response.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body twoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} entity.RecoveryCodes
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/2fa/confirm [post]
+func (r *AuthRoutes) confirmTwoFactor(c *fiber.Ctx) error {
+	var req twoFactorCodeRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	codes, err := r.authUC.ConfirmTwoFactor(c.Context(), middleware.UserID(c), req.Code)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(codes)
+}
+
+// @Summary Disable two-factor authentication
+// @Description Turn two-factor authentication off, which takes a TOTP code or a recovery code.
+// @Tags auth
+// @Accept json
+// @Security Bearer
+// @Param request body twoFactorCodeRequest true "TOTP or recovery code"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/2fa/disable [post]
+func (r *AuthRoutes) disableTwoFactor(c *fiber.Ctx) error {
+	var req twoFactorCodeRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	if err := r.authUC.DisableTwoFactor(c.Context(), middleware.UserID(c), req.Code); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary Regenerate recovery codes
+// @Description Replace the recovery codes, which takes a TOTP code. The new codes are only shown in this response.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body twoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} entity.RecoveryCodes
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/2fa/recovery-codes [post]
+func (r *AuthRoutes) regenerateRecoveryCodes(c *fiber.Ctx) error {
+	var req twoFactorCodeRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	codes, err := r.authUC.RegenerateRecoveryCodes(c.Context(), middleware.UserID(c), req.Code)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(codes)
+}
+
+// @Summary List sessions
+// @Description List the devices the user is signed in on, most recently used first. The session of the request is marked as current.
+// @Tags auth
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} entity.Session
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/sessions [get]
+func (r *AuthRoutes) listSessions(c *fiber.Ctx) error {
+	sessions, err := r.authUC.ListSessions(c.Context(), middleware.UserID(c), middleware.SessionID(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(sessions)
+}
+
+// @Summary Revoke session
+// @Description Sign a device out. Revoking the current session signs out, its access token stays valid until it expires.
+// @Tags auth
+// @Security Bearer
+// @Param id path string true "Session ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/sessions/{id} [delete]
+func (r *AuthRoutes) revokeSession(c *fiber.Ctx) error {
+	if err := r.authUC.RevokeSession(c.Context(), middleware.UserID(c), c.Params("id")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary Revoke other sessions
+// @Description Sign every device out but the one of the request.
+// @Tags auth
+// @Security Bearer
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/sessions/revoke-others [post]
+func (r *AuthRoutes) revokeOtherSessions(c *fiber.Ctx) error {
+	if err := r.authUC.RevokeOtherSessions(c.Context(), middleware.UserID(c), middleware.SessionID(c)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,115 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+type CoachRoutes struct {
+	coachUC *usecase.CoachUseCase
+	log     logger.Interface
+}
+
+func NewCoachRoutes(handler fiber.Router, uc *usecase.CoachUseCase, l logger.Interface) {
+	r := &CoachRoutes{
+		coachUC: uc,
+		log:     l,
+	}
+
+	// Who sees a user's progress photos is decided from a session, not by apps acting for the user
+	h := handler.Group("/coaches", middleware.RequireAuth(), middleware.DenyDelegatedTokens())
+	{
+		h.Post("/", r.authorize)
+		h.Get("/", r.listCoaches)
+		h.Get("/clients", r.listClients)
+		h.Delete("/:coachID", r.revoke)
+	}
+}
+
+// @Summary Authorise a coach
+// @Description Let another user see the signed in user's progress photos and the measurements they are compared with.
+// @Description Authorising a coach again changes nothing.
+// @Tags coaches
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body authorizeCoachRequest true "Username of the coach"
+// @Success 201 {object} entity.CoachGrant
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /coaches [post]
+func (r *CoachRoutes) authorize(c *fiber.Ctx) error {
+	var req authorizeCoachRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	grant, err := r.coachUC.Authorize(c.Context(), middleware.UserID(c), req.Username)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(grant)
+}
+
+// @Summary List coaches
+// @Description List the coaches the signed in user authorised
+// @Tags coaches
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} entity.CoachGrant
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /coaches [get]
+func (r *CoachRoutes) listCoaches(c *fiber.Ctx) error {
+	coaches, err := r.coachUC.ListCoaches(c.Context(), middleware.UserID(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(coaches)
+}
+
+// @Summary List clients
+// @Description List the users who authorised the signed in user as their coach
+// @Tags coaches
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} entity.CoachGrant
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /coaches/clients [get]
+func (r *CoachRoutes) listClients(c *fiber.Ctx) error {
+	clients, err := r.coachUC.ListClients(c.Context(), middleware.UserID(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(clients)
+}
+
+// @Summary Revoke a coach
+// @Description Withdraw a coach's access to the signed in user's progress photos
+// @Tags coaches
+// @Security Bearer
+// @Param coachID path string true "Coach user ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /coaches/{coachID} [delete]
+func (r *CoachRoutes) revoke(c *fiber.Ctx) error {
+	if err := r.coachUC.Revoke(c.Context(), middleware.UserID(c), c.Params("coachID")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+const routeExportDownload = "exports.download"
+
+type DataExportRoutes struct {
+	exportUC *usecase.DataExportUseCase
+	log      logger.Interface
+}
+
+func NewDataExportRoutes(handler fiber.Router, uc *usecase.DataExportUseCase, l logger.Interface) {
+	r := &DataExportRoutes{
+		exportUC: uc,
+		log:      l,
+	}
+
+	scope := middleware.RequireScope(entity.ScopeReadExports, entity.ScopeWriteExports)
+	h := handler.Group("/exports")
+	{
+		h.Post("/user/:userID", middleware.RequireAuth(), scope, middleware.RequireOwner("userID"), r.request)
+		h.Get("/user/:userID/:id", middleware.RequireAuth(), scope, middleware.RequireOwner("userID"), r.get)
+		// The signed link is the authorisation, so that the archive downloads from a plain link
+		h.Get("/:id/download", r.download).Name(routeExportDownload)
+	}
+}
+
+// response adds the download link to a completed export
+func (r *DataExportRoutes) response(c *fiber.Ctx, export *entity.DataExport) (DataExportResponse, error) {
+	resp := DataExportResponse{DataExport: export}
+
+	signature := r.exportUC.DownloadSignature(export)
+	if signature == "" {
+		return resp, nil
+	}
+	path, err := c.GetRouteURL(routeExportDownload, fiber.Map{"id": export.ID})
+	if err != nil {
+		return resp, err
+	}
+	resp.DownloadURL = c.BaseURL() + path + "?expires=" + strconv.FormatInt(export.ExpiresAt.Unix(), 10) + "&signature=" + signature
+	return resp, nil
+}
+
+// @Summary Request a data export
+// @Description Queue an export of everything tied to a user: profile, biometrics, nutrition goals, meals with their food items,
+// @Description workout sessions with their logs, custom exercises, custom food items and workout plans.
+// @Description The export is built in the background, poll it until its status is completed and download the ZIP of JSON and CSV files
+// @Description from download_url before expires_at. While an export is still pending or running it is returned instead of a new one.
+// @Tags exports
+// @Produce json
+// @Security Bearer
+// @Param userID path string true "User ID"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
+// @Success 202 {object} DataExportResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /exports/user/{userID} [post]
+func (r *DataExportRoutes) request(c *fiber.Ctx) error {
+	export, err := r.exportUC.RequestExport(c.Context(), c.Params("userID"))
+	if err != nil {
+		return err
+	}
+
+	resp, err := r.response(c, export)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(resp)
+}
+
+// @Summary Get a data export
+// @Description Get the status of a data export, completed exports carry a download_url that is valid until expires_at
+// @Tags exports
+// @Produce json
+// @Security Bearer
+// @Param userID path string true "User ID"
+// @Param id path string true "Export ID"
+// @Success 200 {object} DataExportResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /exports/user/{userID}/{id} [get]
+func (r *DataExportRoutes) get(c *fiber.Ctx) error {
+	export, err := r.exportUC.GetExport(c.Context(), c.Params("userID"), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	resp, err := r.response(c, export)
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
+
+// @Summary Download a data export
+// @Description Download the ZIP archive of a completed data export through the signed download_url of the export
+// @Tags exports
+// @Produce application/zip
+// @Param id path string true "Export ID"
+// @Param expires query int true "Expiry of the link as a Unix timestamp"
+// @Param signature query string true "Signature of the link"
+// @Success 200 {file} file
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /exports/{id}/download [get]
+func (r *DataExportRoutes) download(c *fiber.Ctx) error {
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		return usecase.ErrDownloadLinkInvalid
+	}
+
+	file, err := r.exportUC.OpenDownload(c.Context(), c.Params("id"), expires, c.Query("signature"))
+	if err != nil {
+		return err
+	}
+
+	c.Attachment("rebound-export-" + c.Params("id") + ".zip")
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	// The response closes the file once it has been sent
+	return c.SendStream(file)
+}
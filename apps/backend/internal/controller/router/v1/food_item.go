@@ -4,7 +4,9 @@ import (
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
 	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
 	"github.com/terrnit/rebound/backend/internal/usecase"
 	"github.com/terrnit/rebound/backend/pkg/logger"
 )
@@ -19,21 +21,25 @@ type foodItemHandler struct {
 // @Tags food-items
 // @Accept json
 // @Produce json
-// @Param foodItem body entity.FoodItem true "Food item details"
+// @Param foodItem body foodItemRequest true "Food item details"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
 // @Success 201 {object} entity.FoodItem
 // @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /food-items [post]
 func (h *foodItemHandler) create(c *fiber.Ctx) error {
-	var foodItem entity.FoodItem
-	if err := c.BodyParser(&foodItem); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid request body"})
+	var req foodItemRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
 	}
 
-	created, err := h.usecase.CreateFoodItem(c.Context(), &foodItem)
+	foodItem := entity.FoodItem{Source: entity.FoodItemSourceUserCreated}
+	req.apply(&foodItem)
+
+	created, err := h.usecase.CreateFoodItem(c.Context(), middleware.UserID(c), &foodItem)
 	if err != nil {
-		h.logger.Error("Failed to create food item", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to create food item"})
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(created)
@@ -45,6 +51,7 @@ func (h *foodItemHandler) create(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path string true "Food item ID"
 // @Success 200 {object} entity.FoodItem
+// @Header 200 {string} ETag "Current version of the food item"
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /food-items/{id} [get]
@@ -52,23 +59,50 @@ func (h *foodItemHandler) getByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 	foodItem, err := h.usecase.GetFoodItem(c.Context(), id)
 	if err != nil {
-		h.logger.Error("Failed to get food item", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to get food item"})
+		return err
 	}
-	if foodItem == nil {
-		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{Error: "Food item not found"})
+
+	setETag(c, foodItem.UpdatedAt)
+	return c.JSON(foodItem)
+}
+
+// @Summary Get a food item by barcode
+// @Description Get a food item by its UPC-A, EAN-13 or EAN-8 barcode, hyphens are ignored.
+// @Description Barcodes missing from the catalogue are looked up in Open Food Facts, the product found is added to the
+// @Description catalogue with source api and nutrition per 100 g.
+// @Tags food-items
+// @Produce json
+// @Param code path string true "Barcode"
+// @Success 200 {object} entity.FoodItem
+// @Header 200 {string} ETag "Current version of the food item"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Router /food-items/barcode/{code} [get]
+func (h *foodItemHandler) getByBarcode(c *fiber.Ctx) error {
+	foodItem, err := h.usecase.GetFoodItemByBarcode(c.Context(), c.Params("code"))
+	if err != nil {
+		return err
 	}
 
+	setETag(c, foodItem.UpdatedAt)
 	return c.JSON(foodItem)
 }
 
 // @Summary List food items
-// @Description Get a paginated list of food items
+// @Description Get a paginated list of food items.
+// @Description Passing limit or cursor switches to cursor pagination ordered by name, otherwise page and page_size are used.
 // @Tags food-items
 // @Produce json
 // @Param page query int false "Page number (default: 1)"
 // @Param page_size query int false "Items per page (default: 10)"
+// @Param limit query int false "Items per page in cursor mode (default: 10)"
+// @Param cursor query string false "Opaque next_cursor or prev_cursor from a previous response"
+// @Param filter query string false "Filters as filter[field][op]=value, op is one of eq, in, gte, lte, ilike"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending (e.g. -created_at,name). Not supported in cursor mode"
 // @Success 200 {object} PaginatedResponse{data=[]entity.FoodItem}
+// @Success 200 {object} CursorResponse{data=[]entity.FoodItem}
 // @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /food-items [get]
@@ -76,10 +110,27 @@ func (h *foodItemHandler) list(c *fiber.Ctx) error {
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	pageSize, _ := strconv.Atoi(c.Query("page_size", "10"))
 
-	items, total, err := h.usecase.ListFoodItems(c.Context(), nil, page, pageSize)
+	spec, err := parseQuerySpec(c, repository.FoodItemFields)
+	if err != nil {
+		return err
+	}
+
+	if c.Query("cursor") != "" || c.Query("limit") != "" {
+		items, info, err := h.usecase.ListFoodItemsPage(c.Context(), spec, parseCursorPage(c))
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(CursorResponse{
+			Data:       items,
+			NextCursor: info.NextCursor,
+			PrevCursor: info.PrevCursor,
+		})
+	}
+
+	items, total, err := h.usecase.ListFoodItems(c.Context(), spec, page, pageSize)
 	if err != nil {
-		h.logger.Error("Failed to list food items", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to list food items"})
+		return err
 	}
 
 	return c.JSON(PaginatedResponse{
@@ -104,7 +155,7 @@ func (h *foodItemHandler) list(c *fiber.Ctx) error {
 func (h *foodItemHandler) search(c *fiber.Ctx) error {
 	query := c.Query("query")
 	if query == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Search query is required"})
+		return usecase.ErrInvalidInput.WithField("query", "is required")
 	}
 
 	page, _ := strconv.Atoi(c.Query("page", "1"))
@@ -112,8 +163,7 @@ func (h *foodItemHandler) search(c *fiber.Ctx) error {
 
 	items, total, err := h.usecase.SearchFoodItems(c.Context(), query, page, pageSize)
 	if err != nil {
-		h.logger.Error("Failed to search food items", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to search food items"})
+		return err
 	}
 
 	return c.JSON(PaginatedResponse{
@@ -125,46 +175,104 @@ func (h *foodItemHandler) search(c *fiber.Ctx) error {
 }
 
 // @Summary Update a food item
-// @Description Update an existing food item
+// @Description Replace an existing food item. Only its creator or an admin can.
 // @Tags food-items
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Food item ID"
-// @Param foodItem body entity.FoodItem true "Updated food item details"
+// @Param If-Match header string false "ETag of the version being replaced"
+// @Param foodItem body foodItemRequest true "Updated food item details"
 // @Success 200 {object} entity.FoodItem
+// @Header 200 {string} ETag "New version of the food item"
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /food-items/{id} [put]
 func (h *foodItemHandler) update(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var foodItem entity.FoodItem
-	if err := c.BodyParser(&foodItem); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid request body"})
+	var req foodItemRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	foodItem, err := h.usecase.GetFoodItem(c.Context(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(c, foodItem.UpdatedAt); err != nil {
+		return err
+	}
+
+	req.apply(foodItem)
+	if err := h.usecase.UpdateFoodItem(c.Context(), middleware.UserID(c), foodItem); err != nil {
+		return err
+	}
+
+	setETag(c, foodItem.UpdatedAt)
+	return c.JSON(foodItem)
+}
+
+// @Summary Patch a food item
+// @Description Partially update a food item with a JSON merge patch (RFC 7396).
+// @Description Omitted fields are left unchanged and null clears a field. Only the creator of the item or an admin can.
+// @Tags food-items
+// @Accept application/merge-patch+json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Food item ID"
+// @Param If-Match header string false "ETag of the version being patched"
+// @Param foodItem body foodItemRequest true "Merge patch"
+// @Success 200 {object} entity.FoodItem
+// @Header 200 {string} ETag "New version of the food item"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /food-items/{id} [patch]
+func (h *foodItemHandler) patch(c *fiber.Ctx) error {
+	foodItem, err := h.usecase.GetFoodItem(c.Context(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(c, foodItem.UpdatedAt); err != nil {
+		return err
+	}
+
+	req, err := bindPatch(c, foodItemRequestFrom(foodItem))
+	if err != nil {
+		return err
 	}
 
-	foodItem.ID = id
-	if err := h.usecase.UpdateFoodItem(c.Context(), &foodItem); err != nil {
-		h.logger.Error("Failed to update food item", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to update food item"})
+	req.apply(foodItem)
+	if err := h.usecase.UpdateFoodItem(c.Context(), middleware.UserID(c), foodItem); err != nil {
+		return err
 	}
 
+	setETag(c, foodItem.UpdatedAt)
 	return c.JSON(foodItem)
 }
 
 // @Summary Delete a food item
-// @Description Delete a food item by its ID
+// @Description Move a food item to the trash, it can be restored for 30 days. Only its creator or an admin can.
 // @Tags food-items
+// @Security Bearer
 // @Param id path string true "Food item ID"
 // @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /food-items/{id} [delete]
 func (h *foodItemHandler) delete(c *fiber.Ctx) error {
 	id := c.Params("id")
-	if err := h.usecase.DeleteFoodItem(c.Context(), id); err != nil {
-		h.logger.Error("Failed to delete food item", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to delete food item"})
+	if err := h.usecase.DeleteFoodItem(c.Context(), middleware.UserID(c), id); err != nil {
+		return err
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
@@ -177,13 +285,15 @@ func NewFoodItemRoutes(router fiber.Router, uc *usecase.FoodItemUseCase, l logge
 		logger:  l,
 	}
 
-	foodItems := router.Group("/food-items")
+	foodItems := router.Group("/food-items", middleware.RequireScope(entity.ScopeReadFoods, entity.ScopeWriteFoods))
 	{
 		foodItems.Post("/", handler.create)
 		foodItems.Get("/:id", handler.getByID)
+		foodItems.Get("/barcode/:code", handler.getByBarcode)
 		foodItems.Get("/", handler.list)
 		foodItems.Get("/search", handler.search)
 		foodItems.Put("/:id", handler.update)
+		foodItems.Patch("/:id", handler.patch)
 		foodItems.Delete("/:id", handler.delete)
 	}
 }
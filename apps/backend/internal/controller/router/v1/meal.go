@@ -2,6 +2,9 @@ package v1
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
 	"github.com/terrnit/rebound/backend/internal/usecase"
 	"github.com/terrnit/rebound/backend/pkg/logger"
 )
@@ -17,13 +20,14 @@ func NewMealRoutes(handler fiber.Router, uc *usecase.MealUseCase, l logger.Inter
 		log:    l,
 	}
 
-	h := handler.Group("/meals")
+	h := handler.Group("/meals", middleware.RequireScope(entity.ScopeReadMeals, entity.ScopeWriteMeals))
 	{
 		h.Post("/", r.createMeal)
 		h.Get("/:id", r.getMeal)
 		h.Put("/:id", r.updateMeal)
+		h.Patch("/:id", r.patchMeal)
 		h.Delete("/:id", r.deleteMeal)
-		h.Get("/user/:userID", r.getUserMeals)
+		h.Get("/user/:userID", middleware.RequireOwner("userID"), r.getUserMeals)
 		h.Post("/:id/food-items", r.addFoodItem)
 		h.Get("/:id/food-items", r.getFoodItems)
 		h.Put("/food-items/:id", r.updateFoodItem)
@@ -36,14 +40,31 @@ func NewMealRoutes(handler fiber.Router, uc *usecase.MealUseCase, l logger.Inter
 // @Tags meals
 // @Accept json
 // @Produce json
-// @Param meal body entity.UserMeal true "Meal object"
+// @Security Bearer
+// @Param meal body createMealRequest true "Meal object"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
 // @Success 201 {object} entity.UserMeal
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /meals [post]
 func (r *MealRoutes) createMeal(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	var req createMealRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	meal := entity.UserMeal{UserID: req.UserID}
+	req.apply(&meal)
+
+	created, err := r.mealUC.CreateMeal(c.Context(), middleware.UserID(c), &meal)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(created)
 }
 
 // @Summary Get a meal by ID
@@ -51,15 +72,24 @@ func (r *MealRoutes) createMeal(c *fiber.Ctx) error {
 // @Tags meals
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Meal ID"
 // @Success 200 {object} entity.UserMeal
+// @Header 200 {string} ETag "Current version of the meal"
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /meals/{id} [get]
 func (r *MealRoutes) getMeal(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	meal, err := r.mealUC.GetMeal(c.Context(), middleware.UserID(c), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	setETag(c, meal.UpdatedAt)
+	return c.JSON(meal)
 }
 
 // @Summary Update a meal
@@ -67,49 +97,139 @@ func (r *MealRoutes) getMeal(c *fiber.Ctx) error {
 // @Tags meals
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Meal ID"
-// @Param meal body entity.UserMeal true "Meal object"
+// @Param If-Match header string false "ETag of the version being replaced"
+// @Param meal body mealRequest true "Meal object"
 // @Success 200 {object} entity.UserMeal
+// @Header 200 {string} ETag "New version of the meal"
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /meals/{id} [put]
 func (r *MealRoutes) updateMeal(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	var req mealRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	meal, err := r.mealUC.GetMeal(c.Context(), middleware.UserID(c), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(c, meal.UpdatedAt); err != nil {
+		return err
+	}
+
+	req.apply(meal)
+	if err := r.mealUC.UpdateMeal(c.Context(), meal); err != nil {
+		return err
+	}
+
+	setETag(c, meal.UpdatedAt)
+	return c.JSON(meal)
+}
+
+// @Summary Patch a meal
+// @Description Partially update a meal with a JSON merge patch (RFC 7396).
+// @Description Omitted fields are left unchanged and null clears a field.
+// @Tags meals
+// @Accept application/merge-patch+json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Meal ID"
+// @Param If-Match header string false "ETag of the version being patched"
+// @Param meal body mealRequest true "Merge patch"
+// @Success 200 {object} entity.UserMeal
+// @Header 200 {string} ETag "New version of the meal"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /meals/{id} [patch]
+func (r *MealRoutes) patchMeal(c *fiber.Ctx) error {
+	meal, err := r.mealUC.GetMeal(c.Context(), middleware.UserID(c), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(c, meal.UpdatedAt); err != nil {
+		return err
+	}
+
+	req, err := bindPatch(c, mealRequestFrom(meal))
+	if err != nil {
+		return err
+	}
+
+	req.apply(meal)
+	if err := r.mealUC.UpdateMeal(c.Context(), meal); err != nil {
+		return err
+	}
+
+	setETag(c, meal.UpdatedAt)
+	return c.JSON(meal)
 }
 
 // @Summary Delete a meal
-// @Description Delete a meal by its ID
+// @Description Move a meal to the trash, it can be restored for 30 days
 // @Tags meals
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Meal ID"
 // @Success 204 "No Content"
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /meals/{id} [delete]
 func (r *MealRoutes) deleteMeal(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	if err := r.mealUC.DeleteMeal(c.Context(), middleware.UserID(c), c.Params("id")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
 }
 
 // @Summary Get user meals
-// @Description Get all meals for a specific user
+// @Description Get a cursor paginated list of meals for a specific user, most recent first
 // @Tags meals
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param userID path string true "User ID"
-// @Param page query int false "Page number" default(1)
-// @Param pageSize query int false "Page size" default(10)
-// @Success 200 {array} entity.UserMeal
+// @Param limit query int false "Page size" default(10)
+// @Param cursor query string false "Opaque next_cursor or prev_cursor from a previous response"
+// @Param filter query string false "Filters as filter[field][op]=value, op is one of eq, in, gte, lte, ilike"
+// @Success 200 {object} CursorResponse{data=[]entity.UserMeal}
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /meals/user/{userID} [get]
 func (r *MealRoutes) getUserMeals(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	spec, err := parseQuerySpec(c, repository.MealFields)
+	if err != nil {
+		return err
+	}
+
+	meals, info, err := r.mealUC.ListUserMeals(c.Context(), c.Params("userID"), spec, parseCursorPage(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(CursorResponse{
+		Data:       meals,
+		NextCursor: info.NextCursor,
+		PrevCursor: info.PrevCursor,
+	})
 }
 
 // @Summary Add food item to meal
@@ -117,16 +237,37 @@ func (r *MealRoutes) getUserMeals(c *fiber.Ctx) error {
 // @Tags meals
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Meal ID"
-// @Param foodItem body entity.MealFoodItem true "Food item object"
+// @Param foodItem body addMealFoodItemRequest true "Food item object"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
 // @Success 201 {object} entity.MealFoodItem
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /meals/{id}/food-items [post]
 func (r *MealRoutes) addFoodItem(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	var req addMealFoodItemRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	meal, err := r.mealUC.GetMeal(c.Context(), middleware.UserID(c), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	foodItem := entity.MealFoodItem{MealID: meal.ID, FoodItemID: req.FoodItemID}
+	req.apply(&foodItem)
+
+	if err := r.mealUC.AddFoodItemToMeal(c.Context(), &foodItem); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(foodItem)
 }
 
 // @Summary Get meal food items
@@ -134,15 +275,27 @@ func (r *MealRoutes) addFoodItem(c *fiber.Ctx) error {
 // @Tags meals
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Meal ID"
 // @Success 200 {array} entity.MealFoodItem
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /meals/{id}/food-items [get]
 func (r *MealRoutes) getFoodItems(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	meal, err := r.mealUC.GetMeal(c.Context(), middleware.UserID(c), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	foodItems, err := r.mealUC.GetMealFoodItems(c.Context(), meal.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(foodItems)
 }
 
 // @Summary Update meal food item
@@ -150,16 +303,30 @@ func (r *MealRoutes) getFoodItems(c *fiber.Ctx) error {
 // @Tags meals
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Food item ID"
-// @Param foodItem body entity.MealFoodItem true "Food item object"
-// @Success 200 {object} entity.MealFoodItem
+// @Param foodItem body mealFoodItemAmountRequest true "Consumed amount"
+// @Success 204 "No Content"
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /meals/food-items/{id} [put]
 func (r *MealRoutes) updateFoodItem(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	var req mealFoodItemAmountRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	foodItem := entity.MealFoodItem{ID: c.Params("id")}
+	req.apply(&foodItem)
+
+	if err := r.mealUC.UpdateMealFoodItem(c.Context(), middleware.UserID(c), &foodItem); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
 }
 
 // @Summary Delete meal food item
@@ -167,13 +334,19 @@ func (r *MealRoutes) updateFoodItem(c *fiber.Ctx) error {
 // @Tags meals
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Food item ID"
 // @Success 204 "No Content"
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /meals/food-items/{id} [delete]
 func (r *MealRoutes) deleteFoodItem(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	if err := r.mealUC.DeleteMealFoodItem(c.Context(), middleware.UserID(c), c.Params("id")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
 }
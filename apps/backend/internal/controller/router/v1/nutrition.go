@@ -2,6 +2,9 @@ package v1
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
 	"github.com/terrnit/rebound/backend/internal/usecase"
 	"github.com/terrnit/rebound/backend/pkg/logger"
 )
@@ -20,20 +23,23 @@ func NewNutritionRoutes(handler fiber.Router, uc *usecase.NutritionUseCase, l lo
 	h := handler.Group("/nutrition")
 	{
 		// Nutrition goals routes
+		h.Use("/goals", middleware.RequireScope(entity.ScopeReadNutrition, entity.ScopeWriteNutrition))
 		h.Post("/goals", r.createNutritionGoals)
 		h.Get("/goals/:id", r.getNutritionGoals)
 		h.Put("/goals/:id", r.updateNutritionGoals)
+		h.Patch("/goals/:id", r.patchNutritionGoals)
 		h.Delete("/goals/:id", r.deleteNutritionGoals)
-		h.Get("/goals/user/:userID/active", r.getActiveNutritionGoals)
-		h.Get("/goals/user/:userID/history", r.getNutritionGoalsHistory)
+		h.Get("/goals/user/:userID/active", middleware.RequireOwner("userID"), r.getActiveNutritionGoals)
+		h.Get("/goals/user/:userID/history", middleware.RequireOwner("userID"), r.getNutritionGoalsHistory)
 
 		// Biometrics routes
+		h.Use("/biometrics", middleware.RequireScope(entity.ScopeReadBiometrics, entity.ScopeWriteBiometrics))
 		h.Post("/biometrics", r.createBiometrics)
 		h.Get("/biometrics/:id", r.getBiometrics)
 		h.Put("/biometrics/:id", r.updateBiometrics)
 		h.Delete("/biometrics/:id", r.deleteBiometrics)
-		h.Get("/biometrics/user/:userID/history", r.getUserBiometricsHistory)
-		h.Get("/biometrics/user/:userID/latest", r.getLatestBiometrics)
+		h.Get("/biometrics/user/:userID/history", middleware.RequireOwner("userID"), r.getUserBiometricsHistory)
+		h.Get("/biometrics/user/:userID/latest", middleware.RequireOwner("userID"), r.getLatestBiometrics)
 	}
 }
 
@@ -42,14 +48,31 @@ func NewNutritionRoutes(handler fiber.Router, uc *usecase.NutritionUseCase, l lo
 // @Tags nutrition
 // @Accept json
 // @Produce json
-// @Param goals body entity.UserNutritionGoal true "Nutrition goals object"
+// @Security Bearer
+// @Param goals body createNutritionGoalsRequest true "Nutrition goals object"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
 // @Success 201 {object} entity.UserNutritionGoal
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /nutrition/goals [post]
 func (r *NutritionRoutes) createNutritionGoals(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	var req createNutritionGoalsRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	goals := entity.UserNutritionGoal{UserID: req.UserID, IsActive: true}
+	req.apply(&goals)
+
+	created, err := r.nutritionUC.CreateNutritionGoals(c.Context(), middleware.UserID(c), &goals)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(created)
 }
 
 // @Summary Get nutrition goals
@@ -57,15 +80,24 @@ func (r *NutritionRoutes) createNutritionGoals(c *fiber.Ctx) error {
 // @Tags nutrition
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Nutrition goals ID"
 // @Success 200 {object} entity.UserNutritionGoal
+// @Header 200 {string} ETag "Current version of the nutrition goals"
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /nutrition/goals/{id} [get]
 func (r *NutritionRoutes) getNutritionGoals(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	goals, err := r.nutritionUC.GetNutritionGoals(c.Context(), middleware.UserID(c), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	setETag(c, goals.UpdatedAt)
+	return c.JSON(goals)
 }
 
 // @Summary Update nutrition goals
@@ -73,16 +105,83 @@ func (r *NutritionRoutes) getNutritionGoals(c *fiber.Ctx) error {
 // @Tags nutrition
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Nutrition goals ID"
-// @Param goals body entity.UserNutritionGoal true "Nutrition goals object"
+// @Param If-Match header string false "ETag of the version being replaced"
+// @Param goals body nutritionGoalsRequest true "Nutrition goals object"
 // @Success 200 {object} entity.UserNutritionGoal
+// @Header 200 {string} ETag "New version of the nutrition goals"
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /nutrition/goals/{id} [put]
 func (r *NutritionRoutes) updateNutritionGoals(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	var req nutritionGoalsRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	goals, err := r.nutritionUC.GetNutritionGoals(c.Context(), middleware.UserID(c), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(c, goals.UpdatedAt); err != nil {
+		return err
+	}
+
+	req.apply(goals)
+	if err := r.nutritionUC.UpdateNutritionGoals(c.Context(), goals); err != nil {
+		return err
+	}
+
+	setETag(c, goals.UpdatedAt)
+	return c.JSON(goals)
+}
+
+// @Summary Patch nutrition goals
+// @Description Partially update nutrition goals with a JSON merge patch (RFC 7396).
+// @Description Omitted fields are left unchanged and null clears a field.
+// @Tags nutrition
+// @Accept application/merge-patch+json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Nutrition goals ID"
+// @Param If-Match header string false "ETag of the version being patched"
+// @Param goals body nutritionGoalsRequest true "Merge patch"
+// @Success 200 {object} entity.UserNutritionGoal
+// @Header 200 {string} ETag "New version of the nutrition goals"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /nutrition/goals/{id} [patch]
+func (r *NutritionRoutes) patchNutritionGoals(c *fiber.Ctx) error {
+	goals, err := r.nutritionUC.GetNutritionGoals(c.Context(), middleware.UserID(c), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(c, goals.UpdatedAt); err != nil {
+		return err
+	}
+
+	req, err := bindPatch(c, nutritionGoalsRequestFrom(goals))
+	if err != nil {
+		return err
+	}
+
+	req.apply(goals)
+	if err := r.nutritionUC.UpdateNutritionGoals(c.Context(), goals); err != nil {
+		return err
+	}
+
+	setETag(c, goals.UpdatedAt)
+	return c.JSON(goals)
 }
 
 // @Summary Delete nutrition goals
@@ -90,15 +189,21 @@ func (r *NutritionRoutes) updateNutritionGoals(c *fiber.Ctx) error {
 // @Tags nutrition
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Nutrition goals ID"
 // @Success 204 "No Content"
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /nutrition/goals/{id} [delete]
 func (r *NutritionRoutes) deleteNutritionGoals(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	if err := r.nutritionUC.DeleteNutritionGoals(c.Context(), middleware.UserID(c), c.Params("id")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
 }
 
 // @Summary Get active nutrition goals
@@ -106,15 +211,22 @@ func (r *NutritionRoutes) deleteNutritionGoals(c *fiber.Ctx) error {
 // @Tags nutrition
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param userID path string true "User ID"
 // @Success 200 {object} entity.UserNutritionGoal
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /nutrition/goals/user/{userID}/active [get]
 func (r *NutritionRoutes) getActiveNutritionGoals(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	goals, err := r.nutritionUC.GetActiveNutritionGoals(c.Context(), c.Params("userID"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(goals)
 }
 
 // @Summary Get nutrition goals history
@@ -122,16 +234,26 @@ func (r *NutritionRoutes) getActiveNutritionGoals(c *fiber.Ctx) error {
 // @Tags nutrition
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param userID path string true "User ID"
 // @Param page query int false "Page number" default(1)
 // @Param pageSize query int false "Page size" default(10)
 // @Success 200 {array} entity.UserNutritionGoal
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /nutrition/goals/user/{userID}/history [get]
 func (r *NutritionRoutes) getNutritionGoalsHistory(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	page := c.QueryInt("page", 1)
+	pageSize := c.QueryInt("pageSize", 10)
+
+	history, err := r.nutritionUC.GetNutritionGoalsHistory(c.Context(), c.Params("userID"), page, pageSize)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(history)
 }
 
 // @Summary Create biometrics
@@ -139,14 +261,31 @@ func (r *NutritionRoutes) getNutritionGoalsHistory(c *fiber.Ctx) error {
 // @Tags nutrition
 // @Accept json
 // @Produce json
-// @Param biometrics body entity.UserBiometric true "Biometrics object"
+// @Security Bearer
+// @Param biometrics body createBiometricsRequest true "Biometrics object"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
 // @Success 201 {object} entity.UserBiometric
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /nutrition/biometrics [post]
 func (r *NutritionRoutes) createBiometrics(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	var req createBiometricsRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	biometrics := entity.UserBiometric{UserID: req.UserID}
+	req.apply(&biometrics)
+
+	created, err := r.nutritionUC.CreateBiometrics(c.Context(), middleware.UserID(c), &biometrics)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(created)
 }
 
 // @Summary Get biometrics
@@ -154,15 +293,22 @@ func (r *NutritionRoutes) createBiometrics(c *fiber.Ctx) error {
 // @Tags nutrition
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Biometrics ID"
 // @Success 200 {object} entity.UserBiometric
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /nutrition/biometrics/{id} [get]
 func (r *NutritionRoutes) getBiometrics(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	biometrics, err := r.nutritionUC.GetBiometrics(c.Context(), middleware.UserID(c), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(biometrics)
 }
 
 // @Summary Update biometrics
@@ -170,49 +316,89 @@ func (r *NutritionRoutes) getBiometrics(c *fiber.Ctx) error {
 // @Tags nutrition
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Biometrics ID"
-// @Param biometrics body entity.UserBiometric true "Biometrics object"
+// @Param biometrics body biometricsRequest true "Biometrics object"
 // @Success 200 {object} entity.UserBiometric
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /nutrition/biometrics/{id} [put]
 func (r *NutritionRoutes) updateBiometrics(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	var req biometricsRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	biometrics, err := r.nutritionUC.GetBiometrics(c.Context(), middleware.UserID(c), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	req.apply(biometrics)
+	if err := r.nutritionUC.UpdateBiometrics(c.Context(), biometrics); err != nil {
+		return err
+	}
+
+	return c.JSON(biometrics)
 }
 
 // @Summary Delete biometrics
-// @Description Delete biometrics by ID
+// @Description Move biometrics to the trash, they can be restored for 30 days
 // @Tags nutrition
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Biometrics ID"
 // @Success 204 "No Content"
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /nutrition/biometrics/{id} [delete]
 func (r *NutritionRoutes) deleteBiometrics(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	if err := r.nutritionUC.DeleteBiometrics(c.Context(), middleware.UserID(c), c.Params("id")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
 }
 
 // @Summary Get user biometrics history
-// @Description Get biometrics history for a user
+// @Description Get a cursor paginated biometrics history for a user, most recent first
 // @Tags nutrition
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param userID path string true "User ID"
-// @Param page query int false "Page number" default(1)
-// @Param pageSize query int false "Page size" default(10)
-// @Success 200 {array} entity.UserBiometric
+// @Param limit query int false "Page size" default(10)
+// @Param cursor query string false "Opaque next_cursor or prev_cursor from a previous response"
+// @Param filter query string false "Filters as filter[field][op]=value, op is one of eq, in, gte, lte"
+// @Success 200 {object} CursorResponse{data=[]entity.UserBiometric}
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /nutrition/biometrics/user/{userID}/history [get]
 func (r *NutritionRoutes) getUserBiometricsHistory(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	spec, err := parseQuerySpec(c, repository.BiometricFields)
+	if err != nil {
+		return err
+	}
+
+	biometrics, info, err := r.nutritionUC.ListUserBiometrics(c.Context(), c.Params("userID"), spec, parseCursorPage(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(CursorResponse{
+		Data:       biometrics,
+		NextCursor: info.NextCursor,
+		PrevCursor: info.PrevCursor,
+	})
 }
 
 // @Summary Get latest biometrics
@@ -220,13 +406,20 @@ func (r *NutritionRoutes) getUserBiometricsHistory(c *fiber.Ctx) error {
 // @Tags nutrition
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param userID path string true "User ID"
 // @Success 200 {object} entity.UserBiometric
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /nutrition/biometrics/user/{userID}/latest [get]
 func (r *NutritionRoutes) getLatestBiometrics(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	biometrics, err := r.nutritionUC.GetLatestBiometrics(c.Context(), c.Params("userID"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(biometrics)
 }
@@ -0,0 +1,336 @@
+package v1
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+type OAuthRoutes struct {
+	oauthUC *usecase.OAuthUseCase
+	log     logger.Interface
+}
+
+func NewOAuthRoutes(handler fiber.Router, uc *usecase.OAuthUseCase, l logger.Interface) {
+	r := &OAuthRoutes{
+		oauthUC: uc,
+		log:     l,
+	}
+
+	h := handler.Group("/oauth")
+	{
+		// Clients authenticate themselves at these endpoints, not users
+		h.Post("/token", r.token)
+		h.Post("/revoke", r.revoke)
+
+		// Only the user, signed in to their own session, can connect apps
+		authorize := h.Group("/authorize", middleware.RequireAuth(), middleware.DenyDelegatedTokens())
+		authorize.Get("", r.getAuthorization)
+		authorize.Post("", r.authorize)
+
+		clients := h.Group("/clients", middleware.RequireAuth(), middleware.DenyDelegatedTokens())
+		clients.Post("/", r.registerClient)
+		clients.Get("/", r.listClients)
+		clients.Delete("/:id", r.deleteClient)
+
+		connections := h.Group("/connections", middleware.RequireAuth(), middleware.DenyDelegatedTokens())
+		connections.Get("/", r.listConnections)
+		connections.Delete("/:clientID", r.revokeConnection)
+	}
+}
+
+// @Summary Register OAuth client
+// @Description Register a partner app that can ask users for access to their data. Confidential clients get a secret, which is only shown in this response.
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body createOAuthClientRequest true "Name, redirect URIs and scopes"
+// @Success 201 {object} entity.OAuthClient
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /oauth/clients [post]
+func (r *OAuthRoutes) registerClient(c *fiber.Ctx) error {
+	var req createOAuthClientRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	client, err := r.oauthUC.RegisterClient(c.Context(), middleware.UserID(c), req.Name, req.RedirectURIs, req.Scopes, req.Confidential)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(client)
+}
+
+// @Summary List OAuth clients
+// @Description List the partner apps the user registered.
+// @Tags oauth
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} entity.OAuthClient
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /oauth/clients [get]
+func (r *OAuthRoutes) listClients(c *fiber.Ctx) error {
+	clients, err := r.oauthUC.ListClients(c.Context(), middleware.UserID(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(clients)
+}
+
+// @Summary Delete OAuth client
+// @Description Delete a partner app, which disconnects it from every user and revokes its tokens.
+// @Tags oauth
+// @Security Bearer
+// @Param id path string true "Client ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /oauth/clients/{id} [delete]
+func (r *OAuthRoutes) deleteClient(c *fiber.Ctx) error {
+	if err := r.oauthUC.DeleteClient(c.Context(), middleware.UserID(c), c.Params("id")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary Get authorization request
+// @Description Validate an authorization request of the authorization code flow and return the client and scopes to show on the consent screen.
+// @Description PKCE with the S256 method is required.
+// @Tags oauth
+// @Produce json
+// @Security Bearer
+// @Param response_type query string true "Must be code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "One of the client's redirect URIs"
+// @Param scope query string true "Space separated scopes"
+// @Param state query string false "Opaque value returned to the client"
+// @Param code_challenge query string true "PKCE code challenge"
+// @Param code_challenge_method query string true "Must be S256"
+// @Success 200 {object} entity.OAuthAuthorization
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /oauth/authorize [get]
+func (r *OAuthRoutes) getAuthorization(c *fiber.Ctx) error {
+	var req oauthAuthorizeRequest
+	if err := c.QueryParser(&req); err != nil {
+		return errInvalidBody
+	}
+	if err := validateRequest(&req); err != nil {
+		return err
+	}
+
+	authorization, err := r.oauthUC.GetAuthorization(c.Context(), middleware.UserID(c), req.toEntity())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(authorization)
+}
+
+// @Summary Answer authorization request
+// @Description Approve or deny an authorization request. Approving records the consent and returns the redirect to the client with an authorization code,
+// @Description denying returns the redirect with the access_denied error.
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body oauthDecisionRequest true "Authorization request and decision"
+// @Success 200 {object} entity.OAuthRedirect
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /oauth/authorize [post]
+func (r *OAuthRoutes) authorize(c *fiber.Ctx) error {
+	var req oauthDecisionRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	redirect, err := r.oauthUC.Authorize(c.Context(), middleware.UserID(c), req.toEntity(), req.Approve)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(redirect)
+}
+
+// @Summary Token endpoint
+// @Description Exchange an authorization code and its PKCE verifier, or a refresh token, for an access token and a new refresh token.
+// @Description Confidential clients authenticate with HTTP Basic or client_secret in the form. Refresh tokens are used once.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "authorization_code or refresh_token"
+// @Param code formData string false "Authorization code"
+// @Param redirect_uri formData string false "Redirect URI of the authorization request"
+// @Param code_verifier formData string false "PKCE code verifier"
+// @Param refresh_token formData string false "Refresh token"
+// @Param client_id formData string false "Client ID"
+// @Param client_secret formData string false "Client secret"
+// @Success 200 {object} entity.OAuthToken
+// @Failure 400 {object} OAuthErrorResponse
+// @Failure 401 {object} OAuthErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /oauth/token [post]
+func (r *OAuthRoutes) token(c *fiber.Ctx) error {
+	var req oauthTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return oauthError(c, usecase.ErrOAuthInvalidRequest)
+	}
+	clientID, clientSecret := clientCredentials(c, req.ClientID, req.ClientSecret)
+
+	token, err := r.oauthUC.Token(c.Context(), &entity.OAuthTokenRequest{
+		GrantType:    req.GrantType,
+		Code:         req.Code,
+		RedirectURI:  req.RedirectURI,
+		CodeVerifier: req.CodeVerifier,
+		RefreshToken: req.RefreshToken,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+	})
+	if err != nil {
+		return oauthError(c, err)
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(token)
+}
+
+// @Summary Revoke OAuth token
+// @Description Revoke an access token or refresh token as in RFC 7009, revoking a refresh token revokes its access tokens too.
+// @Description Unknown tokens are answered with 200 as well.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Param token formData string true "Access or refresh token"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Param client_id formData string false "Client ID"
+// @Param client_secret formData string false "Client secret"
+// @Success 200 "OK"
+// @Failure 400 {object} OAuthErrorResponse
+// @Failure 401 {object} OAuthErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /oauth/revoke [post]
+func (r *OAuthRoutes) revoke(c *fiber.Ctx) error {
+	var req oauthRevokeRequest
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return oauthError(c, usecase.ErrOAuthInvalidRequest)
+	}
+	clientID, clientSecret := clientCredentials(c, req.ClientID, req.ClientSecret)
+
+	if err := r.oauthUC.Revoke(c.Context(), clientID, clientSecret, req.Token); err != nil {
+		return oauthError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// @Summary List connected apps
+// @Description List the apps the user granted access to and the scopes they hold.
+// @Tags oauth
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} entity.OAuthConsent
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /oauth/connections [get]
+func (r *OAuthRoutes) listConnections(c *fiber.Ctx) error {
+	connections, err := r.oauthUC.ListConnections(c.Context(), middleware.UserID(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(connections)
+}
+
+// @Summary Disconnect app
+// @Description Withdraw the consent of an app and revoke its tokens, its requests are refused from then on.
+// @Tags oauth
+// @Security Bearer
+// @Param clientID path string true "Client ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /oauth/connections/{clientID} [delete]
+func (r *OAuthRoutes) revokeConnection(c *fiber.Ctx) error {
+	if err := r.oauthUC.RevokeConnection(c.Context(), middleware.UserID(c), c.Params("clientID")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// clientCredentials returns the client credentials of HTTP Basic authentication, falling back to the form fields
+func clientCredentials(c *fiber.Ctx, clientID, clientSecret string) (string, string) {
+	if id, secret, ok := basicAuth(c); ok {
+		return id, secret
+	}
+	return clientID, clientSecret
+}
+
+// basicAuth reads the client credentials of an Authorization: Basic header, which RFC 6749 form encodes
+func basicAuth(c *fiber.Ctx) (string, string, bool) {
+	scheme, encoded, found := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if !found || !strings.EqualFold(scheme, "Basic") {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", "", false
+	}
+	id, secret, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return "", "", false
+	}
+	if id, err = url.QueryUnescape(id); err != nil {
+		return "", "", false
+	}
+	if secret, err = url.QueryUnescape(secret); err != nil {
+		return "", "", false
+	}
+	return id, secret, true
+}
+
+// oauthError writes a domain error in the error format of RFC 6749, other errors go to the error handler
+func oauthError(c *fiber.Ctx, err error) error {
+	var domainErr *entity.Error
+	if !errors.As(err, &domainErr) || domainErr.Kind == entity.ErrorKindInternal {
+		return err
+	}
+
+	status := fiber.StatusBadRequest
+	if domainErr.Kind == entity.ErrorKindUnauthorized {
+		status = fiber.StatusUnauthorized
+		c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(status).JSON(OAuthErrorResponse{
+		Error:            domainErr.Code,
+		ErrorDescription: domainErr.Message,
+	})
+}
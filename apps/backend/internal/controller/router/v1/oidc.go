@@ -0,0 +1,137 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+type OIDCRoutes struct {
+	oidcUC *usecase.OIDCUseCase
+	log    logger.Interface
+}
+
+func NewOIDCRoutes(handler fiber.Router, uc *usecase.OIDCUseCase, l logger.Interface) {
+	r := &OIDCRoutes{
+		oidcUC: uc,
+		log:    l,
+	}
+
+	h := handler.Group("/auth")
+	{
+		h.Get("/oidc/providers", r.providers)
+		h.Post("/oidc/:provider/authorize", r.begin)
+		h.Post("/oidc/:provider/callback", r.complete)
+
+		identities := h.Group("/identities", middleware.RequireAuth(), middleware.DenyDelegatedTokens())
+		identities.Get("", r.listIdentities)
+		identities.Delete("/:id", r.unlink)
+	}
+}
+
+// @Summary List sign in providers
+// @Description List the OpenID Connect providers users can sign in with.
+// @Tags auth
+// @Produce json
+// @Success 200 {array} entity.OIDCProvider
+// @Router /auth/oidc/providers [get]
+func (r *OIDCRoutes) providers(c *fiber.Ctx) error {
+	return c.JSON(r.oidcUC.Providers())
+}
+
+// @Summary Start provider sign in
+// @Description Start signing in with an OpenID Connect provider. Send the user to the returned URL, the provider sends them back
+// @Description to the app with a code and state to post to /auth/oidc/{provider}/callback.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param request body oidcBeginRequest true "Device name"
+// @Success 200 {object} entity.OAuthRedirect
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/oidc/{provider}/authorize [post]
+func (r *OIDCRoutes) begin(c *fiber.Ctx) error {
+	var req oidcBeginRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	redirect, err := r.oidcUC.Begin(c.Context(), c.Params("provider"), req.DeviceName)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(redirect)
+}
+
+// @Summary Complete provider sign in
+// @Description Finish signing in with the code and state the provider sent back. A new provider account is linked to the account
+// @Description with the same verified email, or gets a new account. Users with two-factor authentication get a challenge token to answer at /auth/login/2fa.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param request body oidcCompleteRequest true "Code and state"
+// @Success 200 {object} entity.LoginResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/oidc/{provider}/callback [post]
+func (r *OIDCRoutes) complete(c *fiber.Ctx) error {
+	var req oidcCompleteRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	result, err := r.oidcUC.Complete(c.Context(), c.Params("provider"), req.Code, req.State)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(result)
+}
+
+// @Summary List linked identities
+// @Description List the provider accounts the user can sign in with.
+// @Tags auth
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} entity.LinkedIdentity
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/identities [get]
+func (r *OIDCRoutes) listIdentities(c *fiber.Ctx) error {
+	identities, err := r.oidcUC.ListIdentities(c.Context(), middleware.UserID(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(identities)
+}
+
+// @Summary Unlink identity
+// @Description Stop signing in with a provider account. An account without a password keeps its last identity.
+// @Tags auth
+// @Security Bearer
+// @Param id path string true "Identity ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/identities/{id} [delete]
+func (r *OIDCRoutes) unlink(c *fiber.Ctx) error {
+	if err := r.oidcUC.Unlink(c.Context(), middleware.UserID(c), c.Params("id")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
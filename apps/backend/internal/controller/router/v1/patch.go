@@ -0,0 +1,99 @@
+package v1
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/repository"
+)
+
+const mimeMergePatch = "application/merge-patch+json"
+
+// etag derives a strong entity tag from a resource version.
+// Postgres stores microseconds, so the version is rounded the same way before encoding.
+func etag(version time.Time) string {
+	return `"` + strconv.FormatInt(version.Round(time.Microsecond).UnixMicro(), 36) + `"`
+}
+
+// setETag exposes the resource version to the client
+func setETag(c *fiber.Ctx, version time.Time) {
+	c.Set(fiber.HeaderETag, etag(version))
+}
+
+// checkIfMatch fails with repository.ErrVersionConflict when the request carries an If-Match
+// header that does not match the current version. Requests without the header are unconditional.
+func checkIfMatch(c *fiber.Ctx, version time.Time) error {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return nil
+	}
+
+	current := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == current {
+			return nil
+		}
+	}
+	return repository.ErrVersionConflict
+}
+
+// bindPatch applies the JSON merge patch (RFC 7396) in the request body to current,
+// which holds the resource's present state, and validates the result.
+func bindPatch[T any](c *fiber.Ctx, current T) (T, error) {
+	var patched T
+
+	ctype := strings.ToLower(strings.TrimSpace(strings.SplitN(c.Get(fiber.HeaderContentType), ";", 2)[0]))
+	if ctype != mimeMergePatch && ctype != fiber.MIMEApplicationJSON {
+		return patched, fiber.ErrUnsupportedMediaType
+	}
+
+	var patch interface{}
+	if err := json.Unmarshal(c.Body(), &patch); err != nil {
+		return patched, errInvalidBody
+	}
+
+	data, err := json.Marshal(current)
+	if err != nil {
+		return patched, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return patched, err
+	}
+
+	data, err = json.Marshal(mergePatch(doc, patch))
+	if err != nil {
+		return patched, err
+	}
+	if err := json.Unmarshal(data, &patched); err != nil {
+		return patched, errInvalidBody
+	}
+
+	return patched, validateRequest(&patched)
+}
+
+// mergePatch merges patch into target: objects are merged recursively,
+// null removes a member and any other value replaces it.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{}, len(patchObj))
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
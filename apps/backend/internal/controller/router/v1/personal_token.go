@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+type PersonalTokenRoutes struct {
+	personalTokenUC *usecase.PersonalTokenUseCase
+	log             logger.Interface
+}
+
+func NewPersonalTokenRoutes(handler fiber.Router, uc *usecase.PersonalTokenUseCase, l logger.Interface) {
+	r := &PersonalTokenRoutes{
+		personalTokenUC: uc,
+		log:             l,
+	}
+
+	// Tokens are managed from a session, a token cannot mint more tokens
+	h := handler.Group("/auth/tokens", middleware.RequireAuth(), middleware.DenyDelegatedTokens())
+	{
+		h.Post("/", r.create)
+		h.Get("/", r.list)
+		h.Delete("/:id", r.revoke)
+	}
+}
+
+// @Summary Create personal access token
+// @Description Create a token for scripts and integrations, limited to its scopes. The token is only shown in this response.
+// @Description Send it as "Authorization: Bearer <token>".
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body createPersonalTokenRequest true "Name, scopes and optional expiry"
+// @Success 201 {object} entity.PersonalToken
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/tokens [post]
+func (r *PersonalTokenRoutes) create(c *fiber.Ctx) error {
+	var req createPersonalTokenRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	token, err := r.personalTokenUC.Create(c.Context(), middleware.UserID(c), req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(token)
+}
+
+// @Summary List personal access tokens
+// @Description List the tokens that are neither revoked nor expired, newest first.
+// @Tags auth
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} entity.PersonalToken
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/tokens [get]
+func (r *PersonalTokenRoutes) list(c *fiber.Ctx) error {
+	tokens, err := r.personalTokenUC.List(c.Context(), middleware.UserID(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(tokens)
+}
+
+// @Summary Revoke personal access token
+// @Description Revoke a token, requests made with it are refused from then on.
+// @Tags auth
+// @Security Bearer
+// @Param id path string true "Token ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/tokens/{id} [delete]
+func (r *PersonalTokenRoutes) revoke(c *fiber.Ctx) error {
+	if err := r.personalTokenUC.Revoke(c.Context(), middleware.UserID(c), c.Params("id")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
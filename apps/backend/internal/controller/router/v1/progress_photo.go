@@ -0,0 +1,263 @@
+package v1
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+const routeProgressPhotoFile = "progress_photos.file"
+
+type ProgressPhotoRoutes struct {
+	photoUC *usecase.ProgressPhotoUseCase
+	log     logger.Interface
+}
+
+func NewProgressPhotoRoutes(handler fiber.Router, uc *usecase.ProgressPhotoUseCase, l logger.Interface) {
+	r := &ProgressPhotoRoutes{
+		photoUC: uc,
+		log:     l,
+	}
+
+	scope := middleware.RequireScope(entity.ScopeReadBiometrics, entity.ScopeWriteBiometrics)
+	h := handler.Group("/progress-photos")
+	{
+		h.Post("/", middleware.RequireAuth(), scope, r.upload)
+		h.Get("/user/:userID", middleware.RequireAuth(), scope, r.list)
+		h.Get("/user/:userID/compare", middleware.RequireAuth(), scope, r.compare)
+		h.Get("/:id", middleware.RequireAuth(), scope, r.get)
+		h.Delete("/:id", middleware.RequireAuth(), scope, r.delete)
+		// The signed link is the authorisation, so that photos load in an img tag
+		h.Get("/:id/file", r.file).Name(routeProgressPhotoFile)
+	}
+}
+
+// withLinks fills in the signed links to the files of photos
+func (r *ProgressPhotoRoutes) withLinks(c *fiber.Ctx, photos ...*entity.ProgressPhoto) error {
+	for _, photo := range photos {
+		path, err := c.GetRouteURL(routeProgressPhotoFile, fiber.Map{"id": photo.ID})
+		if err != nil {
+			return err
+		}
+		link := func(variant string) string {
+			expires, signature := r.photoUC.LinkSignature(photo, variant)
+			return c.BaseURL() + path + "?variant=" + variant + "&expires=" + strconv.FormatInt(expires, 10) + "&signature=" + signature
+		}
+		photo.URL = link(usecase.ProgressPhotoFull)
+		photo.ThumbnailURL = link(usecase.ProgressPhotoThumbnail)
+	}
+	return nil
+}
+
+// @Summary Upload a progress photo
+// @Description Attach a front, side or back photo to one of the signed in user's biometrics entries. The photo is scaled down
+// @Description and stored without its EXIF metadata and with a thumbnail, replacing the photo of the same pose. Photos are
+// @Description private: url and thumbnail_url are signed links that expire after a few minutes.
+// @Tags progress-photos
+// @Accept multipart/form-data
+// @Produce json
+// @Param biometrics_id formData string true "Biometrics entry ID"
+// @Param pose formData string true "front, side or back"
+// @Param file formData file true "Photo"
+// @Success 201 {object} entity.ProgressPhoto
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /progress-photos [post]
+func (r *ProgressPhotoRoutes) upload(c *fiber.Ctx) error {
+	var req uploadProgressPhotoRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return errInvalidBody.WithField("file", "is required")
+	}
+	if header.Size > r.photoUC.MaxBytes() {
+		return usecase.ErrUploadTooLarge
+	}
+	file, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, r.photoUC.MaxBytes()+1))
+	if err != nil {
+		return err
+	}
+
+	photo, err := r.photoUC.Upload(c.Context(), middleware.UserID(c), req.BiometricsID, req.Pose, data)
+	if err != nil {
+		return err
+	}
+
+	if err := r.withLinks(c, photo); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(photo)
+}
+
+// @Summary Get a progress photo
+// @Description Get a progress photo of the signed in user or of a user who authorised them as their coach
+// @Tags progress-photos
+// @Produce json
+// @Param id path string true "Photo ID"
+// @Success 200 {object} entity.ProgressPhoto
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /progress-photos/{id} [get]
+func (r *ProgressPhotoRoutes) get(c *fiber.Ctx) error {
+	photo, err := r.photoUC.Get(c.Context(), middleware.UserID(c), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	if err := r.withLinks(c, photo); err != nil {
+		return err
+	}
+	return c.JSON(photo)
+}
+
+// @Summary List progress photos
+// @Description List a user's progress photos of biometrics entries logged between two dates, inclusive, oldest first.
+// @Description Users list their own photos and those of the users who authorised them as their coach.
+// @Tags progress-photos
+// @Produce json
+// @Param userID path string true "User ID"
+// @Param from query string true "First date, YYYY-MM-DD"
+// @Param to query string true "Last date, YYYY-MM-DD"
+// @Success 200 {array} entity.ProgressPhoto
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /progress-photos/user/{userID} [get]
+func (r *ProgressPhotoRoutes) list(c *fiber.Ctx) error {
+	from, err := parseDateQuery(c, "from")
+	if err != nil {
+		return err
+	}
+	to, err := parseDateQuery(c, "to")
+	if err != nil {
+		return err
+	}
+
+	photos, err := r.photoUC.List(c.Context(), middleware.UserID(c), c.Params("userID"), from, to)
+	if err != nil {
+		return err
+	}
+
+	if err := r.withLinks(c, photos...); err != nil {
+		return err
+	}
+	if photos == nil {
+		photos = []*entity.ProgressPhoto{}
+	}
+	return c.JSON(photos)
+}
+
+// @Summary Compare progress between two dates
+// @Description Put a user's progress at two dates side by side. For each date the biometrics entry logged on it, or the closest
+// @Description one before it, is returned with its photos, and changes holds how much each measurement changed between the two.
+// @Description Users compare their own progress and that of the users who authorised them as their coach.
+// @Tags progress-photos
+// @Produce json
+// @Param userID path string true "User ID"
+// @Param from query string true "Earlier date, YYYY-MM-DD"
+// @Param to query string true "Later date, YYYY-MM-DD"
+// @Success 200 {object} entity.ProgressComparison
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /progress-photos/user/{userID}/compare [get]
+func (r *ProgressPhotoRoutes) compare(c *fiber.Ctx) error {
+	from, err := parseDateQuery(c, "from")
+	if err != nil {
+		return err
+	}
+	to, err := parseDateQuery(c, "to")
+	if err != nil {
+		return err
+	}
+
+	comparison, err := r.photoUC.Compare(c.Context(), middleware.UserID(c), c.Params("userID"), from, to)
+	if err != nil {
+		return err
+	}
+
+	if err := r.withLinks(c, comparison.From.Photos...); err != nil {
+		return err
+	}
+	if err := r.withLinks(c, comparison.To.Photos...); err != nil {
+		return err
+	}
+	return c.JSON(comparison)
+}
+
+// @Summary Delete a progress photo
+// @Description Delete one of the signed in user's progress photos
+// @Tags progress-photos
+// @Param id path string true "Photo ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /progress-photos/{id} [delete]
+func (r *ProgressPhotoRoutes) delete(c *fiber.Ctx) error {
+	if err := r.photoUC.Delete(c.Context(), middleware.UserID(c), c.Params("id")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary View a progress photo
+// @Description View a progress photo or its thumbnail through the signed url or thumbnail_url of the photo. Depending on the
+// @Description storage the image is sent directly or through a redirect to a short-lived signed URL.
+// @Tags progress-photos
+// @Produce image/jpeg,image/png
+// @Param id path string true "Photo ID"
+// @Param variant query string true "full or thumbnail"
+// @Param expires query int true "Expiry of the link as a Unix timestamp"
+// @Param signature query string true "Signature of the link"
+// @Success 200 {file} file
+// @Success 302
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /progress-photos/{id}/file [get]
+func (r *ProgressPhotoRoutes) file(c *fiber.Ctx) error {
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		return usecase.ErrPhotoLinkInvalid
+	}
+
+	file, contentType, signedURL, err := r.photoUC.OpenFile(c.Context(), c.Params("id"), c.Query("variant"), expires, c.Query("signature"))
+	if err != nil {
+		return err
+	}
+	if signedURL != "" {
+		return c.Redirect(signedURL, fiber.StatusFound)
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	// The response closes the file once it has been sent
+	return c.SendStream(file)
+}
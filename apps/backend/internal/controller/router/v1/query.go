@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+)
+
+var filterParam = regexp.MustCompile(`^filter\[([a-z0-9_]+)\](?:\[([a-z]+)\])?$`)
+
+// parseQuerySpec builds a query spec from filter[field][op]=value and sort=-field,field query parameters.
+// A filter without an operator is treated as eq.
+func parseQuerySpec(c *fiber.Ctx, fields repository.Fields) (repository.QuerySpec, error) {
+	var (
+		spec repository.QuerySpec
+		err  error
+	)
+
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		if err != nil {
+			return
+		}
+
+		match := filterParam.FindStringSubmatch(string(key))
+		if match == nil {
+			if strings.HasPrefix(string(key), "filter") {
+				err = repository.ErrInvalidQuery.WithField(string(key), "malformed filter parameter")
+			}
+			return
+		}
+
+		op := repository.OpEq
+		if match[2] != "" {
+			op = repository.Operator(match[2])
+		}
+
+		var filter repository.Filter
+		filter, err = fields.Filter(match[1], op, string(value))
+		if err == nil {
+			spec.Filters = append(spec.Filters, filter)
+		}
+	})
+	if err != nil {
+		return spec, err
+	}
+
+	if raw := c.Query("sort"); raw != "" {
+		for _, name := range strings.Split(raw, ",") {
+			name = strings.TrimSpace(name)
+			desc := strings.HasPrefix(name, "-")
+
+			sort, err := fields.Sort(strings.TrimPrefix(name, "-"), desc)
+			if err != nil {
+				return spec, err
+			}
+			spec.Sort = append(spec.Sort, sort)
+		}
+	}
+
+	return spec, nil
+}
+
+// parseCursorPage reads the limit and cursor query parameters of a cursor paginated list
+func parseCursorPage(c *fiber.Ctx) repository.CursorPage {
+	return repository.CursorPage{
+		Limit:  c.QueryInt("limit", 10),
+		Cursor: c.Query("cursor"),
+	}
+}
+
+// parseDateQuery reads a required YYYY-MM-DD query parameter
+func parseDateQuery(c *fiber.Ctx, name string) (time.Time, error) {
+	date, err := time.Parse(time.DateOnly, c.Query(name))
+	if err != nil {
+		return time.Time{}, usecase.ErrInvalidInput.WithField(name, "must be a date formatted as YYYY-MM-DD")
+	}
+	return date, nil
+}
@@ -0,0 +1,582 @@
+package v1
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+)
+
+// Request bodies accepted by the API. Server managed fields such as IDs, timestamps and
+// password hashes are never read from the client. Ranges follow the column types and
+// CHECK constraints of the database schema.
+
+// userRequest holds the user fields a client may set
+type userRequest struct {
+	Username          string            `json:"username" validate:"required,min=3,max=50,username"`
+	Email             string            `json:"email" validate:"required,email,max=255"`
+	FirstName         string            `json:"first_name" validate:"max=100"`
+	LastName          string            `json:"last_name" validate:"max=100"`
+	DateOfBirth       *time.Time        `json:"date_of_birth,omitempty"`
+	Gender            entity.UserGender `json:"gender,omitempty" validate:"omitempty,enum"`
+	ProfilePictureURL string            `json:"profile_picture_url,omitempty" validate:"omitempty,url,max=512"`
+}
+
+func (r userRequest) apply(user *entity.User) {
+	user.Username = r.Username
+	user.Email = r.Email
+	user.FirstName = r.FirstName
+	user.LastName = r.LastName
+	user.DateOfBirth = r.DateOfBirth
+	user.Gender = r.Gender
+	user.ProfilePictureURL = r.ProfilePictureURL
+}
+
+func userRequestFrom(user *entity.User) userRequest {
+	return userRequest{
+		Username:          user.Username,
+		Email:             user.Email,
+		FirstName:         user.FirstName,
+		LastName:          user.LastName,
+		DateOfBirth:       user.DateOfBirth,
+		Gender:            user.Gender,
+		ProfilePictureURL: user.ProfilePictureURL,
+	}
+}
+
+// createUserRequest is the body of POST /users
+type createUserRequest struct {
+	User     userRequest `json:"user" validate:"required"`
+	Password string      `json:"password" validate:"required,min=8,max=72"`
+}
+
+// loginRequest is the body of POST /auth/login
+type loginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,max=72"`
+	// DeviceName labels the session in the list of active sessions, such as "Pixel 8"
+	DeviceName string `json:"device_name" validate:"max=100"`
+}
+
+// verifyTwoFactorRequest is the body of POST /auth/login/2fa
+type verifyTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	// Code is a TOTP code or a recovery code
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+// refreshRequest is the body of POST /auth/refresh
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// twoFactorCodeRequest is the body of the POST /auth/2fa endpoints that take a code
+type twoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+// oidcBeginRequest is the body of POST /auth/oidc/{provider}/authorize
+type oidcBeginRequest struct {
+	// DeviceName labels the session started once the sign in completes
+	DeviceName string `json:"device_name" validate:"max=100"`
+}
+
+// oidcCompleteRequest is the body of POST /auth/oidc/{provider}/callback, the parameters the provider redirected back with
+type oidcCompleteRequest struct {
+	Code  string `json:"code" validate:"required,max=2048"`
+	State string `json:"state" validate:"required,max=512"`
+}
+
+// createPersonalTokenRequest is the body of POST /auth/tokens
+type createPersonalTokenRequest struct {
+	Name   string         `json:"name" validate:"required,max=100"`
+	Scopes []entity.Scope `json:"scopes" validate:"required,min=1,dive,enum"`
+	// ExpiresAt is omitted for a token that does not expire
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// createOAuthClientRequest is the body of POST /oauth/clients
+type createOAuthClientRequest struct {
+	Name         string         `json:"name" validate:"required,max=100"`
+	RedirectURIs []string       `json:"redirect_uris" validate:"required,min=1,max=10,dive,required,max=512"`
+	Scopes       []entity.Scope `json:"scopes" validate:"required,min=1,dive,enum"`
+	// Confidential clients run on a server and get a secret, apps on devices are public
+	Confidential bool `json:"confidential"`
+}
+
+// oauthAuthorizeRequest holds the authorization request parameters of GET /oauth/authorize
+type oauthAuthorizeRequest struct {
+	ResponseType        string `json:"response_type" query:"response_type" validate:"required"`
+	ClientID            string `json:"client_id" query:"client_id" validate:"required"`
+	RedirectURI         string `json:"redirect_uri" query:"redirect_uri" validate:"required"`
+	Scope               string `json:"scope" query:"scope" validate:"required"`
+	State               string `json:"state" query:"state" validate:"max=512"`
+	CodeChallenge       string `json:"code_challenge" query:"code_challenge" validate:"required,max=128"`
+	CodeChallengeMethod string `json:"code_challenge_method" query:"code_challenge_method" validate:"required"`
+}
+
+func (r oauthAuthorizeRequest) toEntity() *entity.OAuthAuthorizeRequest {
+	return &entity.OAuthAuthorizeRequest{
+		ResponseType:        r.ResponseType,
+		ClientID:            r.ClientID,
+		RedirectURI:         r.RedirectURI,
+		Scope:               r.Scope,
+		State:               r.State,
+		CodeChallenge:       r.CodeChallenge,
+		CodeChallengeMethod: r.CodeChallengeMethod,
+	}
+}
+
+// oauthDecisionRequest is the body of POST /oauth/authorize, the authorization request and the user's answer
+type oauthDecisionRequest struct {
+	oauthAuthorizeRequest
+	Approve bool `json:"approve"`
+}
+
+// oauthTokenRequest is the form of POST /oauth/token. Client credentials may come in the
+// Authorization header instead.
+type oauthTokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// oauthRevokeRequest is the form of POST /oauth/revoke
+type oauthRevokeRequest struct {
+	Token         string `form:"token"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientID      string `form:"client_id"`
+	ClientSecret  string `form:"client_secret"`
+}
+
+// updatePasswordRequest is the body of PUT /users/{id}/password
+type updatePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=72"`
+	// RevokeOtherSessions signs every other device out
+	RevokeOtherSessions bool `json:"revoke_other_sessions"`
+}
+
+// updateEmailVerificationRequest is the body of PUT /users/{id}/verify-email
+type updateEmailVerificationRequest struct {
+	Verified *bool `json:"verified" validate:"required"`
+}
+
+// foodItemRequest is the body of POST, PUT and PATCH /food-items
+type foodItemRequest struct {
+	Name                               string   `json:"name" validate:"required,max=255"`
+	BrandName                          *string  `json:"brand_name,omitempty" validate:"omitempty,max=150"`
+	BarcodeUPC                         *string  `json:"barcode_upc,omitempty" validate:"omitempty,max=50"`
+	ServingSizeDefaultQty              float64  `json:"serving_size_default_qty" validate:"gt=0,lte=99999999.99"`
+	ServingSizeDefaultUnit             string   `json:"serving_size_default_unit" validate:"required,max=50"`
+	CaloriesPerDefaultServing          float64  `json:"calories_per_default_serving" validate:"gte=0,lte=999999.99"`
+	ProteinGramsPerDefaultServing      float64  `json:"protein_grams_per_default_serving" validate:"gte=0,lte=999999.99"`
+	FatGramsPerDefaultServing          float64  `json:"fat_grams_per_default_serving" validate:"gte=0,lte=999999.99"`
+	CarbsGramsPerDefaultServing        float64  `json:"carbs_grams_per_default_serving" validate:"gte=0,lte=999999.99"`
+	FiberGramsPerDefaultServing        *float64 `json:"fiber_grams_per_default_serving,omitempty" validate:"omitempty,gte=0,lte=999999.99"`
+	SugarGramsPerDefaultServing        *float64 `json:"sugar_grams_per_default_serving,omitempty" validate:"omitempty,gte=0,lte=999999.99"`
+	SaturatedFatGramsPerDefaultServing *float64 `json:"saturated_fat_grams_per_default_serving,omitempty" validate:"omitempty,gte=0,lte=999999.99"`
+	TransFatGramsPerDefaultServing     *float64 `json:"trans_fat_grams_per_default_serving,omitempty" validate:"omitempty,gte=0,lte=999999.99"`
+	CholesterolMgPerDefaultServing     *float64 `json:"cholesterol_mg_per_default_serving,omitempty" validate:"omitempty,gte=0,lte=999999.99"`
+	SodiumMgPerDefaultServing          *float64 `json:"sodium_mg_per_default_serving,omitempty" validate:"omitempty,gte=0,lte=999999.99"`
+	PotassiumMgPerDefaultServing       *float64 `json:"potassium_mg_per_default_serving,omitempty" validate:"omitempty,gte=0,lte=999999.99"`
+	VitaminAMcgPerDefaultServing       *float64 `json:"vitamin_a_mcg_per_default_serving,omitempty" validate:"omitempty,gte=0,lte=99999999.99"`
+	VitaminCMgPerDefaultServing        *float64 `json:"vitamin_c_mg_per_default_serving,omitempty" validate:"omitempty,gte=0,lte=99999999.99"`
+	CalciumMgPerDefaultServing         *float64 `json:"calcium_mg_per_default_serving,omitempty" validate:"omitempty,gte=0,lte=99999999.99"`
+	IronMgPerDefaultServing            *float64 `json:"iron_mg_per_default_serving,omitempty" validate:"omitempty,gte=0,lte=99999999.99"`
+}
+
+func (r foodItemRequest) apply(item *entity.FoodItem) {
+	item.Name = r.Name
+	item.BrandName = r.BrandName
+	item.BarcodeUPC = r.BarcodeUPC
+	item.ServingSizeDefaultQty = r.ServingSizeDefaultQty
+	item.ServingSizeDefaultUnit = r.ServingSizeDefaultUnit
+	item.CaloriesPerDefaultServing = r.CaloriesPerDefaultServing
+	item.ProteinGramsPerDefaultServing = r.ProteinGramsPerDefaultServing
+	item.FatGramsPerDefaultServing = r.FatGramsPerDefaultServing
+	item.CarbsGramsPerDefaultServing = r.CarbsGramsPerDefaultServing
+	item.FiberGramsPerDefaultServing = r.FiberGramsPerDefaultServing
+	item.SugarGramsPerDefaultServing = r.SugarGramsPerDefaultServing
+	item.SaturatedFatGramsPerDefaultServing = r.SaturatedFatGramsPerDefaultServing
+	item.TransFatGramsPerDefaultServing = r.TransFatGramsPerDefaultServing
+	item.CholesterolMgPerDefaultServing = r.CholesterolMgPerDefaultServing
+	item.SodiumMgPerDefaultServing = r.SodiumMgPerDefaultServing
+	item.PotassiumMgPerDefaultServing = r.PotassiumMgPerDefaultServing
+	item.VitaminAMcgPerDefaultServing = r.VitaminAMcgPerDefaultServing
+	item.VitaminCMgPerDefaultServing = r.VitaminCMgPerDefaultServing
+	item.CalciumMgPerDefaultServing = r.CalciumMgPerDefaultServing
+	item.IronMgPerDefaultServing = r.IronMgPerDefaultServing
+}
+
+func foodItemRequestFrom(item *entity.FoodItem) foodItemRequest {
+	return foodItemRequest{
+		Name:                               item.Name,
+		BrandName:                          item.BrandName,
+		BarcodeUPC:                         item.BarcodeUPC,
+		ServingSizeDefaultQty:              item.ServingSizeDefaultQty,
+		ServingSizeDefaultUnit:             item.ServingSizeDefaultUnit,
+		CaloriesPerDefaultServing:          item.CaloriesPerDefaultServing,
+		ProteinGramsPerDefaultServing:      item.ProteinGramsPerDefaultServing,
+		FatGramsPerDefaultServing:          item.FatGramsPerDefaultServing,
+		CarbsGramsPerDefaultServing:        item.CarbsGramsPerDefaultServing,
+		FiberGramsPerDefaultServing:        item.FiberGramsPerDefaultServing,
+		SugarGramsPerDefaultServing:        item.SugarGramsPerDefaultServing,
+		SaturatedFatGramsPerDefaultServing: item.SaturatedFatGramsPerDefaultServing,
+		TransFatGramsPerDefaultServing:     item.TransFatGramsPerDefaultServing,
+		CholesterolMgPerDefaultServing:     item.CholesterolMgPerDefaultServing,
+		SodiumMgPerDefaultServing:          item.SodiumMgPerDefaultServing,
+		PotassiumMgPerDefaultServing:       item.PotassiumMgPerDefaultServing,
+		VitaminAMcgPerDefaultServing:       item.VitaminAMcgPerDefaultServing,
+		VitaminCMgPerDefaultServing:        item.VitaminCMgPerDefaultServing,
+		CalciumMgPerDefaultServing:         item.CalciumMgPerDefaultServing,
+		IronMgPerDefaultServing:            item.IronMgPerDefaultServing,
+	}
+}
+
+// mealRequest holds the meal fields a client may set
+type mealRequest struct {
+	MealType       entity.UserMealType `json:"meal_type" validate:"required,enum"`
+	MealDate       time.Time           `json:"meal_date" validate:"required"`
+	MealTime       *time.Time          `json:"meal_time,omitempty"`
+	CustomMealName *string             `json:"custom_meal_name,omitempty" validate:"omitempty,max=150"`
+	Notes          *string             `json:"notes,omitempty"`
+}
+
+func (r mealRequest) apply(meal *entity.UserMeal) {
+	meal.MealType = r.MealType
+	meal.MealDate = r.MealDate
+	meal.MealTime = r.MealTime
+	meal.CustomMealName = r.CustomMealName
+	meal.Notes = r.Notes
+}
+
+func mealRequestFrom(meal *entity.UserMeal) mealRequest {
+	return mealRequest{
+		MealType:       meal.MealType,
+		MealDate:       meal.MealDate,
+		MealTime:       meal.MealTime,
+		CustomMealName: meal.CustomMealName,
+		Notes:          meal.Notes,
+	}
+}
+
+// createMealRequest is the body of POST /meals
+type createMealRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+	mealRequest
+}
+
+// mealFoodItemAmountRequest holds the consumed amount of a food item in a meal
+type mealFoodItemAmountRequest struct {
+	QuantityConsumed    float64 `json:"quantity_consumed" validate:"gt=0,lte=99999999.99"`
+	ServingUnitConsumed string  `json:"serving_unit_consumed" validate:"required,max=50"`
+	CaloriesConsumed    float64 `json:"calories_consumed" validate:"gte=0,lte=999999.99"`
+	ProteinConsumed     float64 `json:"protein_consumed" validate:"gte=0,lte=999999.99"`
+	FatConsumed         float64 `json:"fat_consumed" validate:"gte=0,lte=999999.99"`
+	CarbsConsumed       float64 `json:"carbs_consumed" validate:"gte=0,lte=999999.99"`
+}
+
+func (r mealFoodItemAmountRequest) apply(item *entity.MealFoodItem) {
+	item.QuantityConsumed = r.QuantityConsumed
+	item.ServingUnitConsumed = r.ServingUnitConsumed
+	item.CaloriesConsumed = r.CaloriesConsumed
+	item.ProteinConsumed = r.ProteinConsumed
+	item.FatConsumed = r.FatConsumed
+	item.CarbsConsumed = r.CarbsConsumed
+}
+
+// addMealFoodItemRequest is the body of POST /meals/{id}/food-items
+type addMealFoodItemRequest struct {
+	FoodItemID string `json:"food_item_id" validate:"required,uuid"`
+	mealFoodItemAmountRequest
+}
+
+// workoutSessionRequest holds the workout session fields a client may set
+type workoutSessionRequest struct {
+	PlanID                  *string                     `json:"plan_id,omitempty" validate:"omitempty,uuid"`
+	SessionName             *string                     `json:"session_name,omitempty" validate:"omitempty,max=255"`
+	ScheduledAt             *time.Time                  `json:"scheduled_at,omitempty"`
+	StartedAt               *time.Time                  `json:"started_at,omitempty"`
+	CompletedAt             *time.Time                  `json:"completed_at,omitempty"`
+	DurationMinutes         *int                        `json:"duration_minutes,omitempty" validate:"omitempty,gte=0"`
+	Status                  entity.WorkoutSessionStatus `json:"status,omitempty" validate:"omitempty,enum"`
+	Notes                   *string                     `json:"notes,omitempty"`
+	Location                *string                     `json:"location,omitempty" validate:"omitempty,max=255"`
+	MoodRating              *int                        `json:"mood_rating,omitempty" validate:"omitempty,min=1,max=5"`
+	PerceivedExertionRating *int                        `json:"perceived_exertion_rating,omitempty" validate:"omitempty,min=1,max=10"`
+}
+
+func (r workoutSessionRequest) apply(session *entity.UserWorkoutSession) {
+	session.PlanID = r.PlanID
+	session.SessionName = r.SessionName
+	session.ScheduledAt = r.ScheduledAt
+	session.StartedAt = r.StartedAt
+	session.CompletedAt = r.CompletedAt
+	session.DurationMinutes = r.DurationMinutes
+	session.Status = r.Status
+	if session.Status == "" {
+		session.Status = entity.WorkoutSessionStatusScheduled
+	}
+	session.Notes = r.Notes
+	session.Location = r.Location
+	session.MoodRating = r.MoodRating
+	session.PerceivedExertionRating = r.PerceivedExertionRating
+}
+
+func workoutSessionRequestFrom(session *entity.UserWorkoutSession) workoutSessionRequest {
+	return workoutSessionRequest{
+		PlanID:                  session.PlanID,
+		SessionName:             session.SessionName,
+		ScheduledAt:             session.ScheduledAt,
+		StartedAt:               session.StartedAt,
+		CompletedAt:             session.CompletedAt,
+		DurationMinutes:         session.DurationMinutes,
+		Status:                  session.Status,
+		Notes:                   session.Notes,
+		Location:                session.Location,
+		MoodRating:              session.MoodRating,
+		PerceivedExertionRating: session.PerceivedExertionRating,
+	}
+}
+
+// createWorkoutSessionRequest is the body of POST /workout-sessions
+type createWorkoutSessionRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+	workoutSessionRequest
+}
+
+// sessionLogResultRequest holds the recorded results of a logged set
+type sessionLogResultRequest struct {
+	RepsCompleted            *int     `json:"reps_completed,omitempty" validate:"omitempty,gte=0"`
+	WeightKg                 *float64 `json:"weight_kg,omitempty" validate:"omitempty,gte=0,lte=9999.99"`
+	DistanceKm               *float64 `json:"distance_km,omitempty" validate:"omitempty,gte=0,lte=9999.999"`
+	DurationSecondsCompleted *int     `json:"duration_seconds_completed,omitempty" validate:"omitempty,gte=0"`
+	RestTakenSeconds         *int     `json:"rest_taken_seconds,omitempty" validate:"omitempty,gte=0"`
+	Notes                    *string  `json:"notes,omitempty"`
+}
+
+func (r sessionLogResultRequest) apply(log *entity.UserWorkoutSessionLog) {
+	log.RepsCompleted = r.RepsCompleted
+	log.WeightKg = r.WeightKg
+	log.DistanceKm = r.DistanceKm
+	log.DurationSecondsCompleted = r.DurationSecondsCompleted
+	log.RestTakenSeconds = r.RestTakenSeconds
+	log.Notes = r.Notes
+}
+
+// addSessionLogRequest is the body of POST /workout-sessions/{id}/exercises
+type addSessionLogRequest struct {
+	ExerciseID     string  `json:"exercise_id" validate:"required,uuid"`
+	PlanExerciseID *string `json:"plan_exercise_id,omitempty" validate:"omitempty,uuid"`
+	SetNumber      int     `json:"set_number" validate:"required,min=1"`
+	sessionLogResultRequest
+}
+
+// nutritionGoalsRequest holds the nutrition goal fields a client may set
+type nutritionGoalsRequest struct {
+	GoalEffectiveDate     *time.Time `json:"goal_effective_date,omitempty"`
+	TargetCalories        float64    `json:"target_calories" validate:"gt=0,lte=999999.99"`
+	TargetProteinGrams    float64    `json:"target_protein_grams" validate:"gte=0,lte=999999.99"`
+	TargetFatGrams        float64    `json:"target_fat_grams" validate:"gte=0,lte=999999.99"`
+	TargetCarbsGrams      float64    `json:"target_carbs_grams" validate:"gte=0,lte=999999.99"`
+	TargetFiberGrams      *float64   `json:"target_fiber_grams,omitempty" validate:"omitempty,gte=0,lte=999999.99"`
+	TargetSugarGramsLimit *float64   `json:"target_sugar_grams_limit,omitempty" validate:"omitempty,gte=0,lte=999999.99"`
+	Notes                 *string    `json:"notes,omitempty"`
+	IsActive              *bool      `json:"is_active,omitempty"`
+}
+
+func (r nutritionGoalsRequest) apply(goals *entity.UserNutritionGoal) {
+	if r.GoalEffectiveDate != nil {
+		goals.GoalEffectiveDate = *r.GoalEffectiveDate
+	} else if goals.GoalEffectiveDate.IsZero() {
+		goals.GoalEffectiveDate = time.Now().Truncate(24 * time.Hour)
+	}
+	goals.TargetCalories = r.TargetCalories
+	goals.TargetProteinGrams = r.TargetProteinGrams
+	goals.TargetFatGrams = r.TargetFatGrams
+	goals.TargetCarbsGrams = r.TargetCarbsGrams
+	goals.TargetFiberGrams = r.TargetFiberGrams
+	goals.TargetSugarGramsLimit = r.TargetSugarGramsLimit
+	goals.Notes = r.Notes
+	if r.IsActive != nil {
+		goals.IsActive = *r.IsActive
+	}
+}
+
+func nutritionGoalsRequestFrom(goals *entity.UserNutritionGoal) nutritionGoalsRequest {
+	effectiveDate := goals.GoalEffectiveDate
+	isActive := goals.IsActive
+	return nutritionGoalsRequest{
+		GoalEffectiveDate:     &effectiveDate,
+		TargetCalories:        goals.TargetCalories,
+		TargetProteinGrams:    goals.TargetProteinGrams,
+		TargetFatGrams:        goals.TargetFatGrams,
+		TargetCarbsGrams:      goals.TargetCarbsGrams,
+		TargetFiberGrams:      goals.TargetFiberGrams,
+		TargetSugarGramsLimit: goals.TargetSugarGramsLimit,
+		Notes:                 goals.Notes,
+		IsActive:              &isActive,
+	}
+}
+
+// createNutritionGoalsRequest is the body of POST /nutrition/goals
+type createNutritionGoalsRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+	nutritionGoalsRequest
+}
+
+// biometricsRequest holds the biometric fields a client may set
+type biometricsRequest struct {
+	LogDate              *time.Time            `json:"log_date,omitempty"`
+	WeightKg             *float64              `json:"weight_kg,omitempty" validate:"omitempty,gt=0,lte=9999.99"`
+	HeightCm             *float64              `json:"height_cm,omitempty" validate:"omitempty,gt=0,lte=9999.9"`
+	BodyFatPercentage    *float64              `json:"body_fat_percentage,omitempty" validate:"omitempty,gte=0,lte=99.99"`
+	WaistCircumferenceCm *float64              `json:"waist_circumference_cm,omitempty" validate:"omitempty,gt=0,lte=9999.9"`
+	HipCircumferenceCm   *float64              `json:"hip_circumference_cm,omitempty" validate:"omitempty,gt=0,lte=9999.9"`
+	ChestCircumferenceCm *float64              `json:"chest_circumference_cm,omitempty" validate:"omitempty,gt=0,lte=9999.9"`
+	RestingHeartRateBpm  *int                  `json:"resting_heart_rate_bpm,omitempty" validate:"omitempty,gt=0"`
+	ActivityLevel        *entity.ActivityLevel `json:"activity_level,omitempty" validate:"omitempty,enum"`
+}
+
+func (r biometricsRequest) apply(biometrics *entity.UserBiometric) {
+	if r.LogDate != nil {
+		biometrics.LogDate = *r.LogDate
+	} else if biometrics.LogDate.IsZero() {
+		biometrics.LogDate = time.Now().Truncate(24 * time.Hour)
+	}
+	biometrics.WeightKg = r.WeightKg
+	biometrics.HeightCm = r.HeightCm
+	biometrics.BodyFatPercentage = r.BodyFatPercentage
+	biometrics.WaistCircumferenceCm = r.WaistCircumferenceCm
+	biometrics.HipCircumferenceCm = r.HipCircumferenceCm
+	biometrics.ChestCircumferenceCm = r.ChestCircumferenceCm
+	biometrics.RestingHeartRateBpm = r.RestingHeartRateBpm
+	biometrics.ActivityLevel = r.ActivityLevel
+}
+
+// createBiometricsRequest is the body of POST /nutrition/biometrics
+type createBiometricsRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+	biometricsRequest
+}
+
+// syncPushRequest is the body of POST /sync/user/{userID}/push
+type syncPushRequest struct {
+	Changes []syncChangeRequest `json:"changes" validate:"required,max=500,dive"`
+}
+
+// syncChangeRequest is a change a client made while offline.
+// Data holds the record for creates and updates, in the shape of the entity's own request body.
+type syncChangeRequest struct {
+	EntityType entity.SyncEntityType `json:"entity_type" validate:"required,enum"`
+	ID         string                `json:"id" validate:"required,uuid"`
+	Operation  entity.SyncOperation  `json:"operation" validate:"required,oneof=create update delete"`
+	ModifiedAt time.Time             `json:"modified_at" validate:"required"`
+	Data       json.RawMessage       `json:"data,omitempty" swaggertype:"object"`
+}
+
+// syncSessionLogRequest is the data of a pushed session log
+type syncSessionLogRequest struct {
+	SessionID string `json:"session_id" validate:"required,uuid"`
+	addSessionLogRequest
+}
+
+// syncMealFoodItemRequest is the data of a pushed meal food item
+type syncMealFoodItemRequest struct {
+	MealID string `json:"meal_id" validate:"required,uuid"`
+	addMealFoodItemRequest
+}
+
+// change decodes and validates the data of the change into the record it describes
+func (r syncChangeRequest) change() (*entity.SyncPushChange, error) {
+	change := &entity.SyncPushChange{
+		EntityType: r.EntityType,
+		EntityID:   r.ID,
+		Operation:  r.Operation,
+		ModifiedAt: r.ModifiedAt,
+	}
+	if r.Operation == entity.SyncOperationDelete {
+		return change, nil
+	}
+
+	switch r.EntityType {
+	case entity.SyncEntityWorkoutSession:
+		var req workoutSessionRequest
+		if err := decodeSyncData(r.Data, &req); err != nil {
+			return nil, err
+		}
+		var session entity.UserWorkoutSession
+		req.apply(&session)
+		change.Record = &session
+	case entity.SyncEntitySessionLog:
+		var req syncSessionLogRequest
+		if err := decodeSyncData(r.Data, &req); err != nil {
+			return nil, err
+		}
+		log := entity.UserWorkoutSessionLog{
+			SessionID:      req.SessionID,
+			ExerciseID:     req.ExerciseID,
+			PlanExerciseID: req.PlanExerciseID,
+			SetNumber:      req.SetNumber,
+		}
+		req.apply(&log)
+		change.Record = &log
+	case entity.SyncEntityMeal:
+		var req mealRequest
+		if err := decodeSyncData(r.Data, &req); err != nil {
+			return nil, err
+		}
+		var meal entity.UserMeal
+		req.apply(&meal)
+		change.Record = &meal
+	case entity.SyncEntityMealFoodItem:
+		var req syncMealFoodItemRequest
+		if err := decodeSyncData(r.Data, &req); err != nil {
+			return nil, err
+		}
+		foodItem := entity.MealFoodItem{MealID: req.MealID, FoodItemID: req.FoodItemID}
+		req.apply(&foodItem)
+		change.Record = &foodItem
+	case entity.SyncEntityBiometrics:
+		var req biometricsRequest
+		if err := decodeSyncData(r.Data, &req); err != nil {
+			return nil, err
+		}
+		var biometrics entity.UserBiometric
+		req.apply(&biometrics)
+		change.Record = &biometrics
+	}
+
+	return change, nil
+}
+
+func decodeSyncData(data json.RawMessage, req interface{}) error {
+	if len(data) == 0 {
+		return errInvalidBody.WithField("data", "is required")
+	}
+	if err := json.Unmarshal(data, req); err != nil {
+		return errInvalidBody.WithField("data", "must be an object of the entity type")
+	}
+	return validateRequest(req)
+}
+
+// uploadImageRequest holds the form fields of POST /uploads/images, next to the file
+type uploadImageRequest struct {
+	Target   entity.UploadTarget `form:"target" json:"target" validate:"required,enum"`
+	TargetID string              `form:"target_id" json:"target_id" validate:"required,uuid"`
+}
+
+// uploadProgressPhotoRequest holds the form fields of POST /progress-photos, next to the file
+type uploadProgressPhotoRequest struct {
+	BiometricsID string                   `form:"biometrics_id" json:"biometrics_id" validate:"required,uuid"`
+	Pose         entity.ProgressPhotoPose `form:"pose" json:"pose" validate:"required,enum"`
+}
+
+type authorizeCoachRequest struct {
+	Username string `json:"username" validate:"required"`
+}
@@ -1,10 +1,29 @@
 package v1
 
-// ErrorResponse represents an error response from the API
+import "github.com/terrnit/rebound/backend/internal/entity"
+
+// ErrorResponse represents an RFC 7807 problem details response from the API.
+// Code is a stable machine readable identifier, RequestID is only set on server errors.
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	Code      string              `json:"code"`
+	RequestID string              `json:"request_id,omitempty"`
+	Errors    []entity.FieldError `json:"errors,omitempty"`
+}
+
+// OAuthErrorResponse is the error response of the OAuth token and revocation endpoints, in the format of RFC 6749
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
 }
 
+// errInvalidBody is returned when a request body cannot be decoded
+var errInvalidBody = entity.NewValidationError("invalid_body", "invalid request body")
+
 // PaginatedResponse represents a paginated response from the API
 type PaginatedResponse struct {
 	Data  interface{} `json:"data"`
@@ -12,3 +31,30 @@ type PaginatedResponse struct {
 	Page  int         `json:"page"`
 	Size  int         `json:"size"`
 }
+
+// CursorResponse represents a cursor paginated response from the API
+type CursorResponse struct {
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	PrevCursor string      `json:"prev_cursor,omitempty"`
+}
+
+// SyncPushResponse reports the outcome of every pushed change, in the order they were pushed
+type SyncPushResponse struct {
+	Results []*entity.SyncPushResult `json:"results"`
+}
+
+// SyncPullResponse holds the server changes since a sync token
+type SyncPullResponse struct {
+	Changes []*entity.SyncChange `json:"changes"`
+	// SyncToken is passed as since on the next pull
+	SyncToken string `json:"sync_token"`
+	// HasMore is set when the next pull returns more changes right away
+	HasMore bool `json:"has_more"`
+}
+
+// DataExportResponse is a data export with the link to download its archive once it is completed
+type DataExportResponse struct {
+	*entity.DataExport
+	DownloadURL string `json:"download_url,omitempty"`
+}
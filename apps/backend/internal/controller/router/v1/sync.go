@@ -0,0 +1,127 @@
+package v1
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+type SyncRoutes struct {
+	syncUC *usecase.SyncUseCase
+	log    logger.Interface
+}
+
+func NewSyncRoutes(handler fiber.Router, uc *usecase.SyncUseCase, l logger.Interface) {
+	r := &SyncRoutes{
+		syncUC: uc,
+		log:    l,
+	}
+
+	h := handler.Group("/sync", middleware.RequireScope(entity.ScopeSync, entity.ScopeSync))
+	{
+		h.Post("/user/:userID/push", middleware.RequireOwner("userID"), r.push)
+		h.Get("/user/:userID/pull", middleware.RequireOwner("userID"), r.pull)
+	}
+}
+
+// @Summary Push offline changes
+// @Description Apply changes a client made offline to workout sessions, session logs, meals, meal food items and biometrics.
+// @Description Records use client generated UUIDs and data takes the same fields as the entity's own request body,
+// @Description session logs add session_id and meal food items add meal_id. Changes are applied in order, so parents must come first.
+// @Description
+// @Description Conflicts are resolved per record, the last writer wins: a change is applied unless the server's copy
+// @Description changed after modified_at. A lost change is reported as a conflict with the server's record in current,
+// @Description or without current if the record was deleted, and the client adopts it. Deletes follow the same rule.
+// @Description Invalid changes are rejected one by one with an error, the rest of the batch is still applied.
+// @Tags sync
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param userID path string true "User ID"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
+// @Param changes body syncPushRequest true "Offline changes"
+// @Success 200 {object} SyncPushResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /sync/user/{userID}/push [post]
+func (r *SyncRoutes) push(c *fiber.Ctx) error {
+	var req syncPushRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	// Changes whose data is invalid are rejected here, the rest go to the use case
+	results := make([]*entity.SyncPushResult, len(req.Changes))
+	changes := make([]*entity.SyncPushChange, 0, len(req.Changes))
+	positions := make([]int, 0, len(req.Changes))
+	for i, changeReq := range req.Changes {
+		change, err := changeReq.change()
+
+		var domainErr *entity.Error
+		if errors.As(err, &domainErr) {
+			results[i] = &entity.SyncPushResult{
+				EntityType: changeReq.EntityType,
+				EntityID:   changeReq.ID,
+				Status:     entity.SyncPushRejected,
+				Error:      domainErr,
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		changes = append(changes, change)
+		positions = append(positions, i)
+	}
+
+	applied, err := r.syncUC.Push(c.Context(), c.Params("userID"), changes)
+	if err != nil {
+		return err
+	}
+	for i, result := range applied {
+		results[positions[i]] = result
+	}
+
+	return c.JSON(SyncPushResponse{Results: results})
+}
+
+// @Summary Pull server changes
+// @Description Get the changes of a user's workout sessions, session logs, meals, meal food items and biometrics since a sync token,
+// @Description in the order they were made. Upserts carry the current record, deletes are tombstones without a record.
+// @Description Logs and meal food items of a deleted session or meal have no tombstone of their own and must be removed with their parent.
+// @Description Start without since and keep passing the returned sync_token, pull again right away while has_more is set.
+// @Tags sync
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param userID path string true "User ID"
+// @Param since query string false "sync_token of the previous pull"
+// @Param limit query int false "Maximum number of changes" default(100)
+// @Success 200 {object} SyncPullResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /sync/user/{userID}/pull [get]
+func (r *SyncRoutes) pull(c *fiber.Ctx) error {
+	changes, position, err := r.syncUC.Pull(c.Context(), c.Params("userID"), c.Query("since"), c.QueryInt("limit"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(SyncPullResponse{
+		Changes:   changes,
+		SyncToken: position.Token,
+		HasMore:   position.HasMore,
+	})
+}
@@ -0,0 +1,84 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+type TrashRoutes struct {
+	trashUC *usecase.TrashUseCase
+	log     logger.Interface
+}
+
+func NewTrashRoutes(handler fiber.Router, uc *usecase.TrashUseCase, l logger.Interface) {
+	r := &TrashRoutes{
+		trashUC: uc,
+		log:     l,
+	}
+
+	h := handler.Group("/trash", middleware.DenyDelegatedTokens())
+	{
+		h.Get("/user/:userID", middleware.RequireOwner("userID"), r.list)
+		h.Post("/user/:userID/:type/:id/restore", middleware.RequireOwner("userID"), r.restore)
+	}
+}
+
+// @Summary List trash
+// @Description Get the workout sessions, session logs, meals, food items, workout plans and biometrics a user deleted
+// @Description in the last 30 days, most recently deleted first. Older items are purged and can no longer be restored.
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param userID path string true "User ID"
+// @Success 200 {array} entity.TrashItem
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /trash/user/{userID} [get]
+func (r *TrashRoutes) list(c *fiber.Ctx) error {
+	items, err := r.trashUC.List(c.Context(), c.Params("userID"))
+	if err != nil {
+		return err
+	}
+	if items == nil {
+		items = []*entity.TrashItem{}
+	}
+
+	return c.JSON(items)
+}
+
+// @Summary Restore from trash
+// @Description Take a deleted record out of the trash. Session logs can only be restored while their session is not deleted.
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param userID path string true "User ID"
+// @Param type path string true "Item type" Enums(workout_session, session_log, meal, food_item, workout_plan, biometrics)
+// @Param id path string true "Item ID"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /trash/user/{userID}/{type}/{id}/restore [post]
+func (r *TrashRoutes) restore(c *fiber.Ctx) error {
+	itemType := entity.TrashItemType(c.Params("type"))
+	if !itemType.IsValid() {
+		return usecase.ErrInvalidInput.WithField("type", "must be one of workout_session session_log meal food_item workout_plan biometrics")
+	}
+
+	if err := r.trashUC.Restore(c.Context(), c.Params("userID"), itemType, c.Params("id")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
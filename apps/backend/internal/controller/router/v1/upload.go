@@ -0,0 +1,111 @@
+package v1
+
+import (
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/usecase"
+	"github.com/terrnit/rebound/backend/pkg/logger"
+)
+
+type UploadRoutes struct {
+	uploadUC *usecase.UploadUseCase
+	log      logger.Interface
+}
+
+func NewUploadRoutes(handler fiber.Router, uc *usecase.UploadUseCase, l logger.Interface) {
+	r := &UploadRoutes{
+		uploadUC: uc,
+		log:      l,
+	}
+
+	h := handler.Group("/uploads")
+	{
+		h.Post("/images", middleware.RequireAuth(), middleware.DenyDelegatedTokens(), r.uploadImage)
+		h.Get("/:name", r.serve)
+	}
+}
+
+// @Summary Upload an image
+// @Description Upload a JPEG, PNG or WebP image as the signed in user's profile picture, the image of an exercise they created
+// @Description or the cover of a workout plan they created. The image is scaled down, stored without its EXIF metadata and with
+// @Description a thumbnail, and its URL is written to the target: profile_picture_url, image_url_main and image_url_thumbnail,
+// @Description or cover_image_url. The image uploaded to the target before is deleted.
+// @Tags uploads
+// @Accept multipart/form-data
+// @Produce json
+// @Param target formData string true "user_profile_picture, exercise_image or workout_plan_cover"
+// @Param target_id formData string true "ID of the user, exercise or workout plan"
+// @Param file formData file true "Image"
+// @Success 201 {object} entity.Upload
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /uploads/images [post]
+func (r *UploadRoutes) uploadImage(c *fiber.Ctx) error {
+	var req uploadImageRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return errInvalidBody.WithField("file", "is required")
+	}
+	if header.Size > r.uploadUC.MaxBytes() {
+		return usecase.ErrUploadTooLarge
+	}
+	file, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, r.uploadUC.MaxBytes()+1))
+	if err != nil {
+		return err
+	}
+
+	upload, err := r.uploadUC.UploadImage(c.Context(), middleware.UserID(c), req.Target, req.TargetID, data)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(upload)
+}
+
+// @Summary Get an uploaded image
+// @Description Get an uploaded image or its thumbnail by the URL written to its target. Depending on the storage the image is
+// @Description sent directly or through a redirect to a short-lived signed URL.
+// @Tags uploads
+// @Produce image/jpeg,image/png
+// @Param name path string true "File name"
+// @Success 200 {file} file
+// @Success 302
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /uploads/{name} [get]
+func (r *UploadRoutes) serve(c *fiber.Ctx) error {
+	signedURL, err := r.uploadUC.SignedURL(c.Params("name"))
+	if err != nil {
+		return err
+	}
+	if signedURL != "" {
+		return c.Redirect(signedURL, fiber.StatusFound)
+	}
+
+	file, contentType, err := r.uploadUC.Open(c.Params("name"))
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	// A file name is never reused for other content
+	c.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	// The response closes the file once it has been sent
+	return c.SendStream(file)
+}
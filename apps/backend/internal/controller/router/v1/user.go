@@ -3,7 +3,9 @@ package v1
 import (
 	"strconv"
 
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
 	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
 	"github.com/terrnit/rebound/backend/internal/usecase"
 	"github.com/terrnit/rebound/backend/pkg/logger"
 
@@ -15,34 +17,25 @@ import (
 // @Tags users
 // @Accept json
 // @Produce json
-// @Param user body entity.User true "User information"
+// @Param user body createUserRequest true "User information"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
 // @Success 201 {object} entity.User
 // @Failure 400 {object} ErrorResponse
 // @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/users [post]
 func (h *userHandler) create(c *fiber.Ctx) error {
-	var body struct {
-		User     entity.User `json:"user"`
-		Password string      `json:"password"`
-	}
-	if err := c.BodyParser(&body); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid request body"})
+	var req createUserRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
 	}
 
-	createdUser, err := h.userUC.CreateUser(c.Context(), &body.User, body.Password)
+	var user entity.User
+	req.User.apply(&user)
+
+	createdUser, err := h.userUC.CreateUser(c.Context(), &user, req.Password)
 	if err != nil {
-		switch err {
-		case usecase.ErrUsernameTaken:
-			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{Error: "Username already taken"})
-		case usecase.ErrEmailTaken:
-			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{Error: "Email already taken"})
-		case usecase.ErrInvalidInput:
-			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid input"})
-		default:
-			h.logger.Error("Failed to create user", "error", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to create user"})
-		}
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(createdUser)
@@ -52,8 +45,12 @@ func (h *userHandler) create(c *fiber.Ctx) error {
 // @Description Get a user by their ID
 // @Tags users
 // @Produce json
+// @Security Bearer
 // @Param id path string true "User ID"
 // @Success 200 {object} entity.User
+// @Header 200 {string} ETag "Current version of the user"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/users/{id} [get]
@@ -62,15 +59,10 @@ func (h *userHandler) getByID(c *fiber.Ctx) error {
 
 	user, err := h.userUC.GetUser(c.Context(), id)
 	if err != nil {
-		switch err {
-		case usecase.ErrUserNotFound:
-			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{Error: "User not found"})
-		default:
-			h.logger.Error("Failed to get user", "error", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to get user"})
-		}
+		return err
 	}
 
+	setETag(c, user.UpdatedAt)
 	return c.JSON(user)
 }
 
@@ -78,20 +70,29 @@ func (h *userHandler) getByID(c *fiber.Ctx) error {
 // @Description Get a paginated list of users
 // @Tags users
 // @Produce json
+// @Security Bearer
 // @Param page query int false "Page number (default: 1)"
 // @Param size query int false "Page size (default: 10)"
+// @Param filter query string false "Filters as filter[field][op]=value, op is one of eq, in, gte, lte, ilike"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending (e.g. -created_at,username)"
 // @Success 200 {object} PaginatedResponse{data=[]entity.User}
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/users [get]
 func (h *userHandler) list(c *fiber.Ctx) error {
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	size, _ := strconv.Atoi(c.Query("size", "10"))
 
-	users, total, err := h.userUC.ListUsers(c.Context(), nil, page, size)
+	spec, err := parseQuerySpec(c, repository.UserFields)
+	if err != nil {
+		return err
+	}
+
+	users, total, err := h.userUC.ListUsers(c.Context(), spec, page, size)
 	if err != nil {
-		h.logger.Error("Failed to list users", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to list users"})
+		return err
 	}
 
 	return c.JSON(PaginatedResponse{
@@ -103,149 +104,271 @@ func (h *userHandler) list(c *fiber.Ctx) error {
 }
 
 // @Summary Update a user
-// @Description Update a user's information
+// @Description Replace a user's information
 // @Tags users
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "User ID"
-// @Param user body entity.User true "Updated user information"
+// @Param If-Match header string false "ETag of the version being replaced"
+// @Param user body userRequest true "Updated user information"
 // @Success 200 {object} entity.User
+// @Header 200 {string} ETag "New version of the user"
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/users/{id} [put]
 func (h *userHandler) update(c *fiber.Ctx) error {
-	id := c.Params("id")
+	var req userRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
 
-	var user entity.User
-	if err := c.BodyParser(&user); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid request body"})
+	user, err := h.userUC.GetUser(c.Context(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(c, user.UpdatedAt); err != nil {
+		return err
 	}
 
-	user.ID = id
-	err := h.userUC.UpdateUser(c.Context(), &user)
+	req.apply(user)
+	if err := h.userUC.UpdateUser(c.Context(), user); err != nil {
+		return err
+	}
+
+	setETag(c, user.UpdatedAt)
+	return c.JSON(user)
+}
+
+// @Summary Patch a user
+// @Description Partially update a user with a JSON merge patch (RFC 7396).
+// @Description Omitted fields are left unchanged and null clears a field.
+// @Tags users
+// @Accept application/merge-patch+json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "User ID"
+// @Param If-Match header string false "ETag of the version being patched"
+// @Param user body userRequest true "Merge patch"
+// @Success 200 {object} entity.User
+// @Header 200 {string} ETag "New version of the user"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/users/{id} [patch]
+func (h *userHandler) patch(c *fiber.Ctx) error {
+	user, err := h.userUC.GetUser(c.Context(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(c, user.UpdatedAt); err != nil {
+		return err
+	}
+
+	req, err := bindPatch(c, userRequestFrom(user))
 	if err != nil {
-		switch err {
-		case usecase.ErrUserNotFound:
-			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{Error: "User not found"})
-		case usecase.ErrUsernameTaken:
-			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{Error: "Username already taken"})
-		case usecase.ErrEmailTaken:
-			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{Error: "Email already taken"})
-		case usecase.ErrInvalidInput:
-			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid input"})
-		default:
-			h.logger.Error("Failed to update user", "error", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to update user"})
-		}
+		return err
 	}
 
+	req.apply(user)
+	if err := h.userUC.UpdateUser(c.Context(), user); err != nil {
+		return err
+	}
+
+	setETag(c, user.UpdatedAt)
 	return c.JSON(user)
 }
 
 // @Summary Delete a user
-// @Description Delete a user by their ID
+// @Description Deactivate a user, sign them out everywhere and schedule the account to be erased at deletion_scheduled_at.
+// @Description Until then the deletion can be cancelled, afterwards all personal data is erased. Public workout plans,
+// @Description public exercises and food items the user created are kept without their author.
 // @Tags users
 // @Produce json
+// @Security Bearer
 // @Param id path string true "User ID"
-// @Success 204 "No Content"
+// @Success 202 {object} entity.User
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/users/{id} [delete]
 func (h *userHandler) delete(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	err := h.userUC.DeleteUser(c.Context(), id)
+	user, err := h.userUC.DeleteUser(c.Context(), id)
 	if err != nil {
-		switch err {
-		case usecase.ErrUserNotFound:
-			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{Error: "User not found"})
-		default:
-			h.logger.Error("Failed to delete user", "error", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to delete user"})
-		}
+		return err
 	}
 
-	return c.SendStatus(fiber.StatusNoContent)
+	return c.Status(fiber.StatusAccepted).JSON(user)
+}
+
+// @Summary Cancel a user deletion
+// @Description Reactivate a user whose account is scheduled for deletion. Signing in again requires new tokens.
+// @Tags users
+// @Produce json
+// @Security Bearer
+// @Param id path string true "User ID"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
+// @Success 200 {object} entity.User
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/users/{id}/cancel-deletion [post]
+func (h *userHandler) cancelDeletion(c *fiber.Ctx) error {
+	user, err := h.userUC.CancelUserDeletion(c.Context(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	setETag(c, user.UpdatedAt)
+	return c.JSON(user)
 }
 
 // @Summary Update user password
-// @Description Update a user's password
+// @Description Update a user's password, optionally signing every other device out
 // @Tags users
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "User ID"
-// @Param password body map[string]string true "New password"
+// @Param password body updatePasswordRequest true "Current and new password, and whether to revoke other sessions"
 // @Success 200 {object} entity.User
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/users/{id}/password [put]
 func (h *userHandler) updatePassword(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	var body struct {
-		CurrentPassword string `json:"current_password"`
-		NewPassword     string `json:"new_password"`
-	}
-	if err := c.BodyParser(&body); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid request body"})
+	var req updatePasswordRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
 	}
 
-	err := h.userUC.UpdatePassword(c.Context(), id, body.CurrentPassword, body.NewPassword)
+	// Users change their own password, the session they do it from is kept
+	err := h.userUC.UpdatePassword(c.Context(), id, req.CurrentPassword, req.NewPassword, req.RevokeOtherSessions, middleware.SessionID(c))
 	if err != nil {
-		switch err {
-		case usecase.ErrUserNotFound:
-			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{Error: "User not found"})
-		case usecase.ErrInvalidPassword:
-			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid password"})
-		default:
-			h.logger.Error("Failed to update password", "error", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to update password"})
-		}
+		return err
 	}
 
 	return c.SendStatus(fiber.StatusOK)
 }
 
 // @Summary Update email verification status
-// @Description Update a user's email verification status
+// @Description Mark a user's email address as verified or not. Only admins can.
 // @Tags users
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "User ID"
-// @Param verified body map[string]bool true "Verification status"
+// @Param verified body updateEmailVerificationRequest true "Verification status"
 // @Success 200 {object} entity.User
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/users/{id}/verify-email [put]
 func (h *userHandler) updateEmailVerification(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	var body struct {
-		Verified bool `json:"verified"`
-	}
-	if err := c.BodyParser(&body); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid request body"})
+	var req updateEmailVerificationRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
 	}
 
-	err := h.userUC.UpdateEmailVerification(c.Context(), id, body.Verified)
+	err := h.userUC.UpdateEmailVerification(c.Context(), id, *req.Verified)
 	if err != nil {
-		switch err {
-		case usecase.ErrUserNotFound:
-			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{Error: "User not found"})
-		default:
-			h.logger.Error("Failed to update email verification", "error", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to update email verification"})
-		}
+		return err
 	}
 
 	return c.SendStatus(fiber.StatusOK)
 }
 
+// @Summary Get user roles
+// @Description Get the roles assigned to a user
+// @Tags users
+// @Produce json
+// @Security Bearer
+// @Param id path string true "User ID"
+// @Success 200 {array} entity.Role
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/users/{id}/roles [get]
+func (h *userHandler) getRoles(c *fiber.Ctx) error {
+	roles, err := h.userUC.GetUserRoles(c.Context(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	if roles == nil {
+		roles = []*entity.Role{}
+	}
+
+	return c.JSON(roles)
+}
+
+// @Summary Assign a role
+// @Description Assign a role to a user, assigning a role the user already has does nothing
+// @Tags users
+// @Produce json
+// @Security Bearer
+// @Param id path string true "User ID"
+// @Param role path string true "Role name"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/users/{id}/roles/{role} [put]
+func (h *userHandler) assignRole(c *fiber.Ctx) error {
+	if err := h.userUC.AssignRole(c.Context(), c.Params("id"), c.Params("role")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary Revoke a role
+// @Description Remove a role from a user, revoking a role the user does not have does nothing
+// @Tags users
+// @Produce json
+// @Security Bearer
+// @Param id path string true "User ID"
+// @Param role path string true "Role name"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/users/{id}/roles/{role} [delete]
+func (h *userHandler) revokeRole(c *fiber.Ctx) error {
+	if err := h.userUC.RevokeRole(c.Context(), c.Params("id"), c.Params("role")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
 type userHandler struct {
 	userUC *usecase.UserUseCase
 	logger logger.Interface
@@ -258,12 +381,23 @@ func NewUserRoutes(router fiber.Router, userUC *usecase.UserUseCase, l logger.In
 		logger: l,
 	}
 
-	users := router.Group("/users")
+	// Signing up is the only anonymous request, users otherwise only see and change their own account
+	users := router.Group("/users", middleware.DenyDelegatedTokens())
+	requireOwner := middleware.RequireOwner("id")
 	users.Post("/", handler.create)
-	users.Get("/:id", handler.getByID)
-	users.Get("/", handler.list)
-	users.Put("/:id", handler.update)
-	users.Delete("/:id", handler.delete)
-	users.Put("/:id/password", handler.updatePassword)
-	users.Put("/:id/verify-email", handler.updateEmailVerification)
+	users.Get("/:id", requireOwner, handler.getByID)
+	users.Put("/:id", requireOwner, handler.update)
+	users.Patch("/:id", requireOwner, handler.patch)
+	users.Delete("/:id", requireOwner, handler.delete)
+	users.Post("/:id/cancel-deletion", requireOwner, handler.cancelDeletion)
+	users.Put("/:id/password", requireOwner, handler.updatePassword)
+	users.Get("/:id/roles", requireOwner, handler.getRoles)
+
+	// Listing users, vouching for an email address and handing out roles, which grant access to other users' data,
+	// is left to admins
+	requireAdmin := middleware.RequireRole(userUC, entity.RoleAdmin)
+	users.Get("/", requireAdmin, handler.list)
+	users.Put("/:id/verify-email", requireAdmin, handler.updateEmailVerification)
+	users.Put("/:id/roles/:role", requireAdmin, handler.assignRole)
+	users.Delete("/:id/roles/:role", requireAdmin, handler.revokeRole)
 }
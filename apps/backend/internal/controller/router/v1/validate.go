@@ -0,0 +1,126 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/entity"
+)
+
+// errValidation is returned when a request body fails its declarative validation rules
+var errValidation = entity.NewValidationError("validation_failed", "request validation failed")
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+var validate = newValidator()
+
+// enum is implemented by entity enum types
+type enum interface {
+	IsValid() bool
+}
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by their JSON names
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	_ = v.RegisterValidation("enum", func(fl validator.FieldLevel) bool {
+		value, ok := fl.Field().Interface().(enum)
+		return ok && value.IsValid()
+	})
+	_ = v.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return usernamePattern.MatchString(fl.Field().String())
+	})
+
+	return v
+}
+
+// bindBody decodes the request body into req and validates it
+func bindBody(c *fiber.Ctx, req interface{}) error {
+	if err := c.BodyParser(req); err != nil {
+		return errInvalidBody
+	}
+
+	return validateRequest(req)
+}
+
+// validateRequest checks req against its validate tags and reports every failing field
+func validateRequest(req interface{}) error {
+	err := validate.Struct(req)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+
+	validationErr := errValidation
+	for _, fe := range fieldErrs {
+		validationErr = validationErr.WithField(fieldPath(fe), fieldMessage(fe))
+	}
+	return validationErr
+}
+
+// fieldPath returns the dotted JSON path of the field.
+// The root struct and embedded request structs have no JSON name and are left out.
+func fieldPath(fe validator.FieldError) string {
+	parts := strings.Split(fe.Namespace(), ".")[1:]
+	path := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if !strings.HasSuffix(part, "Request") {
+			path = append(path, part)
+		}
+	}
+	return strings.Join(path, ".")
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "uuid":
+		return "must be a valid UUID"
+	case "username":
+		return "may only contain letters, digits, dots, dashes and underscores"
+	case "enum":
+		return fmt.Sprintf("%q is not an allowed value", fmt.Sprint(fe.Value()))
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be greater than or equal to " + fe.Param()
+	case "lte":
+		return "must be less than or equal to " + fe.Param()
+	default:
+		return "failed the " + fe.Tag() + " rule"
+	}
+}
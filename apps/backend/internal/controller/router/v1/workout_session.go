@@ -2,6 +2,9 @@ package v1
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/terrnit/rebound/backend/internal/controller/middleware"
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
 	"github.com/terrnit/rebound/backend/internal/usecase"
 	"github.com/terrnit/rebound/backend/pkg/logger"
 )
@@ -17,13 +20,14 @@ func NewWorkoutSessionRoutes(handler fiber.Router, uc *usecase.WorkoutSessionUse
 		log:              l,
 	}
 
-	h := handler.Group("/workout-sessions")
+	h := handler.Group("/workout-sessions", middleware.RequireScope(entity.ScopeReadWorkouts, entity.ScopeWriteWorkouts))
 	{
 		h.Post("/", r.createWorkoutSession)
 		h.Get("/:id", r.getWorkoutSession)
 		h.Put("/:id", r.updateWorkoutSession)
+		h.Patch("/:id", r.patchWorkoutSession)
 		h.Delete("/:id", r.deleteWorkoutSession)
-		h.Get("/user/:userID", r.getUserWorkoutSessions)
+		h.Get("/user/:userID", middleware.RequireOwner("userID"), r.getUserWorkoutSessions)
 		h.Post("/:id/exercises", r.addExercise)
 		h.Get("/:id/exercises", r.getExercises)
 		h.Put("/exercises/:id", r.updateExercise)
@@ -36,14 +40,31 @@ func NewWorkoutSessionRoutes(handler fiber.Router, uc *usecase.WorkoutSessionUse
 // @Tags workout-sessions
 // @Accept json
 // @Produce json
-// @Param session body entity.UserWorkoutSession true "Workout session object"
+// @Security Bearer
+// @Param session body createWorkoutSessionRequest true "Workout session object"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
 // @Success 201 {object} entity.UserWorkoutSession
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /workout-sessions [post]
 func (r *WorkoutSessionRoutes) createWorkoutSession(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	var req createWorkoutSessionRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	session := entity.UserWorkoutSession{UserID: req.UserID}
+	req.apply(&session)
+
+	created, err := r.workoutSessionUC.CreateWorkoutSession(c.Context(), middleware.UserID(c), &session)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(created)
 }
 
 // @Summary Get a workout session by ID
@@ -51,15 +72,24 @@ func (r *WorkoutSessionRoutes) createWorkoutSession(c *fiber.Ctx) error {
 // @Tags workout-sessions
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Workout session ID"
 // @Success 200 {object} entity.UserWorkoutSession
+// @Header 200 {string} ETag "Current version of the workout session"
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /workout-sessions/{id} [get]
 func (r *WorkoutSessionRoutes) getWorkoutSession(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	session, err := r.workoutSessionUC.GetWorkoutSession(c.Context(), middleware.UserID(c), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	setETag(c, session.UpdatedAt)
+	return c.JSON(session)
 }
 
 // @Summary Update a workout session
@@ -67,49 +97,139 @@ func (r *WorkoutSessionRoutes) getWorkoutSession(c *fiber.Ctx) error {
 // @Tags workout-sessions
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Workout session ID"
-// @Param session body entity.UserWorkoutSession true "Workout session object"
+// @Param If-Match header string false "ETag of the version being replaced"
+// @Param session body workoutSessionRequest true "Workout session object"
 // @Success 200 {object} entity.UserWorkoutSession
+// @Header 200 {string} ETag "New version of the workout session"
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /workout-sessions/{id} [put]
 func (r *WorkoutSessionRoutes) updateWorkoutSession(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	var req workoutSessionRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	session, err := r.workoutSessionUC.GetWorkoutSession(c.Context(), middleware.UserID(c), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(c, session.UpdatedAt); err != nil {
+		return err
+	}
+
+	req.apply(session)
+	if err := r.workoutSessionUC.UpdateWorkoutSession(c.Context(), session); err != nil {
+		return err
+	}
+
+	setETag(c, session.UpdatedAt)
+	return c.JSON(session)
+}
+
+// @Summary Patch a workout session
+// @Description Partially update a workout session with a JSON merge patch (RFC 7396).
+// @Description Omitted fields are left unchanged and null clears a field.
+// @Tags workout-sessions
+// @Accept application/merge-patch+json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Workout session ID"
+// @Param If-Match header string false "ETag of the version being patched"
+// @Param session body workoutSessionRequest true "Merge patch"
+// @Success 200 {object} entity.UserWorkoutSession
+// @Header 200 {string} ETag "New version of the workout session"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /workout-sessions/{id} [patch]
+func (r *WorkoutSessionRoutes) patchWorkoutSession(c *fiber.Ctx) error {
+	session, err := r.workoutSessionUC.GetWorkoutSession(c.Context(), middleware.UserID(c), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(c, session.UpdatedAt); err != nil {
+		return err
+	}
+
+	req, err := bindPatch(c, workoutSessionRequestFrom(session))
+	if err != nil {
+		return err
+	}
+
+	req.apply(session)
+	if err := r.workoutSessionUC.UpdateWorkoutSession(c.Context(), session); err != nil {
+		return err
+	}
+
+	setETag(c, session.UpdatedAt)
+	return c.JSON(session)
 }
 
 // @Summary Delete a workout session
-// @Description Delete a workout session by its ID
+// @Description Move a workout session to the trash, it can be restored for 30 days
 // @Tags workout-sessions
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Workout session ID"
 // @Success 204 "No Content"
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /workout-sessions/{id} [delete]
 func (r *WorkoutSessionRoutes) deleteWorkoutSession(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	if err := r.workoutSessionUC.DeleteWorkoutSession(c.Context(), middleware.UserID(c), c.Params("id")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
 }
 
 // @Summary Get user workout sessions
-// @Description Get all workout sessions for a specific user
+// @Description Get a cursor paginated list of workout sessions for a specific user, newest first
 // @Tags workout-sessions
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param userID path string true "User ID"
-// @Param page query int false "Page number" default(1)
-// @Param pageSize query int false "Page size" default(10)
-// @Success 200 {array} entity.UserWorkoutSession
+// @Param limit query int false "Page size" default(10)
+// @Param cursor query string false "Opaque next_cursor or prev_cursor from a previous response"
+// @Param filter query string false "Filters as filter[field][op]=value, op is one of eq, in, gte, lte, ilike"
+// @Success 200 {object} CursorResponse{data=[]entity.UserWorkoutSession}
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /workout-sessions/user/{userID} [get]
 func (r *WorkoutSessionRoutes) getUserWorkoutSessions(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	spec, err := parseQuerySpec(c, repository.WorkoutSessionFields)
+	if err != nil {
+		return err
+	}
+
+	sessions, info, err := r.workoutSessionUC.ListUserWorkoutSessions(c.Context(), c.Params("userID"), spec, parseCursorPage(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(CursorResponse{
+		Data:       sessions,
+		NextCursor: info.NextCursor,
+		PrevCursor: info.PrevCursor,
+	})
 }
 
 // @Summary Add exercise to workout session
@@ -117,32 +237,71 @@ func (r *WorkoutSessionRoutes) getUserWorkoutSessions(c *fiber.Ctx) error {
 // @Tags workout-sessions
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Workout session ID"
-// @Param exercise body entity.UserWorkoutSession true "Exercise object"
-// @Success 201 {object} entity.UserWorkoutSession
+// @Param exercise body addSessionLogRequest true "Exercise log object"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
+// @Success 201 {object} entity.UserWorkoutSessionLog
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /workout-sessions/{id}/exercises [post]
 func (r *WorkoutSessionRoutes) addExercise(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	var req addSessionLogRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	session, err := r.workoutSessionUC.GetWorkoutSession(c.Context(), middleware.UserID(c), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	log := entity.UserWorkoutSessionLog{
+		SessionID:      session.ID,
+		ExerciseID:     req.ExerciseID,
+		PlanExerciseID: req.PlanExerciseID,
+		SetNumber:      req.SetNumber,
+	}
+	req.apply(&log)
+
+	if err := r.workoutSessionUC.AddSessionLog(c.Context(), &log); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(log)
 }
 
 // @Summary Get workout session exercises
-// @Description Get all exercises for a workout session
+// @Description Get a cursor paginated list of the exercise logs of a workout session, in logging order
 // @Tags workout-sessions
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Workout session ID"
-// @Success 200 {array} entity.UserWorkoutSession
+// @Param limit query int false "Page size" default(10)
+// @Param cursor query string false "Opaque next_cursor or prev_cursor from a previous response"
+// @Success 200 {object} CursorResponse{data=[]entity.UserWorkoutSessionLog}
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /workout-sessions/{id}/exercises [get]
 func (r *WorkoutSessionRoutes) getExercises(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	logs, info, err := r.workoutSessionUC.ListSessionLogs(c.Context(), middleware.UserID(c), c.Params("id"), parseCursorPage(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(CursorResponse{
+		Data:       logs,
+		NextCursor: info.NextCursor,
+		PrevCursor: info.PrevCursor,
+	})
 }
 
 // @Summary Update workout exercise
@@ -150,30 +309,50 @@ func (r *WorkoutSessionRoutes) getExercises(c *fiber.Ctx) error {
 // @Tags workout-sessions
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Exercise ID"
-// @Param exercise body entity.UserWorkoutSession true "Exercise object"
-// @Success 200 {object} entity.UserWorkoutSession
+// @Param exercise body sessionLogResultRequest true "Exercise log results"
+// @Success 204 "No Content"
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /workout-sessions/exercises/{id} [put]
 func (r *WorkoutSessionRoutes) updateExercise(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	var req sessionLogResultRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	log := entity.UserWorkoutSessionLog{ID: c.Params("id")}
+	req.apply(&log)
+
+	if err := r.workoutSessionUC.UpdateSessionLog(c.Context(), middleware.UserID(c), &log); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
 }
 
 // @Summary Delete workout exercise
-// @Description Delete an exercise from a workout session
+// @Description Move an exercise log of a workout session to the trash, it can be restored for 30 days
 // @Tags workout-sessions
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param id path string true "Exercise ID"
 // @Success 204 "No Content"
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /workout-sessions/exercises/{id} [delete]
 func (r *WorkoutSessionRoutes) deleteExercise(c *fiber.Ctx) error {
-	// TODO: Implement
-	return c.SendStatus(fiber.StatusNotImplemented)
+	if err := r.workoutSessionUC.DeleteSessionLog(c.Context(), middleware.UserID(c), c.Params("id")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
 }
@@ -0,0 +1,70 @@
+package entity
+
+import "time"
+
+// AuditAction is a security or data sensitive action recorded in the audit log
+type AuditAction string
+
+const (
+	AuditActionLogin                   AuditAction = "login"
+	AuditActionLoginFailed             AuditAction = "login_failed"
+	AuditActionLogout                  AuditAction = "logout"
+	AuditActionPasswordChange          AuditAction = "password_change"
+	AuditActionTwoFactorEnable         AuditAction = "two_factor_enable"
+	AuditActionTwoFactorDisable        AuditAction = "two_factor_disable"
+	AuditActionRecoveryCodesRegenerate AuditAction = "recovery_codes_regenerate"
+	AuditActionPersonalTokenCreate     AuditAction = "personal_token_create"
+	AuditActionPersonalTokenRevoke     AuditAction = "personal_token_revoke"
+	AuditActionOAuthClientCreate       AuditAction = "oauth_client_create"
+	AuditActionOAuthClientDelete       AuditAction = "oauth_client_delete"
+	AuditActionOAuthConsentGrant       AuditAction = "oauth_consent_grant"
+	AuditActionOAuthConsentRevoke      AuditAction = "oauth_consent_revoke"
+	AuditActionIdentityLink            AuditAction = "identity_link"
+	AuditActionIdentityUnlink          AuditAction = "identity_unlink"
+	AuditActionCoachGrant              AuditAction = "coach_grant"
+	AuditActionCoachRevoke             AuditAction = "coach_revoke"
+	AuditActionRoleAssign              AuditAction = "role_assign"
+	AuditActionRoleRevoke              AuditAction = "role_revoke"
+	AuditActionEmailVerificationChange AuditAction = "email_verification_change"
+	AuditActionAccountDeletionRequest  AuditAction = "account_deletion_request"
+	AuditActionAccountDeletionCancel   AuditAction = "account_deletion_cancel"
+	AuditActionAccountDelete           AuditAction = "account_delete"
+	AuditActionFoodItemCreate          AuditAction = "food_item_create"
+	AuditActionFoodItemUpdate          AuditAction = "food_item_update"
+	AuditActionFoodItemDelete          AuditAction = "food_item_delete"
+)
+
+// AuditTargetType identifies the kind of record an audited action was performed on
+type AuditTargetType string
+
+const (
+	AuditTargetUser     AuditTargetType = "user"
+	AuditTargetFoodItem AuditTargetType = "food_item"
+)
+
+// AuditChange holds the value of a field before and after an audited action
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditSource describes who performed an action and from where
+type AuditSource struct {
+	// ActorID is the ID of the signed in user, empty for anonymous requests and the system
+	ActorID   string
+	IPAddress string
+	UserAgent string
+}
+
+// AuditLog is an entry of the audit log
+type AuditLog struct {
+	ID         string                 `json:"id"`
+	ActorID    *string                `json:"actor_id,omitempty"`
+	Action     AuditAction            `json:"action"`
+	TargetType AuditTargetType        `json:"target_type"`
+	TargetID   string                 `json:"target_id"`
+	Changes    map[string]AuditChange `json:"changes,omitempty"`
+	IPAddress  string                 `json:"ip_address,omitempty"`
+	UserAgent  string                 `json:"user_agent,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
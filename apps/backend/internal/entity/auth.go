@@ -0,0 +1,95 @@
+package entity
+
+import "time"
+
+// TokenPair is the access and refresh token issued when signing in
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	// ExpiresIn is the lifetime of the access token in seconds
+	ExpiresIn int `json:"expires_in"`
+}
+
+// Session is a signed in device, the chain of refresh tokens issued after one sign in
+type Session struct {
+	ID         string    `json:"id"`
+	DeviceName string    `json:"device_name,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	SignedInAt time.Time `json:"signed_in_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Current marks the session of the request
+	Current bool `json:"current"`
+}
+
+// Principal is who a request is authenticated as, through a session's access token,
+// a personal access token or a token issued to an OAuth client
+type Principal struct {
+	UserID string
+	// SessionID is set for access tokens of sessions
+	SessionID string
+	// PersonalToken is set for personal access tokens
+	PersonalToken *PersonalToken
+	// ClientID is set for tokens issued to OAuth clients
+	ClientID string
+	// Scopes limit what personal access tokens and OAuth clients can do
+	Scopes []Scope
+}
+
+// IsDelegated reports whether the request is made with a personal access token or by an OAuth client,
+// which are limited to their scopes
+func (p *Principal) IsDelegated() bool {
+	return p.PersonalToken != nil || p.ClientID != ""
+}
+
+// Allows reports whether the principal may use scope, sessions are not limited by scopes
+func (p *Principal) Allows(scope Scope) bool {
+	if !p.IsDelegated() {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// LoginResult is the outcome of the password step of signing in.
+// Users with two-factor authentication get a challenge to answer with a code instead of tokens.
+type LoginResult struct {
+	*TokenPair
+	TwoFactorRequired  bool       `json:"two_factor_required"`
+	ChallengeToken     string     `json:"challenge_token,omitempty"`
+	ChallengeExpiresAt *time.Time `json:"challenge_expires_at,omitempty"`
+}
+
+// TwoFactor is a user's TOTP configuration
+type TwoFactor struct {
+	UserID string
+	// Secret is encrypted
+	Secret string
+	// EnabledAt is nil while the enrollment waits for its confirmation code
+	EnabledAt    *time.Time
+	LastUsedStep *int64
+	CreatedAt    time.Time
+}
+
+// IsEnabled reports whether the enrollment was confirmed
+func (t *TwoFactor) IsEnabled() bool {
+	return t != nil && t.EnabledAt != nil
+}
+
+// TwoFactorEnrollment holds what an authenticator app needs to generate codes
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// RecoveryCodes are one-time codes that replace a TOTP code when the authenticator is lost.
+// They are only shown when generated.
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
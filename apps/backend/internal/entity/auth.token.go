@@ -20,4 +20,16 @@ type AuthToken struct {
 	ExpiresAt time.Time     `json:"expires_at"`
 	IssuedAt  time.Time     `json:"issued_at"`
 	IsRevoked bool          `json:"is_revoked"`
+
+	// Refresh tokens belong to a session, which rotation carries over to the next token
+	SessionID  string    `json:"session_id"`
+	DeviceName string    `json:"device_name,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	SignedInAt time.Time `json:"signed_in_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+
+	// Tokens issued to OAuth clients carry the client and the scopes the user granted
+	ClientID string  `json:"client_id,omitempty"`
+	Scopes   []Scope `json:"scopes,omitempty"`
 }
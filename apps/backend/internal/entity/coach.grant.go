@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// CoachGrant authorises a coach to see a user's progress photos and the measurements they are compared with
+type CoachGrant struct {
+	UserID        string    `json:"user_id"`
+	Username      string    `json:"username"`
+	CoachID       string    `json:"coach_id"`
+	CoachUsername string    `json:"coach_username"`
+	CreatedAt     time.Time `json:"created_at"`
+}
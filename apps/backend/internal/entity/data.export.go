@@ -0,0 +1,42 @@
+package entity
+
+import "time"
+
+// DataExportStatus is the state of a data export job
+type DataExportStatus string
+
+const (
+	DataExportPending   DataExportStatus = "pending"
+	DataExportRunning   DataExportStatus = "running"
+	DataExportCompleted DataExportStatus = "completed"
+	DataExportFailed    DataExportStatus = "failed"
+	DataExportExpired   DataExportStatus = "expired"
+)
+
+// IsFinished reports whether the export job will not change anymore, apart from expiring
+func (v DataExportStatus) IsFinished() bool {
+	return v == DataExportCompleted || v == DataExportFailed || v == DataExportExpired
+}
+
+// DataExport is a job that gathers all data tied to a user into a downloadable archive
+type DataExport struct {
+	ID          string           `json:"id"`
+	UserID      string           `json:"user_id"`
+	Status      DataExportStatus `json:"status"`
+	FileName    string           `json:"-"`
+	Error       string           `json:"error,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	StartedAt   *time.Time       `json:"started_at,omitempty"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
+}
+
+// DataExportSection is one kind of record in a data export, such as meals or biometrics
+type DataExportSection struct {
+	Name string
+	// JSON is an array of the records with their typed fields
+	JSON []byte
+	// Columns and Rows hold the same records as text, NULL becomes an empty string
+	Columns []string
+	Rows    [][]string
+}
@@ -0,0 +1,123 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// ErrorKind classifies a domain error so that transports can map it to a status code
+type ErrorKind int
+
+const (
+	ErrorKindInternal ErrorKind = iota
+	ErrorKindNotFound
+	ErrorKindConflict
+	ErrorKindValidation
+	ErrorKindUnauthorized
+	ErrorKindForbidden
+	ErrorKindPreconditionFailed
+	ErrorKindTooManyRequests
+	ErrorKindPayloadTooLarge
+	ErrorKindUnsupportedMediaType
+	ErrorKindBadGateway
+)
+
+// FieldError describes why a single field failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a domain error with a stable machine readable code
+type Error struct {
+	Kind    ErrorKind    `json:"-"`
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"errors,omitempty"`
+	// RetryAfter tells clients how long to wait before trying again
+	RetryAfter time.Duration `json:"-"`
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+
+	details := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		details = append(details, f.Field+": "+f.Message)
+	}
+	return e.Message + ": " + strings.Join(details, "; ")
+}
+
+// Is reports whether target is a domain error with the same code,
+// so that errors.Is matches copies created by WithField.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithField returns a copy of the error with an additional field error
+func (e *Error) WithField(field, message string) *Error {
+	c := *e
+	c.Fields = append(append([]FieldError(nil), e.Fields...), FieldError{Field: field, Message: message})
+	return &c
+}
+
+// WithRetryAfter returns a copy of the error telling clients to wait d before trying again
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	c := *e
+	c.RetryAfter = d
+	return &c
+}
+
+// NewNotFoundError creates an error for a missing resource
+func NewNotFoundError(code, message string) *Error {
+	return &Error{Kind: ErrorKindNotFound, Code: code, Message: message}
+}
+
+// NewConflictError creates an error for a request that conflicts with the current state
+func NewConflictError(code, message string) *Error {
+	return &Error{Kind: ErrorKindConflict, Code: code, Message: message}
+}
+
+// NewValidationError creates an error for invalid input
+func NewValidationError(code, message string, fields ...FieldError) *Error {
+	return &Error{Kind: ErrorKindValidation, Code: code, Message: message, Fields: fields}
+}
+
+// NewUnauthorizedError creates an error for a request without valid credentials
+func NewUnauthorizedError(code, message string) *Error {
+	return &Error{Kind: ErrorKindUnauthorized, Code: code, Message: message}
+}
+
+// NewForbiddenError creates an error for an action the caller is not allowed to perform
+func NewForbiddenError(code, message string) *Error {
+	return &Error{Kind: ErrorKindForbidden, Code: code, Message: message}
+}
+
+// NewPreconditionFailedError creates an error for a conditional request whose precondition does not hold
+func NewPreconditionFailedError(code, message string) *Error {
+	return &Error{Kind: ErrorKindPreconditionFailed, Code: code, Message: message}
+}
+
+// NewTooManyRequestsError creates an error for a caller that has to slow down
+func NewTooManyRequestsError(code, message string) *Error {
+	return &Error{Kind: ErrorKindTooManyRequests, Code: code, Message: message}
+}
+
+// NewPayloadTooLargeError creates an error for a request body over the size limit
+func NewPayloadTooLargeError(code, message string) *Error {
+	return &Error{Kind: ErrorKindPayloadTooLarge, Code: code, Message: message}
+}
+
+// NewUnsupportedMediaTypeError creates an error for a request body in a format that is not accepted
+func NewUnsupportedMediaTypeError(code, message string) *Error {
+	return &Error{Kind: ErrorKindUnsupportedMediaType, Code: code, Message: message}
+}
+
+// NewBadGatewayError creates an error for a service the request depends on that failed or could not be reached
+func NewBadGatewayError(code, message string) *Error {
+	return &Error{Kind: ErrorKindBadGateway, Code: code, Message: message}
+}
@@ -12,6 +12,15 @@ const (
 	ExerciseDifficultyExpert       ExerciseDifficulty = "expert"
 )
 
+// IsValid reports whether the value is a known exercise difficulty
+func (v ExerciseDifficulty) IsValid() bool {
+	switch v {
+	case ExerciseDifficultyBeginner, ExerciseDifficultyIntermediate, ExerciseDifficultyAdvanced, ExerciseDifficultyExpert:
+		return true
+	}
+	return false
+}
+
 // ExerciseType represents the type of exercise
 type ExerciseType string
 
@@ -26,6 +35,15 @@ const (
 	ExerciseTypeOther       ExerciseType = "other"
 )
 
+// IsValid reports whether the value is a known exercise type
+func (v ExerciseType) IsValid() bool {
+	switch v {
+	case ExerciseTypeStrength, ExerciseTypeCardio, ExerciseTypeFlexibility, ExerciseTypeBalance, ExerciseTypeHIIT, ExerciseTypeYoga, ExerciseTypePilates, ExerciseTypeOther:
+		return true
+	}
+	return false
+}
+
 // Exercise represents a physical exercise
 type Exercise struct {
 	ID                    string             `json:"id"`
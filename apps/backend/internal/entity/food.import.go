@@ -0,0 +1,29 @@
+package entity
+
+// FoodImportRecord is a food item read from a food database dump, with its household serving units
+type FoodImportRecord struct {
+	// SourceID identifies the food in the database it comes from, such as usda:171688
+	SourceID     string
+	Item         *FoodItem
+	ServingUnits []*ServingUnit
+}
+
+// FoodImportResult counts what an import did with the records it read
+type FoodImportResult struct {
+	Read     int64 `json:"read"`
+	Inserted int64 `json:"inserted"`
+	Updated  int64 `json:"updated"`
+	// Skipped records match a food item created by a user or imported from another database, which imports leave alone
+	Skipped int64 `json:"skipped"`
+	// Rejected records could not be read or hold values out of range
+	Rejected int64 `json:"rejected"`
+}
+
+// Add adds the counts of another result
+func (r *FoodImportResult) Add(other FoodImportResult) {
+	r.Read += other.Read
+	r.Inserted += other.Inserted
+	r.Updated += other.Updated
+	r.Skipped += other.Skipped
+	r.Rejected += other.Rejected
+}
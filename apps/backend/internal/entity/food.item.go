@@ -11,6 +11,15 @@ const (
 	FoodItemSourceAPI         FoodItemSource = "api"
 )
 
+// IsValid reports whether the value is a known food item source
+func (v FoodItemSource) IsValid() bool {
+	switch v {
+	case FoodItemSourceUserCreated, FoodItemSourceSystem, FoodItemSourceAPI:
+		return true
+	}
+	return false
+}
+
 // FoodItem represents a food item in the system
 type FoodItem struct {
 	ID                                 string         `json:"id"`
@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// IdempotencyKey stores the response of a request sent with an Idempotency-Key header
+// so that retries of the same request can be answered without executing it again.
+// Keys are scoped to the caller that sent them, so callers cannot collide or see each other's responses.
+type IdempotencyKey struct {
+	Scope        string    `json:"-"`
+	Key          string    `json:"key"`
+	RequestHash  string    `json:"request_hash"`
+	StatusCode   *int      `json:"status_code,omitempty"`
+	ContentType  string    `json:"content_type,omitempty"`
+	ResponseBody []byte    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// IsCompleted reports whether the response of the original request has been stored
+func (k *IdempotencyKey) IsCompleted() bool {
+	return k.StatusCode != nil
+}
@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+// LinkedIdentity is an account at an OpenID Connect provider that signs a user in
+type LinkedIdentity struct {
+	ID       string `json:"id"`
+	UserID   string `json:"-"`
+	Provider string `json:"provider"`
+	// Subject is the provider's stable ID of the account
+	Subject     string     `json:"-"`
+	Email       string     `json:"email,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// OIDCLoginState is a sign in with a provider in progress, looked up by the state the provider sends back
+type OIDCLoginState struct {
+	StateHash    string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	// DeviceName labels the session started once the sign in completes
+	DeviceName string
+	ExpiresAt  time.Time
+}
+
+// OIDCProvider is a provider users can sign in with
+type OIDCProvider struct {
+	Name string `json:"name"`
+}
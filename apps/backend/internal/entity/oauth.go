@@ -0,0 +1,88 @@
+package entity
+
+import "time"
+
+// OAuthClient is a partner app registered to access user data with their consent
+type OAuthClient struct {
+	ID      string  `json:"client_id"`
+	OwnerID *string `json:"owner_id,omitempty"`
+	Name    string  `json:"name"`
+	// Confidential clients authenticate with their secret, public ones rely on PKCE alone
+	Confidential bool     `json:"confidential"`
+	SecretHash   string   `json:"-"`
+	RedirectURIs []string `json:"redirect_uris"`
+	// Scopes are the most the client can ask users for
+	Scopes    []Scope   `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Secret is only set in the response that registers a confidential client
+	Secret string `json:"client_secret,omitempty"`
+}
+
+// OAuthConsent records the scopes a user granted to a client, a connected app
+type OAuthConsent struct {
+	UserID     string    `json:"-"`
+	ClientID   string    `json:"client_id"`
+	ClientName string    `json:"client_name"`
+	Scopes     []Scope   `json:"scopes"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// OAuthAuthorizationCode is a one-time code that a client exchanges for tokens, bound to a PKCE challenge
+type OAuthAuthorizationCode struct {
+	CodeHash    string
+	ClientID    string
+	UserID      string
+	RedirectURI string
+	Scopes      []Scope
+	// CodeChallenge is the S256 challenge the code verifier must match
+	CodeChallenge string
+	ExpiresAt     time.Time
+	UsedAt        *time.Time
+}
+
+// OAuthAuthorizeRequest holds the parameters of an authorization request
+type OAuthAuthorizeRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// OAuthAuthorization is what the consent screen shows for an authorization request
+type OAuthAuthorization struct {
+	Client *OAuthClient `json:"client"`
+	Scopes []Scope      `json:"scopes"`
+	// Consented is set when the user already granted all the scopes, so the request can be approved without asking
+	Consented bool `json:"consented"`
+}
+
+// OAuthRedirect is where the user agent goes back to the client with the outcome of an authorization request
+type OAuthRedirect struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+// OAuthTokenRequest holds the parameters of a token request
+type OAuthTokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	ClientID     string
+	ClientSecret string
+}
+
+// OAuthToken is the response of the token endpoint
+type OAuthToken struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	// Scope is the space separated list of granted scopes
+	Scope string `json:"scope"`
+}
@@ -0,0 +1,63 @@
+package entity
+
+import "time"
+
+// Scope is a permission granted to a personal access token
+type Scope string
+
+const (
+	ScopeReadWorkouts    Scope = "read:workouts"
+	ScopeWriteWorkouts   Scope = "write:workouts"
+	ScopeReadMeals       Scope = "read:meals"
+	ScopeWriteMeals      Scope = "write:meals"
+	ScopeReadFoods       Scope = "read:foods"
+	ScopeWriteFoods      Scope = "write:foods"
+	ScopeReadNutrition   Scope = "read:nutrition"
+	ScopeWriteNutrition  Scope = "write:nutrition"
+	ScopeReadBiometrics  Scope = "read:biometrics"
+	ScopeWriteBiometrics Scope = "write:biometrics"
+	ScopeReadExports     Scope = "read:exports"
+	ScopeWriteExports    Scope = "write:exports"
+	ScopeSync            Scope = "sync"
+)
+
+// IsValid reports whether the value is a known scope
+func (v Scope) IsValid() bool {
+	switch v {
+	case ScopeReadWorkouts, ScopeWriteWorkouts, ScopeReadMeals, ScopeWriteMeals, ScopeReadFoods, ScopeWriteFoods,
+		ScopeReadNutrition, ScopeWriteNutrition, ScopeReadBiometrics, ScopeWriteBiometrics,
+		ScopeReadExports, ScopeWriteExports, ScopeSync:
+		return true
+	}
+	return false
+}
+
+// PersonalToken is a long-lived token users create for their own scripts and integrations.
+// Only its hash is stored, the token itself is shown once when it is created.
+type PersonalToken struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	Name   string `json:"name"`
+	// Prefix is the start of the token, to tell tokens apart
+	Prefix    string  `json:"prefix"`
+	TokenHash string  `json:"-"`
+	Scopes    []Scope `json:"scopes"`
+	// ExpiresAt is nil for tokens that do not expire
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"-"`
+
+	// Token is only set in the response that creates it
+	Token string `json:"token,omitempty"`
+}
+
+// HasScope reports whether the token was granted scope
+func (t *PersonalToken) HasScope(scope Scope) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
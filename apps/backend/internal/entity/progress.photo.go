@@ -0,0 +1,66 @@
+package entity
+
+import "time"
+
+// ProgressPhotoPose is the angle a progress photo is taken from
+type ProgressPhotoPose string
+
+const (
+	ProgressPhotoFront ProgressPhotoPose = "front"
+	ProgressPhotoSide  ProgressPhotoPose = "side"
+	ProgressPhotoBack  ProgressPhotoPose = "back"
+)
+
+// IsValid reports whether the value is a known pose
+func (v ProgressPhotoPose) IsValid() bool {
+	switch v {
+	case ProgressPhotoFront, ProgressPhotoSide, ProgressPhotoBack:
+		return true
+	}
+	return false
+}
+
+// ProgressPhoto is a photo of a user's progress attached to a biometrics entry, one per pose.
+// Its files are private, URL and ThumbnailURL are short-lived signed links.
+type ProgressPhoto struct {
+	ID           string            `json:"id"`
+	UserID       string            `json:"user_id"`
+	BiometricsID string            `json:"biometrics_id"`
+	LogDate      time.Time         `json:"log_date"`
+	Pose         ProgressPhotoPose `json:"pose"`
+	// FileName and ThumbnailFileName are the names of the files in progress photo storage
+	FileName          string    `json:"-"`
+	ThumbnailFileName string    `json:"-"`
+	URL               string    `json:"url,omitempty"`
+	ThumbnailURL      string    `json:"thumbnail_url,omitempty"`
+	ContentType       string    `json:"content_type"`
+	Size              int64     `json:"size_bytes"`
+	Width             int       `json:"width"`
+	Height            int       `json:"height"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// ProgressSnapshot is the biometrics entry closest to a date, on or before it, with its photos
+type ProgressSnapshot struct {
+	// Date is the date asked for, Biometrics.LogDate the date of the entry found
+	Date       time.Time        `json:"date"`
+	Biometrics *UserBiometric   `json:"biometrics,omitempty"`
+	Photos     []*ProgressPhoto `json:"photos"`
+}
+
+// ProgressComparison puts a user's progress at two dates side by side
+type ProgressComparison struct {
+	From    *ProgressSnapshot `json:"from"`
+	To      *ProgressSnapshot `json:"to"`
+	Changes *BiometricChanges `json:"changes,omitempty"`
+}
+
+// BiometricChanges holds how much each measurement changed between two entries, nil when either lacks it
+type BiometricChanges struct {
+	WeightKg             *float64 `json:"weight_kg,omitempty"`
+	BodyFatPercentage    *float64 `json:"body_fat_percentage,omitempty"`
+	WaistCircumferenceCm *float64 `json:"waist_circumference_cm,omitempty"`
+	HipCircumferenceCm   *float64 `json:"hip_circumference_cm,omitempty"`
+	ChestCircumferenceCm *float64 `json:"chest_circumference_cm,omitempty"`
+	RestingHeartRateBpm  *int     `json:"resting_heart_rate_bpm,omitempty"`
+}
@@ -1,5 +1,8 @@
 package entity
 
+// RoleAdmin is the role of the operators who manage roles and read the audit log
+const RoleAdmin = "admin"
+
 // Role represents a user role
 type Role struct {
 	ID          int     `json:"id"`
@@ -0,0 +1,79 @@
+package entity
+
+import "time"
+
+// SyncEntityType identifies the kind of record exchanged through offline sync
+type SyncEntityType string
+
+const (
+	SyncEntityWorkoutSession SyncEntityType = "workout_session"
+	SyncEntitySessionLog     SyncEntityType = "session_log"
+	SyncEntityMeal           SyncEntityType = "meal"
+	SyncEntityMealFoodItem   SyncEntityType = "meal_food_item"
+	SyncEntityBiometrics     SyncEntityType = "biometrics"
+)
+
+// IsValid reports whether the value is a known sync entity type
+func (v SyncEntityType) IsValid() bool {
+	switch v {
+	case SyncEntityWorkoutSession, SyncEntitySessionLog, SyncEntityMeal, SyncEntityMealFoodItem, SyncEntityBiometrics:
+		return true
+	}
+	return false
+}
+
+// SyncOperation is the kind of change made to a synced record.
+// Clients push creates, updates and deletes, the change feed reports upserts and deletes.
+type SyncOperation string
+
+const (
+	SyncOperationCreate SyncOperation = "create"
+	SyncOperationUpdate SyncOperation = "update"
+	SyncOperationUpsert SyncOperation = "upsert"
+	SyncOperationDelete SyncOperation = "delete"
+)
+
+// SyncChange is the latest change of a record in the change feed.
+// A change with the delete operation is a tombstone.
+type SyncChange struct {
+	EntityType SyncEntityType `json:"entity_type"`
+	EntityID   string         `json:"entity_id"`
+	UserID     string         `json:"-"`
+	Operation  SyncOperation  `json:"operation"`
+	ChangedAt  time.Time      `json:"changed_at"`
+	// Record holds the current record of an upsert
+	Record interface{} `json:"record,omitempty"`
+}
+
+// SyncPushChange is a change a client made while offline.
+// Record is one of *UserWorkoutSession, *UserWorkoutSessionLog, *UserMeal, *MealFoodItem
+// or *UserBiometric carrying the client generated ID, and nil for deletes.
+type SyncPushChange struct {
+	EntityType SyncEntityType
+	EntityID   string
+	Operation  SyncOperation
+	ModifiedAt time.Time
+	Record     interface{}
+}
+
+// SyncPushStatus is the outcome of a pushed change
+type SyncPushStatus string
+
+const (
+	// SyncPushApplied means the change is now the server state
+	SyncPushApplied SyncPushStatus = "applied"
+	// SyncPushConflict means a newer server change won, Current holds the server state
+	SyncPushConflict SyncPushStatus = "conflict"
+	// SyncPushRejected means the change is invalid and was not applied, Error tells why
+	SyncPushRejected SyncPushStatus = "rejected"
+)
+
+// SyncPushResult reports what happened to a pushed change
+type SyncPushResult struct {
+	EntityType SyncEntityType `json:"entity_type"`
+	EntityID   string         `json:"entity_id"`
+	Status     SyncPushStatus `json:"status"`
+	// Current is the server's record when a conflict was lost, nil if the record was deleted
+	Current interface{} `json:"current,omitempty"`
+	Error   *Error      `json:"error,omitempty"`
+}
@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+// TrashItemType identifies the kind of record that can be restored from the trash
+type TrashItemType string
+
+const (
+	TrashItemWorkoutSession TrashItemType = "workout_session"
+	TrashItemSessionLog     TrashItemType = "session_log"
+	TrashItemMeal           TrashItemType = "meal"
+	TrashItemFoodItem       TrashItemType = "food_item"
+	TrashItemWorkoutPlan    TrashItemType = "workout_plan"
+	TrashItemBiometrics     TrashItemType = "biometrics"
+)
+
+// IsValid reports whether the value is a known trash item type
+func (v TrashItemType) IsValid() bool {
+	switch v {
+	case TrashItemWorkoutSession, TrashItemSessionLog, TrashItemMeal, TrashItemFoodItem, TrashItemWorkoutPlan, TrashItemBiometrics:
+		return true
+	}
+	return false
+}
+
+// TrashItem is a soft deleted record that can still be restored
+type TrashItem struct {
+	Type      TrashItemType `json:"type"`
+	ID        string        `json:"id"`
+	Title     string        `json:"title"`
+	DeletedAt time.Time     `json:"deleted_at"`
+	// RestoreUntil is when the record is purged for good
+	RestoreUntil time.Time `json:"restore_until"`
+}
@@ -0,0 +1,40 @@
+package entity
+
+import "time"
+
+// UploadTarget is the image field an upload is attached to
+type UploadTarget string
+
+const (
+	UploadTargetProfilePicture   UploadTarget = "user_profile_picture"
+	UploadTargetExerciseImage    UploadTarget = "exercise_image"
+	UploadTargetWorkoutPlanCover UploadTarget = "workout_plan_cover"
+)
+
+// IsValid reports whether v is a known upload target
+func (v UploadTarget) IsValid() bool {
+	switch v {
+	case UploadTargetProfilePicture, UploadTargetExerciseImage, UploadTargetWorkoutPlanCover:
+		return true
+	}
+	return false
+}
+
+// Upload is an uploaded image, re-encoded without its metadata and stored with a thumbnail
+type Upload struct {
+	ID       string       `json:"id"`
+	Target   UploadTarget `json:"target"`
+	TargetID string       `json:"target_id"`
+	// FileName and ThumbnailFileName are the names of the files in upload storage
+	FileName          string `json:"-"`
+	ThumbnailFileName string `json:"-"`
+	// URL and ThumbnailURL are where the files are served, the URLs written to the target
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size_bytes"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	UploadedBy   *string   `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -13,6 +13,15 @@ const (
 	ActivityLevelExtraActive      ActivityLevel = "extra_active"
 )
 
+// IsValid reports whether the value is a known activity level
+func (v ActivityLevel) IsValid() bool {
+	switch v {
+	case ActivityLevelSedentary, ActivityLevelLightlyActive, ActivityLevelModeratelyActive, ActivityLevelVeryActive, ActivityLevelExtraActive:
+		return true
+	}
+	return false
+}
+
 // UserBiometric represents a user's biometric data
 type UserBiometric struct {
 	ID                   string         `json:"id"`
@@ -10,6 +10,15 @@ const (
 	UserGenderFemale UserGender = "Female"
 )
 
+// IsValid reports whether the value is a known user gender
+func (v UserGender) IsValid() bool {
+	switch v {
+	case UserGenderMale, UserGenderFemale:
+		return true
+	}
+	return false
+}
+
 // User represents a user in the system
 type User struct {
 	ID                string     `json:"id"`
@@ -24,6 +33,10 @@ type User struct {
 	IsActive          bool       `json:"is_active"`
 	IsEmailVerified   bool       `json:"is_email_verified"`
 	LastLoginAt       *time.Time `json:"last_login_at,omitempty"`
-	CreatedAt         time.Time  `json:"created_at"`
-	UpdatedAt         time.Time  `json:"updated_at"`
+	// DeletionScheduledAt is set while the account waits to be erased, the deletion can be cancelled until then
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+	// LockedUntil is set while sign in is locked after too many failed attempts
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 }
@@ -13,6 +13,15 @@ const (
 	UserMealTypeOther     UserMealType = "other"
 )
 
+// IsValid reports whether the value is a known meal type
+func (v UserMealType) IsValid() bool {
+	switch v {
+	case UserMealTypeBreakfast, UserMealTypeLunch, UserMealTypeDinner, UserMealTypeSnack, UserMealTypeOther:
+		return true
+	}
+	return false
+}
+
 // UserMeal represents a user's meal
 type UserMeal struct {
 	ID                    string       `json:"id"`
@@ -12,6 +12,15 @@ const (
 	WorkoutSessionStatusCancelled  WorkoutSessionStatus = "cancelled"
 )
 
+// IsValid reports whether the value is a known workout session status
+func (v WorkoutSessionStatus) IsValid() bool {
+	switch v {
+	case WorkoutSessionStatusScheduled, WorkoutSessionStatusInProgress, WorkoutSessionStatusCompleted, WorkoutSessionStatusCancelled:
+		return true
+	}
+	return false
+}
+
 // UserWorkoutSession represents a user's workout session
 type UserWorkoutSession struct {
 	ID                      string               `json:"id"`
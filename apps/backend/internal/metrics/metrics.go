@@ -0,0 +1,59 @@
+// Package metrics defines the business metrics of the application.
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const namespace = "rebound"
+
+var (
+	// UsersRegistered counts created user accounts
+	UsersRegistered = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "users_registered_total",
+		Help:      "Number of user accounts created.",
+	})
+
+	// Logins counts sign in attempts by result, success, failure or locked
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Number of sign in attempts by result.",
+	}, []string{"result"})
+
+	// WorkoutSessionsCompleted counts workout sessions that reached the completed status
+	WorkoutSessionsCompleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "workout_sessions_completed_total",
+		Help:      "Number of workout sessions completed.",
+	})
+
+	// MealsLogged counts logged meals
+	MealsLogged = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "meals_logged_total",
+		Help:      "Number of meals logged.",
+	})
+
+	// MealFoodItemsLogged counts food items added to logged meals
+	MealFoodItemsLogged = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "meal_food_items_logged_total",
+		Help:      "Number of food items added to meals.",
+	})
+)
+
+// Register registers the business metrics with reg
+func Register(reg prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{
+		UsersRegistered,
+		Logins,
+		WorkoutSessionsCompleted,
+		MealsLogged,
+		MealFoodItemsLogged,
+	} {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
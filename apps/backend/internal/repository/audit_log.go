@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/pkg/postgres"
+)
+
+// AuditLogRepository defines the interface for audit log database operations.
+// Entries are only ever appended, never updated or deleted.
+type AuditLogRepository interface {
+	Create(ctx context.Context, entry *entity.AuditLog) error
+	ListPage(ctx context.Context, spec QuerySpec, page CursorPage) ([]*entity.AuditLog, PageInfo, error)
+}
+
+// AuditLogFields lists the audit log columns that can be filtered through the API
+var AuditLogFields = Fields{
+	"actor_id":    {Column: "actor_user_id", Type: FieldString, Operators: enumOps},
+	"action":      {Column: "action", Type: FieldString, Operators: enumOps},
+	"target_type": {Column: "target_type", Type: FieldString, Operators: enumOps},
+	"target_id":   {Column: "target_id", Type: FieldString, Operators: enumOps},
+	"ip_address":  {Column: "ip_address", Type: FieldString, Operators: enumOps},
+	"created_at":  {Column: "created_at", Type: FieldTime, Operators: rangeOps},
+}
+
+// auditLogKeyset is used for cursor pagination of audit log entries
+var auditLogKeyset = keyset{columns: []string{"created_at", "audit_log_id"}, desc: true}
+
+// auditLogRepository implements AuditLogRepository
+type auditLogRepository struct {
+	db *postgres.Postgres
+}
+
+// NewAuditLogRepository creates a new instance of AuditLogRepository
+func NewAuditLogRepository(db *postgres.Postgres) AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+// Create appends an entry to the audit log
+func (r *auditLogRepository) Create(ctx context.Context, entry *entity.AuditLog) error {
+	var changes []byte
+	if len(entry.Changes) > 0 {
+		var err error
+		if changes, err = json.Marshal(entry.Changes); err != nil {
+			return err
+		}
+	}
+
+	query, args, err := r.db.Builder.Insert("audit_logs").
+		Columns("audit_log_id", "actor_user_id", "action", "target_type", "target_id", "changes", "ip_address", "user_agent", "created_at").
+		Values(entry.ID, entry.ActorID, entry.Action, entry.TargetType, entry.TargetID, changes, nullIfEmpty(entry.IPAddress), nullIfEmpty(entry.UserAgent), entry.CreatedAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+// ListPage returns a cursor paginated list of audit log entries matching the query spec, most recent first
+func (r *auditLogRepository) ListPage(ctx context.Context, spec QuerySpec, page CursorPage) ([]*entity.AuditLog, PageInfo, error) {
+	query := r.db.Builder.Select("audit_log_id", "actor_user_id", "action", "target_type", "target_id", "changes", "COALESCE(ip_address, '')", "COALESCE(user_agent, '')", "created_at").
+		From("audit_logs")
+
+	query, err := AuditLogFields.where(query, spec)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	query, c, err := auditLogKeyset.apply(query, spec, page)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	rows, err := r.db.Pool.Query(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	defer rows.Close()
+
+	var entries []*entity.AuditLog
+	for rows.Next() {
+		var entry entity.AuditLog
+		var changes []byte
+		err := rows.Scan(
+			&entry.ID, &entry.ActorID, &entry.Action, &entry.TargetType, &entry.TargetID, &changes, &entry.IPAddress, &entry.UserAgent, &entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, PageInfo{}, err
+		}
+		if changes != nil {
+			if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+				return nil, PageInfo{}, err
+			}
+		}
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	entries, info := paginate(entries, page, c, func(e *entity.AuditLog) []interface{} {
+		return []interface{}{e.CreatedAt, e.ID}
+	})
+	return entries, info, nil
+}
+
+// nullIfEmpty stores empty strings as NULL
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/pkg/postgres"
+)
+
+// AuthTokenRepository defines the interface for authentication token database operations
+type AuthTokenRepository interface {
+	Create(ctx context.Context, token *entity.AuthToken) error
+	GetByHash(ctx context.Context, tokenType entity.AuthTokenType, hash string) (*entity.AuthToken, error)
+	Revoke(ctx context.Context, id string) (bool, error)
+	RevokeAllByUserID(ctx context.Context, userID string) error
+	ListSessions(ctx context.Context, userID string) ([]*entity.AuthToken, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) (bool, error)
+	RevokeOtherSessions(ctx context.Context, userID, keepSessionID string) (int64, error)
+	RevokeClientTokens(ctx context.Context, userID, clientID string) error
+}
+
+// authTokenColumns are the columns scanned by scanAuthToken, nullable ones are coalesced for tokens issued before sessions
+var authTokenColumns = []string{
+	"token_id", "user_id", "token_type", "token_hash", "expires_at", "issued_at", "is_revoked",
+	"session_id", "COALESCE(device_name, '')", "COALESCE(user_agent, '')", "COALESCE(ip_address, '')", "signed_in_at", "last_used_at",
+	"COALESCE(client_id::text, '')", "COALESCE(scopes, '{}')",
+}
+
+func scanAuthToken(row pgx.Row) (*entity.AuthToken, error) {
+	var (
+		token  entity.AuthToken
+		scopes []string
+	)
+	err := row.Scan(
+		&token.ID, &token.UserID, &token.Type, &token.TokenHash, &token.ExpiresAt, &token.IssuedAt, &token.IsRevoked,
+		&token.SessionID, &token.DeviceName, &token.UserAgent, &token.IPAddress, &token.SignedInAt, &token.LastUsedAt,
+		&token.ClientID, &scopes,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, scope := range scopes {
+		token.Scopes = append(token.Scopes, entity.Scope(scope))
+	}
+	return &token, nil
+}
+
+// authTokenRepository implements AuthTokenRepository
+type authTokenRepository struct {
+	db *postgres.Postgres
+}
+
+// NewAuthTokenRepository creates a new instance of AuthTokenRepository
+func NewAuthTokenRepository(db *postgres.Postgres) AuthTokenRepository {
+	return &authTokenRepository{db: db}
+}
+
+// Create stores a new token
+func (r *authTokenRepository) Create(ctx context.Context, token *entity.AuthToken) error {
+	// Tokens of sessions have neither a client nor scopes
+	var (
+		clientID *string
+		scopes   []string
+	)
+	if token.ClientID != "" {
+		clientID = &token.ClientID
+		scopes = make([]string, len(token.Scopes))
+		for i, scope := range token.Scopes {
+			scopes[i] = string(scope)
+		}
+	}
+
+	query, args, err := r.db.Builder.Insert("auth_tokens").
+		Columns(
+			"token_id", "user_id", "token_type", "token_hash", "expires_at", "issued_at", "is_revoked",
+			"session_id", "device_name", "user_agent", "ip_address", "signed_in_at", "last_used_at",
+			"client_id", "scopes",
+		).
+		Values(
+			token.ID, token.UserID, token.Type, token.TokenHash, token.ExpiresAt, token.IssuedAt, token.IsRevoked,
+			token.SessionID, token.DeviceName, token.UserAgent, token.IPAddress, token.SignedInAt, token.LastUsedAt,
+			clientID, scopes,
+		).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+// GetByHash retrieves a token by its type and hash, revoked and expired tokens included
+func (r *authTokenRepository) GetByHash(ctx context.Context, tokenType entity.AuthTokenType, hash string) (*entity.AuthToken, error) {
+	query, args, err := r.db.Builder.Select(authTokenColumns...).
+		From("auth_tokens").
+		Where(squirrel.Eq{"token_type": tokenType, "token_hash": hash}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := scanAuthToken(r.db.Pool.QueryRow(ctx, query, args...))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// Revoke revokes a token and reports whether it was still valid, so that only one caller can use it up
+func (r *authTokenRepository) Revoke(ctx context.Context, id string) (bool, error) {
+	query, args, err := r.db.Builder.Update("auth_tokens").
+		Set("is_revoked", true).
+		Where(squirrel.Eq{"token_id": id, "is_revoked": false}).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	tag, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// RevokeAllByUserID revokes every token issued to a user
+func (r *authTokenRepository) RevokeAllByUserID(ctx context.Context, userID string) error {
+	query, args, err := r.db.Builder.Update("auth_tokens").
+		Set("is_revoked", true).
+		Where(squirrel.Eq{"user_id": userID, "is_revoked": false}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+// ListSessions retrieves the live refresh token of each of a user's sessions, most recently used first.
+// Tokens of OAuth clients are connected apps rather than sessions and are left out.
+func (r *authTokenRepository) ListSessions(ctx context.Context, userID string) ([]*entity.AuthToken, error) {
+	query, args, err := r.db.Builder.Select(authTokenColumns...).
+		From("auth_tokens").
+		Where(squirrel.Eq{"user_id": userID, "token_type": entity.AuthTokenTypeRefresh, "is_revoked": false, "client_id": nil}).
+		Where(squirrel.Expr("expires_at > CURRENT_TIMESTAMP")).
+		OrderBy("last_used_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*entity.AuthToken
+	for rows.Next() {
+		token, err := scanAuthToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// RevokeSession revokes the tokens of one of a user's sessions and reports whether it was still active
+func (r *authTokenRepository) RevokeSession(ctx context.Context, userID, sessionID string) (bool, error) {
+	query, args, err := r.db.Builder.Update("auth_tokens").
+		Set("is_revoked", true).
+		Where(squirrel.Eq{"user_id": userID, "session_id": sessionID, "is_revoked": false}).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	tag, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// RevokeOtherSessions revokes the tokens of all of a user's sessions but one and returns the number of tokens revoked.
+// An empty keepSessionID revokes them all. Tokens of OAuth clients are kept.
+func (r *authTokenRepository) RevokeOtherSessions(ctx context.Context, userID, keepSessionID string) (int64, error) {
+	update := r.db.Builder.Update("auth_tokens").
+		Set("is_revoked", true).
+		Where(squirrel.Eq{"user_id": userID, "is_revoked": false, "client_id": nil})
+	if keepSessionID != "" {
+		update = update.Where(squirrel.NotEq{"session_id": keepSessionID})
+	}
+	query, args, err := update.ToSql()
+	if err != nil {
+		return 0, err
+	}
+	tag, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// RevokeClientTokens revokes every token a user's consent issued to an OAuth client
+func (r *authTokenRepository) RevokeClientTokens(ctx context.Context, userID, clientID string) error {
+	query, args, err := r.db.Builder.Update("auth_tokens").
+		Set("is_revoked", true).
+		Where(squirrel.Eq{"user_id": userID, "client_id": clientID, "is_revoked": false}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
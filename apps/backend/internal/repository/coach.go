@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/pkg/postgres"
+)
+
+// CoachRepository defines the interface for coach grant database operations
+type CoachRepository interface {
+	Grant(ctx context.Context, userID, coachID string) error
+	Revoke(ctx context.Context, userID, coachID string) (bool, error)
+	IsCoach(ctx context.Context, userID, coachID string) (bool, error)
+	ListCoaches(ctx context.Context, userID string) ([]*entity.CoachGrant, error)
+	ListClients(ctx context.Context, coachID string) ([]*entity.CoachGrant, error)
+}
+
+// coachRepository implements CoachRepository
+type coachRepository struct {
+	db *postgres.Postgres
+}
+
+// NewCoachRepository creates a new instance of CoachRepository
+func NewCoachRepository(db *postgres.Postgres) CoachRepository {
+	return &coachRepository{db: db}
+}
+
+// Grant authorises a coach for a user, granting again is a no-op
+func (r *coachRepository) Grant(ctx context.Context, userID, coachID string) error {
+	query, args, err := r.db.Builder.Insert("coach_grants").
+		Columns("user_id", "coach_user_id").
+		Values(userID, coachID).
+		Suffix("ON CONFLICT (user_id, coach_user_id) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+// Revoke withdraws a coach's authorisation and reports whether it existed
+func (r *coachRepository) Revoke(ctx context.Context, userID, coachID string) (bool, error) {
+	query, args, err := r.db.Builder.Delete("coach_grants").
+		Where(squirrel.Eq{"user_id": userID, "coach_user_id": coachID}).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	tag, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// IsCoach reports whether a user has authorised a coach
+func (r *coachRepository) IsCoach(ctx context.Context, userID, coachID string) (bool, error) {
+	query, args, err := r.db.Builder.Select("1").
+		From("coach_grants").
+		Where(squirrel.Eq{"user_id": userID, "coach_user_id": coachID}).
+		Prefix("SELECT EXISTS (").
+		Suffix(")").
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	var exists bool
+	if err := r.db.Pool.QueryRow(ctx, query, args...).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// ListCoaches retrieves the coaches a user has authorised, most recent first
+func (r *coachRepository) ListCoaches(ctx context.Context, userID string) ([]*entity.CoachGrant, error) {
+	return r.list(ctx, squirrel.Eq{"g.user_id": userID})
+}
+
+// ListClients retrieves the users who have authorised a coach, most recent first
+func (r *coachRepository) ListClients(ctx context.Context, coachID string) ([]*entity.CoachGrant, error) {
+	return r.list(ctx, squirrel.Eq{"g.coach_user_id": coachID})
+}
+
+func (r *coachRepository) list(ctx context.Context, where squirrel.Eq) ([]*entity.CoachGrant, error) {
+	query, args, err := r.db.Builder.Select("g.user_id", "u.username", "g.coach_user_id", "c.username", "g.created_at").
+		From("coach_grants g").
+		Join("users u ON u.user_id = g.user_id").
+		Join("users c ON c.user_id = g.coach_user_id").
+		Where(where).
+		OrderBy("g.created_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.CoachGrant, error) {
+		var grant entity.CoachGrant
+		err := row.Scan(&grant.UserID, &grant.Username, &grant.CoachID, &grant.CoachUsername, &grant.CreatedAt)
+		return &grant, err
+	})
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// CursorPage requests one page of a keyset paginated list.
+// An empty Cursor requests the first page.
+type CursorPage struct {
+	Limit  int
+	Cursor string
+}
+
+// PageInfo holds the opaque cursors of the pages around the returned items
+type PageInfo struct {
+	NextCursor string
+	PrevCursor string
+}
+
+// keyset is the stable ordering a resource is paginated by.
+// The last column must be unique so that every row has a distinct position.
+type keyset struct {
+	columns []string
+	desc    bool
+}
+
+// cursor is the decoded form of an opaque page cursor
+type cursor struct {
+	Keys []interface{} `json:"k"`
+	Prev bool          `json:"p,omitempty"`
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string, size int) (cursor, error) {
+	var c cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidQuery.WithField("cursor", "malformed cursor")
+	}
+	if err := json.Unmarshal(data, &c); err != nil || len(c.Keys) != size {
+		return c, ErrInvalidQuery.WithField("cursor", "malformed cursor")
+	}
+
+	return c, nil
+}
+
+// apply adds the cursor condition, ordering and limit to the query.
+// One extra row is requested so the caller can tell whether another page exists.
+func (k keyset) apply(query squirrel.SelectBuilder, spec QuerySpec, page CursorPage) (squirrel.SelectBuilder, cursor, error) {
+	var c cursor
+	if len(spec.Sort) > 0 {
+		return query, c, ErrInvalidQuery.WithField("sort", "not supported with cursor pagination")
+	}
+	if page.Cursor != "" {
+		var err error
+		c, err = decodeCursor(page.Cursor, len(k.columns))
+		if err != nil {
+			return query, c, err
+		}
+	}
+
+	// Walking backwards flips both the comparison and the ordering
+	desc := k.desc != c.Prev
+
+	if len(c.Keys) > 0 {
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(c.Keys)), ", ")
+		query = query.Where(
+			fmt.Sprintf("(%s) %s (%s)", strings.Join(k.columns, ", "), op, placeholders),
+			c.Keys...,
+		)
+	}
+
+	order := make([]string, 0, len(k.columns))
+	for _, column := range k.columns {
+		if desc {
+			order = append(order, column+" DESC")
+		} else {
+			order = append(order, column+" ASC")
+		}
+	}
+
+	return query.OrderBy(order...).Limit(uint64(page.Limit) + 1), c, nil
+}
+
+// paginate trims the extra row fetched by keyset.apply, restores the natural order
+// and builds the cursors pointing at the neighbouring pages.
+func paginate[T any](items []T, page CursorPage, c cursor, key func(T) []interface{}) ([]T, PageInfo) {
+	var info PageInfo
+
+	hasMore := len(items) > page.Limit
+	if hasMore {
+		items = items[:page.Limit]
+	}
+
+	if c.Prev {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	if len(items) == 0 {
+		return items, info
+	}
+
+	first, last := items[0], items[len(items)-1]
+	if c.Prev {
+		info.NextCursor = encodeCursor(cursor{Keys: key(last)})
+		if hasMore {
+			info.PrevCursor = encodeCursor(cursor{Keys: key(first), Prev: true})
+		}
+	} else {
+		if hasMore {
+			info.NextCursor = encodeCursor(cursor{Keys: key(last)})
+		}
+		if page.Cursor != "" {
+			info.PrevCursor = encodeCursor(cursor{Keys: key(first), Prev: true})
+		}
+	}
+
+	return items, info
+}
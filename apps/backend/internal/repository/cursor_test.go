@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+)
+
+func TestKeyset_Apply(t *testing.T) {
+	k := keyset{columns: []string{"created_at", "id"}, desc: true}
+	query := squirrel.Select("id").From("meals")
+
+	tests := []struct {
+		name     string
+		cursor   string
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:    "first page",
+			wantSQL: "SELECT id FROM meals ORDER BY created_at DESC, id DESC LIMIT 3",
+		},
+		{
+			name:     "next page",
+			cursor:   encodeCursor(cursor{Keys: []interface{}{"2026-10-19", "b"}}),
+			wantSQL:  "SELECT id FROM meals WHERE (created_at, id) < (?, ?) ORDER BY created_at DESC, id DESC LIMIT 3",
+			wantArgs: []interface{}{"2026-10-19", "b"},
+		},
+		{
+			name:     "previous page",
+			cursor:   encodeCursor(cursor{Keys: []interface{}{"2026-10-19", "b"}, Prev: true}),
+			wantSQL:  "SELECT id FROM meals WHERE (created_at, id) > (?, ?) ORDER BY created_at ASC, id ASC LIMIT 3",
+			wantArgs: []interface{}{"2026-10-19", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _, err := k.apply(query, QuerySpec{}, CursorPage{Limit: 2, Cursor: tt.cursor})
+			if err != nil {
+				t.Fatalf("apply() error = %v", err)
+			}
+			sql, args, err := q.ToSql()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("sql = %q, want %q", sql, tt.wantSQL)
+			}
+			if len(args) != 0 || len(tt.wantArgs) != 0 {
+				if !reflect.DeepEqual(args, tt.wantArgs) {
+					t.Errorf("args = %v, want %v", args, tt.wantArgs)
+				}
+			}
+		})
+	}
+}
+
+func TestKeyset_ApplyRejects(t *testing.T) {
+	k := keyset{columns: []string{"created_at", "id"}}
+	query := squirrel.Select("id").From("meals")
+
+	tests := []struct {
+		name string
+		spec QuerySpec
+		page CursorPage
+	}{
+		{name: "sort", spec: QuerySpec{Sort: []Sort{{Field: "name"}}}, page: CursorPage{Limit: 1}},
+		{name: "not base64", page: CursorPage{Limit: 1, Cursor: "%%%"}},
+		{name: "not json", page: CursorPage{Limit: 1, Cursor: "bm90IGpzb24"}},
+		{name: "wrong number of keys", page: CursorPage{Limit: 1, Cursor: encodeCursor(cursor{Keys: []interface{}{"a"}})}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := k.apply(query, tt.spec, tt.page); !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("apply() error = %v, want %v", err, ErrInvalidQuery)
+			}
+		})
+	}
+}
+
+// fetchPage plays the part of the database for a query built by keyset.apply on ids sorted in descending order
+func fetchPage(t *testing.T, ids []float64, page CursorPage) ([]float64, PageInfo) {
+	t.Helper()
+	k := keyset{columns: []string{"id"}, desc: true}
+	_, c, err := k.apply(squirrel.Select("id"), QuerySpec{}, page)
+	if err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+
+	var rows []float64
+	for _, id := range ids {
+		if len(c.Keys) == 0 || (c.Prev && id > c.Keys[0].(float64)) || (!c.Prev && id < c.Keys[0].(float64)) {
+			rows = append(rows, id)
+		}
+	}
+	if c.Prev {
+		sort.Float64s(rows)
+	}
+	if len(rows) > page.Limit+1 {
+		rows = rows[:page.Limit+1]
+	}
+
+	return paginate(rows, page, c, func(id float64) []interface{} { return []interface{}{id} })
+}
+
+func TestPaginate(t *testing.T) {
+	ids := []float64{7, 6, 5, 4, 3, 2, 1}
+
+	// Walk forwards to the end, then back to the start
+	var pages [][]float64
+	page := CursorPage{Limit: 3}
+	for {
+		items, info := fetchPage(t, ids, page)
+		pages = append(pages, items)
+		if (page.Cursor == "") != (info.PrevCursor == "") {
+			t.Errorf("page %d: PrevCursor = %q with cursor %q", len(pages), info.PrevCursor, page.Cursor)
+		}
+		if info.NextCursor == "" {
+			break
+		}
+		page.Cursor = info.NextCursor
+		if len(pages) > len(ids) {
+			t.Fatal("pagination does not end")
+		}
+	}
+	if want := [][]float64{{7, 6, 5}, {4, 3, 2}, {1}}; !reflect.DeepEqual(pages, want) {
+		t.Fatalf("forward pages = %v, want %v", pages, want)
+	}
+
+	_, last := fetchPage(t, ids, page)
+	items, info := fetchPage(t, ids, CursorPage{Limit: 3, Cursor: last.PrevCursor})
+	if want := []float64{4, 3, 2}; !reflect.DeepEqual(items, want) {
+		t.Errorf("previous page = %v, want %v", items, want)
+	}
+	if info.NextCursor == "" || info.PrevCursor == "" {
+		t.Errorf("middle page cursors = %+v, want both", info)
+	}
+
+	items, info = fetchPage(t, ids, CursorPage{Limit: 3, Cursor: info.PrevCursor})
+	if want := []float64{7, 6, 5}; !reflect.DeepEqual(items, want) {
+		t.Errorf("first page walking back = %v, want %v", items, want)
+	}
+	if info.PrevCursor != "" {
+		t.Errorf("first page walking back PrevCursor = %q, want none", info.PrevCursor)
+	}
+}
+
+func TestPaginate_Empty(t *testing.T) {
+	items, info := fetchPage(t, nil, CursorPage{Limit: 3})
+	if len(items) != 0 || info != (PageInfo{}) {
+		t.Errorf("paginate() = %v, %+v, want nothing", items, info)
+	}
+}
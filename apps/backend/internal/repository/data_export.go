@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/pkg/postgres"
+)
+
+// DataExportRepository defines the interface for data export jobs and the data they gather
+type DataExportRepository interface {
+	Create(ctx context.Context, export *entity.DataExport) error
+	GetByID(ctx context.Context, id string) (*entity.DataExport, error)
+	GetUnfinishedByUserID(ctx context.Context, userID string) (*entity.DataExport, error)
+	ClaimNext(ctx context.Context, staleBefore time.Time) (*entity.DataExport, error)
+	Update(ctx context.Context, export *entity.DataExport) error
+	ListExpired(ctx context.Context, now time.Time) ([]*entity.DataExport, error)
+	UserData(ctx context.Context, userID string) ([]*entity.DataExportSection, error)
+}
+
+// dataExportSection selects the rows of one section of a user's data export
+type dataExportSection struct {
+	name  string
+	query squirrel.SelectBuilder
+}
+
+// dataExportSections lists everything tied to a user, soft deleted records included
+func dataExportSections(b squirrel.StatementBuilderType, userID string) []dataExportSection {
+	sessions := "session_id IN (SELECT session_id FROM user_workout_sessions WHERE user_id = ?)"
+	meals := "meal_id IN (SELECT meal_id FROM user_meals WHERE user_id = ?)"
+	plans := "plan_id IN (SELECT plan_id FROM workout_plans WHERE user_id = ?)"
+
+	return []dataExportSection{
+		{
+			name: "profile",
+			// Everything but the password hash
+			query: b.Select("user_id", "username", "email", "first_name", "last_name", "date_of_birth", "gender", "profile_picture_url", "is_active", "is_email_verified", "last_login_at", "deletion_scheduled_at", "created_at", "updated_at").
+				From("users").Where(squirrel.Eq{"user_id": userID}),
+		},
+		{name: "biometrics", query: b.Select("*").From("user_biometrics").Where(squirrel.Eq{"user_id": userID}).OrderBy("log_date")},
+		{name: "nutrition_goals", query: b.Select("*").From("user_nutrition_goals").Where(squirrel.Eq{"user_id": userID}).OrderBy("created_at")},
+		{name: "meals", query: b.Select("*").From("user_meals").Where(squirrel.Eq{"user_id": userID}).OrderBy("meal_date", "created_at")},
+		{name: "meal_food_items", query: b.Select("*").From("meal_food_items").Where(meals, userID).OrderBy("logged_at")},
+		{name: "workout_sessions", query: b.Select("*").From("user_workout_sessions").Where(squirrel.Eq{"user_id": userID}).OrderBy("created_at")},
+		{name: "workout_session_logs", query: b.Select("*").From("user_workout_session_logs").Where(sessions, userID).OrderBy("logged_at")},
+		{name: "exercises", query: b.Select("*").From("exercises").Where(squirrel.Eq{"created_by_user_id": userID}).OrderBy("created_at")},
+		{name: "food_items", query: b.Select("*").From("food_items").Where(squirrel.Eq{"created_by_user_id": userID}).OrderBy("created_at")},
+		{name: "workout_plans", query: b.Select("*").From("workout_plans").Where(squirrel.Eq{"user_id": userID}).OrderBy("created_at")},
+		{name: "workout_plan_exercises", query: b.Select("*").From("workout_plan_exercises").Where(plans, userID).OrderBy("plan_id", "exercise_order")},
+	}
+}
+
+// dataExportRepository implements DataExportRepository
+type dataExportRepository struct {
+	db *postgres.Postgres
+}
+
+// NewDataExportRepository creates a new instance of DataExportRepository
+func NewDataExportRepository(db *postgres.Postgres) DataExportRepository {
+	return &dataExportRepository{db: db}
+}
+
+const dataExportColumns = "export_id, user_id, status, COALESCE(file_name, ''), COALESCE(error, ''), created_at, started_at, completed_at, expires_at"
+
+func scanDataExport(row pgx.Row) (*entity.DataExport, error) {
+	var export entity.DataExport
+	err := row.Scan(
+		&export.ID, &export.UserID, &export.Status, &export.FileName, &export.Error, &export.CreatedAt, &export.StartedAt, &export.CompletedAt, &export.ExpiresAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// Create creates a new data export job
+func (r *dataExportRepository) Create(ctx context.Context, export *entity.DataExport) error {
+	query, args, err := r.db.Builder.Insert("data_exports").
+		Columns("export_id", "user_id", "status", "created_at").
+		Values(export.ID, export.UserID, export.Status, export.CreatedAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return translateWriteError(err)
+}
+
+// GetByID retrieves a data export job by its ID
+func (r *dataExportRepository) GetByID(ctx context.Context, id string) (*entity.DataExport, error) {
+	query, args, err := r.db.Builder.Select(dataExportColumns).
+		From("data_exports").
+		Where(squirrel.Eq{"export_id": id}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return scanDataExport(r.db.Pool.QueryRow(ctx, query, args...))
+}
+
+// GetUnfinishedByUserID retrieves the pending or running data export job of a user
+func (r *dataExportRepository) GetUnfinishedByUserID(ctx context.Context, userID string) (*entity.DataExport, error) {
+	query, args, err := r.db.Builder.Select(dataExportColumns).
+		From("data_exports").
+		Where(squirrel.Eq{"user_id": userID, "status": []entity.DataExportStatus{entity.DataExportPending, entity.DataExportRunning}}).
+		OrderBy("created_at DESC").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return scanDataExport(r.db.Pool.QueryRow(ctx, query, args...))
+}
+
+// ClaimNext marks the oldest pending job as running and returns it, nil if there is none.
+// Jobs that have been running since before staleBefore are claimed again, their worker is assumed dead.
+func (r *dataExportRepository) ClaimNext(ctx context.Context, staleBefore time.Time) (*entity.DataExport, error) {
+	query, args, err := r.db.Builder.Update("data_exports").
+		Set("status", entity.DataExportRunning).
+		Set("started_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(`export_id = (
+			SELECT export_id FROM data_exports
+			WHERE status = ? OR (status = ? AND started_at < ?)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED)`, entity.DataExportPending, entity.DataExportRunning, staleBefore).
+		Suffix("RETURNING " + dataExportColumns).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return scanDataExport(r.db.Pool.QueryRow(ctx, query, args...))
+}
+
+// Update stores the status and outcome of a data export job
+func (r *dataExportRepository) Update(ctx context.Context, export *entity.DataExport) error {
+	query, args, err := r.db.Builder.Update("data_exports").
+		Set("status", export.Status).
+		Set("file_name", nullIfEmpty(export.FileName)).
+		Set("error", nullIfEmpty(export.Error)).
+		Set("completed_at", export.CompletedAt).
+		Set("expires_at", export.ExpiresAt).
+		Where(squirrel.Eq{"export_id": export.ID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+// ListExpired returns the completed jobs whose archive expired before now
+func (r *dataExportRepository) ListExpired(ctx context.Context, now time.Time) ([]*entity.DataExport, error) {
+	query, args, err := r.db.Builder.Select(dataExportColumns).
+		From("data_exports").
+		Where(squirrel.Eq{"status": entity.DataExportCompleted}).
+		Where(squirrel.LtOrEq{"expires_at": now}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exports []*entity.DataExport
+	for rows.Next() {
+		export, err := scanDataExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, export)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+// UserData gathers everything tied to a user, section by section
+func (r *dataExportRepository) UserData(ctx context.Context, userID string) ([]*entity.DataExportSection, error) {
+	// All sections are read from the same snapshot so that they are consistent with each other
+	tx, err := r.db.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // read only transaction
+
+	var sections []*entity.DataExportSection
+	for _, s := range dataExportSections(r.db.Builder, userID) {
+		query, args, err := s.query.ToSql()
+		if err != nil {
+			return nil, err
+		}
+		section := &entity.DataExportSection{Name: s.name}
+
+		// Typed records as a JSON array
+		err = tx.QueryRow(ctx, fmt.Sprintf("SELECT COALESCE(json_agg(t), '[]'::json) FROM (%s) t", query), args...).Scan(&section.JSON)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.name, err)
+		}
+
+		// The same records in their text representation, which the simple protocol returns for every type
+		rows, err := tx.Query(ctx, query, append([]interface{}{pgx.QueryExecModeSimpleProtocol}, args...)...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.name, err)
+		}
+		for _, fd := range rows.FieldDescriptions() {
+			section.Columns = append(section.Columns, fd.Name)
+		}
+		for rows.Next() {
+			raw := rows.RawValues()
+			row := make([]string, len(raw))
+			for i, value := range raw {
+				row[i] = string(value)
+			}
+			section.Rows = append(section.Rows, row)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("%s: %w", s.name, err)
+		}
+
+		sections = append(sections, section)
+	}
+
+	return sections, nil
+}
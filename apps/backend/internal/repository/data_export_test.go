@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+)
+
+func TestDataExportSections(t *testing.T) {
+	// Every dataset tied to a user, by section and the table it is read from
+	want := map[string]string{
+		"profile":                "users",
+		"biometrics":             "user_biometrics",
+		"nutrition_goals":        "user_nutrition_goals",
+		"meals":                  "user_meals",
+		"meal_food_items":        "meal_food_items",
+		"workout_sessions":       "user_workout_sessions",
+		"workout_session_logs":   "user_workout_session_logs",
+		"exercises":              "exercises",
+		"food_items":             "food_items",
+		"workout_plans":          "workout_plans",
+		"workout_plan_exercises": "workout_plan_exercises",
+	}
+
+	sections := dataExportSections(squirrel.StatementBuilder, "alice")
+	seen := map[string]bool{}
+	for _, s := range sections {
+		table, ok := want[s.name]
+		if !ok {
+			t.Errorf("unexpected section %s", s.name)
+			continue
+		}
+		if seen[s.name] {
+			t.Errorf("section %s is exported twice", s.name)
+		}
+		seen[s.name] = true
+
+		sql, args, err := s.query.ToSql()
+		if err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		if !strings.Contains(sql, " FROM "+table+" ") {
+			t.Errorf("%s reads %s, want %s", s.name, sql, table)
+		}
+		if len(args) != 1 || args[0] != "alice" {
+			t.Errorf("%s args = %v, want only the user", s.name, args)
+		}
+	}
+	for name := range want {
+		if !seen[name] {
+			t.Errorf("section %s is missing", name)
+		}
+	}
+}
+
+func TestDataExportSections_ProfileLeavesOutPassword(t *testing.T) {
+	for _, s := range dataExportSections(squirrel.StatementBuilder, "alice") {
+		if s.name != "profile" {
+			continue
+		}
+		sql, _, err := s.query.ToSql()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(sql, "password") || strings.Contains(sql, "*") {
+			t.Errorf("profile query %s may export the password hash", sql)
+		}
+		return
+	}
+	t.Fatal("no profile section")
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+)
+
+const (
+	// pgForeignKeyViolation is the SQLSTATE Postgres reports when a write refers to a missing row
+	pgForeignKeyViolation = "23503"
+	// pgUniqueViolation is the SQLSTATE Postgres reports when a write duplicates a unique value
+	pgUniqueViolation = "23505"
+)
+
+// ErrVersionConflict is returned by versioned updates when the row was modified after it was read.
+// Versioned entities use UpdatedAt as their version.
+var ErrVersionConflict = entity.NewPreconditionFailedError("version_conflict", "the resource has been modified since it was read")
+
+// ErrReferenceNotFound is returned by writes that refer to a record that does not exist
+var ErrReferenceNotFound = entity.NewValidationError("reference_not_found", "a referenced record does not exist")
+
+// ErrAlreadyExists is returned by writes that duplicate a value that must be unique
+var ErrAlreadyExists = entity.NewConflictError("already_exists", "a record with the same unique value already exists")
+
+// translateWriteError maps constraint violations reported by Postgres to domain errors
+func translateWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgForeignKeyViolation:
+			return ErrReferenceNotFound
+		case pgUniqueViolation:
+			return ErrAlreadyExists
+		}
+	}
+	return err
+}
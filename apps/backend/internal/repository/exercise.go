@@ -16,12 +16,24 @@ type ExerciseRepository interface {
 	GetByID(ctx context.Context, id string) (*entity.Exercise, error)
 	Update(ctx context.Context, exercise *entity.Exercise) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]*entity.Exercise, error)
-	Count(ctx context.Context, filters map[string]interface{}) (int64, error)
+	List(ctx context.Context, spec QuerySpec, page, pageSize int) ([]*entity.Exercise, error)
+	Count(ctx context.Context, spec QuerySpec) (int64, error)
 	Search(ctx context.Context, query string, limit, offset int) ([]*entity.Exercise, error)
 	GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*entity.Exercise, error)
 }
 
+// ExerciseFields lists the exercise columns that can be filtered and sorted through the API
+var ExerciseFields = Fields{
+	"name":                 {Column: "exercise_name", Type: FieldString, Operators: textOps, Sortable: true},
+	"muscle_group_primary": {Column: "muscle_group_primary", Type: FieldString, Operators: textOps, Sortable: true},
+	"equipment_required":   {Column: "equipment_required", Type: FieldString, Operators: textOps},
+	"difficulty_level":     {Column: "difficulty_level", Type: FieldString, Operators: enumOps, Sortable: true},
+	"type":                 {Column: "exercise_type", Type: FieldString, Operators: enumOps, Sortable: true},
+	"created_by_user_id":   {Column: "created_by_user_id", Type: FieldString, Operators: enumOps},
+	"is_public":            {Column: "is_public", Type: FieldBool, Operators: boolOps},
+	"created_at":           {Column: "created_at", Type: FieldTime, Operators: rangeOps, Sortable: true},
+}
+
 // exerciseRepository implements ExerciseRepository
 type exerciseRepository struct {
 	db *postgres.Postgres
@@ -107,14 +119,19 @@ func (r *exerciseRepository) Delete(ctx context.Context, id string) error {
 	return err
 }
 
-// List returns a paginated list of exercises matching the filters
-func (r *exerciseRepository) List(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]*entity.Exercise, error) {
+// List returns a paginated list of exercises matching the query spec
+func (r *exerciseRepository) List(ctx context.Context, spec QuerySpec, page, pageSize int) ([]*entity.Exercise, error) {
 	query := r.db.Builder.Select("exercise_id", "exercise_name", "description", "muscle_group_primary", "muscle_groups_secondary", "equipment_required", "difficulty_level", "video_url", "image_url_thumbnail", "image_url_main", "exercise_type", "created_by_user_id", "is_public", "created_at", "updated_at").
 		From("exercises")
 
-	// Apply filters
-	for key, value := range filters {
-		query = query.Where(squirrel.Eq{key: value})
+	// Apply filters and sort order
+	query, err := ExerciseFields.where(query, spec)
+	if err != nil {
+		return nil, err
+	}
+	query, err = ExerciseFields.orderBy(query, spec, "exercise_name ASC")
+	if err != nil {
+		return nil, err
 	}
 
 	// Apply pagination
@@ -147,14 +164,15 @@ func (r *exerciseRepository) List(ctx context.Context, filters map[string]interf
 	return exercises, nil
 }
 
-// Count returns the total number of exercises matching the filters
-func (r *exerciseRepository) Count(ctx context.Context, filters map[string]interface{}) (int64, error) {
+// Count returns the total number of exercises matching the query spec
+func (r *exerciseRepository) Count(ctx context.Context, spec QuerySpec) (int64, error) {
 	query := r.db.Builder.Select("COUNT(*)").
 		From("exercises")
 
 	// Apply filters
-	for key, value := range filters {
-		query = query.Where(squirrel.Eq{key: value})
+	query, err := ExerciseFields.where(query, spec)
+	if err != nil {
+		return 0, err
 	}
 
 	sqlQuery, args, err := query.ToSql()
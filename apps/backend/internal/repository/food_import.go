@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/pkg/postgres"
+)
+
+// FoodImportRepository defines the interface for bulk imports of food items
+type FoodImportRepository interface {
+	// Upsert writes a batch of records in one transaction. A record updates the food item with its source ID or,
+	// failing that, with its barcode; other records are inserted. Food items created by users are left alone, and
+	// so are the items of another database that have the same barcode. Records of a batch must not share a source
+	// ID or a barcode.
+	Upsert(ctx context.Context, records []*entity.FoodImportRecord) (entity.FoodImportResult, error)
+}
+
+// foodImportRepository implements FoodImportRepository
+type foodImportRepository struct {
+	db *postgres.Postgres
+}
+
+// NewFoodImportRepository creates a new instance of FoodImportRepository
+func NewFoodImportRepository(db *postgres.Postgres) FoodImportRepository {
+	return &foodImportRepository{db: db}
+}
+
+// foodImportColumns are the columns records are copied into, before they are matched with food items
+var foodImportColumns = []string{
+	"row_number", "id", "source_id", "name", "brand_name", "barcode_upc", "serving_size_default_qty", "serving_size_default_unit",
+	"calories_per_default_serving", "protein_grams_per_default_serving", "fat_grams_per_default_serving", "carbs_grams_per_default_serving",
+	"fiber_grams_per_default_serving", "sugar_grams_per_default_serving", "saturated_fat_grams_per_default_serving", "trans_fat_grams_per_default_serving",
+	"cholesterol_mg_per_default_serving", "sodium_mg_per_default_serving", "potassium_mg_per_default_serving", "vitamin_a_mcg_per_default_serving",
+	"vitamin_c_mg_per_default_serving", "calcium_mg_per_default_serving", "iron_mg_per_default_serving", "source", "is_verified",
+}
+
+var foodImportUnitColumns = []string{"row_number", "unit_name", "abbreviation", "grams_equivalent", "ml_equivalent"}
+
+// foodImportStaging creates the tables records are copied into. They are dropped with the transaction.
+var foodImportStaging = []string{
+	`CREATE TEMP TABLE food_import (
+		row_number INTEGER PRIMARY KEY,
+		id TEXT NOT NULL,
+		existing BOOLEAN NOT NULL DEFAULT FALSE,
+		skip BOOLEAN NOT NULL DEFAULT FALSE,
+		source_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		brand_name TEXT,
+		barcode_upc TEXT,
+		serving_size_default_qty NUMERIC NOT NULL,
+		serving_size_default_unit TEXT NOT NULL,
+		calories_per_default_serving NUMERIC NOT NULL,
+		protein_grams_per_default_serving NUMERIC NOT NULL,
+		fat_grams_per_default_serving NUMERIC NOT NULL,
+		carbs_grams_per_default_serving NUMERIC NOT NULL,
+		fiber_grams_per_default_serving NUMERIC,
+		sugar_grams_per_default_serving NUMERIC,
+		saturated_fat_grams_per_default_serving NUMERIC,
+		trans_fat_grams_per_default_serving NUMERIC,
+		cholesterol_mg_per_default_serving NUMERIC,
+		sodium_mg_per_default_serving NUMERIC,
+		potassium_mg_per_default_serving NUMERIC,
+		vitamin_a_mcg_per_default_serving NUMERIC,
+		vitamin_c_mg_per_default_serving NUMERIC,
+		calcium_mg_per_default_serving NUMERIC,
+		iron_mg_per_default_serving NUMERIC,
+		source TEXT NOT NULL,
+		is_verified BOOLEAN NOT NULL
+	) ON COMMIT DROP`,
+	`CREATE TEMP TABLE food_import_units (
+		row_number INTEGER NOT NULL,
+		unit_name TEXT NOT NULL,
+		abbreviation TEXT NOT NULL,
+		grams_equivalent NUMERIC,
+		ml_equivalent NUMERIC
+	) ON COMMIT DROP`,
+}
+
+// foodImportMatch finds the food items records update: by source ID, then by barcode. A barcode match is skipped when
+// the item was created by a user or imported from another database; items of the same database take the new source
+// ID, as when a food is listed again under a new ID.
+var foodImportMatch = []string{
+	`UPDATE food_import s SET id = f.id::text, existing = TRUE, skip = f.source IS NOT NULL AND f.source = $1
+	FROM food_items f WHERE f.source_id = s.source_id`,
+	`UPDATE food_import s SET id = f.id::text, existing = TRUE,
+		skip = (f.source IS NOT NULL AND f.source = $1) OR (f.source_id IS NOT NULL AND split_part(f.source_id, ':', 1) <> split_part(s.source_id, ':', 1))
+	FROM food_items f WHERE NOT s.existing AND f.barcode_upc = s.barcode_upc`,
+}
+
+// Upsert writes a batch of records in one transaction
+func (r *foodImportRepository) Upsert(ctx context.Context, records []*entity.FoodImportRecord) (entity.FoodImportResult, error) {
+	result := entity.FoodImportResult{Read: int64(len(records))}
+	if len(records) == 0 {
+		return result, nil
+	}
+
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op once committed
+
+	for _, statement := range foodImportStaging {
+		if _, err := tx.Exec(ctx, statement); err != nil {
+			return result, err
+		}
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"food_import"}, foodImportColumns, pgx.CopyFromSlice(len(records), func(i int) ([]any, error) {
+		item := records[i].Item
+		return []any{i, item.ID, records[i].SourceID, item.Name, item.BrandName, item.BarcodeUPC, item.ServingSizeDefaultQty, item.ServingSizeDefaultUnit,
+			item.CaloriesPerDefaultServing, item.ProteinGramsPerDefaultServing, item.FatGramsPerDefaultServing, item.CarbsGramsPerDefaultServing,
+			item.FiberGramsPerDefaultServing, item.SugarGramsPerDefaultServing, item.SaturatedFatGramsPerDefaultServing, item.TransFatGramsPerDefaultServing,
+			item.CholesterolMgPerDefaultServing, item.SodiumMgPerDefaultServing, item.PotassiumMgPerDefaultServing, item.VitaminAMcgPerDefaultServing,
+			item.VitaminCMgPerDefaultServing, item.CalciumMgPerDefaultServing, item.IronMgPerDefaultServing, string(item.Source), item.IsVerified}, nil
+	}))
+	if err != nil {
+		return result, err
+	}
+	var units [][]any
+	for i, record := range records {
+		for _, unit := range record.ServingUnits {
+			units = append(units, []any{i, unit.UnitName, unit.Abbreviation, unit.GramsEquivalent, unit.MlEquivalent})
+		}
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"food_import_units"}, foodImportUnitColumns, pgx.CopyFromRows(units)); err != nil {
+		return result, err
+	}
+	// Temporary tables have no statistics until analysed, which would leave the planner guessing their size
+	if _, err := tx.Exec(ctx, `ANALYZE food_import`); err != nil {
+		return result, err
+	}
+
+	for _, statement := range foodImportMatch {
+		if _, err := tx.Exec(ctx, statement, string(entity.FoodItemSourceUserCreated)); err != nil {
+			return result, err
+		}
+	}
+
+	tag, err := tx.Exec(ctx, `UPDATE food_items f SET
+		name = s.name, brand_name = s.brand_name, serving_size_default_qty = s.serving_size_default_qty,
+		serving_size_default_unit = s.serving_size_default_unit, calories_per_default_serving = s.calories_per_default_serving,
+		protein_grams_per_default_serving = s.protein_grams_per_default_serving, fat_grams_per_default_serving = s.fat_grams_per_default_serving,
+		carbs_grams_per_default_serving = s.carbs_grams_per_default_serving, fiber_grams_per_default_serving = s.fiber_grams_per_default_serving,
+		sugar_grams_per_default_serving = s.sugar_grams_per_default_serving, saturated_fat_grams_per_default_serving = s.saturated_fat_grams_per_default_serving,
+		trans_fat_grams_per_default_serving = s.trans_fat_grams_per_default_serving, cholesterol_mg_per_default_serving = s.cholesterol_mg_per_default_serving,
+		sodium_mg_per_default_serving = s.sodium_mg_per_default_serving, potassium_mg_per_default_serving = s.potassium_mg_per_default_serving,
+		vitamin_a_mcg_per_default_serving = s.vitamin_a_mcg_per_default_serving, vitamin_c_mg_per_default_serving = s.vitamin_c_mg_per_default_serving,
+		calcium_mg_per_default_serving = s.calcium_mg_per_default_serving, iron_mg_per_default_serving = s.iron_mg_per_default_serving,
+		source = s.source, is_verified = s.is_verified, source_id = s.source_id, updated_at = NOW()
+	FROM food_import s WHERE f.id = s.id::uuid AND s.existing AND NOT s.skip`)
+	if err != nil {
+		return result, err
+	}
+	result.Updated = tag.RowsAffected()
+
+	tag, err = tx.Exec(ctx, `INSERT INTO food_items (id, name, brand_name, barcode_upc, serving_size_default_qty, serving_size_default_unit,
+		calories_per_default_serving, protein_grams_per_default_serving, fat_grams_per_default_serving, carbs_grams_per_default_serving,
+		fiber_grams_per_default_serving, sugar_grams_per_default_serving, saturated_fat_grams_per_default_serving, trans_fat_grams_per_default_serving,
+		cholesterol_mg_per_default_serving, sodium_mg_per_default_serving, potassium_mg_per_default_serving, vitamin_a_mcg_per_default_serving,
+		vitamin_c_mg_per_default_serving, calcium_mg_per_default_serving, iron_mg_per_default_serving, source, is_verified, source_id, created_at, updated_at)
+	SELECT s.id::uuid, s.name, s.brand_name, s.barcode_upc, s.serving_size_default_qty, s.serving_size_default_unit,
+		s.calories_per_default_serving, s.protein_grams_per_default_serving, s.fat_grams_per_default_serving, s.carbs_grams_per_default_serving,
+		s.fiber_grams_per_default_serving, s.sugar_grams_per_default_serving, s.saturated_fat_grams_per_default_serving, s.trans_fat_grams_per_default_serving,
+		s.cholesterol_mg_per_default_serving, s.sodium_mg_per_default_serving, s.potassium_mg_per_default_serving, s.vitamin_a_mcg_per_default_serving,
+		s.vitamin_c_mg_per_default_serving, s.calcium_mg_per_default_serving, s.iron_mg_per_default_serving, s.source, s.is_verified, s.source_id, NOW(), NOW()
+	FROM food_import s WHERE NOT s.existing`)
+	if err != nil {
+		return result, translateWriteError(err)
+	}
+	result.Inserted = tag.RowsAffected()
+	result.Skipped = result.Read - result.Inserted - result.Updated
+
+	// The serving units of a food item are replaced by those of the record
+	_, err = tx.Exec(ctx, `DELETE FROM serving_units u USING food_import s WHERE u.food_item_id = s.id::uuid AND s.existing AND NOT s.skip`)
+	if err != nil {
+		return result, err
+	}
+	_, err = tx.Exec(ctx, `INSERT INTO serving_units (food_item_id, unit_name, abbreviation, grams_equivalent, ml_equivalent)
+	SELECT s.id::uuid, u.unit_name, u.abbreviation, u.grams_equivalent, u.ml_equivalent
+	FROM food_import_units u JOIN food_import s ON s.row_number = u.row_number WHERE NOT s.skip`)
+	if err != nil {
+		return result, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return result, err
+	}
+	return result, nil
+}
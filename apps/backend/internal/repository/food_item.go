@@ -20,11 +20,31 @@ type FoodItemRepository interface {
 	Update(ctx context.Context, foodItem *entity.FoodItem) error
 	Delete(ctx context.Context, id string) error
 	GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*entity.FoodItem, error)
-	Count(ctx context.Context, filters map[string]interface{}) (int64, error)
+	Count(ctx context.Context, spec QuerySpec) (int64, error)
 	CountBySearch(ctx context.Context, query string) (int64, error)
-	List(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]*entity.FoodItem, error)
+	List(ctx context.Context, spec QuerySpec, page, pageSize int) ([]*entity.FoodItem, error)
+	ListPage(ctx context.Context, spec QuerySpec, page CursorPage) ([]*entity.FoodItem, PageInfo, error)
 }
 
+// FoodItemFields lists the food item columns that can be filtered and sorted through the API
+var FoodItemFields = Fields{
+	"name":                              {Column: "name", Type: FieldString, Operators: textOps, Sortable: true},
+	"brand_name":                        {Column: "brand_name", Type: FieldString, Operators: textOps, Sortable: true},
+	"barcode_upc":                       {Column: "barcode_upc", Type: FieldString, Operators: enumOps},
+	"calories_per_default_serving":      {Column: "calories_per_default_serving", Type: FieldNumber, Operators: rangeOps, Sortable: true},
+	"protein_grams_per_default_serving": {Column: "protein_grams_per_default_serving", Type: FieldNumber, Operators: rangeOps, Sortable: true},
+	"fat_grams_per_default_serving":     {Column: "fat_grams_per_default_serving", Type: FieldNumber, Operators: rangeOps, Sortable: true},
+	"carbs_grams_per_default_serving":   {Column: "carbs_grams_per_default_serving", Type: FieldNumber, Operators: rangeOps, Sortable: true},
+	"source":                            {Column: "source", Type: FieldString, Operators: enumOps},
+	"is_verified":                       {Column: "is_verified", Type: FieldBool, Operators: boolOps},
+	"created_by_user_id":                {Column: "created_by_user_id", Type: FieldString, Operators: enumOps},
+	"created_at":                        {Column: "created_at", Type: FieldTime, Operators: rangeOps, Sortable: true},
+	"updated_at":                        {Column: "updated_at", Type: FieldTime, Operators: rangeOps, Sortable: true},
+}
+
+// foodItemKeyset is used for cursor pagination of food items
+var foodItemKeyset = keyset{columns: []string{"name", "id"}}
+
 // foodItemRepository implements FoodItemRepository
 type foodItemRepository struct {
 	db *postgres.Postgres
@@ -46,7 +66,7 @@ func (r *foodItemRepository) Create(ctx context.Context, foodItem *entity.FoodIt
 	}
 	_, err = r.db.Pool.Exec(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, translateWriteError(err)
 	}
 	return foodItem, nil
 }
@@ -55,6 +75,7 @@ func (r *foodItemRepository) Create(ctx context.Context, foodItem *entity.FoodIt
 func (r *foodItemRepository) GetByID(ctx context.Context, id string) (*entity.FoodItem, error) {
 	query, args, err := r.db.Builder.Select("id", "name", "brand_name", "barcode_upc", "serving_size_default_qty", "serving_size_default_unit", "calories_per_default_serving", "protein_grams_per_default_serving", "fat_grams_per_default_serving", "carbs_grams_per_default_serving", "fiber_grams_per_default_serving", "sugar_grams_per_default_serving", "saturated_fat_grams_per_default_serving", "trans_fat_grams_per_default_serving", "cholesterol_mg_per_default_serving", "sodium_mg_per_default_serving", "potassium_mg_per_default_serving", "vitamin_a_mcg_per_default_serving", "vitamin_c_mg_per_default_serving", "calcium_mg_per_default_serving", "iron_mg_per_default_serving", "source", "is_verified", "created_by_user_id", "created_at", "updated_at").
 		From("food_items").
+		Where(squirrel.Eq{"deleted_at": nil}).
 		Where(squirrel.Eq{"id": id}).
 		ToSql()
 	if err != nil {
@@ -77,6 +98,7 @@ func (r *foodItemRepository) GetByID(ctx context.Context, id string) (*entity.Fo
 func (r *foodItemRepository) GetByBarcode(ctx context.Context, barcode string) (*entity.FoodItem, error) {
 	query, args, err := r.db.Builder.Select("id", "name", "brand_name", "barcode_upc", "serving_size_default_qty", "serving_size_default_unit", "calories_per_default_serving", "protein_grams_per_default_serving", "fat_grams_per_default_serving", "carbs_grams_per_default_serving", "fiber_grams_per_default_serving", "sugar_grams_per_default_serving", "saturated_fat_grams_per_default_serving", "trans_fat_grams_per_default_serving", "cholesterol_mg_per_default_serving", "sodium_mg_per_default_serving", "potassium_mg_per_default_serving", "vitamin_a_mcg_per_default_serving", "vitamin_c_mg_per_default_serving", "calcium_mg_per_default_serving", "iron_mg_per_default_serving", "source", "is_verified", "created_by_user_id", "created_at", "updated_at").
 		From("food_items").
+		Where(squirrel.Eq{"deleted_at": nil}).
 		Where(squirrel.Eq{"barcode_upc": barcode}).
 		ToSql()
 	if err != nil {
@@ -99,6 +121,7 @@ func (r *foodItemRepository) GetByBarcode(ctx context.Context, barcode string) (
 func (r *foodItemRepository) Search(ctx context.Context, query string, limit, offset int) ([]*entity.FoodItem, error) {
 	sqlQuery, args, err := r.db.Builder.Select("id", "name", "brand_name", "barcode_upc", "serving_size_default_qty", "serving_size_default_unit", "calories_per_default_serving", "protein_grams_per_default_serving", "fat_grams_per_default_serving", "carbs_grams_per_default_serving", "fiber_grams_per_default_serving", "sugar_grams_per_default_serving", "saturated_fat_grams_per_default_serving", "trans_fat_grams_per_default_serving", "cholesterol_mg_per_default_serving", "sodium_mg_per_default_serving", "potassium_mg_per_default_serving", "vitamin_a_mcg_per_default_serving", "vitamin_c_mg_per_default_serving", "calcium_mg_per_default_serving", "iron_mg_per_default_serving", "source", "is_verified", "created_by_user_id", "created_at", "updated_at").
 		From("food_items").
+		Where(squirrel.Eq{"deleted_at": nil}).
 		Where(squirrel.Or{
 			squirrel.Like{"name": "%" + query + "%"},
 			squirrel.Like{"brand_name": "%" + query + "%"},
@@ -128,7 +151,8 @@ func (r *foodItemRepository) Search(ctx context.Context, query string, limit, of
 	return foodItems, nil
 }
 
-// Update updates an existing food item in the database
+// Update updates an existing food item in the database.
+// The row is only written if its updated_at still equals UpdatedAt, which is then set to the new version.
 func (r *foodItemRepository) Update(ctx context.Context, foodItem *entity.FoodItem) error {
 	query, args, err := r.db.Builder.Update("food_items").
 		Set("name", foodItem.Name).
@@ -154,20 +178,26 @@ func (r *foodItemRepository) Update(ctx context.Context, foodItem *entity.FoodIt
 		Set("source", foodItem.Source).
 		Set("is_verified", foodItem.IsVerified).
 		Set("created_by_user_id", foodItem.CreatedByUserID).
-		Set("updated_at", foodItem.UpdatedAt).
-		Where(squirrel.Eq{"id": foodItem.ID}).
+		Set("updated_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"id": foodItem.ID, "updated_at": foodItem.UpdatedAt, "deleted_at": nil}).
+		Suffix("RETURNING updated_at").
 		ToSql()
 	if err != nil {
 		return err
 	}
-	_, err = r.db.Pool.Exec(ctx, query, args...)
+
+	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(&foodItem.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return ErrVersionConflict
+	}
 	return err
 }
 
-// Delete deletes a food item from the database
+// Delete moves a food item to the trash
 func (r *foodItemRepository) Delete(ctx context.Context, id string) error {
-	query, args, err := r.db.Builder.Delete("food_items").
-		Where(squirrel.Eq{"id": id}).
+	query, args, err := r.db.Builder.Update("food_items").
+		Set("deleted_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"id": id, "deleted_at": nil}).
 		ToSql()
 	if err != nil {
 		return err
@@ -180,6 +210,7 @@ func (r *foodItemRepository) Delete(ctx context.Context, id string) error {
 func (r *foodItemRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*entity.FoodItem, error) {
 	query, args, err := r.db.Builder.Select("id", "name", "brand_name", "barcode_upc", "serving_size_default_qty", "serving_size_default_unit", "calories_per_default_serving", "protein_grams_per_default_serving", "fat_grams_per_default_serving", "carbs_grams_per_default_serving", "fiber_grams_per_default_serving", "sugar_grams_per_default_serving", "saturated_fat_grams_per_default_serving", "trans_fat_grams_per_default_serving", "cholesterol_mg_per_default_serving", "sodium_mg_per_default_serving", "potassium_mg_per_default_serving", "vitamin_a_mcg_per_default_serving", "vitamin_c_mg_per_default_serving", "calcium_mg_per_default_serving", "iron_mg_per_default_serving", "source", "is_verified", "created_by_user_id", "created_at", "updated_at").
 		From("food_items").
+		Where(squirrel.Eq{"deleted_at": nil}).
 		Where(squirrel.Eq{"created_by_user_id": userID}).
 		Limit(uint64(limit)).
 		Offset(uint64(offset)).
@@ -206,14 +237,16 @@ func (r *foodItemRepository) GetByUserID(ctx context.Context, userID string, lim
 	return foodItems, nil
 }
 
-// Count returns the total number of food items matching the filters
-func (r *foodItemRepository) Count(ctx context.Context, filters map[string]interface{}) (int64, error) {
+// Count returns the total number of food items matching the query spec
+func (r *foodItemRepository) Count(ctx context.Context, spec QuerySpec) (int64, error) {
 	query := r.db.Builder.Select("COUNT(*)").
-		From("food_items")
+		From("food_items").
+		Where(squirrel.Eq{"deleted_at": nil})
 
 	// Apply filters
-	for key, value := range filters {
-		query = query.Where(squirrel.Eq{key: value})
+	query, err := FoodItemFields.where(query, spec)
+	if err != nil {
+		return 0, err
 	}
 
 	sqlQuery, args, err := query.ToSql()
@@ -234,6 +267,7 @@ func (r *foodItemRepository) Count(ctx context.Context, filters map[string]inter
 func (r *foodItemRepository) CountBySearch(ctx context.Context, query string) (int64, error) {
 	sqlQuery, args, err := r.db.Builder.Select("COUNT(*)").
 		From("food_items").
+		Where(squirrel.Eq{"deleted_at": nil}).
 		Where(squirrel.Or{
 			squirrel.Like{"name": "%" + query + "%"},
 			squirrel.Like{"brand_name": "%" + query + "%"},
@@ -252,14 +286,20 @@ func (r *foodItemRepository) CountBySearch(ctx context.Context, query string) (i
 	return count, nil
 }
 
-// List returns a paginated list of food items matching the filters
-func (r *foodItemRepository) List(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]*entity.FoodItem, error) {
+// List returns a paginated list of food items matching the query spec
+func (r *foodItemRepository) List(ctx context.Context, spec QuerySpec, page, pageSize int) ([]*entity.FoodItem, error) {
 	query := r.db.Builder.Select("id", "name", "brand_name", "barcode_upc", "serving_size_default_qty", "serving_size_default_unit", "calories_per_default_serving", "protein_grams_per_default_serving", "fat_grams_per_default_serving", "carbs_grams_per_default_serving", "fiber_grams_per_default_serving", "sugar_grams_per_default_serving", "saturated_fat_grams_per_default_serving", "trans_fat_grams_per_default_serving", "cholesterol_mg_per_default_serving", "sodium_mg_per_default_serving", "potassium_mg_per_default_serving", "vitamin_a_mcg_per_default_serving", "vitamin_c_mg_per_default_serving", "calcium_mg_per_default_serving", "iron_mg_per_default_serving", "source", "is_verified", "created_by_user_id", "created_at", "updated_at").
-		From("food_items")
+		From("food_items").
+		Where(squirrel.Eq{"deleted_at": nil})
 
-	// Apply filters
-	for key, value := range filters {
-		query = query.Where(squirrel.Eq{key: value})
+	// Apply filters and sort order
+	query, err := FoodItemFields.where(query, spec)
+	if err != nil {
+		return nil, err
+	}
+	query, err = FoodItemFields.orderBy(query, spec, "name ASC")
+	if err != nil {
+		return nil, err
 	}
 
 	// Apply pagination
@@ -291,3 +331,49 @@ func (r *foodItemRepository) List(ctx context.Context, filters map[string]interf
 
 	return foodItems, nil
 }
+
+// ListPage returns a cursor paginated list of food items matching the query spec, ordered by name
+func (r *foodItemRepository) ListPage(ctx context.Context, spec QuerySpec, page CursorPage) ([]*entity.FoodItem, PageInfo, error) {
+	query := r.db.Builder.Select("id", "name", "brand_name", "barcode_upc", "serving_size_default_qty", "serving_size_default_unit", "calories_per_default_serving", "protein_grams_per_default_serving", "fat_grams_per_default_serving", "carbs_grams_per_default_serving", "fiber_grams_per_default_serving", "sugar_grams_per_default_serving", "saturated_fat_grams_per_default_serving", "trans_fat_grams_per_default_serving", "cholesterol_mg_per_default_serving", "sodium_mg_per_default_serving", "potassium_mg_per_default_serving", "vitamin_a_mcg_per_default_serving", "vitamin_c_mg_per_default_serving", "calcium_mg_per_default_serving", "iron_mg_per_default_serving", "source", "is_verified", "created_by_user_id", "created_at", "updated_at").
+		From("food_items").
+		Where(squirrel.Eq{"deleted_at": nil})
+
+	query, err := FoodItemFields.where(query, spec)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	query, c, err := foodItemKeyset.apply(query, spec, page)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	rows, err := r.db.Pool.Query(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	defer rows.Close()
+
+	var foodItems []*entity.FoodItem
+	for rows.Next() {
+		var foodItem entity.FoodItem
+		err := rows.Scan(
+			&foodItem.ID, &foodItem.Name, &foodItem.BrandName, &foodItem.BarcodeUPC, &foodItem.ServingSizeDefaultQty, &foodItem.ServingSizeDefaultUnit, &foodItem.CaloriesPerDefaultServing, &foodItem.ProteinGramsPerDefaultServing, &foodItem.FatGramsPerDefaultServing, &foodItem.CarbsGramsPerDefaultServing, &foodItem.FiberGramsPerDefaultServing, &foodItem.SugarGramsPerDefaultServing, &foodItem.SaturatedFatGramsPerDefaultServing, &foodItem.TransFatGramsPerDefaultServing, &foodItem.CholesterolMgPerDefaultServing, &foodItem.SodiumMgPerDefaultServing, &foodItem.PotassiumMgPerDefaultServing, &foodItem.VitaminAMcgPerDefaultServing, &foodItem.VitaminCMgPerDefaultServing, &foodItem.CalciumMgPerDefaultServing, &foodItem.IronMgPerDefaultServing, &foodItem.Source, &foodItem.IsVerified, &foodItem.CreatedByUserID, &foodItem.CreatedAt, &foodItem.UpdatedAt,
+		)
+		if err != nil {
+			return nil, PageInfo{}, err
+		}
+		foodItems = append(foodItems, &foodItem)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	foodItems, info := paginate(foodItems, page, c, func(f *entity.FoodItem) []interface{} {
+		return []interface{}{f.Name, f.ID}
+	})
+	return foodItems, info, nil
+}
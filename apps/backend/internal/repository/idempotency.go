@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/pkg/postgres"
+)
+
+// IdempotencyRepository defines the interface for idempotency key database operations
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, key *entity.IdempotencyKey) (bool, error)
+	GetByKey(ctx context.Context, scope, key string) (*entity.IdempotencyKey, error)
+	Complete(ctx context.Context, key *entity.IdempotencyKey) error
+	Delete(ctx context.Context, scope, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// idempotencyRepository implements IdempotencyRepository
+type idempotencyRepository struct {
+	db *postgres.Postgres
+}
+
+// NewIdempotencyRepository creates a new instance of IdempotencyRepository
+func NewIdempotencyRepository(db *postgres.Postgres) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Reserve stores a pending key and reports whether it was stored.
+// A key that already exists is only replaced once it has expired.
+func (r *idempotencyRepository) Reserve(ctx context.Context, key *entity.IdempotencyKey) (bool, error) {
+	query, args, err := r.db.Builder.Insert("idempotency_keys").
+		Columns("scope", "idempotency_key", "request_hash", "created_at", "expires_at").
+		Values(key.Scope, key.Key, key.RequestHash, key.CreatedAt, key.ExpiresAt).
+		Suffix(`ON CONFLICT (scope, idempotency_key) DO UPDATE SET
+			request_hash = EXCLUDED.request_hash,
+			status_code = NULL,
+			content_type = NULL,
+			response_body = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at <= EXCLUDED.created_at`).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	tag, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// GetByKey retrieves the idempotency key of a scope
+func (r *idempotencyRepository) GetByKey(ctx context.Context, scope, key string) (*entity.IdempotencyKey, error) {
+	query, args, err := r.db.Builder.Select("scope", "idempotency_key", "request_hash", "status_code", "COALESCE(content_type, '')", "response_body", "created_at", "expires_at").
+		From("idempotency_keys").
+		Where(squirrel.Eq{"scope": scope, "idempotency_key": key}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var k entity.IdempotencyKey
+	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(
+		&k.Scope, &k.Key, &k.RequestHash, &k.StatusCode, &k.ContentType, &k.ResponseBody, &k.CreatedAt, &k.ExpiresAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// Complete stores the response of the request that reserved the key
+func (r *idempotencyRepository) Complete(ctx context.Context, key *entity.IdempotencyKey) error {
+	query, args, err := r.db.Builder.Update("idempotency_keys").
+		Set("status_code", key.StatusCode).
+		Set("content_type", key.ContentType).
+		Set("response_body", key.ResponseBody).
+		Where(squirrel.Eq{"scope": key.Scope, "idempotency_key": key.Key}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+// Delete removes the idempotency key of a scope
+func (r *idempotencyRepository) Delete(ctx context.Context, scope, key string) error {
+	query, args, err := r.db.Builder.Delete("idempotency_keys").
+		Where(squirrel.Eq{"scope": scope, "idempotency_key": key}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+// DeleteExpired removes every key that expired before now and returns how many were removed
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	query, args, err := r.db.Builder.Delete("idempotency_keys").
+		Where(squirrel.LtOrEq{"expires_at": now}).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+	tag, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
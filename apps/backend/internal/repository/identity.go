@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/pkg/postgres"
+)
+
+// IdentityRepository defines the interface for linked identity and OpenID Connect sign in state database operations
+type IdentityRepository interface {
+	GetByProviderSubject(ctx context.Context, provider, subject string) (*entity.LinkedIdentity, error)
+	ListByUserID(ctx context.Context, userID string) ([]*entity.LinkedIdentity, error)
+	Create(ctx context.Context, identity *entity.LinkedIdentity) error
+	CreateWithUser(ctx context.Context, user *entity.User, identity *entity.LinkedIdentity) error
+	TouchLogin(ctx context.Context, id string) error
+	Delete(ctx context.Context, userID, id string) (bool, error)
+
+	CreateState(ctx context.Context, state *entity.OIDCLoginState) error
+	TakeState(ctx context.Context, stateHash string) (*entity.OIDCLoginState, error)
+	PurgeStates(ctx context.Context) (int64, error)
+}
+
+// identityRepository implements IdentityRepository
+type identityRepository struct {
+	db *postgres.Postgres
+}
+
+// NewIdentityRepository creates a new instance of IdentityRepository
+func NewIdentityRepository(db *postgres.Postgres) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+var identityColumns = []string{"identity_id", "user_id", "provider", "subject", "COALESCE(email, '')", "created_at", "last_login_at"}
+
+func scanIdentity(row pgx.Row) (*entity.LinkedIdentity, error) {
+	var identity entity.LinkedIdentity
+	err := row.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt, &identity.LastLoginAt)
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// GetByProviderSubject retrieves the identity of a provider account
+func (r *identityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (*entity.LinkedIdentity, error) {
+	query, args, err := r.db.Builder.Select(identityColumns...).
+		From("linked_identities").
+		Where(squirrel.Eq{"provider": provider, "subject": subject}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := scanIdentity(r.db.Pool.QueryRow(ctx, query, args...))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// ListByUserID retrieves a user's identities, oldest first
+func (r *identityRepository) ListByUserID(ctx context.Context, userID string) ([]*entity.LinkedIdentity, error) {
+	query, args, err := r.db.Builder.Select(identityColumns...).
+		From("linked_identities").
+		Where(squirrel.Eq{"user_id": userID}).
+		OrderBy("created_at").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var identities []*entity.LinkedIdentity
+	for rows.Next() {
+		identity, err := scanIdentity(rows)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+// Create links an identity to an existing user
+func (r *identityRepository) Create(ctx context.Context, identity *entity.LinkedIdentity) error {
+	query, args, err := r.insertIdentity(identity)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+// CreateWithUser creates a user signing up with a provider together with their identity
+func (r *identityRepository) CreateWithUser(ctx context.Context, user *entity.User, identity *entity.LinkedIdentity) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op once committed
+
+	query, args, err := r.db.Builder.Insert("users").
+		Columns("user_id", "username", "email", "password_hash", "first_name", "last_name", "is_active", "is_email_verified", "created_at", "updated_at").
+		Values(user.ID, user.Username, user.Email, user.PasswordHash, user.FirstName, user.LastName, user.IsActive, user.IsEmailVerified, user.CreatedAt, user.UpdatedAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return err
+	}
+
+	query, args, err = r.insertIdentity(identity)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *identityRepository) insertIdentity(identity *entity.LinkedIdentity) (string, []interface{}, error) {
+	var email *string
+	if identity.Email != "" {
+		email = &identity.Email
+	}
+	return r.db.Builder.Insert("linked_identities").
+		Columns("identity_id", "user_id", "provider", "subject", "email", "created_at", "last_login_at").
+		Values(identity.ID, identity.UserID, identity.Provider, identity.Subject, email, identity.CreatedAt, identity.LastLoginAt).
+		ToSql()
+}
+
+// TouchLogin records a sign in with an identity
+func (r *identityRepository) TouchLogin(ctx context.Context, id string) error {
+	query, args, err := r.db.Builder.Update("linked_identities").
+		Set("last_login_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"identity_id": id}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+// Delete unlinks one of a user's identities and reports whether it existed
+func (r *identityRepository) Delete(ctx context.Context, userID, id string) (bool, error) {
+	query, args, err := r.db.Builder.Delete("linked_identities").
+		Where(squirrel.Eq{"identity_id": id, "user_id": userID}).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	tag, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// CreateState stores a sign in in progress
+func (r *identityRepository) CreateState(ctx context.Context, state *entity.OIDCLoginState) error {
+	query, args, err := r.db.Builder.Insert("oidc_login_states").
+		Columns("state_hash", "provider", "nonce", "code_verifier", "device_name", "expires_at").
+		Values(state.StateHash, state.Provider, state.Nonce, state.CodeVerifier, state.DeviceName, state.ExpiresAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+// TakeState deletes a sign in in progress and returns it, so that a state is used once.
+// Unknown states return nil.
+func (r *identityRepository) TakeState(ctx context.Context, stateHash string) (*entity.OIDCLoginState, error) {
+	query, args, err := r.db.Builder.Delete("oidc_login_states").
+		Where(squirrel.Eq{"state_hash": stateHash}).
+		Suffix("RETURNING state_hash, provider, nonce, code_verifier, device_name, expires_at").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var state entity.OIDCLoginState
+	err = r.db.Pool.QueryRow(ctx, query, args...).
+		Scan(&state.StateHash, &state.Provider, &state.Nonce, &state.CodeVerifier, &state.DeviceName, &state.ExpiresAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// PurgeStates deletes sign ins that were never completed and returns how many were deleted
+func (r *identityRepository) PurgeStates(ctx context.Context) (int64, error) {
+	query, args, err := r.db.Builder.Delete("oidc_login_states").
+		Where(squirrel.Expr("expires_at < CURRENT_TIMESTAMP")).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+	tag, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -16,15 +16,30 @@ type MealRepository interface {
 	GetByID(ctx context.Context, id string) (*entity.UserMeal, error)
 	Update(ctx context.Context, meal *entity.UserMeal) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]*entity.UserMeal, error)
-	Count(ctx context.Context, filters map[string]interface{}) (int64, error)
+	List(ctx context.Context, spec QuerySpec, page, pageSize int) ([]*entity.UserMeal, error)
+	Count(ctx context.Context, spec QuerySpec) (int64, error)
+	ListPage(ctx context.Context, spec QuerySpec, page CursorPage) ([]*entity.UserMeal, PageInfo, error)
 	GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*entity.UserMeal, error)
 	AddFoodItem(ctx context.Context, foodItem *entity.MealFoodItem) error
 	GetFoodItems(ctx context.Context, mealID string) ([]*entity.MealFoodItem, error)
+	GetFoodItemByID(ctx context.Context, foodItemID string) (*entity.MealFoodItem, error)
 	UpdateFoodItem(ctx context.Context, foodItem *entity.MealFoodItem) error
 	DeleteFoodItem(ctx context.Context, foodItemID string) error
 }
 
+// MealFields lists the meal columns that can be filtered and sorted through the API
+var MealFields = Fields{
+	"user_id":                 {Column: "user_id", Type: FieldString, Operators: enumOps},
+	"meal_type":               {Column: "meal_type", Type: FieldString, Operators: enumOps, Sortable: true},
+	"meal_date":               {Column: "meal_date", Type: FieldDate, Operators: rangeOps, Sortable: true},
+	"total_calories_consumed": {Column: "total_calories_consumed", Type: FieldNumber, Operators: rangeOps, Sortable: true},
+	"created_at":              {Column: "created_at", Type: FieldTime, Operators: rangeOps, Sortable: true},
+	"updated_at":              {Column: "updated_at", Type: FieldTime, Operators: rangeOps, Sortable: true},
+}
+
+// mealKeyset is used for cursor pagination of meals
+var mealKeyset = keyset{columns: []string{"meal_date", "created_at", "meal_id"}, desc: true}
+
 // mealRepository implements MealRepository
 type mealRepository struct {
 	db *postgres.Postgres
@@ -46,7 +61,7 @@ func (r *mealRepository) Create(ctx context.Context, meal *entity.UserMeal) (*en
 	}
 	_, err = r.db.Pool.Exec(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, translateWriteError(err)
 	}
 	return meal, nil
 }
@@ -55,6 +70,7 @@ func (r *mealRepository) Create(ctx context.Context, meal *entity.UserMeal) (*en
 func (r *mealRepository) GetByID(ctx context.Context, id string) (*entity.UserMeal, error) {
 	query, args, err := r.db.Builder.Select("meal_id", "user_id", "meal_type", "meal_date", "meal_time", "custom_meal_name", "notes", "total_calories_consumed", "total_protein_consumed", "total_fat_consumed", "total_carbs_consumed", "created_at", "updated_at").
 		From("user_meals").
+		Where(squirrel.Eq{"deleted_at": nil}).
 		Where(squirrel.Eq{"meal_id": id}).
 		ToSql()
 	if err != nil {
@@ -73,7 +89,8 @@ func (r *mealRepository) GetByID(ctx context.Context, id string) (*entity.UserMe
 	return &meal, nil
 }
 
-// Update updates an existing meal in the database
+// Update updates an existing meal in the database.
+// The row is only written if its updated_at still equals UpdatedAt, which is then set to the new version.
 func (r *mealRepository) Update(ctx context.Context, meal *entity.UserMeal) error {
 	query, args, err := r.db.Builder.Update("user_meals").
 		Set("user_id", meal.UserID).
@@ -86,20 +103,26 @@ func (r *mealRepository) Update(ctx context.Context, meal *entity.UserMeal) erro
 		Set("total_protein_consumed", meal.TotalProteinConsumed).
 		Set("total_fat_consumed", meal.TotalFatConsumed).
 		Set("total_carbs_consumed", meal.TotalCarbsConsumed).
-		Set("updated_at", meal.UpdatedAt).
-		Where(squirrel.Eq{"meal_id": meal.ID}).
+		Set("updated_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"meal_id": meal.ID, "updated_at": meal.UpdatedAt, "deleted_at": nil}).
+		Suffix("RETURNING updated_at").
 		ToSql()
 	if err != nil {
 		return err
 	}
-	_, err = r.db.Pool.Exec(ctx, query, args...)
+
+	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(&meal.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return ErrVersionConflict
+	}
 	return err
 }
 
-// Delete deletes a meal from the database
+// Delete moves a meal to the trash
 func (r *mealRepository) Delete(ctx context.Context, id string) error {
-	query, args, err := r.db.Builder.Delete("user_meals").
-		Where(squirrel.Eq{"meal_id": id}).
+	query, args, err := r.db.Builder.Update("user_meals").
+		Set("deleted_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"meal_id": id, "deleted_at": nil}).
 		ToSql()
 	if err != nil {
 		return err
@@ -108,14 +131,20 @@ func (r *mealRepository) Delete(ctx context.Context, id string) error {
 	return err
 }
 
-// List returns a paginated list of meals matching the filters
-func (r *mealRepository) List(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]*entity.UserMeal, error) {
+// List returns a paginated list of meals matching the query spec
+func (r *mealRepository) List(ctx context.Context, spec QuerySpec, page, pageSize int) ([]*entity.UserMeal, error) {
 	query := r.db.Builder.Select("meal_id", "user_id", "meal_type", "meal_date", "meal_time", "custom_meal_name", "notes", "total_calories_consumed", "total_protein_consumed", "total_fat_consumed", "total_carbs_consumed", "created_at", "updated_at").
-		From("user_meals")
+		From("user_meals").
+		Where(squirrel.Eq{"deleted_at": nil})
 
-	// Apply filters
-	for key, value := range filters {
-		query = query.Where(squirrel.Eq{key: value})
+	// Apply filters and sort order
+	query, err := MealFields.where(query, spec)
+	if err != nil {
+		return nil, err
+	}
+	query, err = MealFields.orderBy(query, spec, "meal_date DESC", "meal_time DESC")
+	if err != nil {
+		return nil, err
 	}
 
 	// Apply pagination
@@ -148,14 +177,16 @@ func (r *mealRepository) List(ctx context.Context, filters map[string]interface{
 	return meals, nil
 }
 
-// Count returns the total number of meals matching the filters
-func (r *mealRepository) Count(ctx context.Context, filters map[string]interface{}) (int64, error) {
+// Count returns the total number of meals matching the query spec
+func (r *mealRepository) Count(ctx context.Context, spec QuerySpec) (int64, error) {
 	query := r.db.Builder.Select("COUNT(*)").
-		From("user_meals")
+		From("user_meals").
+		Where(squirrel.Eq{"deleted_at": nil})
 
 	// Apply filters
-	for key, value := range filters {
-		query = query.Where(squirrel.Eq{key: value})
+	query, err := MealFields.where(query, spec)
+	if err != nil {
+		return 0, err
 	}
 
 	sqlQuery, args, err := query.ToSql()
@@ -176,6 +207,7 @@ func (r *mealRepository) Count(ctx context.Context, filters map[string]interface
 func (r *mealRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*entity.UserMeal, error) {
 	query, args, err := r.db.Builder.Select("meal_id", "user_id", "meal_type", "meal_date", "meal_time", "custom_meal_name", "notes", "total_calories_consumed", "total_protein_consumed", "total_fat_consumed", "total_carbs_consumed", "created_at", "updated_at").
 		From("user_meals").
+		Where(squirrel.Eq{"deleted_at": nil}).
 		Where(squirrel.Eq{"user_id": userID}).
 		OrderBy("meal_date DESC", "meal_time DESC").
 		Limit(uint64(limit)).
@@ -213,7 +245,7 @@ func (r *mealRepository) AddFoodItem(ctx context.Context, foodItem *entity.MealF
 		return err
 	}
 	_, err = r.db.Pool.Exec(ctx, query, args...)
-	return err
+	return translateWriteError(err)
 }
 
 // GetFoodItems retrieves all food items for a meal
@@ -244,6 +276,28 @@ func (r *mealRepository) GetFoodItems(ctx context.Context, mealID string) ([]*en
 	return foodItems, nil
 }
 
+// GetFoodItemByID retrieves a food item of a meal by its ID
+func (r *mealRepository) GetFoodItemByID(ctx context.Context, foodItemID string) (*entity.MealFoodItem, error) {
+	query, args, err := r.db.Builder.Select("meal_food_item_id", "meal_id", "food_item_id", "quantity_consumed", "serving_unit_consumed", "calories_consumed", "protein_consumed", "fat_consumed", "carbs_consumed", "logged_at").
+		From("meal_food_items").
+		Where(squirrel.Eq{"meal_food_item_id": foodItemID}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var foodItem entity.MealFoodItem
+	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(
+		&foodItem.ID, &foodItem.MealID, &foodItem.FoodItemID, &foodItem.QuantityConsumed, &foodItem.ServingUnitConsumed, &foodItem.CaloriesConsumed, &foodItem.ProteinConsumed, &foodItem.FatConsumed, &foodItem.CarbsConsumed, &foodItem.LoggedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &foodItem, nil
+}
+
 // UpdateFoodItem updates an existing food item
 func (r *mealRepository) UpdateFoodItem(ctx context.Context, foodItem *entity.MealFoodItem) error {
 	query, args, err := r.db.Builder.Update("meal_food_items").
@@ -273,3 +327,49 @@ func (r *mealRepository) DeleteFoodItem(ctx context.Context, foodItemID string)
 	_, err = r.db.Pool.Exec(ctx, query, args...)
 	return err
 }
+
+// ListPage returns a cursor paginated list of meals matching the query spec, most recent first
+func (r *mealRepository) ListPage(ctx context.Context, spec QuerySpec, page CursorPage) ([]*entity.UserMeal, PageInfo, error) {
+	query := r.db.Builder.Select("meal_id", "user_id", "meal_type", "meal_date", "meal_time", "custom_meal_name", "notes", "total_calories_consumed", "total_protein_consumed", "total_fat_consumed", "total_carbs_consumed", "created_at", "updated_at").
+		From("user_meals").
+		Where(squirrel.Eq{"deleted_at": nil})
+
+	query, err := MealFields.where(query, spec)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	query, c, err := mealKeyset.apply(query, spec, page)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	rows, err := r.db.Pool.Query(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	defer rows.Close()
+
+	var meals []*entity.UserMeal
+	for rows.Next() {
+		var meal entity.UserMeal
+		err := rows.Scan(
+			&meal.ID, &meal.UserID, &meal.MealType, &meal.MealDate, &meal.MealTime, &meal.CustomMealName, &meal.Notes, &meal.TotalCaloriesConsumed, &meal.TotalProteinConsumed, &meal.TotalFatConsumed, &meal.TotalCarbsConsumed, &meal.CreatedAt, &meal.UpdatedAt,
+		)
+		if err != nil {
+			return nil, PageInfo{}, err
+		}
+		meals = append(meals, &meal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	meals, info := paginate(meals, page, c, func(m *entity.UserMeal) []interface{} {
+		return []interface{}{m.MealDate, m.CreatedAt, m.ID}
+	})
+	return meals, info, nil
+}
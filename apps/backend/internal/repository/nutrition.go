@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
@@ -27,8 +28,22 @@ type NutritionRepository interface {
 	DeleteBiometrics(ctx context.Context, id string) error
 	GetUserBiometricsHistory(ctx context.Context, userID string, limit, offset int) ([]*entity.UserBiometric, error)
 	GetLatestBiometrics(ctx context.Context, userID string) (*entity.UserBiometric, error)
+	GetBiometricsOnOrBefore(ctx context.Context, userID string, date time.Time) (*entity.UserBiometric, error)
+	ListBiometricsPage(ctx context.Context, spec QuerySpec, page CursorPage) ([]*entity.UserBiometric, PageInfo, error)
 }
 
+// BiometricFields lists the biometric columns that can be filtered through the API
+var BiometricFields = Fields{
+	"user_id":             {Column: "user_id", Type: FieldString, Operators: enumOps},
+	"log_date":            {Column: "log_date", Type: FieldDate, Operators: rangeOps},
+	"weight_kg":           {Column: "weight_kg", Type: FieldNumber, Operators: rangeOps},
+	"body_fat_percentage": {Column: "body_fat_percentage", Type: FieldNumber, Operators: rangeOps},
+	"activity_level":      {Column: "activity_level", Type: FieldString, Operators: enumOps},
+}
+
+// biometricKeyset is used for cursor pagination of biometrics
+var biometricKeyset = keyset{columns: []string{"log_date", "biometrics_id"}, desc: true}
+
 // nutritionRepository implements NutritionRepository
 type nutritionRepository struct {
 	db *postgres.Postgres
@@ -77,7 +92,8 @@ func (r *nutritionRepository) GetNutritionGoalsByID(ctx context.Context, id stri
 	return &goals, nil
 }
 
-// UpdateNutritionGoals updates existing nutrition goals
+// UpdateNutritionGoals updates existing nutrition goals.
+// The row is only written if its updated_at still equals UpdatedAt, which is then set to the new version.
 func (r *nutritionRepository) UpdateNutritionGoals(ctx context.Context, goals *entity.UserNutritionGoal) error {
 	query, args, err := r.db.Builder.Update("user_nutrition_goals").
 		Set("user_id", goals.UserID).
@@ -90,13 +106,18 @@ func (r *nutritionRepository) UpdateNutritionGoals(ctx context.Context, goals *e
 		Set("target_sugar_grams_limit", goals.TargetSugarGramsLimit).
 		Set("notes", goals.Notes).
 		Set("is_active", goals.IsActive).
-		Set("updated_at", goals.UpdatedAt).
-		Where(squirrel.Eq{"nutrition_goals_id": goals.ID}).
+		Set("updated_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"nutrition_goals_id": goals.ID, "updated_at": goals.UpdatedAt}).
+		Suffix("RETURNING updated_at").
 		ToSql()
 	if err != nil {
 		return err
 	}
-	_, err = r.db.Pool.Exec(ctx, query, args...)
+
+	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(&goals.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return ErrVersionConflict
+	}
 	return err
 }
 
@@ -179,7 +200,7 @@ func (r *nutritionRepository) CreateBiometrics(ctx context.Context, biometrics *
 	}
 	_, err = r.db.Pool.Exec(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, translateWriteError(err)
 	}
 	return biometrics, nil
 }
@@ -188,6 +209,7 @@ func (r *nutritionRepository) CreateBiometrics(ctx context.Context, biometrics *
 func (r *nutritionRepository) GetBiometricsByID(ctx context.Context, id string) (*entity.UserBiometric, error) {
 	query, args, err := r.db.Builder.Select("biometrics_id", "user_id", "log_date", "weight_kg", "height_cm", "body_fat_percentage", "waist_circumference_cm", "hip_circumference_cm", "chest_circumference_cm", "resting_heart_rate_bpm", "activity_level", "created_at").
 		From("user_biometrics").
+		Where(squirrel.Eq{"deleted_at": nil}).
 		Where(squirrel.Eq{"biometrics_id": id}).
 		ToSql()
 	if err != nil {
@@ -219,7 +241,7 @@ func (r *nutritionRepository) UpdateBiometrics(ctx context.Context, biometrics *
 		Set("chest_circumference_cm", biometrics.ChestCircumferenceCm).
 		Set("resting_heart_rate_bpm", biometrics.RestingHeartRateBpm).
 		Set("activity_level", biometrics.ActivityLevel).
-		Where(squirrel.Eq{"biometrics_id": biometrics.ID}).
+		Where(squirrel.Eq{"biometrics_id": biometrics.ID, "deleted_at": nil}).
 		ToSql()
 	if err != nil {
 		return err
@@ -228,10 +250,11 @@ func (r *nutritionRepository) UpdateBiometrics(ctx context.Context, biometrics *
 	return err
 }
 
-// DeleteBiometrics deletes biometrics entry
+// DeleteBiometrics moves a biometrics entry to the trash
 func (r *nutritionRepository) DeleteBiometrics(ctx context.Context, id string) error {
-	query, args, err := r.db.Builder.Delete("user_biometrics").
-		Where(squirrel.Eq{"biometrics_id": id}).
+	query, args, err := r.db.Builder.Update("user_biometrics").
+		Set("deleted_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"biometrics_id": id, "deleted_at": nil}).
 		ToSql()
 	if err != nil {
 		return err
@@ -244,6 +267,7 @@ func (r *nutritionRepository) DeleteBiometrics(ctx context.Context, id string) e
 func (r *nutritionRepository) GetUserBiometricsHistory(ctx context.Context, userID string, limit, offset int) ([]*entity.UserBiometric, error) {
 	query, args, err := r.db.Builder.Select("biometrics_id", "user_id", "log_date", "weight_kg", "height_cm", "body_fat_percentage", "waist_circumference_cm", "hip_circumference_cm", "chest_circumference_cm", "resting_heart_rate_bpm", "activity_level", "created_at").
 		From("user_biometrics").
+		Where(squirrel.Eq{"deleted_at": nil}).
 		Where(squirrel.Eq{"user_id": userID}).
 		OrderBy("log_date DESC").
 		Limit(uint64(limit)).
@@ -275,6 +299,7 @@ func (r *nutritionRepository) GetUserBiometricsHistory(ctx context.Context, user
 func (r *nutritionRepository) GetLatestBiometrics(ctx context.Context, userID string) (*entity.UserBiometric, error) {
 	query, args, err := r.db.Builder.Select("biometrics_id", "user_id", "log_date", "weight_kg", "height_cm", "body_fat_percentage", "waist_circumference_cm", "hip_circumference_cm", "chest_circumference_cm", "resting_heart_rate_bpm", "activity_level", "created_at").
 		From("user_biometrics").
+		Where(squirrel.Eq{"deleted_at": nil}).
 		Where(squirrel.Eq{"user_id": userID}).
 		OrderBy("log_date DESC").
 		Limit(1).
@@ -294,3 +319,75 @@ func (r *nutritionRepository) GetLatestBiometrics(ctx context.Context, userID st
 	}
 	return &biometrics, nil
 }
+
+// GetBiometricsOnOrBefore retrieves a user's most recent biometrics logged on or before a date
+func (r *nutritionRepository) GetBiometricsOnOrBefore(ctx context.Context, userID string, date time.Time) (*entity.UserBiometric, error) {
+	query, args, err := r.db.Builder.Select("biometrics_id", "user_id", "log_date", "weight_kg", "height_cm", "body_fat_percentage", "waist_circumference_cm", "hip_circumference_cm", "chest_circumference_cm", "resting_heart_rate_bpm", "activity_level", "created_at").
+		From("user_biometrics").
+		Where(squirrel.Eq{"deleted_at": nil}).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.LtOrEq{"log_date": date}).
+		OrderBy("log_date DESC", "created_at DESC").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var biometrics entity.UserBiometric
+	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(
+		&biometrics.ID, &biometrics.UserID, &biometrics.LogDate, &biometrics.WeightKg, &biometrics.HeightCm, &biometrics.BodyFatPercentage, &biometrics.WaistCircumferenceCm, &biometrics.HipCircumferenceCm, &biometrics.ChestCircumferenceCm, &biometrics.RestingHeartRateBpm, &biometrics.ActivityLevel, &biometrics.CreatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &biometrics, nil
+}
+
+// ListBiometricsPage returns a cursor paginated list of biometrics matching the query spec, most recent first
+func (r *nutritionRepository) ListBiometricsPage(ctx context.Context, spec QuerySpec, page CursorPage) ([]*entity.UserBiometric, PageInfo, error) {
+	query := r.db.Builder.Select("biometrics_id", "user_id", "log_date", "weight_kg", "height_cm", "body_fat_percentage", "waist_circumference_cm", "hip_circumference_cm", "chest_circumference_cm", "resting_heart_rate_bpm", "activity_level", "created_at").
+		From("user_biometrics").
+		Where(squirrel.Eq{"deleted_at": nil})
+
+	query, err := BiometricFields.where(query, spec)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	query, c, err := biometricKeyset.apply(query, spec, page)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	rows, err := r.db.Pool.Query(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	defer rows.Close()
+
+	var biometrics []*entity.UserBiometric
+	for rows.Next() {
+		var bio entity.UserBiometric
+		err := rows.Scan(
+			&bio.ID, &bio.UserID, &bio.LogDate, &bio.WeightKg, &bio.HeightCm, &bio.BodyFatPercentage, &bio.WaistCircumferenceCm, &bio.HipCircumferenceCm, &bio.ChestCircumferenceCm, &bio.RestingHeartRateBpm, &bio.ActivityLevel, &bio.CreatedAt,
+		)
+		if err != nil {
+			return nil, PageInfo{}, err
+		}
+		biometrics = append(biometrics, &bio)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	biometrics, info := paginate(biometrics, page, c, func(b *entity.UserBiometric) []interface{} {
+		return []interface{}{b.LogDate, b.ID}
+	})
+	return biometrics, info, nil
+}
@@ -0,0 +1,330 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/pkg/postgres"
+)
+
+// OAuthRepository defines the interface for OAuth client, consent and authorization code database operations
+type OAuthRepository interface {
+	CreateClient(ctx context.Context, client *entity.OAuthClient) error
+	GetClient(ctx context.Context, id string) (*entity.OAuthClient, error)
+	ListClientsByOwner(ctx context.Context, ownerID string) ([]*entity.OAuthClient, error)
+	DeleteClient(ctx context.Context, ownerID, id string) (bool, error)
+
+	GetConsent(ctx context.Context, userID, clientID string) (*entity.OAuthConsent, error)
+	GrantConsent(ctx context.Context, userID, clientID string, scopes []entity.Scope) error
+	ListConsents(ctx context.Context, userID string) ([]*entity.OAuthConsent, error)
+	DeleteConsent(ctx context.Context, userID, clientID string) (bool, error)
+
+	CreateCode(ctx context.Context, code *entity.OAuthAuthorizationCode) error
+	UseCode(ctx context.Context, codeHash string) (*entity.OAuthAuthorizationCode, bool, error)
+	PurgeCodes(ctx context.Context) (int64, error)
+}
+
+// oauthRepository implements OAuthRepository
+type oauthRepository struct {
+	db *postgres.Postgres
+}
+
+// NewOAuthRepository creates a new instance of OAuthRepository
+func NewOAuthRepository(db *postgres.Postgres) OAuthRepository {
+	return &oauthRepository{db: db}
+}
+
+func scopeStrings(scopes []entity.Scope) []string {
+	values := make([]string, len(scopes))
+	for i, scope := range scopes {
+		values[i] = string(scope)
+	}
+	return values
+}
+
+func scopesFromStrings(values []string) []entity.Scope {
+	scopes := make([]entity.Scope, len(values))
+	for i, value := range values {
+		scopes[i] = entity.Scope(value)
+	}
+	return scopes
+}
+
+var oauthClientColumns = []string{"client_id", "owner_user_id", "name", "COALESCE(secret_hash, '')", "redirect_uris", "scopes", "created_at"}
+
+func scanOAuthClient(row pgx.Row) (*entity.OAuthClient, error) {
+	var (
+		client entity.OAuthClient
+		scopes []string
+	)
+	err := row.Scan(&client.ID, &client.OwnerID, &client.Name, &client.SecretHash, &client.RedirectURIs, &scopes, &client.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	client.Confidential = client.SecretHash != ""
+	client.Scopes = scopesFromStrings(scopes)
+	return &client, nil
+}
+
+// CreateClient registers a client
+func (r *oauthRepository) CreateClient(ctx context.Context, client *entity.OAuthClient) error {
+	var secretHash *string
+	if client.SecretHash != "" {
+		secretHash = &client.SecretHash
+	}
+
+	query, args, err := r.db.Builder.Insert("oauth_clients").
+		Columns("client_id", "owner_user_id", "name", "secret_hash", "redirect_uris", "scopes", "created_at").
+		Values(client.ID, client.OwnerID, client.Name, secretHash, client.RedirectURIs, scopeStrings(client.Scopes), client.CreatedAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+// GetClient retrieves a client by its ID
+func (r *oauthRepository) GetClient(ctx context.Context, id string) (*entity.OAuthClient, error) {
+	query, args, err := r.db.Builder.Select(oauthClientColumns...).
+		From("oauth_clients").
+		Where(squirrel.Eq{"client_id": id}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := scanOAuthClient(r.db.Pool.QueryRow(ctx, query, args...))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// ListClientsByOwner retrieves the clients a user registered, newest first
+func (r *oauthRepository) ListClientsByOwner(ctx context.Context, ownerID string) ([]*entity.OAuthClient, error) {
+	query, args, err := r.db.Builder.Select(oauthClientColumns...).
+		From("oauth_clients").
+		Where(squirrel.Eq{"owner_user_id": ownerID}).
+		OrderBy("created_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clients []*entity.OAuthClient
+	for rows.Next() {
+		client, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	return clients, rows.Err()
+}
+
+// DeleteClient deletes one of a user's clients, its consents, codes and tokens go with it
+func (r *oauthRepository) DeleteClient(ctx context.Context, ownerID, id string) (bool, error) {
+	query, args, err := r.db.Builder.Delete("oauth_clients").
+		Where(squirrel.Eq{"client_id": id, "owner_user_id": ownerID}).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	tag, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *oauthRepository) consents() squirrel.SelectBuilder {
+	return r.db.Builder.Select(
+		"oauth_consents.user_id", "oauth_consents.client_id", "oauth_clients.name",
+		"oauth_consents.scopes", "oauth_consents.created_at", "oauth_consents.updated_at",
+	).
+		From("oauth_consents").
+		Join("oauth_clients ON oauth_clients.client_id = oauth_consents.client_id")
+}
+
+func scanOAuthConsent(row pgx.Row) (*entity.OAuthConsent, error) {
+	var (
+		consent entity.OAuthConsent
+		scopes  []string
+	)
+	err := row.Scan(&consent.UserID, &consent.ClientID, &consent.ClientName, &scopes, &consent.CreatedAt, &consent.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	consent.Scopes = scopesFromStrings(scopes)
+	return &consent, nil
+}
+
+// GetConsent retrieves the consent a user gave a client
+func (r *oauthRepository) GetConsent(ctx context.Context, userID, clientID string) (*entity.OAuthConsent, error) {
+	query, args, err := r.consents().
+		Where(squirrel.Eq{"oauth_consents.user_id": userID, "oauth_consents.client_id": clientID}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	consent, err := scanOAuthConsent(r.db.Pool.QueryRow(ctx, query, args...))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return consent, nil
+}
+
+// GrantConsent records that a user granted scopes to a client, in addition to those granted before
+func (r *oauthRepository) GrantConsent(ctx context.Context, userID, clientID string, scopes []entity.Scope) error {
+	query, args, err := r.db.Builder.Insert("oauth_consents").
+		Columns("user_id", "client_id", "scopes").
+		Values(userID, clientID, scopeStrings(scopes)).
+		Suffix(`ON CONFLICT (user_id, client_id) DO UPDATE SET
+			scopes = ARRAY(SELECT DISTINCT unnest(oauth_consents.scopes || EXCLUDED.scopes) ORDER BY 1),
+			updated_at = CURRENT_TIMESTAMP`).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+// ListConsents retrieves the clients a user connected, most recently updated first
+func (r *oauthRepository) ListConsents(ctx context.Context, userID string) ([]*entity.OAuthConsent, error) {
+	query, args, err := r.consents().
+		Where(squirrel.Eq{"oauth_consents.user_id": userID}).
+		OrderBy("oauth_consents.updated_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var consents []*entity.OAuthConsent
+	for rows.Next() {
+		consent, err := scanOAuthConsent(rows)
+		if err != nil {
+			return nil, err
+		}
+		consents = append(consents, consent)
+	}
+	return consents, rows.Err()
+}
+
+// DeleteConsent withdraws the consent a user gave a client and reports whether there was one
+func (r *oauthRepository) DeleteConsent(ctx context.Context, userID, clientID string) (bool, error) {
+	query, args, err := r.db.Builder.Delete("oauth_consents").
+		Where(squirrel.Eq{"user_id": userID, "client_id": clientID}).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	tag, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// CreateCode stores an authorization code
+func (r *oauthRepository) CreateCode(ctx context.Context, code *entity.OAuthAuthorizationCode) error {
+	query, args, err := r.db.Builder.Insert("oauth_authorization_codes").
+		Columns("code_hash", "client_id", "user_id", "redirect_uri", "scopes", "code_challenge", "expires_at").
+		Values(code.CodeHash, code.ClientID, code.UserID, code.RedirectURI, scopeStrings(code.Scopes), code.CodeChallenge, code.ExpiresAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+// UseCode marks an authorization code used and returns it. It reports whether this call used it,
+// false for a code that was already used. Unknown codes return nil.
+func (r *oauthRepository) UseCode(ctx context.Context, codeHash string) (*entity.OAuthAuthorizationCode, bool, error) {
+	returning := "RETURNING code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at, used_at"
+
+	query, args, err := r.db.Builder.Update("oauth_authorization_codes").
+		Set("used_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"code_hash": codeHash, "used_at": nil}).
+		Suffix(returning).
+		ToSql()
+	if err != nil {
+		return nil, false, err
+	}
+	code, err := scanOAuthCode(r.db.Pool.QueryRow(ctx, query, args...))
+	if err == nil {
+		return code, true, nil
+	}
+	if err != pgx.ErrNoRows {
+		return nil, false, err
+	}
+
+	// Either unknown or already used
+	query, args, err = r.db.Builder.Select("code_hash", "client_id", "user_id", "redirect_uri", "scopes", "code_challenge", "expires_at", "used_at").
+		From("oauth_authorization_codes").
+		Where(squirrel.Eq{"code_hash": codeHash}).
+		ToSql()
+	if err != nil {
+		return nil, false, err
+	}
+	code, err = scanOAuthCode(r.db.Pool.QueryRow(ctx, query, args...))
+	if err == pgx.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return code, false, nil
+}
+
+func scanOAuthCode(row pgx.Row) (*entity.OAuthAuthorizationCode, error) {
+	var (
+		code   entity.OAuthAuthorizationCode
+		scopes []string
+	)
+	err := row.Scan(&code.CodeHash, &code.ClientID, &code.UserID, &code.RedirectURI, &scopes, &code.CodeChallenge, &code.ExpiresAt, &code.UsedAt)
+	if err != nil {
+		return nil, err
+	}
+	code.Scopes = scopesFromStrings(scopes)
+	return &code, nil
+}
+
+// PurgeCodes deletes expired authorization codes and returns how many were deleted
+func (r *oauthRepository) PurgeCodes(ctx context.Context) (int64, error) {
+	query, args, err := r.db.Builder.Delete("oauth_authorization_codes").
+		Where(squirrel.Expr("expires_at < CURRENT_TIMESTAMP")).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+	tag, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/pkg/postgres"
+)
+
+// PersonalTokenRepository defines the interface for personal access token database operations
+type PersonalTokenRepository interface {
+	Create(ctx context.Context, token *entity.PersonalToken) error
+	GetByHash(ctx context.Context, hash string) (*entity.PersonalToken, error)
+	ListActiveByUserID(ctx context.Context, userID string) ([]*entity.PersonalToken, error)
+	Revoke(ctx context.Context, userID, id string) (bool, error)
+	Touch(ctx context.Context, id string, interval time.Duration) error
+}
+
+// personalTokenRepository implements PersonalTokenRepository
+type personalTokenRepository struct {
+	db *postgres.Postgres
+}
+
+// NewPersonalTokenRepository creates a new instance of PersonalTokenRepository
+func NewPersonalTokenRepository(db *postgres.Postgres) PersonalTokenRepository {
+	return &personalTokenRepository{db: db}
+}
+
+var personalTokenColumns = []string{
+	"token_id", "user_id", "name", "token_prefix", "token_hash", "scopes", "expires_at", "last_used_at", "created_at", "revoked_at",
+}
+
+func scanPersonalToken(row pgx.Row) (*entity.PersonalToken, error) {
+	var (
+		token  entity.PersonalToken
+		scopes []string
+	)
+	err := row.Scan(
+		&token.ID, &token.UserID, &token.Name, &token.Prefix, &token.TokenHash, &scopes,
+		&token.ExpiresAt, &token.LastUsedAt, &token.CreatedAt, &token.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	token.Scopes = make([]entity.Scope, len(scopes))
+	for i, scope := range scopes {
+		token.Scopes[i] = entity.Scope(scope)
+	}
+	return &token, nil
+}
+
+// Create stores a new token
+func (r *personalTokenRepository) Create(ctx context.Context, token *entity.PersonalToken) error {
+	scopes := make([]string, len(token.Scopes))
+	for i, scope := range token.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	query, args, err := r.db.Builder.Insert("personal_tokens").
+		Columns("token_id", "user_id", "name", "token_prefix", "token_hash", "scopes", "expires_at", "created_at").
+		Values(token.ID, token.UserID, token.Name, token.Prefix, token.TokenHash, scopes, token.ExpiresAt, token.CreatedAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+// GetByHash retrieves a token by its hash, revoked and expired tokens included
+func (r *personalTokenRepository) GetByHash(ctx context.Context, hash string) (*entity.PersonalToken, error) {
+	query, args, err := r.db.Builder.Select(personalTokenColumns...).
+		From("personal_tokens").
+		Where(squirrel.Eq{"token_hash": hash}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := scanPersonalToken(r.db.Pool.QueryRow(ctx, query, args...))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// ListActiveByUserID retrieves a user's tokens that are neither revoked nor expired, newest first
+func (r *personalTokenRepository) ListActiveByUserID(ctx context.Context, userID string) ([]*entity.PersonalToken, error) {
+	query, args, err := r.db.Builder.Select(personalTokenColumns...).
+		From("personal_tokens").
+		Where(squirrel.Eq{"user_id": userID, "revoked_at": nil}).
+		Where(squirrel.Or{squirrel.Eq{"expires_at": nil}, squirrel.Expr("expires_at > CURRENT_TIMESTAMP")}).
+		OrderBy("created_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*entity.PersonalToken
+	for rows.Next() {
+		token, err := scanPersonalToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// Revoke revokes one of a user's tokens and reports whether it was still active
+func (r *personalTokenRepository) Revoke(ctx context.Context, userID, id string) (bool, error) {
+	query, args, err := r.db.Builder.Update("personal_tokens").
+		Set("revoked_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"token_id": id, "user_id": userID, "revoked_at": nil}).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	tag, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Touch records that a token was used. It writes at most once per interval, so busy scripts do not write on every request.
+func (r *personalTokenRepository) Touch(ctx context.Context, id string, interval time.Duration) error {
+	query, args, err := r.db.Builder.Update("personal_tokens").
+		Set("last_used_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"token_id": id}).
+		Where(squirrel.Or{
+			squirrel.Eq{"last_used_at": nil},
+			squirrel.Lt{"last_used_at": time.Now().Add(-interval)},
+		}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
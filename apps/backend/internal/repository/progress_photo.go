@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/pkg/postgres"
+)
+
+// ProgressPhotoRepository defines the interface for progress photo database operations.
+// Photos of biometrics entries in the trash are left out of every read.
+type ProgressPhotoRepository interface {
+	Attach(ctx context.Context, photo *entity.ProgressPhoto) (*entity.ProgressPhoto, error)
+	GetByID(ctx context.Context, id string) (*entity.ProgressPhoto, error)
+	ListByUser(ctx context.Context, userID string, from, to time.Time) ([]*entity.ProgressPhoto, error)
+	ListByBiometrics(ctx context.Context, biometricsID string) ([]*entity.ProgressPhoto, error)
+	Delete(ctx context.Context, id string) error
+	ListOrphans(ctx context.Context, limit int) ([]*entity.ProgressPhoto, error)
+}
+
+// progressPhotoRepository implements ProgressPhotoRepository
+type progressPhotoRepository struct {
+	db *postgres.Postgres
+}
+
+// NewProgressPhotoRepository creates a new instance of ProgressPhotoRepository
+func NewProgressPhotoRepository(db *postgres.Postgres) ProgressPhotoRepository {
+	return &progressPhotoRepository{db: db}
+}
+
+const progressPhotoColumns = "p.photo_id, p.user_id, p.biometrics_id, b.log_date, p.pose, p.file_name, p.thumbnail_file_name, p.content_type, p.size_bytes, p.width, p.height, p.created_at"
+
+func scanProgressPhoto(row pgx.Row) (*entity.ProgressPhoto, error) {
+	var photo entity.ProgressPhoto
+	err := row.Scan(&photo.ID, &photo.UserID, &photo.BiometricsID, &photo.LogDate, &photo.Pose, &photo.FileName, &photo.ThumbnailFileName,
+		&photo.ContentType, &photo.Size, &photo.Width, &photo.Height, &photo.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
+
+// selectProgressPhotos selects photos together with the date of their biometrics entry, which must not be in the trash
+func (r *progressPhotoRepository) selectProgressPhotos() squirrel.SelectBuilder {
+	return r.db.Builder.Select(progressPhotoColumns).
+		From("progress_photos p").
+		Join("user_biometrics b ON b.biometrics_id = p.biometrics_id").
+		Where(squirrel.Eq{"b.deleted_at": nil})
+}
+
+// Attach stores a photo for the pose of a biometrics entry and returns the photo it replaced, nil if there was none
+func (r *progressPhotoRepository) Attach(ctx context.Context, photo *entity.ProgressPhoto) (*entity.ProgressPhoto, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op once committed
+
+	query, args, err := r.db.Builder.Delete("progress_photos p").
+		Where(squirrel.Eq{"p.biometrics_id": photo.BiometricsID, "p.pose": photo.Pose}).
+		Suffix("RETURNING p.photo_id, p.user_id, p.biometrics_id, p.created_at::DATE, p.pose, p.file_name, p.thumbnail_file_name, p.content_type, p.size_bytes, p.width, p.height, p.created_at").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	replaced, err := scanProgressPhoto(tx.QueryRow(ctx, query, args...))
+	if err == pgx.ErrNoRows {
+		replaced = nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	query, args, err = r.db.Builder.Insert("progress_photos").
+		Columns("photo_id", "user_id", "biometrics_id", "pose", "file_name", "thumbnail_file_name", "content_type", "size_bytes", "width", "height", "created_at").
+		Values(photo.ID, photo.UserID, photo.BiometricsID, photo.Pose, photo.FileName, photo.ThumbnailFileName, photo.ContentType,
+			photo.Size, photo.Width, photo.Height, photo.CreatedAt).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return replaced, nil
+}
+
+// GetByID retrieves a photo by ID
+func (r *progressPhotoRepository) GetByID(ctx context.Context, id string) (*entity.ProgressPhoto, error) {
+	query, args, err := r.selectProgressPhotos().
+		Where(squirrel.Eq{"p.photo_id": id}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	photo, err := scanProgressPhoto(r.db.Pool.QueryRow(ctx, query, args...))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return photo, nil
+}
+
+// ListByUser retrieves a user's photos of biometrics entries logged between two dates, inclusive, oldest first
+func (r *progressPhotoRepository) ListByUser(ctx context.Context, userID string, from, to time.Time) ([]*entity.ProgressPhoto, error) {
+	query, args, err := r.selectProgressPhotos().
+		Where(squirrel.Eq{"p.user_id": userID}).
+		Where(squirrel.GtOrEq{"b.log_date": from}).
+		Where(squirrel.LtOrEq{"b.log_date": to}).
+		OrderBy("b.log_date", "p.pose").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return r.query(ctx, query, args...)
+}
+
+// ListByBiometrics retrieves the photos of a biometrics entry
+func (r *progressPhotoRepository) ListByBiometrics(ctx context.Context, biometricsID string) ([]*entity.ProgressPhoto, error) {
+	query, args, err := r.selectProgressPhotos().
+		Where(squirrel.Eq{"p.biometrics_id": biometricsID}).
+		OrderBy("p.pose").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return r.query(ctx, query, args...)
+}
+
+// Delete removes the record of a photo
+func (r *progressPhotoRepository) Delete(ctx context.Context, id string) error {
+	query, args, err := r.db.Builder.Delete("progress_photos").
+		Where(squirrel.Eq{"photo_id": id}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+// ListOrphans retrieves photos whose biometrics entry no longer exists, after the trash was purged or the account erased
+func (r *progressPhotoRepository) ListOrphans(ctx context.Context, limit int) ([]*entity.ProgressPhoto, error) {
+	// Orphans have no entry to take the date from, the upload date stands in
+	query, args, err := r.db.Builder.Select("p.photo_id, p.user_id, p.biometrics_id, p.created_at::DATE, p.pose, p.file_name, p.thumbnail_file_name, p.content_type, p.size_bytes, p.width, p.height, p.created_at").
+		From("progress_photos p").
+		Where(squirrel.Expr("NOT EXISTS (SELECT 1 FROM user_biometrics b WHERE b.biometrics_id = p.biometrics_id)")).
+		OrderBy("p.created_at").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return r.query(ctx, query, args...)
+}
+
+func (r *progressPhotoRepository) query(ctx context.Context, query string, args ...interface{}) ([]*entity.ProgressPhoto, error) {
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var photos []*entity.ProgressPhoto
+	for rows.Next() {
+		photo, err := scanProgressPhoto(rows)
+		if err != nil {
+			return nil, err
+		}
+		photos = append(photos, photo)
+	}
+	return photos, rows.Err()
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/terrnit/rebound/backend/internal/entity"
+)
+
+// ErrInvalidQuery is returned when a filter or sort does not match the resource's query fields
+var ErrInvalidQuery = entity.NewValidationError("invalid_query", "invalid query")
+
+// Operator represents a comparison operator that can be used in a filter
+type Operator string
+
+const (
+	OpEq    Operator = "eq"
+	OpIn    Operator = "in"
+	OpGte   Operator = "gte"
+	OpLte   Operator = "lte"
+	OpILike Operator = "ilike"
+)
+
+// FieldType determines how raw filter values for a field are parsed
+type FieldType int
+
+const (
+	FieldString FieldType = iota
+	FieldNumber
+	FieldBool
+	FieldDate
+	FieldTime
+)
+
+// Field describes a column that can be filtered or sorted through the API
+type Field struct {
+	Column    string
+	Type      FieldType
+	Operators []Operator
+	Sortable  bool
+}
+
+// Fields maps public field names to their column definitions for a resource
+type Fields map[string]Field
+
+// Filter is a single typed condition on a field
+type Filter struct {
+	Field    string
+	Operator Operator
+	Value    interface{}
+}
+
+// Sort is a single ordering on a field
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// QuerySpec holds the filters and sort order applied to List and Count
+type QuerySpec struct {
+	Filters []Filter
+	Sort    []Sort
+}
+
+// Where returns a copy of the spec with an additional equality filter.
+// It is meant for server-side constraints such as scoping a list to a user.
+func (s QuerySpec) Where(field string, value interface{}) QuerySpec {
+	filters := make([]Filter, 0, len(s.Filters)+1)
+	filters = append(filters, s.Filters...)
+	s.Filters = append(filters, Filter{Field: field, Operator: OpEq, Value: value})
+	return s
+}
+
+// Filter parses raw into a typed filter on the named field.
+// For OpIn the raw value is a comma separated list.
+func (f Fields) Filter(name string, op Operator, raw string) (Filter, error) {
+	field, ok := f[name]
+	if !ok {
+		return Filter{}, ErrInvalidQuery.WithField("filter["+name+"]", "unknown field")
+	}
+	if !field.allows(op) {
+		return Filter{}, ErrInvalidQuery.WithField("filter["+name+"]", fmt.Sprintf("operator %q is not allowed", op))
+	}
+
+	if op == OpIn {
+		parts := strings.Split(raw, ",")
+		values := make([]interface{}, 0, len(parts))
+		for _, part := range parts {
+			value, err := field.parse(strings.TrimSpace(part))
+			if err != nil {
+				return Filter{}, ErrInvalidQuery.WithField("filter["+name+"]", err.Error())
+			}
+			values = append(values, value)
+		}
+		return Filter{Field: name, Operator: op, Value: values}, nil
+	}
+
+	value, err := field.parse(raw)
+	if err != nil {
+		return Filter{}, ErrInvalidQuery.WithField("filter["+name+"]", err.Error())
+	}
+	return Filter{Field: name, Operator: op, Value: value}, nil
+}
+
+// Sort validates that the named field can be used for ordering
+func (f Fields) Sort(name string, desc bool) (Sort, error) {
+	field, ok := f[name]
+	if !ok || !field.Sortable {
+		return Sort{}, ErrInvalidQuery.WithField("sort", fmt.Sprintf("cannot sort by %q", name))
+	}
+	return Sort{Field: name, Desc: desc}, nil
+}
+
+// where applies the spec's filters to the query
+func (f Fields) where(query squirrel.SelectBuilder, spec QuerySpec) (squirrel.SelectBuilder, error) {
+	for _, filter := range spec.Filters {
+		field, ok := f[filter.Field]
+		if !ok {
+			return query, ErrInvalidQuery.WithField("filter["+filter.Field+"]", "unknown field")
+		}
+
+		switch filter.Operator {
+		case OpEq, OpIn:
+			query = query.Where(squirrel.Eq{field.Column: filter.Value})
+		case OpGte:
+			query = query.Where(squirrel.GtOrEq{field.Column: filter.Value})
+		case OpLte:
+			query = query.Where(squirrel.LtOrEq{field.Column: filter.Value})
+		case OpILike:
+			query = query.Where(squirrel.ILike{field.Column: "%" + fmt.Sprint(filter.Value) + "%"})
+		default:
+			return query, ErrInvalidQuery.WithField("filter["+filter.Field+"]", fmt.Sprintf("unknown operator %q", filter.Operator))
+		}
+	}
+	return query, nil
+}
+
+// orderBy applies the spec's sort order to the query, falling back to defaults
+func (f Fields) orderBy(query squirrel.SelectBuilder, spec QuerySpec, defaults ...string) (squirrel.SelectBuilder, error) {
+	if len(spec.Sort) == 0 {
+		return query.OrderBy(defaults...), nil
+	}
+
+	clauses := make([]string, 0, len(spec.Sort))
+	for _, s := range spec.Sort {
+		field, ok := f[s.Field]
+		if !ok || !field.Sortable {
+			return query, ErrInvalidQuery.WithField("sort", fmt.Sprintf("cannot sort by %q", s.Field))
+		}
+		if s.Desc {
+			clauses = append(clauses, field.Column+" DESC")
+		} else {
+			clauses = append(clauses, field.Column+" ASC")
+		}
+	}
+	return query.OrderBy(clauses...), nil
+}
+
+func (field Field) allows(op Operator) bool {
+	for _, allowed := range field.Operators {
+		if allowed == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (field Field) parse(raw string) (interface{}, error) {
+	switch field.Type {
+	case FieldNumber:
+		return strconv.ParseFloat(raw, 64)
+	case FieldBool:
+		return strconv.ParseBool(raw)
+	case FieldDate:
+		return time.Parse(time.DateOnly, raw)
+	case FieldTime:
+		return time.Parse(time.RFC3339, raw)
+	default:
+		return raw, nil
+	}
+}
+
+// Operator sets shared by the resource field definitions
+var (
+	textOps  = []Operator{OpEq, OpIn, OpILike}
+	enumOps  = []Operator{OpEq, OpIn}
+	rangeOps = []Operator{OpEq, OpIn, OpGte, OpLte}
+	boolOps  = []Operator{OpEq}
+)
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+var testFields = Fields{
+	"name":       {Column: "name", Type: FieldString, Operators: textOps, Sortable: true},
+	"calories":   {Column: "calories", Type: FieldNumber, Operators: rangeOps, Sortable: true},
+	"verified":   {Column: "is_verified", Type: FieldBool, Operators: boolOps},
+	"date":       {Column: "log_date", Type: FieldDate, Operators: rangeOps},
+	"created_at": {Column: "created_at", Type: FieldTime, Operators: rangeOps},
+}
+
+func TestFields_Filter(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   string
+		op      Operator
+		raw     string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "string", field: "name", op: OpILike, raw: "oat", want: "oat"},
+		{name: "number", field: "calories", op: OpGte, raw: "120.5", want: 120.5},
+		{name: "bool", field: "verified", op: OpEq, raw: "true", want: true},
+		{name: "date", field: "date", op: OpLte, raw: "2026-10-19", want: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{name: "time", field: "created_at", op: OpGte, raw: "2026-10-19T09:00:00Z", want: time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)},
+		{name: "list", field: "calories", op: OpIn, raw: "1, 2,3", want: []interface{}{1.0, 2.0, 3.0}},
+		{name: "unknown field", field: "password_hash", op: OpEq, raw: "x", wantErr: true},
+		{name: "operator not allowed", field: "verified", op: OpGte, raw: "true", wantErr: true},
+		{name: "malformed number", field: "calories", op: OpEq, raw: "many", wantErr: true},
+		{name: "malformed list item", field: "calories", op: OpIn, raw: "1,two", wantErr: true},
+		{name: "malformed date", field: "date", op: OpEq, raw: "19/10/2026", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testFields.Filter(tt.field, tt.op, tt.raw)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidQuery) {
+					t.Fatalf("Filter() error = %v, want %v", err, ErrInvalidQuery)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Filter() error = %v", err)
+			}
+			want := Filter{Field: tt.field, Operator: tt.op, Value: tt.want}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Filter() = %#v, want %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestFields_Sort(t *testing.T) {
+	if _, err := testFields.Sort("calories", true); err != nil {
+		t.Errorf("Sort(calories) error = %v", err)
+	}
+	for _, name := range []string{"verified", "unknown"} {
+		if _, err := testFields.Sort(name, false); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("Sort(%s) error = %v, want %v", name, err, ErrInvalidQuery)
+		}
+	}
+}
+
+func TestQuerySpec_Where(t *testing.T) {
+	spec := QuerySpec{Filters: make([]Filter, 1, 2)}
+	a := spec.Where("name", "a")
+	b := spec.Where("name", "b")
+
+	if len(spec.Filters) != 1 {
+		t.Errorf("Where() modified the original spec")
+	}
+	if a.Filters[1].Value != "a" || b.Filters[1].Value != "b" {
+		t.Errorf("Where() copies share their filters: %v, %v", a.Filters, b.Filters)
+	}
+}
+
+func TestFields_WhereAndOrderBy(t *testing.T) {
+	spec := QuerySpec{
+		Filters: []Filter{
+			{Field: "name", Operator: OpILike, Value: "oat"},
+			{Field: "calories", Operator: OpGte, Value: 100.0},
+			{Field: "calories", Operator: OpIn, Value: []interface{}{1.0, 2.0}},
+		},
+		Sort: []Sort{{Field: "calories", Desc: true}, {Field: "name"}},
+	}
+
+	query := squirrel.Select("id").From("food_items")
+	query, err := testFields.where(query, spec)
+	if err != nil {
+		t.Fatalf("where() error = %v", err)
+	}
+	query, err = testFields.orderBy(query, spec, "created_at DESC")
+	if err != nil {
+		t.Fatalf("orderBy() error = %v", err)
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSQL := "SELECT id FROM food_items WHERE name ILIKE ? AND calories >= ? AND calories IN (?,?) ORDER BY calories DESC, name ASC"
+	if sql != wantSQL {
+		t.Errorf("sql = %q, want %q", sql, wantSQL)
+	}
+	if wantArgs := []interface{}{"%oat%", 100.0, 1.0, 2.0}; !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %v, want %v", args, wantArgs)
+	}
+
+	sql, _, _ = mustOrderBy(t, QuerySpec{}).ToSql()
+	if want := "SELECT id FROM food_items ORDER BY created_at DESC"; sql != want {
+		t.Errorf("default order sql = %q, want %q", sql, want)
+	}
+
+	if _, err := testFields.where(squirrel.Select("id"), QuerySpec{Filters: []Filter{{Field: "unknown", Operator: OpEq}}}); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("where() with an unknown field error = %v, want %v", err, ErrInvalidQuery)
+	}
+	if _, err := testFields.orderBy(squirrel.Select("id"), QuerySpec{Sort: []Sort{{Field: "verified"}}}); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("orderBy() with an unsortable field error = %v, want %v", err, ErrInvalidQuery)
+	}
+}
+
+func mustOrderBy(t *testing.T, spec QuerySpec) squirrel.SelectBuilder {
+	t.Helper()
+	query, err := testFields.orderBy(squirrel.Select("id").From("food_items"), spec, "created_at DESC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return query
+}
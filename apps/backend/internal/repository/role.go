@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/pkg/postgres"
+)
+
+// RoleRepository defines the interface for role and role assignment database operations
+type RoleRepository interface {
+	GetByName(ctx context.Context, name string) (*entity.Role, error)
+	ListByUserID(ctx context.Context, userID string) ([]*entity.Role, error)
+	Assign(ctx context.Context, userRole *entity.UserRole) (bool, error)
+	Revoke(ctx context.Context, userID string, roleID int) (bool, error)
+}
+
+// roleRepository implements RoleRepository
+type roleRepository struct {
+	db *postgres.Postgres
+}
+
+// NewRoleRepository creates a new instance of RoleRepository
+func NewRoleRepository(db *postgres.Postgres) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+// GetByName retrieves a role by its name
+func (r *roleRepository) GetByName(ctx context.Context, name string) (*entity.Role, error) {
+	query, args, err := r.db.Builder.Select("role_id", "role_name", "description").
+		From("roles").
+		Where(squirrel.Eq{"role_name": name}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var role entity.Role
+	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(&role.ID, &role.Name, &role.Description)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// ListByUserID returns the roles assigned to a user
+func (r *roleRepository) ListByUserID(ctx context.Context, userID string) ([]*entity.Role, error) {
+	query, args, err := r.db.Builder.Select("roles.role_id", "roles.role_name", "roles.description").
+		From("roles").
+		Join("user_roles ON user_roles.role_id = roles.role_id").
+		Where(squirrel.Eq{"user_roles.user_id": userID}).
+		OrderBy("roles.role_name").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*entity.Role
+	for rows.Next() {
+		var role entity.Role
+		if err := rows.Scan(&role.ID, &role.Name, &role.Description); err != nil {
+			return nil, err
+		}
+		roles = append(roles, &role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// Assign assigns a role to a user and reports whether it was not assigned before
+func (r *roleRepository) Assign(ctx context.Context, userRole *entity.UserRole) (bool, error) {
+	query, args, err := r.db.Builder.Insert("user_roles").
+		Columns("user_id", "role_id", "assigned_at").
+		Values(userRole.UserID, userRole.RoleID, userRole.AssignedAt).
+		Suffix("ON CONFLICT (user_id, role_id) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	tag, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Revoke removes a role from a user and reports whether it was assigned
+func (r *roleRepository) Revoke(ctx context.Context, userID string, roleID int) (bool, error) {
+	query, args, err := r.db.Builder.Delete("user_roles").
+		Where(squirrel.Eq{"user_id": userID, "role_id": roleID}).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	tag, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
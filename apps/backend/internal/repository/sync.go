@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/pkg/postgres"
+)
+
+// SyncRepository defines the interface for reading the sync change feed.
+// The feed is written by database triggers on the synced tables.
+type SyncRepository interface {
+	ListChanges(ctx context.Context, userID, token string, limit int) ([]*entity.SyncChange, SyncPosition, error)
+	GetChange(ctx context.Context, entityType entity.SyncEntityType, entityID string) (*entity.SyncChange, error)
+}
+
+// SyncPosition tells a client how far it has read the change feed
+type SyncPosition struct {
+	// Token is the opaque sync token to pass to the next ListChanges call
+	Token string
+	// HasMore is set when more changes are ready to be read right away
+	HasMore bool
+}
+
+// syncToken is the decoded position after the last change a client has seen
+type syncToken struct {
+	txid     int64
+	changeID int64
+}
+
+func (t syncToken) encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d.%d", t.txid, t.changeID)))
+}
+
+func decodeSyncToken(s string) (syncToken, error) {
+	var t syncToken
+	if s == "" {
+		return t, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return t, ErrInvalidQuery.WithField("since", "malformed sync token")
+	}
+	if _, err := fmt.Sscanf(string(data), "%d.%d", &t.txid, &t.changeID); err != nil {
+		return t, ErrInvalidQuery.WithField("since", "malformed sync token")
+	}
+
+	return t, nil
+}
+
+// syncRepository implements SyncRepository
+type syncRepository struct {
+	db *postgres.Postgres
+}
+
+// NewSyncRepository creates a new instance of SyncRepository
+func NewSyncRepository(db *postgres.Postgres) SyncRepository {
+	return &syncRepository{db: db}
+}
+
+// ListChanges returns the changes of a user's records after token in the order they were committed.
+// Changes of transactions that may still be running are held back, so that a token never moves past
+// a change that has not become visible yet.
+func (r *syncRepository) ListChanges(ctx context.Context, userID, token string, limit int) ([]*entity.SyncChange, SyncPosition, error) {
+	since, err := decodeSyncToken(token)
+	if err != nil {
+		return nil, SyncPosition{}, err
+	}
+
+	query, args, err := r.db.Builder.Select("entity_type", "entity_id", "user_id", "operation", "changed_at", "txid", "change_id").
+		From("sync_changes").
+		Where(squirrel.Eq{"user_id": userID}).
+		Where("(txid, change_id) > (?, ?)", since.txid, since.changeID).
+		Where("txid < pg_snapshot_xmin(pg_current_snapshot())::TEXT::BIGINT").
+		OrderBy("txid", "change_id").
+		Limit(uint64(limit + 1)).
+		ToSql()
+	if err != nil {
+		return nil, SyncPosition{}, err
+	}
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, SyncPosition{}, err
+	}
+	defer rows.Close()
+
+	var changes []*entity.SyncChange
+	last := since
+	position := SyncPosition{}
+	for rows.Next() {
+		if len(changes) == limit {
+			position.HasMore = true
+			break
+		}
+		var change entity.SyncChange
+		err := rows.Scan(&change.EntityType, &change.EntityID, &change.UserID, &change.Operation, &change.ChangedAt, &last.txid, &last.changeID)
+		if err != nil {
+			return nil, SyncPosition{}, err
+		}
+		changes = append(changes, &change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, SyncPosition{}, err
+	}
+
+	position.Token = last.encode()
+	return changes, position, nil
+}
+
+// GetChange retrieves the latest change of a record, which is a tombstone if the record was deleted
+func (r *syncRepository) GetChange(ctx context.Context, entityType entity.SyncEntityType, entityID string) (*entity.SyncChange, error) {
+	query, args, err := r.db.Builder.Select("entity_type", "entity_id", "user_id", "operation", "changed_at").
+		From("sync_changes").
+		Where(squirrel.Eq{"entity_type": entityType, "entity_id": entityID}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var change entity.SyncChange
+	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(&change.EntityType, &change.EntityID, &change.UserID, &change.Operation, &change.ChangedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/pkg/postgres"
+)
+
+// TrashRepository defines the interface for soft deleted records
+type TrashRepository interface {
+	List(ctx context.Context, userID string, since time.Time) ([]*entity.TrashItem, error)
+	Restore(ctx context.Context, userID string, itemType entity.TrashItemType, id string, since time.Time) (bool, error)
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// trashTable describes how a soft deleted table is listed, restored and purged
+type trashTable struct {
+	itemType entity.TrashItemType
+	table    string
+	idColumn string
+	// owner is a condition on the owner's user ID
+	owner string
+	// title is an expression that labels a row in the trash
+	title string
+	// purgeable is an extra condition a row must meet before it is purged
+	purgeable string
+}
+
+// trashTables lists the soft deleted tables in the order they are purged, children first
+var trashTables = []trashTable{
+	{
+		itemType: entity.TrashItemSessionLog,
+		table:    "user_workout_session_logs",
+		idColumn: "log_id",
+		owner:    "session_id IN (SELECT session_id FROM user_workout_sessions WHERE user_id = ? AND deleted_at IS NULL)",
+		title:    "'Set ' || set_number",
+	},
+	{
+		itemType: entity.TrashItemWorkoutSession,
+		table:    "user_workout_sessions",
+		idColumn: "session_id",
+		owner:    "user_id = ?",
+		title:    "COALESCE(session_name, 'Workout session')",
+	},
+	{
+		itemType: entity.TrashItemMeal,
+		table:    "user_meals",
+		idColumn: "meal_id",
+		owner:    "user_id = ?",
+		title:    "COALESCE(custom_meal_name, meal_type::TEXT || ' ' || meal_date::TEXT)",
+	},
+	{
+		itemType: entity.TrashItemBiometrics,
+		table:    "user_biometrics",
+		idColumn: "biometrics_id",
+		owner:    "user_id = ?",
+		title:    "'Biometrics ' || log_date::TEXT",
+	},
+	{
+		itemType: entity.TrashItemWorkoutPlan,
+		table:    "workout_plans",
+		idColumn: "plan_id",
+		owner:    "user_id = ?",
+		title:    "plan_name",
+	},
+	{
+		itemType: entity.TrashItemFoodItem,
+		table:    "food_items",
+		idColumn: "id",
+		owner:    "created_by_user_id = ?",
+		title:    "name",
+		// Food items still used by a logged meal are kept until the meal is gone
+		purgeable: "NOT EXISTS (SELECT 1 FROM meal_food_items WHERE meal_food_items.food_item_id = food_items.id)",
+	},
+}
+
+// listQuery selects a user's rows deleted after since
+func (t trashTable) listQuery(b squirrel.StatementBuilderType, userID string, since time.Time) squirrel.SelectBuilder {
+	return b.Select(t.idColumn, t.title, "deleted_at").
+		From(t.table).
+		Where(squirrel.Gt{"deleted_at": since}).
+		Where(t.owner, userID)
+}
+
+// restoreQuery clears the deletion of a user's row deleted after since
+func (t trashTable) restoreQuery(b squirrel.StatementBuilderType, userID, id string, since time.Time) squirrel.UpdateBuilder {
+	return b.Update(t.table).
+		Set("deleted_at", nil).
+		Where(squirrel.Eq{t.idColumn: id}).
+		Where(squirrel.Gt{"deleted_at": since}).
+		Where(t.owner, userID)
+}
+
+// purgeQuery deletes the purgeable rows deleted before before
+func (t trashTable) purgeQuery(b squirrel.StatementBuilderType, before time.Time) squirrel.DeleteBuilder {
+	q := b.Delete(t.table).
+		Where(squirrel.LtOrEq{"deleted_at": before})
+	if t.purgeable != "" {
+		q = q.Where(t.purgeable)
+	}
+	return q
+}
+
+func trashTableOf(itemType entity.TrashItemType) (trashTable, bool) {
+	for _, t := range trashTables {
+		if t.itemType == itemType {
+			return t, true
+		}
+	}
+	return trashTable{}, false
+}
+
+// trashRepository implements TrashRepository
+type trashRepository struct {
+	db *postgres.Postgres
+}
+
+// NewTrashRepository creates a new instance of TrashRepository
+func NewTrashRepository(db *postgres.Postgres) TrashRepository {
+	return &trashRepository{db: db}
+}
+
+// List returns a user's records deleted after since, most recently deleted first
+func (r *trashRepository) List(ctx context.Context, userID string, since time.Time) ([]*entity.TrashItem, error) {
+	var items []*entity.TrashItem
+	for _, t := range trashTables {
+		query, args, err := t.listQuery(r.db.Builder, userID, since).ToSql()
+		if err != nil {
+			return nil, err
+		}
+		rows, err := r.db.Pool.Query(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			item := &entity.TrashItem{Type: t.itemType}
+			if err := rows.Scan(&item.ID, &item.Title, &item.DeletedAt); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// Restore takes a user's record deleted after since out of the trash and reports whether it was found
+func (r *trashRepository) Restore(ctx context.Context, userID string, itemType entity.TrashItemType, id string, since time.Time) (bool, error) {
+	t, ok := trashTableOf(itemType)
+	if !ok {
+		return false, nil
+	}
+
+	query, args, err := t.restoreQuery(r.db.Builder, userID, id, since).ToSql()
+	if err != nil {
+		return false, err
+	}
+	tag, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Purge permanently deletes records deleted before before and returns how many were deleted
+func (r *trashRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	for _, t := range trashTables {
+		query, args, err := t.purgeQuery(r.db.Builder, before).ToSql()
+		if err != nil {
+			return purged, err
+		}
+		tag, err := r.db.Pool.Exec(ctx, query, args...)
+		if err != nil {
+			return purged, err
+		}
+		purged += tag.RowsAffected()
+	}
+	return purged, nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+)
+
+func TestTrashTables_PurgeOrder(t *testing.T) {
+	order := map[string]int{}
+	for i, table := range trashTables {
+		order[table.table] = i
+	}
+
+	// A row is purged before the rows it references, so that a single purge clears both
+	references := []struct{ child, parent string }{
+		{child: "user_workout_session_logs", parent: "user_workout_sessions"},
+		// Purging a meal drops its meal_food_items, which keep food items from being purged
+		{child: "user_meals", parent: "food_items"},
+	}
+	for _, ref := range references {
+		child, ok := order[ref.child]
+		parent, ok2 := order[ref.parent]
+		if !ok || !ok2 {
+			t.Fatalf("trashTables lacks %s or %s", ref.child, ref.parent)
+		}
+		if child > parent {
+			t.Errorf("%s is purged after %s", ref.child, ref.parent)
+		}
+	}
+}
+
+func TestTrashTables_Types(t *testing.T) {
+	types := []entity.TrashItemType{
+		entity.TrashItemSessionLog,
+		entity.TrashItemWorkoutSession,
+		entity.TrashItemMeal,
+		entity.TrashItemBiometrics,
+		entity.TrashItemWorkoutPlan,
+		entity.TrashItemFoodItem,
+	}
+	for _, itemType := range types {
+		if table, ok := trashTableOf(itemType); !ok || table.itemType != itemType {
+			t.Errorf("trashTableOf(%s) = %s, %v", itemType, table.table, ok)
+		}
+	}
+	if _, ok := trashTableOf("user"); ok {
+		t.Errorf("trashTableOf(user) found a table")
+	}
+}
+
+func TestTrashTable_Queries(t *testing.T) {
+	b := squirrel.StatementBuilder
+	food, _ := trashTableOf(entity.TrashItemFoodItem)
+	cutoff := time.Date(2026, 9, 19, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		query    squirrel.Sqlizer
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "list",
+			query:    food.listQuery(b, "alice", cutoff),
+			wantSQL:  "SELECT id, name, deleted_at FROM food_items WHERE deleted_at > ? AND created_by_user_id = ?",
+			wantArgs: []interface{}{cutoff, "alice"},
+		},
+		{
+			name:     "restore",
+			query:    food.restoreQuery(b, "alice", "f1", cutoff),
+			wantSQL:  "UPDATE food_items SET deleted_at = ? WHERE id = ? AND deleted_at > ? AND created_by_user_id = ?",
+			wantArgs: []interface{}{nil, "f1", cutoff, "alice"},
+		},
+		{
+			name:     "purge",
+			query:    food.purgeQuery(b, cutoff),
+			wantSQL:  "DELETE FROM food_items WHERE deleted_at <= ? AND " + food.purgeable,
+			wantArgs: []interface{}{cutoff},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.query.ToSql()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("SQL = %s, want %s", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+
+	// Without a purge condition, everything past retention goes
+	sessions, _ := trashTableOf(entity.TrashItemWorkoutSession)
+	sql, _, err := sessions.purgeQuery(b, cutoff).ToSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "DELETE FROM user_workout_sessions WHERE deleted_at <= ?"; sql != want {
+		t.Errorf("SQL = %s, want %s", sql, want)
+	}
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/pkg/postgres"
+)
+
+// TwoFactorRepository defines the interface for two-factor authentication database operations
+type TwoFactorRepository interface {
+	GetByUserID(ctx context.Context, userID string) (*entity.TwoFactor, error)
+	Enroll(ctx context.Context, userID, secret string) error
+	Enable(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) (bool, error)
+	UseStep(ctx context.Context, userID string, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error
+	Delete(ctx context.Context, userID string) error
+}
+
+// twoFactorRepository implements TwoFactorRepository
+type twoFactorRepository struct {
+	db *postgres.Postgres
+}
+
+// NewTwoFactorRepository creates a new instance of TwoFactorRepository
+func NewTwoFactorRepository(db *postgres.Postgres) TwoFactorRepository {
+	return &twoFactorRepository{db: db}
+}
+
+// GetByUserID retrieves a user's two-factor configuration, confirmed or not
+func (r *twoFactorRepository) GetByUserID(ctx context.Context, userID string) (*entity.TwoFactor, error) {
+	query, args, err := r.db.Builder.Select("user_id", "secret", "enabled_at", "last_used_step", "created_at").
+		From("user_two_factor").
+		Where(squirrel.Eq{"user_id": userID}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var tf entity.TwoFactor
+	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(&tf.UserID, &tf.Secret, &tf.EnabledAt, &tf.LastUsedStep, &tf.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tf, nil
+}
+
+// Enroll starts an enrollment with a new secret, replacing an enrollment that was not confirmed
+func (r *twoFactorRepository) Enroll(ctx context.Context, userID, secret string) error {
+	query, args, err := r.db.Builder.Insert("user_two_factor").
+		Columns("user_id", "secret").
+		Values(userID, secret).
+		Suffix("ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = NULL, created_at = CURRENT_TIMESTAMP WHERE user_two_factor.enabled_at IS NULL").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+// Enable confirms an enrollment with the step of its first code and stores the recovery codes,
+// it reports whether there was an enrollment to confirm
+func (r *twoFactorRepository) Enable(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) (bool, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op once committed
+
+	query, args, err := r.db.Builder.Update("user_two_factor").
+		Set("enabled_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Set("last_used_step", step).
+		Where(squirrel.Eq{"user_id": userID, "enabled_at": nil}).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if err := r.replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// UseStep records that the code of step was accepted and reports whether it is newer than the last one,
+// so that a code cannot be replayed
+func (r *twoFactorRepository) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	query, args, err := r.db.Builder.Update("user_two_factor").
+		Set("last_used_step", step).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Or{squirrel.Eq{"last_used_step": nil}, squirrel.Lt{"last_used_step": step}}).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	tag, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// UseRecoveryCode uses up an unused recovery code and reports whether there was one
+func (r *twoFactorRepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	query, args, err := r.db.Builder.Update("user_recovery_codes").
+		Set("used_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"user_id": userID, "code_hash": codeHash, "used_at": nil}).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	tag, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ReplaceRecoveryCodes replaces all of a user's recovery codes
+func (r *twoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op once committed
+
+	if err := r.replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *twoFactorRepository) replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID string, codeHashes []string) error {
+	query, args, err := r.db.Builder.Delete("user_recovery_codes").
+		Where(squirrel.Eq{"user_id": userID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return err
+	}
+
+	insert := r.db.Builder.Insert("user_recovery_codes").Columns("user_id", "code_hash")
+	for _, hash := range codeHashes {
+		insert = insert.Values(userID, hash)
+	}
+	query, args, err = insert.ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, query, args...)
+	return err
+}
+
+// Delete turns two-factor authentication off and removes the recovery codes
+func (r *twoFactorRepository) Delete(ctx context.Context, userID string) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op once committed
+
+	for _, table := range []string{"user_recovery_codes", "user_two_factor"} {
+		query, args, err := r.db.Builder.Delete(table).
+			Where(squirrel.Eq{"user_id": userID}).
+			ToSql()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/pkg/postgres"
+)
+
+// UploadRepository defines the interface for uploaded image database operations
+type UploadRepository interface {
+	Attach(ctx context.Context, upload *entity.Upload) ([]*entity.Upload, error)
+	ListOrphans(ctx context.Context, limit int) ([]*entity.Upload, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// uploadRepository implements UploadRepository
+type uploadRepository struct {
+	db *postgres.Postgres
+}
+
+// NewUploadRepository creates a new instance of UploadRepository
+func NewUploadRepository(db *postgres.Postgres) UploadRepository {
+	return &uploadRepository{db: db}
+}
+
+// uploadTargets maps each target to its table, key column and the columns the URLs are written to
+var uploadTargets = map[entity.UploadTarget]struct {
+	table, key, urlColumn, thumbnailColumn string
+}{
+	entity.UploadTargetProfilePicture:   {table: "users", key: "user_id", urlColumn: "profile_picture_url"},
+	entity.UploadTargetExerciseImage:    {table: "exercises", key: "exercise_id", urlColumn: "image_url_main", thumbnailColumn: "image_url_thumbnail"},
+	entity.UploadTargetWorkoutPlanCover: {table: "workout_plans", key: "plan_id", urlColumn: "cover_image_url"},
+}
+
+const uploadColumns = "upload_id, target, target_id, file_name, thumbnail_file_name, content_type, size_bytes, width, height, uploaded_by, created_at"
+
+func scanUpload(row pgx.Row) (*entity.Upload, error) {
+	var upload entity.Upload
+	err := row.Scan(&upload.ID, &upload.Target, &upload.TargetID, &upload.FileName, &upload.ThumbnailFileName, &upload.ContentType,
+		&upload.Size, &upload.Width, &upload.Height, &upload.UploadedBy, &upload.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// Attach records an upload and writes its URLs to its target in one transaction.
+// It returns the uploads the new one replaced, whose files the caller removes from upload storage.
+func (r *uploadRepository) Attach(ctx context.Context, upload *entity.Upload) ([]*entity.Upload, error) {
+	target, ok := uploadTargets[upload.Target]
+	if !ok {
+		return nil, fmt.Errorf("repository - Attach: unknown upload target %q", upload.Target)
+	}
+
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op once committed
+
+	// Updating the target first locks its row, so that concurrent uploads to it replace each other in turn
+	update := r.db.Builder.Update(target.table).
+		Set(target.urlColumn, upload.URL).
+		Set("updated_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{target.key: upload.TargetID})
+	if target.thumbnailColumn != "" {
+		update = update.Set(target.thumbnailColumn, upload.ThumbnailURL)
+	}
+	query, args, err := update.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	query, args, err = r.db.Builder.Delete("uploads").
+		Where(squirrel.Eq{"target": upload.Target, "target_id": upload.TargetID}).
+		Suffix("RETURNING " + uploadColumns).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	replaced, err := collectUploads(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	query, args, err = r.db.Builder.Insert("uploads").
+		Columns("upload_id", "target", "target_id", "file_name", "thumbnail_file_name", "content_type", "size_bytes", "width", "height", "uploaded_by", "created_at").
+		Values(upload.ID, upload.Target, upload.TargetID, upload.FileName, upload.ThumbnailFileName, upload.ContentType,
+			upload.Size, upload.Width, upload.Height, upload.UploadedBy, upload.CreatedAt).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return replaced, nil
+}
+
+// ListOrphans retrieves uploads whose target no longer exists, such as the profile picture of an erased account
+func (r *uploadRepository) ListOrphans(ctx context.Context, limit int) ([]*entity.Upload, error) {
+	orphaned := squirrel.Or{}
+	for name, target := range uploadTargets {
+		orphaned = append(orphaned, squirrel.And{
+			squirrel.Eq{"target": name},
+			squirrel.Expr(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s WHERE %s.%s = uploads.target_id)", target.table, target.table, target.key)),
+		})
+	}
+
+	query, args, err := r.db.Builder.Select(uploadColumns).
+		From("uploads").
+		Where(orphaned).
+		OrderBy("created_at").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return collectUploads(rows)
+}
+
+// Delete removes the record of an upload
+func (r *uploadRepository) Delete(ctx context.Context, id string) error {
+	query, args, err := r.db.Builder.Delete("uploads").
+		Where(squirrel.Eq{"upload_id": id}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+func collectUploads(rows pgx.Rows) ([]*entity.Upload, error) {
+	defer rows.Close()
+
+	var uploads []*entity.Upload
+	for rows.Next() {
+		upload, err := scanUpload(rows)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, upload)
+	}
+	return uploads, rows.Err()
+}
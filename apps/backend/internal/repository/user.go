@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
@@ -17,13 +18,31 @@ type UserRepository interface {
 	GetByEmail(ctx context.Context, email string) (*entity.User, error)
 	GetByUsername(ctx context.Context, username string) (*entity.User, error)
 	Update(ctx context.Context, user *entity.User) error
-	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]*entity.User, error)
-	Count(ctx context.Context, filters map[string]interface{}) (int64, error)
+	ScheduleDeletion(ctx context.Context, id string, at time.Time) error
+	CancelDeletion(ctx context.Context, id string) (bool, error)
+	ListDueDeletions(ctx context.Context, now time.Time, limit int) ([]string, error)
+	Erase(ctx context.Context, id string) ([]string, error)
+	List(ctx context.Context, spec QuerySpec, page, pageSize int) ([]*entity.User, error)
+	Count(ctx context.Context, spec QuerySpec) (int64, error)
 	UpdateLastLogin(ctx context.Context, id string) error
+	RecordLoginFailure(ctx context.Context, id string, lockout Lockout) (*time.Time, error)
 	UpdateEmailVerification(ctx context.Context, id string, isVerified bool) error
 }
 
+// UserFields lists the user columns that can be filtered and sorted through the API
+var UserFields = Fields{
+	"username":              {Column: "username", Type: FieldString, Operators: textOps, Sortable: true},
+	"email":                 {Column: "email", Type: FieldString, Operators: textOps, Sortable: true},
+	"first_name":            {Column: "first_name", Type: FieldString, Operators: textOps, Sortable: true},
+	"last_name":             {Column: "last_name", Type: FieldString, Operators: textOps, Sortable: true},
+	"gender":                {Column: "gender", Type: FieldString, Operators: enumOps},
+	"is_active":             {Column: "is_active", Type: FieldBool, Operators: boolOps},
+	"is_email_verified":     {Column: "is_email_verified", Type: FieldBool, Operators: boolOps},
+	"last_login_at":         {Column: "last_login_at", Type: FieldTime, Operators: rangeOps, Sortable: true},
+	"deletion_scheduled_at": {Column: "deletion_scheduled_at", Type: FieldTime, Operators: rangeOps, Sortable: true},
+	"created_at":            {Column: "created_at", Type: FieldTime, Operators: rangeOps, Sortable: true},
+}
+
 // userRepository implements UserRepository
 type userRepository struct {
 	db *postgres.Postgres
@@ -52,7 +71,7 @@ func (r *userRepository) Create(ctx context.Context, user *entity.User) (*entity
 
 // GetByID retrieves a user by their ID
 func (r *userRepository) GetByID(ctx context.Context, id string) (*entity.User, error) {
-	query, args, err := r.db.Builder.Select("user_id", "username", "email", "password_hash", "first_name", "last_name", "date_of_birth", "gender", "profile_picture_url", "is_active", "is_email_verified", "last_login_at", "created_at", "updated_at").
+	query, args, err := r.db.Builder.Select("user_id", "username", "email", "password_hash", "first_name", "last_name", "date_of_birth", "gender", "profile_picture_url", "is_active", "is_email_verified", "last_login_at", "deletion_scheduled_at", "locked_until", "created_at", "updated_at").
 		From("users").
 		Where(squirrel.Eq{"user_id": id}).
 		ToSql()
@@ -62,7 +81,7 @@ func (r *userRepository) GetByID(ctx context.Context, id string) (*entity.User,
 
 	var user entity.User
 	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(
-		&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.DateOfBirth, &user.Gender, &user.ProfilePictureURL, &user.IsActive, &user.IsEmailVerified, &user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
+		&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.DateOfBirth, &user.Gender, &user.ProfilePictureURL, &user.IsActive, &user.IsEmailVerified, &user.LastLoginAt, &user.DeletionScheduledAt, &user.LockedUntil, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -75,7 +94,7 @@ func (r *userRepository) GetByID(ctx context.Context, id string) (*entity.User,
 
 // GetByEmail retrieves a user by their email
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
-	query, args, err := r.db.Builder.Select("user_id", "username", "email", "password_hash", "first_name", "last_name", "date_of_birth", "gender", "profile_picture_url", "is_active", "is_email_verified", "last_login_at", "created_at", "updated_at").
+	query, args, err := r.db.Builder.Select("user_id", "username", "email", "password_hash", "first_name", "last_name", "date_of_birth", "gender", "profile_picture_url", "is_active", "is_email_verified", "last_login_at", "deletion_scheduled_at", "locked_until", "created_at", "updated_at").
 		From("users").
 		Where(squirrel.Eq{"email": email}).
 		ToSql()
@@ -85,7 +104,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*entity.
 
 	var user entity.User
 	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(
-		&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.DateOfBirth, &user.Gender, &user.ProfilePictureURL, &user.IsActive, &user.IsEmailVerified, &user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
+		&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.DateOfBirth, &user.Gender, &user.ProfilePictureURL, &user.IsActive, &user.IsEmailVerified, &user.LastLoginAt, &user.DeletionScheduledAt, &user.LockedUntil, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -98,7 +117,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*entity.
 
 // GetByUsername retrieves a user by their username
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
-	query, args, err := r.db.Builder.Select("user_id", "username", "email", "password_hash", "first_name", "last_name", "date_of_birth", "gender", "profile_picture_url", "is_active", "is_email_verified", "last_login_at", "created_at", "updated_at").
+	query, args, err := r.db.Builder.Select("user_id", "username", "email", "password_hash", "first_name", "last_name", "date_of_birth", "gender", "profile_picture_url", "is_active", "is_email_verified", "last_login_at", "deletion_scheduled_at", "locked_until", "created_at", "updated_at").
 		From("users").
 		Where(squirrel.Eq{"username": username}).
 		ToSql()
@@ -108,7 +127,7 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*e
 
 	var user entity.User
 	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(
-		&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.DateOfBirth, &user.Gender, &user.ProfilePictureURL, &user.IsActive, &user.IsEmailVerified, &user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
+		&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.DateOfBirth, &user.Gender, &user.ProfilePictureURL, &user.IsActive, &user.IsEmailVerified, &user.LastLoginAt, &user.DeletionScheduledAt, &user.LockedUntil, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -119,7 +138,8 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*e
 	return &user, nil
 }
 
-// Update updates an existing user in the database
+// Update updates an existing user in the database.
+// The row is only written if its updated_at still equals UpdatedAt, which is then set to the new version.
 func (r *userRepository) Update(ctx context.Context, user *entity.User) error {
 	query, args, err := r.db.Builder.Update("users").
 		Set("username", user.Username).
@@ -132,19 +152,27 @@ func (r *userRepository) Update(ctx context.Context, user *entity.User) error {
 		Set("profile_picture_url", user.ProfilePictureURL).
 		Set("is_active", user.IsActive).
 		Set("is_email_verified", user.IsEmailVerified).
-		Set("updated_at", user.UpdatedAt).
-		Where(squirrel.Eq{"user_id": user.ID}).
+		Set("updated_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"user_id": user.ID, "updated_at": user.UpdatedAt}).
+		Suffix("RETURNING updated_at").
 		ToSql()
 	if err != nil {
 		return err
 	}
-	_, err = r.db.Pool.Exec(ctx, query, args...)
+
+	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(&user.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return ErrVersionConflict
+	}
 	return err
 }
 
-// Delete deletes a user from the database
-func (r *userRepository) Delete(ctx context.Context, id string) error {
-	query, args, err := r.db.Builder.Delete("users").
+// ScheduleDeletion deactivates a user and schedules the account to be erased at the given time
+func (r *userRepository) ScheduleDeletion(ctx context.Context, id string, at time.Time) error {
+	query, args, err := r.db.Builder.Update("users").
+		Set("is_active", false).
+		Set("deletion_scheduled_at", at).
+		Set("updated_at", squirrel.Expr("CURRENT_TIMESTAMP")).
 		Where(squirrel.Eq{"user_id": id}).
 		ToSql()
 	if err != nil {
@@ -154,14 +182,137 @@ func (r *userRepository) Delete(ctx context.Context, id string) error {
 	return err
 }
 
-// List returns a paginated list of users matching the filters
-func (r *userRepository) List(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]*entity.User, error) {
-	query := r.db.Builder.Select("user_id", "username", "email", "password_hash", "first_name", "last_name", "date_of_birth", "gender", "profile_picture_url", "is_active", "is_email_verified", "last_login_at", "created_at", "updated_at").
+// CancelDeletion reactivates a user scheduled for deletion and reports whether a deletion was scheduled
+func (r *userRepository) CancelDeletion(ctx context.Context, id string) (bool, error) {
+	query, args, err := r.db.Builder.Update("users").
+		Set("is_active", true).
+		Set("deletion_scheduled_at", nil).
+		Set("updated_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"user_id": id}).
+		Where(squirrel.NotEq{"deletion_scheduled_at": nil}).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	tag, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ListDueDeletions returns the IDs of users whose deletion was scheduled at or before now
+func (r *userRepository) ListDueDeletions(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	query, args, err := r.db.Builder.Select("user_id").
+		From("users").
+		Where(squirrel.LtOrEq{"deletion_scheduled_at": now}).
+		OrderBy("deletion_scheduled_at").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// userErasure erases or anonymises everything tied to a user, in order.
+// Shared content, public workout plans and exercises and the food catalogue, loses its author instead of being deleted,
+// as does private content other users still depend on.
+var userErasure = []string{
+	// Personal records, their children go with them
+	`DELETE FROM user_workout_sessions WHERE user_id = $1`,
+	`DELETE FROM user_meals WHERE user_id = $1`,
+	`DELETE FROM user_biometrics WHERE user_id = $1`,
+	`DELETE FROM user_nutrition_goals WHERE user_id = $1`,
+	`DELETE FROM data_exports WHERE user_id = $1`,
+	`DELETE FROM user_roles WHERE user_id = $1`,
+	`DELETE FROM auth_tokens WHERE user_id = $1`,
+	`DELETE FROM user_recovery_codes WHERE user_id = $1`,
+	`DELETE FROM user_two_factor WHERE user_id = $1`,
+	`DELETE FROM personal_tokens WHERE user_id = $1`,
+	`DELETE FROM linked_identities WHERE user_id = $1`,
+	`DELETE FROM oauth_authorization_codes WHERE user_id = $1`,
+	`DELETE FROM oauth_consents WHERE user_id = $1`,
+	`DELETE FROM coach_grants WHERE user_id = $1 OR coach_user_id = $1`,
+	// Apps the user registered keep serving the users connected to them
+	`UPDATE oauth_clients SET owner_user_id = NULL WHERE owner_user_id = $1`,
+	// Authored content
+	`DELETE FROM workout_plans WHERE user_id = $1 AND NOT is_public`,
+	`UPDATE workout_plans SET user_id = NULL WHERE user_id = $1`,
+	`DELETE FROM exercises WHERE created_by_user_id = $1 AND NOT is_public
+		AND NOT EXISTS (SELECT 1 FROM workout_plan_exercises WHERE workout_plan_exercises.exercise_id = exercises.exercise_id)
+		AND NOT EXISTS (SELECT 1 FROM user_workout_session_logs WHERE user_workout_session_logs.exercise_id = exercises.exercise_id)`,
+	`UPDATE exercises SET created_by_user_id = NULL WHERE created_by_user_id = $1`,
+	`DELETE FROM food_items WHERE created_by_user_id = $1 AND deleted_at IS NOT NULL
+		AND NOT EXISTS (SELECT 1 FROM meal_food_items WHERE meal_food_items.food_item_id = food_items.id)`,
+	`UPDATE food_items SET created_by_user_id = NULL WHERE created_by_user_id = $1`,
+	// Audit entries are kept, without where they came from
+	`UPDATE audit_logs SET ip_address = NULL, user_agent = NULL WHERE actor_user_id = $1 OR (target_type = 'user' AND target_id = $1::TEXT)`,
+	`DELETE FROM users WHERE user_id = $1`,
+	// Last, the deletes above add tombstones to the change feed
+	`DELETE FROM sync_changes WHERE user_id = $1`,
+}
+
+// Erase removes a user and all personal data in one transaction.
+// It returns the file names of the user's data export archives, which the caller removes from file storage.
+func (r *userRepository) Erase(ctx context.Context, id string) ([]string, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op once committed
+
+	rows, err := tx.Query(ctx, `SELECT file_name FROM data_exports WHERE user_id = $1 AND file_name IS NOT NULL`, id)
+	if err != nil {
+		return nil, err
+	}
+	files, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, statement := range userErasure {
+		if _, err := tx.Exec(ctx, statement, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// List returns a paginated list of users matching the query spec
+func (r *userRepository) List(ctx context.Context, spec QuerySpec, page, pageSize int) ([]*entity.User, error) {
+	query := r.db.Builder.Select("user_id", "username", "email", "password_hash", "first_name", "last_name", "date_of_birth", "gender", "profile_picture_url", "is_active", "is_email_verified", "last_login_at", "deletion_scheduled_at", "locked_until", "created_at", "updated_at").
 		From("users")
 
-	// Apply filters
-	for key, value := range filters {
-		query = query.Where(squirrel.Eq{key: value})
+	// Apply filters and sort order
+	query, err := UserFields.where(query, spec)
+	if err != nil {
+		return nil, err
+	}
+	query, err = UserFields.orderBy(query, spec, "created_at DESC")
+	if err != nil {
+		return nil, err
 	}
 
 	// Apply pagination
@@ -183,7 +334,7 @@ func (r *userRepository) List(ctx context.Context, filters map[string]interface{
 	for rows.Next() {
 		var user entity.User
 		err := rows.Scan(
-			&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.DateOfBirth, &user.Gender, &user.ProfilePictureURL, &user.IsActive, &user.IsEmailVerified, &user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
+			&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.DateOfBirth, &user.Gender, &user.ProfilePictureURL, &user.IsActive, &user.IsEmailVerified, &user.LastLoginAt, &user.DeletionScheduledAt, &user.LockedUntil, &user.CreatedAt, &user.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -194,14 +345,15 @@ func (r *userRepository) List(ctx context.Context, filters map[string]interface{
 	return users, nil
 }
 
-// Count returns the total number of users matching the filters
-func (r *userRepository) Count(ctx context.Context, filters map[string]interface{}) (int64, error) {
+// Count returns the total number of users matching the query spec
+func (r *userRepository) Count(ctx context.Context, spec QuerySpec) (int64, error) {
 	query := r.db.Builder.Select("COUNT(*)").
 		From("users")
 
 	// Apply filters
-	for key, value := range filters {
-		query = query.Where(squirrel.Eq{key: value})
+	query, err := UserFields.where(query, spec)
+	if err != nil {
+		return 0, err
 	}
 
 	sqlQuery, args, err := query.ToSql()
@@ -218,10 +370,12 @@ func (r *userRepository) Count(ctx context.Context, filters map[string]interface
 	return count, nil
 }
 
-// UpdateLastLogin updates the last login timestamp for a user
+// UpdateLastLogin updates the last login timestamp for a user and clears their failed attempts
 func (r *userRepository) UpdateLastLogin(ctx context.Context, id string) error {
 	query, args, err := r.db.Builder.Update("users").
 		Set("last_login_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Set("failed_login_attempts", 0).
+		Set("locked_until", nil).
 		Where(squirrel.Eq{"user_id": id}).
 		ToSql()
 	if err != nil {
@@ -231,6 +385,42 @@ func (r *userRepository) UpdateLastLogin(ctx context.Context, id string) error {
 	return err
 }
 
+// Lockout describes how long an account is locked after failed sign in attempts
+type Lockout struct {
+	// Threshold is the number of failed attempts that locks the account
+	Threshold int
+	// BaseDelay is the first lock, every further failed attempt doubles it up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// RecordLoginFailure counts a failed sign in attempt and returns until when the account is locked, if it is
+func (r *userRepository) RecordLoginFailure(ctx context.Context, id string, lockout Lockout) (*time.Time, error) {
+	// Counted atomically, so concurrent attempts cannot slip past the threshold
+	query, args, err := r.db.Builder.Update("users").
+		Set("failed_login_attempts", squirrel.Expr("failed_login_attempts + 1")).
+		Set("locked_until", squirrel.Expr(
+			"CASE WHEN failed_login_attempts + 1 >= ? THEN CURRENT_TIMESTAMP + make_interval(secs => LEAST(?, ? * power(2, failed_login_attempts + 1 - ?))) ELSE locked_until END",
+			lockout.Threshold, lockout.MaxDelay.Seconds(), lockout.BaseDelay.Seconds(), lockout.Threshold,
+		)).
+		Where(squirrel.Eq{"user_id": id}).
+		Suffix("RETURNING locked_until").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var lockedUntil *time.Time
+	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(&lockedUntil)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lockedUntil, nil
+}
+
 // UpdateEmailVerification updates the email verification status for a user
 func (r *userRepository) UpdateEmailVerification(ctx context.Context, id string, isVerified bool) error {
 	query, args, err := r.db.Builder.Update("users").
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUserErasure_ReattributesSharedContent(t *testing.T) {
+	statementOf := func(prefix string) int {
+		for i, statement := range userErasure {
+			if strings.HasPrefix(statement, prefix) {
+				return i
+			}
+		}
+		return -1
+	}
+	deleteUser := statementOf("DELETE FROM users ")
+	if deleteUser < 0 {
+		t.Fatal("the user row is not deleted")
+	}
+
+	tests := []struct {
+		table  string
+		author string
+	}{
+		{table: "exercises", author: "created_by_user_id"},
+		{table: "food_items", author: "created_by_user_id"},
+		{table: "workout_plans", author: "user_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.table, func(t *testing.T) {
+			// What is left of the user's content loses its author rather than going with the user
+			reattribute := statementOf("UPDATE " + tt.table + " SET " + tt.author + " = NULL WHERE " + tt.author + " = $1")
+			if reattribute < 0 || reattribute > deleteUser {
+				t.Fatalf("%s are not re-attributed before the user is deleted", tt.table)
+			}
+
+			// Only the user's private content is deleted, before the rest is re-attributed
+			deletes := 0
+			for i, statement := range userErasure {
+				if !strings.HasPrefix(statement, "DELETE FROM "+tt.table+" ") {
+					continue
+				}
+				deletes++
+				if i > reattribute {
+					t.Errorf("%s are deleted after they were re-attributed", tt.table)
+				}
+				if !strings.Contains(statement, " AND ") {
+					t.Errorf("%s are deleted without sparing shared ones: %s", tt.table, statement)
+				}
+			}
+			if deletes > 1 {
+				t.Errorf("%s are deleted by %d statements", tt.table, deletes)
+			}
+		})
+	}
+}
@@ -16,14 +16,26 @@ type WorkoutPlanRepository interface {
 	GetByID(ctx context.Context, id string) (*entity.WorkoutPlan, error)
 	Update(ctx context.Context, plan *entity.WorkoutPlan) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]*entity.WorkoutPlan, error)
-	Count(ctx context.Context, filters map[string]interface{}) (int64, error)
+	List(ctx context.Context, spec QuerySpec, page, pageSize int) ([]*entity.WorkoutPlan, error)
+	Count(ctx context.Context, spec QuerySpec) (int64, error)
 	GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*entity.WorkoutPlan, error)
 	AddExercise(ctx context.Context, planExercise *entity.WorkoutPlanExercise) error
 	RemoveExercise(ctx context.Context, planID, exerciseID string) error
 	GetExercises(ctx context.Context, planID string) ([]*entity.WorkoutPlanExercise, error)
 }
 
+// WorkoutPlanFields lists the workout plan columns that can be filtered and sorted through the API
+var WorkoutPlanFields = Fields{
+	"user_id":                   {Column: "user_id", Type: FieldString, Operators: enumOps},
+	"name":                      {Column: "plan_name", Type: FieldString, Operators: textOps, Sortable: true},
+	"type":                      {Column: "plan_type", Type: FieldString, Operators: textOps, Sortable: true},
+	"difficulty_level":          {Column: "difficulty_level", Type: FieldString, Operators: enumOps, Sortable: true},
+	"duration_estimate_minutes": {Column: "duration_estimate_minutes", Type: FieldNumber, Operators: rangeOps, Sortable: true},
+	"frequency_per_week":        {Column: "frequency_per_week", Type: FieldNumber, Operators: rangeOps, Sortable: true},
+	"is_public":                 {Column: "is_public", Type: FieldBool, Operators: boolOps},
+	"created_at":                {Column: "created_at", Type: FieldTime, Operators: rangeOps, Sortable: true},
+}
+
 // workoutPlanRepository implements WorkoutPlanRepository
 type workoutPlanRepository struct {
 	db *postgres.Postgres
@@ -54,6 +66,7 @@ func (r *workoutPlanRepository) Create(ctx context.Context, plan *entity.Workout
 func (r *workoutPlanRepository) GetByID(ctx context.Context, id string) (*entity.WorkoutPlan, error) {
 	query, args, err := r.db.Builder.Select("plan_id", "user_id", "plan_name", "description", "plan_type", "difficulty_level", "duration_estimate_minutes", "frequency_per_week", "is_public", "cover_image_url", "created_at", "updated_at").
 		From("workout_plans").
+		Where(squirrel.Eq{"deleted_at": nil}).
 		Where(squirrel.Eq{"plan_id": id}).
 		ToSql()
 	if err != nil {
@@ -85,7 +98,7 @@ func (r *workoutPlanRepository) Update(ctx context.Context, plan *entity.Workout
 		Set("is_public", plan.IsPublic).
 		Set("cover_image_url", plan.CoverImageURL).
 		Set("updated_at", plan.UpdatedAt).
-		Where(squirrel.Eq{"plan_id": plan.ID}).
+		Where(squirrel.Eq{"plan_id": plan.ID, "deleted_at": nil}).
 		ToSql()
 	if err != nil {
 		return err
@@ -94,10 +107,11 @@ func (r *workoutPlanRepository) Update(ctx context.Context, plan *entity.Workout
 	return err
 }
 
-// Delete deletes a workout plan from the database
+// Delete moves a workout plan to the trash
 func (r *workoutPlanRepository) Delete(ctx context.Context, id string) error {
-	query, args, err := r.db.Builder.Delete("workout_plans").
-		Where(squirrel.Eq{"plan_id": id}).
+	query, args, err := r.db.Builder.Update("workout_plans").
+		Set("deleted_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"plan_id": id, "deleted_at": nil}).
 		ToSql()
 	if err != nil {
 		return err
@@ -106,14 +120,20 @@ func (r *workoutPlanRepository) Delete(ctx context.Context, id string) error {
 	return err
 }
 
-// List returns a paginated list of workout plans matching the filters
-func (r *workoutPlanRepository) List(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]*entity.WorkoutPlan, error) {
+// List returns a paginated list of workout plans matching the query spec
+func (r *workoutPlanRepository) List(ctx context.Context, spec QuerySpec, page, pageSize int) ([]*entity.WorkoutPlan, error) {
 	query := r.db.Builder.Select("plan_id", "user_id", "plan_name", "description", "plan_type", "difficulty_level", "duration_estimate_minutes", "frequency_per_week", "is_public", "cover_image_url", "created_at", "updated_at").
-		From("workout_plans")
+		From("workout_plans").
+		Where(squirrel.Eq{"deleted_at": nil})
 
-	// Apply filters
-	for key, value := range filters {
-		query = query.Where(squirrel.Eq{key: value})
+	// Apply filters and sort order
+	query, err := WorkoutPlanFields.where(query, spec)
+	if err != nil {
+		return nil, err
+	}
+	query, err = WorkoutPlanFields.orderBy(query, spec, "created_at DESC")
+	if err != nil {
+		return nil, err
 	}
 
 	// Apply pagination
@@ -146,14 +166,16 @@ func (r *workoutPlanRepository) List(ctx context.Context, filters map[string]int
 	return plans, nil
 }
 
-// Count returns the total number of workout plans matching the filters
-func (r *workoutPlanRepository) Count(ctx context.Context, filters map[string]interface{}) (int64, error) {
+// Count returns the total number of workout plans matching the query spec
+func (r *workoutPlanRepository) Count(ctx context.Context, spec QuerySpec) (int64, error) {
 	query := r.db.Builder.Select("COUNT(*)").
-		From("workout_plans")
+		From("workout_plans").
+		Where(squirrel.Eq{"deleted_at": nil})
 
 	// Apply filters
-	for key, value := range filters {
-		query = query.Where(squirrel.Eq{key: value})
+	query, err := WorkoutPlanFields.where(query, spec)
+	if err != nil {
+		return 0, err
 	}
 
 	sqlQuery, args, err := query.ToSql()
@@ -174,6 +196,7 @@ func (r *workoutPlanRepository) Count(ctx context.Context, filters map[string]in
 func (r *workoutPlanRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*entity.WorkoutPlan, error) {
 	query, args, err := r.db.Builder.Select("plan_id", "user_id", "plan_name", "description", "plan_type", "difficulty_level", "duration_estimate", "frequency_per_week", "is_public", "cover_image_url", "created_at", "updated_at").
 		From("workout_plans").
+		Where(squirrel.Eq{"deleted_at": nil}).
 		Where(squirrel.Eq{"user_id": userID}).
 		Limit(uint64(limit)).
 		Offset(uint64(offset)).
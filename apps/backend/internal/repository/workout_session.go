@@ -16,15 +16,48 @@ type WorkoutSessionRepository interface {
 	GetByID(ctx context.Context, id string) (*entity.UserWorkoutSession, error)
 	Update(ctx context.Context, session *entity.UserWorkoutSession) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]*entity.UserWorkoutSession, error)
-	Count(ctx context.Context, filters map[string]interface{}) (int64, error)
+	List(ctx context.Context, spec QuerySpec, page, pageSize int) ([]*entity.UserWorkoutSession, error)
+	Count(ctx context.Context, spec QuerySpec) (int64, error)
+	ListPage(ctx context.Context, spec QuerySpec, page CursorPage) ([]*entity.UserWorkoutSession, PageInfo, error)
 	GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*entity.UserWorkoutSession, error)
 	AddLog(ctx context.Context, log *entity.UserWorkoutSessionLog) error
 	GetLogs(ctx context.Context, sessionID string) ([]*entity.UserWorkoutSessionLog, error)
+	GetLogByID(ctx context.Context, logID string) (*entity.UserWorkoutSessionLog, error)
+	ListLogsPage(ctx context.Context, spec QuerySpec, page CursorPage) ([]*entity.UserWorkoutSessionLog, PageInfo, error)
 	UpdateLog(ctx context.Context, log *entity.UserWorkoutSessionLog) error
 	DeleteLog(ctx context.Context, logID string) error
 }
 
+// WorkoutSessionFields lists the workout session columns that can be filtered and sorted through the API
+var WorkoutSessionFields = Fields{
+	"user_id":                   {Column: "user_id", Type: FieldString, Operators: enumOps},
+	"plan_id":                   {Column: "plan_id", Type: FieldString, Operators: enumOps},
+	"status":                    {Column: "status", Type: FieldString, Operators: enumOps, Sortable: true},
+	"location":                  {Column: "location", Type: FieldString, Operators: textOps},
+	"scheduled_at":              {Column: "scheduled_at", Type: FieldTime, Operators: rangeOps, Sortable: true},
+	"started_at":                {Column: "started_at", Type: FieldTime, Operators: rangeOps, Sortable: true},
+	"completed_at":              {Column: "completed_at", Type: FieldTime, Operators: rangeOps, Sortable: true},
+	"duration_minutes":          {Column: "duration_minutes", Type: FieldNumber, Operators: rangeOps, Sortable: true},
+	"mood_rating":               {Column: "mood_rating", Type: FieldNumber, Operators: rangeOps, Sortable: true},
+	"perceived_exertion_rating": {Column: "perceived_exertion_rating", Type: FieldNumber, Operators: rangeOps, Sortable: true},
+	"created_at":                {Column: "created_at", Type: FieldTime, Operators: rangeOps, Sortable: true},
+}
+
+// WorkoutSessionLogFields lists the workout session log columns that can be filtered through the API
+var WorkoutSessionLogFields = Fields{
+	"session_id":  {Column: "session_id", Type: FieldString, Operators: enumOps},
+	"exercise_id": {Column: "exercise_id", Type: FieldString, Operators: enumOps},
+	"set_number":  {Column: "set_number", Type: FieldNumber, Operators: rangeOps},
+	"weight_kg":   {Column: "weight_kg", Type: FieldNumber, Operators: rangeOps},
+	"logged_at":   {Column: "logged_at", Type: FieldTime, Operators: rangeOps},
+}
+
+// Keysets used for cursor pagination of workout sessions and their logs
+var (
+	workoutSessionKeyset    = keyset{columns: []string{"created_at", "session_id"}, desc: true}
+	workoutSessionLogKeyset = keyset{columns: []string{"logged_at", "log_id"}}
+)
+
 // workoutSessionRepository implements WorkoutSessionRepository
 type workoutSessionRepository struct {
 	db *postgres.Postgres
@@ -46,7 +79,7 @@ func (r *workoutSessionRepository) Create(ctx context.Context, session *entity.U
 	}
 	_, err = r.db.Pool.Exec(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, translateWriteError(err)
 	}
 	return session, nil
 }
@@ -55,6 +88,7 @@ func (r *workoutSessionRepository) Create(ctx context.Context, session *entity.U
 func (r *workoutSessionRepository) GetByID(ctx context.Context, id string) (*entity.UserWorkoutSession, error) {
 	query, args, err := r.db.Builder.Select("session_id", "user_id", "plan_id", "session_name", "scheduled_at", "started_at", "completed_at", "duration_minutes", "status", "notes", "location", "mood_rating", "perceived_exertion_rating", "created_at", "updated_at").
 		From("user_workout_sessions").
+		Where(squirrel.Eq{"deleted_at": nil}).
 		Where(squirrel.Eq{"session_id": id}).
 		ToSql()
 	if err != nil {
@@ -73,7 +107,8 @@ func (r *workoutSessionRepository) GetByID(ctx context.Context, id string) (*ent
 	return &session, nil
 }
 
-// Update updates an existing workout session in the database
+// Update updates an existing workout session in the database.
+// The row is only written if its updated_at still equals UpdatedAt, which is then set to the new version.
 func (r *workoutSessionRepository) Update(ctx context.Context, session *entity.UserWorkoutSession) error {
 	query, args, err := r.db.Builder.Update("user_workout_sessions").
 		Set("user_id", session.UserID).
@@ -88,20 +123,26 @@ func (r *workoutSessionRepository) Update(ctx context.Context, session *entity.U
 		Set("location", session.Location).
 		Set("mood_rating", session.MoodRating).
 		Set("perceived_exertion_rating", session.PerceivedExertionRating).
-		Set("updated_at", session.UpdatedAt).
-		Where(squirrel.Eq{"session_id": session.ID}).
+		Set("updated_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"session_id": session.ID, "updated_at": session.UpdatedAt, "deleted_at": nil}).
+		Suffix("RETURNING updated_at").
 		ToSql()
 	if err != nil {
 		return err
 	}
-	_, err = r.db.Pool.Exec(ctx, query, args...)
+
+	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(&session.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return ErrVersionConflict
+	}
 	return err
 }
 
-// Delete deletes a workout session from the database
+// Delete moves a workout session to the trash
 func (r *workoutSessionRepository) Delete(ctx context.Context, id string) error {
-	query, args, err := r.db.Builder.Delete("user_workout_sessions").
-		Where(squirrel.Eq{"session_id": id}).
+	query, args, err := r.db.Builder.Update("user_workout_sessions").
+		Set("deleted_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"session_id": id, "deleted_at": nil}).
 		ToSql()
 	if err != nil {
 		return err
@@ -110,14 +151,20 @@ func (r *workoutSessionRepository) Delete(ctx context.Context, id string) error
 	return err
 }
 
-// List returns a paginated list of workout sessions matching the filters
-func (r *workoutSessionRepository) List(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]*entity.UserWorkoutSession, error) {
+// List returns a paginated list of workout sessions matching the query spec
+func (r *workoutSessionRepository) List(ctx context.Context, spec QuerySpec, page, pageSize int) ([]*entity.UserWorkoutSession, error) {
 	query := r.db.Builder.Select("session_id", "user_id", "plan_id", "session_name", "scheduled_at", "started_at", "completed_at", "duration_minutes", "status", "notes", "location", "mood_rating", "perceived_exertion_rating", "created_at", "updated_at").
-		From("user_workout_sessions")
+		From("user_workout_sessions").
+		Where(squirrel.Eq{"deleted_at": nil})
 
-	// Apply filters
-	for key, value := range filters {
-		query = query.Where(squirrel.Eq{key: value})
+	// Apply filters and sort order
+	query, err := WorkoutSessionFields.where(query, spec)
+	if err != nil {
+		return nil, err
+	}
+	query, err = WorkoutSessionFields.orderBy(query, spec, "created_at DESC")
+	if err != nil {
+		return nil, err
 	}
 
 	// Apply pagination
@@ -150,14 +197,16 @@ func (r *workoutSessionRepository) List(ctx context.Context, filters map[string]
 	return sessions, nil
 }
 
-// Count returns the total number of workout sessions matching the filters
-func (r *workoutSessionRepository) Count(ctx context.Context, filters map[string]interface{}) (int64, error) {
+// Count returns the total number of workout sessions matching the query spec
+func (r *workoutSessionRepository) Count(ctx context.Context, spec QuerySpec) (int64, error) {
 	query := r.db.Builder.Select("COUNT(*)").
-		From("user_workout_sessions")
+		From("user_workout_sessions").
+		Where(squirrel.Eq{"deleted_at": nil})
 
 	// Apply filters
-	for key, value := range filters {
-		query = query.Where(squirrel.Eq{key: value})
+	query, err := WorkoutSessionFields.where(query, spec)
+	if err != nil {
+		return 0, err
 	}
 
 	sqlQuery, args, err := query.ToSql()
@@ -178,6 +227,7 @@ func (r *workoutSessionRepository) Count(ctx context.Context, filters map[string
 func (r *workoutSessionRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*entity.UserWorkoutSession, error) {
 	query, args, err := r.db.Builder.Select("session_id", "user_id", "plan_id", "session_name", "scheduled_at", "started_at", "completed_at", "duration_minutes", "status", "notes", "location", "mood_rating", "perceived_exertion_rating", "created_at", "updated_at").
 		From("user_workout_sessions").
+		Where(squirrel.Eq{"deleted_at": nil}).
 		Where(squirrel.Eq{"user_id": userID}).
 		OrderBy("scheduled_at DESC").
 		Limit(uint64(limit)).
@@ -215,13 +265,14 @@ func (r *workoutSessionRepository) AddLog(ctx context.Context, log *entity.UserW
 		return err
 	}
 	_, err = r.db.Pool.Exec(ctx, query, args...)
-	return err
+	return translateWriteError(err)
 }
 
 // GetLogs retrieves all logs for a workout session
 func (r *workoutSessionRepository) GetLogs(ctx context.Context, sessionID string) ([]*entity.UserWorkoutSessionLog, error) {
 	query, args, err := r.db.Builder.Select("log_id", "session_id", "exercise_id", "plan_exercise_id", "set_number", "reps_completed", "weight_kg", "distance_km", "duration_seconds_completed", "rest_taken_seconds", "notes", "logged_at").
 		From("user_workout_session_logs").
+		Where(squirrel.Eq{"deleted_at": nil}).
 		Where(squirrel.Eq{"session_id": sessionID}).
 		OrderBy("exercise_id", "set_number").
 		ToSql()
@@ -247,6 +298,29 @@ func (r *workoutSessionRepository) GetLogs(ctx context.Context, sessionID string
 	return logs, nil
 }
 
+// GetLogByID retrieves a log entry by its ID
+func (r *workoutSessionRepository) GetLogByID(ctx context.Context, logID string) (*entity.UserWorkoutSessionLog, error) {
+	query, args, err := r.db.Builder.Select("log_id", "session_id", "exercise_id", "plan_exercise_id", "set_number", "reps_completed", "weight_kg", "distance_km", "duration_seconds_completed", "rest_taken_seconds", "notes", "logged_at").
+		From("user_workout_session_logs").
+		Where(squirrel.Eq{"deleted_at": nil}).
+		Where(squirrel.Eq{"log_id": logID}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var log entity.UserWorkoutSessionLog
+	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(
+		&log.ID, &log.SessionID, &log.ExerciseID, &log.PlanExerciseID, &log.SetNumber, &log.RepsCompleted, &log.WeightKg, &log.DistanceKm, &log.DurationSecondsCompleted, &log.RestTakenSeconds, &log.Notes, &log.LoggedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
 // UpdateLog updates an existing log entry
 func (r *workoutSessionRepository) UpdateLog(ctx context.Context, log *entity.UserWorkoutSessionLog) error {
 	query, args, err := r.db.Builder.Update("user_workout_session_logs").
@@ -256,7 +330,7 @@ func (r *workoutSessionRepository) UpdateLog(ctx context.Context, log *entity.Us
 		Set("duration_seconds_completed", log.DurationSecondsCompleted).
 		Set("rest_taken_seconds", log.RestTakenSeconds).
 		Set("notes", log.Notes).
-		Where(squirrel.Eq{"log_id": log.ID}).
+		Where(squirrel.Eq{"log_id": log.ID, "deleted_at": nil}).
 		ToSql()
 	if err != nil {
 		return err
@@ -265,10 +339,11 @@ func (r *workoutSessionRepository) UpdateLog(ctx context.Context, log *entity.Us
 	return err
 }
 
-// DeleteLog deletes a log entry
+// DeleteLog moves a log entry to the trash
 func (r *workoutSessionRepository) DeleteLog(ctx context.Context, logID string) error {
-	query, args, err := r.db.Builder.Delete("user_workout_session_logs").
-		Where(squirrel.Eq{"log_id": logID}).
+	query, args, err := r.db.Builder.Update("user_workout_session_logs").
+		Set("deleted_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"log_id": logID, "deleted_at": nil}).
 		ToSql()
 	if err != nil {
 		return err
@@ -276,3 +351,95 @@ func (r *workoutSessionRepository) DeleteLog(ctx context.Context, logID string)
 	_, err = r.db.Pool.Exec(ctx, query, args...)
 	return err
 }
+
+// ListPage returns a cursor paginated list of workout sessions matching the query spec, newest first
+func (r *workoutSessionRepository) ListPage(ctx context.Context, spec QuerySpec, page CursorPage) ([]*entity.UserWorkoutSession, PageInfo, error) {
+	query := r.db.Builder.Select("session_id", "user_id", "plan_id", "session_name", "scheduled_at", "started_at", "completed_at", "duration_minutes", "status", "notes", "location", "mood_rating", "perceived_exertion_rating", "created_at", "updated_at").
+		From("user_workout_sessions").
+		Where(squirrel.Eq{"deleted_at": nil})
+
+	query, err := WorkoutSessionFields.where(query, spec)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	query, c, err := workoutSessionKeyset.apply(query, spec, page)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	rows, err := r.db.Pool.Query(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	defer rows.Close()
+
+	var sessions []*entity.UserWorkoutSession
+	for rows.Next() {
+		var session entity.UserWorkoutSession
+		err := rows.Scan(
+			&session.ID, &session.UserID, &session.PlanID, &session.SessionName, &session.ScheduledAt, &session.StartedAt, &session.CompletedAt, &session.DurationMinutes, &session.Status, &session.Notes, &session.Location, &session.MoodRating, &session.PerceivedExertionRating, &session.CreatedAt, &session.UpdatedAt,
+		)
+		if err != nil {
+			return nil, PageInfo{}, err
+		}
+		sessions = append(sessions, &session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	sessions, info := paginate(sessions, page, c, func(s *entity.UserWorkoutSession) []interface{} {
+		return []interface{}{s.CreatedAt, s.ID}
+	})
+	return sessions, info, nil
+}
+
+// ListLogsPage returns a cursor paginated list of workout session logs matching the query spec, in logging order
+func (r *workoutSessionRepository) ListLogsPage(ctx context.Context, spec QuerySpec, page CursorPage) ([]*entity.UserWorkoutSessionLog, PageInfo, error) {
+	query := r.db.Builder.Select("log_id", "session_id", "exercise_id", "plan_exercise_id", "set_number", "reps_completed", "weight_kg", "distance_km", "duration_seconds_completed", "rest_taken_seconds", "notes", "logged_at").
+		From("user_workout_session_logs").
+		Where(squirrel.Eq{"deleted_at": nil})
+
+	query, err := WorkoutSessionLogFields.where(query, spec)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	query, c, err := workoutSessionLogKeyset.apply(query, spec, page)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	rows, err := r.db.Pool.Query(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	defer rows.Close()
+
+	var logs []*entity.UserWorkoutSessionLog
+	for rows.Next() {
+		var log entity.UserWorkoutSessionLog
+		err := rows.Scan(
+			&log.ID, &log.SessionID, &log.ExerciseID, &log.PlanExerciseID, &log.SetNumber, &log.RepsCompleted, &log.WeightKg, &log.DistanceKm, &log.DurationSecondsCompleted, &log.RestTakenSeconds, &log.Notes, &log.LoggedAt,
+		)
+		if err != nil {
+			return nil, PageInfo{}, err
+		}
+		logs = append(logs, &log)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	logs, info := paginate(logs, page, c, func(l *entity.UserWorkoutSessionLog) []interface{} {
+		return []interface{}{l.LoggedAt, l.ID}
+	})
+	return logs, info, nil
+}
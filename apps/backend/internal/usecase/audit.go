@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
+)
+
+type auditSourceKey struct{}
+
+// AuditSourceKey is the context key under which transports store the entity.AuditSource of a request
+var AuditSourceKey = auditSourceKey{}
+
+// WithAuditSource returns a copy of ctx that carries the source of the actions performed with it
+func WithAuditSource(ctx context.Context, source entity.AuditSource) context.Context {
+	return context.WithValue(ctx, AuditSourceKey, source)
+}
+
+func auditSourceFrom(ctx context.Context) entity.AuditSource {
+	source, _ := ctx.Value(AuditSourceKey).(entity.AuditSource)
+	return source
+}
+
+// auditor appends entries to the audit log on behalf of the use cases
+type auditor struct {
+	repo repository.AuditLogRepository
+}
+
+// record appends an entry for an action performed by the source carried by ctx
+func (a auditor) record(ctx context.Context, action entity.AuditAction, targetType entity.AuditTargetType, targetID string, changes map[string]entity.AuditChange) error {
+	source := auditSourceFrom(ctx)
+
+	entry := &entity.AuditLog{
+		ID:         uuid.New().String(),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Changes:    changes,
+		IPAddress:  source.IPAddress,
+		UserAgent:  source.UserAgent,
+		CreatedAt:  time.Now(),
+	}
+	if source.ActorID != "" {
+		entry.ActorID = &source.ActorID
+	}
+
+	return a.repo.Create(ctx, entry)
+}
+
+// auditChanges returns the fields whose JSON representation differs between before and after.
+// Fields hidden from JSON, such as password hashes, never show up in the diff.
+func auditChanges(before, after interface{}) (map[string]entity.AuditChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]entity.AuditChange)
+	for name, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[name]) {
+			changes[name] = entity.AuditChange{Before: value, After: afterFields[name]}
+		}
+	}
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			changes[name] = entity.AuditChange{After: value}
+		}
+	}
+	// The version moves with every write and is not a change of its own
+	delete(changes, "updated_at")
+
+	return changes, nil
+}
+
+func auditFields(record interface{}) (map[string]interface{}, error) {
+	if record == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// AuditUseCase handles querying of the audit log
+type AuditUseCase struct {
+	repo   repository.AuditLogRepository
+	config Config
+}
+
+// NewAuditUseCase creates a new instance of AuditUseCase
+func NewAuditUseCase(r repository.AuditLogRepository, config Config) *AuditUseCase {
+	return &AuditUseCase{
+		repo:   r,
+		config: config,
+	}
+}
+
+// ListAuditLogs returns a cursor paginated list of audit log entries, most recent first
+func (uc *AuditUseCase) ListAuditLogs(ctx context.Context, spec repository.QuerySpec, page repository.CursorPage) ([]*entity.AuditLog, repository.PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "AuditUseCase.ListAuditLogs")
+	defer span.End()
+
+	// Validate page size
+	if page.Limit <= 0 {
+		page.Limit = uc.config.DefaultPageSize
+	}
+	if page.Limit > uc.config.MaxPageSize {
+		page.Limit = uc.config.MaxPageSize
+	}
+
+	return uc.repo.ListPage(ctx, spec, page)
+}
@@ -0,0 +1,642 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/metrics"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/secretbox"
+	"github.com/terrnit/rebound/backend/pkg/totp"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
+)
+
+const (
+	// _tokenTypeBearer is the OAuth 2.0 type of the issued access tokens
+	_tokenTypeBearer = "Bearer"
+
+	// JWT typ claims keep access and challenge tokens from being used for one another
+	_claimTypeAccess    = "access"
+	_claimTypeChallenge = "mfa_challenge"
+
+	// _totpSkew accepts codes one step, 30 seconds, either side of the server clock
+	_totpSkew = 1
+
+	_recoveryCodeCount = 10
+	// _recoveryCodeBytes gives 48 random bits in 10 base32 characters, shown as two groups of 5
+	_recoveryCodeBytes = 6
+)
+
+type AuthConfig struct {
+	// JWTSecret signs access and challenge tokens
+	JWTSecret       []byte
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// ChallengeTTL is how long the challenge of the password step can be answered with a code
+	ChallengeTTL time.Duration
+	// TOTPIssuer is the name authenticator apps show next to the account
+	TOTPIssuer string
+	// Lockout locks an account after failed sign in attempts, a zero threshold disables it
+	Lockout repository.Lockout
+}
+
+type AuthUseCase struct {
+	userRepo      repository.UserRepository
+	tokenRepo     repository.AuthTokenRepository
+	twoFactorRepo repository.TwoFactorRepository
+	audit         auditor
+	// secrets encrypts TOTP secrets at rest
+	secrets *secretbox.Box
+	config  AuthConfig
+}
+
+// NewAuthUseCase creates a new instance of AuthUseCase
+func NewAuthUseCase(
+	userRepo repository.UserRepository,
+	tokenRepo repository.AuthTokenRepository,
+	twoFactorRepo repository.TwoFactorRepository,
+	auditRepo repository.AuditLogRepository,
+	secrets *secretbox.Box,
+	config AuthConfig,
+) *AuthUseCase {
+	return &AuthUseCase{
+		userRepo:      userRepo,
+		tokenRepo:     tokenRepo,
+		twoFactorRepo: twoFactorRepo,
+		audit:         auditor{repo: auditRepo},
+		secrets:       secrets,
+		config:        config,
+	}
+}
+
+// tokenClaims are the claims of access and challenge tokens
+type tokenClaims struct {
+	Type string `json:"typ"`
+	// SessionID is the session an access token was issued to
+	SessionID string `json:"sid,omitempty"`
+	// DeviceName is carried by challenge tokens to the session started once the challenge is answered
+	DeviceName string `json:"device_name,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Login verifies a user's email and password and starts a session for the device.
+// Users without two-factor authentication get their tokens, the others a challenge to answer with VerifyTwoFactor.
+func (uc *AuthUseCase) Login(ctx context.Context, email, password, deviceName string) (*entity.LoginResult, error) {
+	ctx, span := tracing.Start(ctx, "AuthUseCase.Login")
+	defer span.End()
+
+	user, err := uc.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidCredentials
+	}
+	if err := uc.checkLocked(ctx, user); err != nil {
+		return nil, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	if err == nil && !user.IsActive {
+		err = ErrAccountInactive
+		if user.DeletionScheduledAt != nil {
+			err = ErrAccountDeletionPending
+		}
+	}
+	if err != nil {
+		// Accounts created through a provider have no password hash
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) || errors.Is(err, bcrypt.ErrHashTooShort) {
+			err = ErrInvalidCredentials
+		}
+		return nil, uc.loginFailed(ctx, user, err)
+	}
+
+	return uc.completeLogin(ctx, user, deviceName)
+}
+
+// completeLogin finishes the sign in of a user whose first factor was verified, by password or by a provider.
+// Users with two-factor authentication get a challenge instead of tokens.
+func (uc *AuthUseCase) completeLogin(ctx context.Context, user *entity.User, deviceName string) (*entity.LoginResult, error) {
+	twoFactor, err := uc.twoFactorRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.IsEnabled() {
+		expiresAt := time.Now().Add(uc.config.ChallengeTTL)
+		challenge, err := uc.signToken(tokenClaims{Type: _claimTypeChallenge, DeviceName: deviceName}, user.ID, expiresAt)
+		if err != nil {
+			return nil, err
+		}
+		return &entity.LoginResult{
+			TwoFactorRequired:  true,
+			ChallengeToken:     challenge,
+			ChallengeExpiresAt: &expiresAt,
+		}, nil
+	}
+
+	pair, err := uc.loginSucceeded(ctx, user, deviceName)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.LoginResult{TokenPair: pair}, nil
+}
+
+// VerifyTwoFactor answers the challenge of the password step with a TOTP code or a recovery code and issues the tokens.
+// Wrong codes count towards the lockout like wrong passwords.
+func (uc *AuthUseCase) VerifyTwoFactor(ctx context.Context, challengeToken, code string) (*entity.TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "AuthUseCase.VerifyTwoFactor")
+	defer span.End()
+
+	challenge, err := uc.parseToken(challengeToken, _claimTypeChallenge)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, challenge.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		return nil, ErrInvalidChallenge
+	}
+	if err := uc.checkLocked(ctx, user); err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := uc.twoFactorRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !twoFactor.IsEnabled() {
+		return nil, ErrInvalidChallenge
+	}
+
+	ok, err := uc.verifyCode(ctx, twoFactor, code, true)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, uc.loginFailed(ctx, user, ErrInvalidTwoFactorCode)
+	}
+
+	return uc.loginSucceeded(ctx, user, challenge.DeviceName)
+}
+
+// Refresh exchanges a refresh token for a new token pair of the same session.
+// Refresh tokens are used once, presenting a used one again revokes all of the user's tokens,
+// since either the user or whoever stole the token is not the legitimate holder.
+func (uc *AuthUseCase) Refresh(ctx context.Context, refreshToken string) (*entity.TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "AuthUseCase.Refresh")
+	defer span.End()
+
+	token, err := uc.tokenRepo.GetByHash(ctx, entity.AuthTokenTypeRefresh, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	// Tokens of OAuth clients are refreshed at the token endpoint, with their scopes
+	if token == nil || token.ClientID != "" || time.Now().After(token.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	rotated, err := uc.tokenRepo.Revoke(ctx, token.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// A reused token leaves the newer token of its session live, a signed out session has none
+		reused, err := uc.tokenRepo.RevokeSession(ctx, token.UserID, token.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		if reused {
+			if err := uc.tokenRepo.RevokeAllByUserID(ctx, token.UserID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		return nil, ErrInvalidRefreshToken
+	}
+	return uc.issueTokens(ctx, user.ID, token)
+}
+
+// ParseAccessToken returns the user and session an access token was issued to.
+// Access tokens are not looked up, so they stay valid until they expire after their session is revoked.
+func (uc *AuthUseCase) ParseAccessToken(accessToken string) (*entity.Principal, error) {
+	claims, err := uc.parseToken(accessToken, _claimTypeAccess)
+	if err != nil {
+		return nil, ErrInvalidAccessToken
+	}
+	return &entity.Principal{UserID: claims.Subject, SessionID: claims.SessionID}, nil
+}
+
+// ListSessions returns a user's active sessions, marking the one of currentSessionID
+func (uc *AuthUseCase) ListSessions(ctx context.Context, userID, currentSessionID string) ([]*entity.Session, error) {
+	ctx, span := tracing.Start(ctx, "AuthUseCase.ListSessions")
+	defer span.End()
+
+	tokens, err := uc.tokenRepo.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*entity.Session, 0, len(tokens))
+	for _, token := range tokens {
+		sessions = append(sessions, &entity.Session{
+			ID:         token.SessionID,
+			DeviceName: token.DeviceName,
+			UserAgent:  token.UserAgent,
+			IPAddress:  token.IPAddress,
+			SignedInAt: token.SignedInAt,
+			LastUsedAt: token.LastUsedAt,
+			ExpiresAt:  token.ExpiresAt,
+			Current:    token.SessionID == currentSessionID,
+		})
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs one of a user's sessions out, which can be the current one
+func (uc *AuthUseCase) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	ctx, span := tracing.Start(ctx, "AuthUseCase.RevokeSession")
+	defer span.End()
+
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return ErrSessionNotFound
+	}
+	revoked, err := uc.tokenRepo.RevokeSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSessionNotFound
+	}
+
+	return uc.audit.record(ctx, entity.AuditActionLogout, entity.AuditTargetUser, userID, map[string]entity.AuditChange{
+		"session_id": {Before: sessionID},
+	})
+}
+
+// RevokeOtherSessions signs all of a user's sessions out but the current one
+func (uc *AuthUseCase) RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) error {
+	ctx, span := tracing.Start(ctx, "AuthUseCase.RevokeOtherSessions")
+	defer span.End()
+
+	revoked, err := uc.tokenRepo.RevokeOtherSessions(ctx, userID, currentSessionID)
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return nil
+	}
+
+	return uc.audit.record(ctx, entity.AuditActionLogout, entity.AuditTargetUser, userID, nil)
+}
+
+// EnrollTwoFactor generates a new TOTP secret for a user, replacing an enrollment that was not confirmed.
+// Two-factor authentication is only turned on once ConfirmTwoFactor receives a code generated from it.
+func (uc *AuthUseCase) EnrollTwoFactor(ctx context.Context, userID string) (*entity.TwoFactorEnrollment, error) {
+	ctx, span := tracing.Start(ctx, "AuthUseCase.EnrollTwoFactor")
+	defer span.End()
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	twoFactor, err := uc.twoFactorRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.IsEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := uc.secrets.Seal(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.twoFactorRepo.Enroll(ctx, userID, sealed); err != nil {
+		return nil, err
+	}
+
+	return &entity.TwoFactorEnrollment{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(uc.config.TOTPIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor turns two-factor authentication on with the first code of the authenticator app
+// and returns the recovery codes
+func (uc *AuthUseCase) ConfirmTwoFactor(ctx context.Context, userID, code string) (*entity.RecoveryCodes, error) {
+	ctx, span := tracing.Start(ctx, "AuthUseCase.ConfirmTwoFactor")
+	defer span.End()
+
+	twoFactor, err := uc.twoFactorRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor == nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if twoFactor.IsEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := uc.secrets.Open(twoFactor.Secret)
+	if err != nil {
+		return nil, err
+	}
+	step, ok, err := totp.Validate(secret, normalizeCode(code), time.Now(), _totpSkew)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := uc.twoFactorRepo.Enable(ctx, userID, step, hashes)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		// Confirmed by a concurrent request
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	if err := uc.audit.record(ctx, entity.AuditActionTwoFactorEnable, entity.AuditTargetUser, userID, nil); err != nil {
+		return nil, err
+	}
+	return &entity.RecoveryCodes{Codes: codes}, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off, which takes a TOTP code or a recovery code
+func (uc *AuthUseCase) DisableTwoFactor(ctx context.Context, userID, code string) error {
+	ctx, span := tracing.Start(ctx, "AuthUseCase.DisableTwoFactor")
+	defer span.End()
+
+	twoFactor, err := uc.enabledTwoFactor(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	ok, err := uc.verifyCode(ctx, twoFactor, code, true)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	if err := uc.twoFactorRepo.Delete(ctx, userID); err != nil {
+		return err
+	}
+	return uc.audit.record(ctx, entity.AuditActionTwoFactorDisable, entity.AuditTargetUser, userID, nil)
+}
+
+// RegenerateRecoveryCodes replaces a user's recovery codes, which takes a TOTP code
+func (uc *AuthUseCase) RegenerateRecoveryCodes(ctx context.Context, userID, code string) (*entity.RecoveryCodes, error) {
+	ctx, span := tracing.Start(ctx, "AuthUseCase.RegenerateRecoveryCodes")
+	defer span.End()
+
+	twoFactor, err := uc.enabledTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A recovery code cannot be traded for a fresh set, so losing the authenticator still ends in disabling 2FA
+	ok, err := uc.verifyCode(ctx, twoFactor, code, false)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.twoFactorRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	if err := uc.audit.record(ctx, entity.AuditActionRecoveryCodesRegenerate, entity.AuditTargetUser, userID, nil); err != nil {
+		return nil, err
+	}
+	return &entity.RecoveryCodes{Codes: codes}, nil
+}
+
+func (uc *AuthUseCase) enabledTwoFactor(ctx context.Context, userID string) (*entity.TwoFactor, error) {
+	twoFactor, err := uc.twoFactorRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !twoFactor.IsEnabled() {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	return twoFactor, nil
+}
+
+// verifyCode checks a TOTP code, or a recovery code when allowRecovery is set, and uses it up
+func (uc *AuthUseCase) verifyCode(ctx context.Context, twoFactor *entity.TwoFactor, code string, allowRecovery bool) (bool, error) {
+	code = normalizeCode(code)
+
+	secret, err := uc.secrets.Open(twoFactor.Secret)
+	if err != nil {
+		return false, err
+	}
+	step, ok, err := totp.Validate(secret, code, time.Now(), _totpSkew)
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		// Refuses a code that was already accepted, or an older one
+		return uc.twoFactorRepo.UseStep(ctx, twoFactor.UserID, step)
+	}
+
+	if !allowRecovery || len(code) != base32.StdEncoding.WithPadding(base32.NoPadding).EncodedLen(_recoveryCodeBytes) {
+		return false, nil
+	}
+	return uc.twoFactorRepo.UseRecoveryCode(ctx, twoFactor.UserID, hashToken(code))
+}
+
+// checkLocked refuses a locked account without checking its credentials, so guessing makes no progress
+func (uc *AuthUseCase) checkLocked(ctx context.Context, user *entity.User) error {
+	now := time.Now()
+	if user.LockedUntil == nil || !user.LockedUntil.After(now) {
+		return nil
+	}
+
+	metrics.Logins.WithLabelValues("locked").Inc()
+	if err := uc.audit.record(ctx, entity.AuditActionLoginFailed, entity.AuditTargetUser, user.ID, nil); err != nil {
+		return err
+	}
+	return ErrAccountLocked.WithRetryAfter(user.LockedUntil.Sub(now))
+}
+
+// loginFailed records a failed sign in attempt and returns cause.
+// Wrong credentials and codes count towards the lockout.
+func (uc *AuthUseCase) loginFailed(ctx context.Context, user *entity.User, cause error) error {
+	metrics.Logins.WithLabelValues("failure").Inc()
+	if err := uc.audit.record(ctx, entity.AuditActionLoginFailed, entity.AuditTargetUser, user.ID, nil); err != nil {
+		return err
+	}
+	if (cause == ErrInvalidCredentials || cause == ErrInvalidTwoFactorCode) && uc.config.Lockout.Threshold > 0 {
+		if _, err := uc.userRepo.RecordLoginFailure(ctx, user.ID, uc.config.Lockout); err != nil {
+			return err
+		}
+	}
+	return cause
+}
+
+func (uc *AuthUseCase) loginSucceeded(ctx context.Context, user *entity.User, deviceName string) (*entity.TokenPair, error) {
+	if err := uc.userRepo.UpdateLastLogin(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	metrics.Logins.WithLabelValues("success").Inc()
+	if err := uc.audit.record(ctx, entity.AuditActionLogin, entity.AuditTargetUser, user.ID, nil); err != nil {
+		return nil, err
+	}
+	return uc.issueTokens(ctx, user.ID, &entity.AuthToken{
+		SessionID:  uuid.New().String(),
+		DeviceName: deviceName,
+		SignedInAt: time.Now(),
+	})
+}
+
+// issueTokens signs an access token and stores the hash of a new refresh token.
+// The tokens continue the session of previous, whose device details are updated from the request.
+func (uc *AuthUseCase) issueTokens(ctx context.Context, userID string, previous *entity.AuthToken) (*entity.TokenPair, error) {
+	now := time.Now()
+	source := auditSourceFrom(ctx)
+
+	accessToken, err := uc.signToken(tokenClaims{Type: _claimTypeAccess, SessionID: previous.SessionID}, userID, now.Add(uc.config.AccessTokenTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	err = uc.tokenRepo.Create(ctx, &entity.AuthToken{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		Type:       entity.AuthTokenTypeRefresh,
+		TokenHash:  hashToken(refreshToken),
+		ExpiresAt:  now.Add(uc.config.RefreshTokenTTL),
+		IssuedAt:   now,
+		SessionID:  previous.SessionID,
+		DeviceName: previous.DeviceName,
+		UserAgent:  source.UserAgent,
+		IPAddress:  source.IPAddress,
+		SignedInAt: previous.SignedInAt,
+		LastUsedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    _tokenTypeBearer,
+		ExpiresIn:    int(uc.config.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// signToken signs claims, filling in the registered claims
+func (uc *AuthUseCase) signToken(claims tokenClaims, userID string, expiresAt time.Time) (string, error) {
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.New().String(),
+		Subject:   userID,
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(uc.config.JWTSecret)
+}
+
+// parseToken verifies a token's signature, expiry and type and returns its claims
+func (uc *AuthUseCase) parseToken(token, tokenType string) (*tokenClaims, error) {
+	var claims tokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return uc.config.JWTSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != tokenType || claims.Subject == "" {
+		return nil, errors.New("unexpected token type")
+	}
+	return &claims, nil
+}
+
+// randomToken returns 256 random bits, URL safe
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 of an opaque token, which is all that is stored of it.
+// The tokens are random, so unlike passwords they need no slow hash.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeCode drops the separators users type into codes, recovery codes are compared in lower case
+func normalizeCode(code string) string {
+	code = strings.NewReplacer(" ", "", "-", "").Replace(code)
+	return strings.ToLower(code)
+}
+
+// generateRecoveryCodes returns new recovery codes, formatted as xxxxx-xxxxx, and their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, _recoveryCodeCount)
+	hashes := make([]string, _recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, _recoveryCodeBytes)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(encoding.EncodeToString(b))
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashToken(code)
+	}
+	return codes, hashes, nil
+}
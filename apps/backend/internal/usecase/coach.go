@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
+)
+
+// CoachUseCase manages the coaches users authorise to see their progress photos
+type CoachUseCase struct {
+	userRepo  repository.UserRepository
+	coachRepo repository.CoachRepository
+	audit     auditor
+}
+
+// NewCoachUseCase creates a new instance of CoachUseCase
+func NewCoachUseCase(userRepo repository.UserRepository, coachRepo repository.CoachRepository, auditRepo repository.AuditLogRepository) *CoachUseCase {
+	return &CoachUseCase{
+		userRepo:  userRepo,
+		coachRepo: coachRepo,
+		audit:     auditor{repo: auditRepo},
+	}
+}
+
+// Authorize lets the user with a username coach a user. Authorising a coach again is a no-op.
+func (uc *CoachUseCase) Authorize(ctx context.Context, userID, coachUsername string) (*entity.CoachGrant, error) {
+	ctx, span := tracing.Start(ctx, "CoachUseCase.Authorize")
+	defer span.End()
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	coach, err := uc.userRepo.GetByUsername(ctx, coachUsername)
+	if err != nil {
+		return nil, err
+	}
+	if coach == nil {
+		return nil, ErrCoachUserNotFound
+	}
+	if coach.ID == userID {
+		return nil, ErrCoachSelf
+	}
+
+	if err := uc.coachRepo.Grant(ctx, userID, coach.ID); err != nil {
+		return nil, err
+	}
+	if err := uc.audit.record(ctx, entity.AuditActionCoachGrant, entity.AuditTargetUser, userID, map[string]entity.AuditChange{
+		"coach": {After: coach.ID},
+	}); err != nil {
+		return nil, err
+	}
+	return &entity.CoachGrant{
+		UserID:        userID,
+		Username:      user.Username,
+		CoachID:       coach.ID,
+		CoachUsername: coach.Username,
+		CreatedAt:     time.Now(),
+	}, nil
+}
+
+// ListCoaches returns the coaches a user has authorised
+func (uc *CoachUseCase) ListCoaches(ctx context.Context, userID string) ([]*entity.CoachGrant, error) {
+	ctx, span := tracing.Start(ctx, "CoachUseCase.ListCoaches")
+	defer span.End()
+
+	return uc.coachRepo.ListCoaches(ctx, userID)
+}
+
+// ListClients returns the users who have authorised a coach
+func (uc *CoachUseCase) ListClients(ctx context.Context, coachID string) ([]*entity.CoachGrant, error) {
+	ctx, span := tracing.Start(ctx, "CoachUseCase.ListClients")
+	defer span.End()
+
+	return uc.coachRepo.ListClients(ctx, coachID)
+}
+
+// Revoke withdraws a coach's access to a user's progress photos
+func (uc *CoachUseCase) Revoke(ctx context.Context, userID, coachID string) error {
+	ctx, span := tracing.Start(ctx, "CoachUseCase.Revoke")
+	defer span.End()
+
+	if _, err := uuid.Parse(coachID); err != nil {
+		return ErrCoachNotFound
+	}
+	revoked, err := uc.coachRepo.Revoke(ctx, userID, coachID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrCoachNotFound
+	}
+
+	return uc.audit.record(ctx, entity.AuditActionCoachRevoke, entity.AuditTargetUser, userID, map[string]entity.AuditChange{
+		"coach": {Before: coachID},
+	})
+}
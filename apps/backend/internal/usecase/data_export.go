@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/storage"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
+)
+
+type DataExportConfig struct {
+	// TTL is how long a finished archive can be downloaded
+	TTL time.Duration
+	// StaleAfter is how long a job may run before another worker takes it over
+	StaleAfter time.Duration
+	// LinkSecret signs the download links
+	LinkSecret []byte
+}
+
+// DataExportUseCase gathers a user's data into a ZIP archive of JSON and CSV files.
+// Exports are requested by the user and built in the background by ProcessPending,
+// the finished archive is downloaded through a signed link that expires with it.
+type DataExportUseCase struct {
+	userRepo   repository.UserRepository
+	exportRepo repository.DataExportRepository
+	files      storage.Storage
+	config     DataExportConfig
+}
+
+// NewDataExportUseCase creates a new instance of DataExportUseCase
+func NewDataExportUseCase(userRepo repository.UserRepository, exportRepo repository.DataExportRepository, files storage.Storage, config DataExportConfig) *DataExportUseCase {
+	return &DataExportUseCase{
+		userRepo:   userRepo,
+		exportRepo: exportRepo,
+		files:      files,
+		config:     config,
+	}
+}
+
+// RequestExport queues an export of a user's data.
+// While an export of the user is still queued or running, that export is returned instead.
+func (uc *DataExportUseCase) RequestExport(ctx context.Context, userID string) (*entity.DataExport, error) {
+	ctx, span := tracing.Start(ctx, "DataExportUseCase.RequestExport")
+	defer span.End()
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	existing, err := uc.exportRepo.GetUnfinishedByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	export := &entity.DataExport{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Status:    entity.DataExportPending,
+		CreatedAt: time.Now(),
+	}
+	if err := uc.exportRepo.Create(ctx, export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// GetExport retrieves an export of a user
+func (uc *DataExportUseCase) GetExport(ctx context.Context, userID, id string) (*entity.DataExport, error) {
+	ctx, span := tracing.Start(ctx, "DataExportUseCase.GetExport")
+	defer span.End()
+
+	export, err := uc.exportRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if export == nil || export.UserID != userID {
+		return nil, ErrDataExportNotFound
+	}
+	return export, nil
+}
+
+// DownloadSignature returns the signature of the download link of a completed export.
+// The link is valid until the export expires.
+func (uc *DataExportUseCase) DownloadSignature(export *entity.DataExport) string {
+	if export.Status != entity.DataExportCompleted || export.ExpiresAt == nil {
+		return ""
+	}
+	return uc.sign(export.ID, export.ExpiresAt.Unix())
+}
+
+// OpenDownload checks a download link and opens the archive it points to
+func (uc *DataExportUseCase) OpenDownload(ctx context.Context, id string, expires int64, signature string) (io.ReadCloser, error) {
+	ctx, span := tracing.Start(ctx, "DataExportUseCase.OpenDownload")
+	defer span.End()
+
+	if !hmac.Equal([]byte(signature), []byte(uc.sign(id, expires))) {
+		return nil, ErrDownloadLinkInvalid
+	}
+	if time.Now().Unix() >= expires {
+		return nil, ErrDownloadLinkExpired
+	}
+
+	export, err := uc.exportRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if export == nil || export.Status != entity.DataExportCompleted {
+		return nil, ErrDownloadLinkExpired
+	}
+
+	file, err := uc.files.Open(export.FileName)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrDownloadLinkExpired
+	}
+	return file, err
+}
+
+func (uc *DataExportUseCase) sign(id string, expires int64) string {
+	mac := hmac.New(sha256.New, uc.config.LinkSecret)
+	mac.Write([]byte(id + "." + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ProcessPending builds the archives of queued exports until none is left
+func (uc *DataExportUseCase) ProcessPending(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "DataExportUseCase.ProcessPending")
+	defer span.End()
+
+	for ctx.Err() == nil {
+		export, err := uc.exportRepo.ClaimNext(ctx, time.Now().Add(-uc.config.StaleAfter))
+		if err != nil {
+			return err
+		}
+		if export == nil {
+			return nil
+		}
+
+		if err := uc.build(ctx, export); err != nil {
+			export.Status = entity.DataExportFailed
+			export.Error = "the export could not be created, please request a new one"
+			if updateErr := uc.exportRepo.Update(ctx, export); updateErr != nil {
+				return updateErr
+			}
+			return fmt.Errorf("export %s: %w", export.ID, err)
+		}
+	}
+	// Stopped, the rest is picked up on the next start
+	return nil
+}
+
+// build writes the archive of an export and marks it completed
+func (uc *DataExportUseCase) build(ctx context.Context, export *entity.DataExport) error {
+	sections, err := uc.exportRepo.UserData(ctx, export.UserID)
+	if err != nil {
+		return err
+	}
+
+	fileName := export.ID + ".zip"
+	w, err := uc.files.Create(fileName)
+	if err != nil {
+		return err
+	}
+	if err := writeExportArchive(w, sections); err != nil {
+		w.Close()
+		uc.files.Remove(fileName) //nolint:errcheck // the write error is more useful
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(uc.config.TTL)
+	export.Status = entity.DataExportCompleted
+	export.FileName = fileName
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+	return uc.exportRepo.Update(ctx, export)
+}
+
+// writeExportArchive writes every section as <name>.json and <name>.csv into a ZIP archive
+func writeExportArchive(w io.Writer, sections []*entity.DataExportSection) error {
+	archive := zip.NewWriter(w)
+
+	for _, section := range sections {
+		f, err := archive.Create(section.Name + ".json")
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(section.JSON); err != nil {
+			return err
+		}
+
+		f, err = archive.Create(section.Name + ".csv")
+		if err != nil {
+			return err
+		}
+		cw := csv.NewWriter(f)
+		if err := cw.Write(section.Columns); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(section.Rows); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// PurgeExpired removes the archives of expired exports and returns how many were removed
+func (uc *DataExportUseCase) PurgeExpired(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "DataExportUseCase.PurgeExpired")
+	defer span.End()
+
+	exports, err := uc.exportRepo.ListExpired(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, export := range exports {
+		if err := uc.files.Remove(export.FileName); err != nil {
+			return purged, err
+		}
+		export.Status = entity.DataExportExpired
+		export.FileName = ""
+		if err := uc.exportRepo.Update(ctx, export); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
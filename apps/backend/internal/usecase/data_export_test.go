@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/storage"
+)
+
+// exportDatasets are the sections a data export is expected to hold
+var exportDatasets = []string{
+	"profile", "biometrics", "nutrition_goals", "meals", "meal_food_items", "workout_sessions",
+	"workout_session_logs", "exercises", "food_items", "workout_plans", "workout_plan_exercises",
+}
+
+// fakeDataExportRepository keeps export jobs in memory and returns one record per dataset as the user's data
+type fakeDataExportRepository struct {
+	repository.DataExportRepository
+	exports []*entity.DataExport
+}
+
+func (r *fakeDataExportRepository) Create(_ context.Context, export *entity.DataExport) error {
+	r.exports = append(r.exports, export)
+	return nil
+}
+
+func (r *fakeDataExportRepository) GetByID(_ context.Context, id string) (*entity.DataExport, error) {
+	for _, export := range r.exports {
+		if export.ID == id {
+			clone := *export
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeDataExportRepository) GetUnfinishedByUserID(_ context.Context, userID string) (*entity.DataExport, error) {
+	for _, export := range r.exports {
+		if export.UserID == userID && !export.Status.IsFinished() {
+			return export, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeDataExportRepository) ClaimNext(context.Context, time.Time) (*entity.DataExport, error) {
+	for _, export := range r.exports {
+		if export.Status == entity.DataExportPending {
+			now := time.Now()
+			export.Status = entity.DataExportRunning
+			export.StartedAt = &now
+			clone := *export
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeDataExportRepository) Update(_ context.Context, export *entity.DataExport) error {
+	for i := range r.exports {
+		if r.exports[i].ID == export.ID {
+			clone := *export
+			r.exports[i] = &clone
+		}
+	}
+	return nil
+}
+
+func (r *fakeDataExportRepository) UserData(_ context.Context, userID string) ([]*entity.DataExportSection, error) {
+	sections := make([]*entity.DataExportSection, len(exportDatasets))
+	for i, name := range exportDatasets {
+		sections[i] = &entity.DataExportSection{
+			Name:    name,
+			JSON:    []byte(`[{"user_id":"` + userID + `"}]`),
+			Columns: []string{"user_id", "note"},
+			Rows:    [][]string{{userID, "with, a comma"}},
+		}
+	}
+	return sections, nil
+}
+
+func TestDataExportUseCase_Export(t *testing.T) {
+	files, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &fakeDataExportRepository{}
+	users := newFakeUserRepository(&entity.User{ID: "alice", IsActive: true})
+	uc := NewDataExportUseCase(users, repo, files, DataExportConfig{TTL: time.Hour, StaleAfter: time.Hour, LinkSecret: []byte("secret")})
+	ctx := context.Background()
+
+	export, err := uc.RequestExport(ctx, "alice")
+	if err != nil {
+		t.Fatalf("RequestExport() error = %v", err)
+	}
+	// A queued export is returned again rather than queueing another
+	if again, err := uc.RequestExport(ctx, "alice"); err != nil || again.ID != export.ID {
+		t.Fatalf("second RequestExport() = %v, %v, want the queued export", again, err)
+	}
+
+	if err := uc.ProcessPending(ctx); err != nil {
+		t.Fatalf("ProcessPending() error = %v", err)
+	}
+	export, err = uc.GetExport(ctx, "alice", export.ID)
+	if err != nil {
+		t.Fatalf("GetExport() error = %v", err)
+	}
+	if export.Status != entity.DataExportCompleted || export.ExpiresAt == nil {
+		t.Fatalf("export = %+v, want completed", export)
+	}
+	if _, err := uc.GetExport(ctx, "bob", export.ID); !errors.Is(err, ErrDataExportNotFound) {
+		t.Errorf("GetExport() of another user error = %v, want %v", err, ErrDataExportNotFound)
+	}
+
+	// The signed link opens the archive
+	if _, err := uc.OpenDownload(ctx, export.ID, export.ExpiresAt.Unix(), "forged"); !errors.Is(err, ErrDownloadLinkInvalid) {
+		t.Errorf("OpenDownload() with a forged signature error = %v, want %v", err, ErrDownloadLinkInvalid)
+	}
+	file, err := uc.OpenDownload(ctx, export.ID, export.ExpiresAt.Unix(), uc.DownloadSignature(export))
+	if err != nil {
+		t.Fatalf("OpenDownload() error = %v", err)
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+
+	entries := map[string]*zip.File{}
+	for _, f := range archive.File {
+		entries[f.Name] = f
+	}
+	if len(entries) != 2*len(exportDatasets) {
+		t.Errorf("archive holds %d files, want %d", len(entries), 2*len(exportDatasets))
+	}
+	for _, name := range exportDatasets {
+		if entries[name+".json"] == nil {
+			t.Errorf("archive lacks %s.json", name)
+		}
+		f := entries[name+".csv"]
+		if f == nil {
+			t.Errorf("archive lacks %s.csv", name)
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		records, err := csv.NewReader(r).ReadAll()
+		r.Close()
+		if err != nil || len(records) != 2 || records[0][0] != "user_id" || records[1][1] != "with, a comma" {
+			t.Errorf("%s.csv = %v, %v", name, records, err)
+		}
+	}
+}
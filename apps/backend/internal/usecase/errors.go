@@ -1,29 +1,218 @@
 package usecase
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+)
 
 var (
 	// ErrUserNotFound is returned when a user is not found
-	ErrUserNotFound = errors.New("user not found")
+	ErrUserNotFound = entity.NewNotFoundError("user_not_found", "user not found")
 
 	// ErrInvalidPassword is returned when a password is invalid
-	ErrInvalidPassword = errors.New("invalid password")
+	ErrInvalidPassword = entity.NewValidationError("invalid_password", "invalid password")
 
 	// ErrUsernameTaken is returned when a username is already taken
-	ErrUsernameTaken = errors.New("username already taken")
+	ErrUsernameTaken = entity.NewConflictError("username_taken", "username already taken")
 
 	// ErrEmailTaken is returned when an email is already taken
-	ErrEmailTaken = errors.New("email already taken")
+	ErrEmailTaken = entity.NewConflictError("email_taken", "email already taken")
 
 	// ErrInvalidInput is returned when input validation fails
-	ErrInvalidInput = errors.New("invalid input")
+	ErrInvalidInput = entity.NewValidationError("invalid_input", "invalid input")
 
 	// ErrUnauthorized is returned when a user is not authorized to perform an action
-	ErrUnauthorized = errors.New("unauthorized")
+	ErrUnauthorized = entity.NewUnauthorizedError("unauthorized", "unauthorized")
 
 	// ErrForbidden is returned when a user is forbidden from performing an action
-	ErrForbidden = errors.New("forbidden")
+	ErrForbidden = entity.NewForbiddenError("forbidden", "forbidden")
 
 	// ErrInternal is returned when an internal error occurs
 	ErrInternal = errors.New("internal error")
+
+	// ErrAccountInactive is returned when a deactivated user tries to sign in
+	ErrAccountInactive = entity.NewForbiddenError("account_inactive", "account is inactive")
+
+	// ErrAccountDeletionPending is returned when a user whose account is scheduled for deletion tries to sign in
+	ErrAccountDeletionPending = entity.NewForbiddenError("account_deletion_pending", "account is scheduled for deletion, cancel the deletion to use it again")
+
+	// ErrInvalidCredentials is returned when signing in with an unknown email or a wrong password
+	ErrInvalidCredentials = entity.NewUnauthorizedError("invalid_credentials", "invalid email or password")
+
+	// ErrAccountLocked is returned when signing in to an account locked after too many failed attempts
+	ErrAccountLocked = entity.NewTooManyRequestsError("account_locked", "too many failed sign in attempts, try again later")
+
+	// ErrInvalidTwoFactorCode is returned when a TOTP or recovery code is wrong or was already used
+	ErrInvalidTwoFactorCode = entity.NewUnauthorizedError("invalid_two_factor_code", "invalid two-factor code")
+
+	// ErrInvalidChallenge is returned when a two-factor challenge token is invalid or expired
+	ErrInvalidChallenge = entity.NewUnauthorizedError("invalid_challenge", "sign in challenge is invalid or expired, sign in again")
+
+	// ErrInvalidRefreshToken is returned when a refresh token is unknown, expired or was already used
+	ErrInvalidRefreshToken = entity.NewUnauthorizedError("invalid_refresh_token", "refresh token is invalid or expired")
+
+	// ErrInvalidAccessToken is returned when an access token is invalid or expired
+	ErrInvalidAccessToken = entity.NewUnauthorizedError("invalid_access_token", "access token is invalid or expired")
+
+	// ErrSessionNotFound is returned when a session is not found or was already signed out
+	ErrSessionNotFound = entity.NewNotFoundError("session_not_found", "session not found")
+
+	// ErrInvalidPersonalToken is returned when a personal access token is unknown, revoked or expired
+	ErrInvalidPersonalToken = entity.NewUnauthorizedError("invalid_personal_token", "personal access token is invalid, revoked or expired")
+
+	// ErrPersonalTokenNotFound is returned when a personal access token is not found or was already revoked
+	ErrPersonalTokenNotFound = entity.NewNotFoundError("personal_token_not_found", "personal access token not found")
+
+	// ErrPersonalTokenLimit is returned when creating a personal access token beyond the limit per user
+	ErrPersonalTokenLimit = entity.NewConflictError("personal_token_limit", "too many personal access tokens, revoke one first")
+
+	// ErrOIDCProviderNotFound is returned for a sign in provider that is not configured
+	ErrOIDCProviderNotFound = entity.NewNotFoundError("oidc_provider_not_found", "sign in provider not found")
+
+	// ErrOIDCInvalidState is returned when the state of a provider sign in is unknown, used or expired
+	ErrOIDCInvalidState = entity.NewValidationError("oidc_invalid_state", "sign in request is invalid or expired, start again")
+
+	// ErrOIDCLoginFailed is returned when the provider refuses the code or returns an invalid ID token
+	ErrOIDCLoginFailed = entity.NewUnauthorizedError("oidc_login_failed", "sign in with the provider failed")
+
+	// ErrOIDCEmailNotVerified is returned when a provider account signs in for the first time without a verified email
+	ErrOIDCEmailNotVerified = entity.NewForbiddenError("oidc_email_not_verified", "the provider has not verified the email of this account")
+
+	// ErrOIDCAccountNotLinkable is returned when the account with the provider's email has not verified it
+	ErrOIDCAccountNotLinkable = entity.NewConflictError("oidc_account_not_linkable", "an account with this email exists, sign in with its password and verify the email to link it")
+
+	// ErrIdentityNotFound is returned when a linked identity is not found
+	ErrIdentityNotFound = entity.NewNotFoundError("identity_not_found", "linked identity not found")
+
+	// ErrLastSignInMethod is returned when unlinking the only way a user without a password can sign in
+	ErrLastSignInMethod = entity.NewConflictError("last_sign_in_method", "cannot unlink the only way to sign in to this account")
+
+	// ErrOAuthClientNotFound is returned when an OAuth client is not found
+	ErrOAuthClientNotFound = entity.NewNotFoundError("oauth_client_not_found", "OAuth client not found")
+
+	// ErrOAuthConnectionNotFound is returned when disconnecting an app the user has not connected
+	ErrOAuthConnectionNotFound = entity.NewNotFoundError("oauth_connection_not_found", "connected app not found")
+
+	// ErrOAuthInvalidRedirectURI is returned when an authorization request names a redirect URI the client did not register
+	ErrOAuthInvalidRedirectURI = entity.NewValidationError("invalid_redirect_uri", "redirect URI is not registered for the client")
+
+	// OAuth errors keep the error codes of RFC 6749, which clients are built to handle
+
+	// ErrOAuthInvalidRequest is returned when an OAuth request misses a parameter
+	ErrOAuthInvalidRequest = entity.NewValidationError("invalid_request", "the request is missing a required parameter")
+
+	// ErrOAuthInvalidClient is returned when an OAuth client fails to authenticate
+	ErrOAuthInvalidClient = entity.NewUnauthorizedError("invalid_client", "client authentication failed")
+
+	// ErrOAuthInvalidGrant is returned when an authorization code or refresh token is invalid, expired, used or issued to another client
+	ErrOAuthInvalidGrant = entity.NewValidationError("invalid_grant", "authorization code or refresh token is invalid or expired")
+
+	// ErrOAuthInvalidScope is returned when an authorization request asks for scopes the client cannot have
+	ErrOAuthInvalidScope = entity.NewValidationError("invalid_scope", "requested scope is invalid")
+
+	// ErrOAuthUnsupportedGrantType is returned for grant types other than authorization_code and refresh_token
+	ErrOAuthUnsupportedGrantType = entity.NewValidationError("unsupported_grant_type", "grant type is not supported")
+
+	// ErrOAuthUnsupportedResponseType is returned for response types other than code
+	ErrOAuthUnsupportedResponseType = entity.NewValidationError("unsupported_response_type", "response type is not supported")
+
+	// ErrTwoFactorAlreadyEnabled is returned when enrolling a user who already has two-factor authentication
+	ErrTwoFactorAlreadyEnabled = entity.NewConflictError("two_factor_already_enabled", "two-factor authentication is already enabled")
+
+	// ErrTwoFactorNotEnrolled is returned when confirming two-factor authentication before enrolling
+	ErrTwoFactorNotEnrolled = entity.NewConflictError("two_factor_not_enrolled", "two-factor authentication enrollment was not started")
+
+	// ErrTwoFactorNotEnabled is returned when managing two-factor authentication of a user who does not use it
+	ErrTwoFactorNotEnabled = entity.NewConflictError("two_factor_not_enabled", "two-factor authentication is not enabled")
+
+	// ErrAccountDeletionNotScheduled is returned when cancelling the deletion of an account that is not scheduled for deletion
+	ErrAccountDeletionNotScheduled = entity.NewConflictError("account_deletion_not_scheduled", "account is not scheduled for deletion")
+
+	// ErrRoleNotFound is returned when a role is not found
+	ErrRoleNotFound = entity.NewNotFoundError("role_not_found", "role not found")
+
+	// ErrFoodItemNotFound is returned when a food item is not found
+	ErrFoodItemNotFound = entity.NewNotFoundError("food_item_not_found", "food item not found")
+
+	// ErrInvalidBarcode is returned for a barcode that is not a valid UPC-A, EAN-13 or EAN-8 code
+	ErrInvalidBarcode = entity.NewValidationError("invalid_barcode", "invalid barcode")
+
+	// ErrFoodCatalogUnavailable is returned when a barcode cannot be looked up in the external food catalog
+	ErrFoodCatalogUnavailable = entity.NewBadGatewayError("food_catalog_unavailable", "the food catalog cannot be reached, try again later")
+
+	// ErrMealNotFound is returned when a meal is not found
+	ErrMealNotFound = entity.NewNotFoundError("meal_not_found", "meal not found")
+
+	// ErrMealFoodItemNotFound is returned when a food item of a meal is not found
+	ErrMealFoodItemNotFound = entity.NewNotFoundError("meal_food_item_not_found", "meal food item not found")
+
+	// ErrWorkoutSessionNotFound is returned when a workout session is not found
+	ErrWorkoutSessionNotFound = entity.NewNotFoundError("workout_session_not_found", "workout session not found")
+
+	// ErrSessionLogNotFound is returned when an exercise log of a workout session is not found
+	ErrSessionLogNotFound = entity.NewNotFoundError("session_log_not_found", "workout session exercise not found")
+
+	// ErrNutritionGoalsNotFound is returned when nutrition goals are not found
+	ErrNutritionGoalsNotFound = entity.NewNotFoundError("nutrition_goals_not_found", "nutrition goals not found")
+
+	// ErrBiometricsNotFound is returned when a biometrics entry is not found
+	ErrBiometricsNotFound = entity.NewNotFoundError("biometrics_not_found", "biometrics not found")
+
+	// ErrExerciseNotFound is returned when an exercise is not found
+	ErrExerciseNotFound = entity.NewNotFoundError("exercise_not_found", "exercise not found")
+
+	// ErrWorkoutPlanNotFound is returned when a workout plan is not found
+	ErrWorkoutPlanNotFound = entity.NewNotFoundError("workout_plan_not_found", "workout plan not found")
+
+	// ErrTrashItemNotFound is returned when a deleted record is not in the trash or can no longer be restored
+	ErrTrashItemNotFound = entity.NewNotFoundError("trash_item_not_found", "trash item not found")
+
+	// ErrDataExportNotFound is returned when a data export is not found
+	ErrDataExportNotFound = entity.NewNotFoundError("data_export_not_found", "data export not found")
+
+	// ErrDownloadLinkInvalid is returned when a download link was not issued by the server
+	ErrDownloadLinkInvalid = entity.NewForbiddenError("download_link_invalid", "download link is invalid")
+
+	// ErrDownloadLinkExpired is returned when a download link or the file it points to has expired
+	ErrDownloadLinkExpired = entity.NewNotFoundError("download_link_expired", "download link has expired")
+
+	// ErrInvalidUploadTarget is returned when an upload names a field images cannot be uploaded to
+	ErrInvalidUploadTarget = entity.NewValidationError("invalid_upload_target", "upload target must be user_profile_picture, exercise_image or workout_plan_cover")
+
+	// ErrUploadTooLarge is returned when an uploaded file is over the size limit
+	ErrUploadTooLarge = entity.NewPayloadTooLargeError("upload_too_large", "uploaded file is too large")
+
+	// ErrImageTooLarge is returned when an uploaded image has more pixels than can be processed
+	ErrImageTooLarge = entity.NewPayloadTooLargeError("image_too_large", "image dimensions are too large")
+
+	// ErrUnsupportedImageType is returned when an uploaded file is not a JPEG, PNG or WebP image
+	ErrUnsupportedImageType = entity.NewUnsupportedMediaTypeError("unsupported_image_type", "image must be a JPEG, PNG or WebP")
+
+	// ErrUploadNotFound is returned when an uploaded file does not exist
+	ErrUploadNotFound = entity.NewNotFoundError("upload_not_found", "upload not found")
+
+	// ErrProgressPhotoNotFound is returned when a progress photo is not found or the user may not see it
+	ErrProgressPhotoNotFound = entity.NewNotFoundError("progress_photo_not_found", "progress photo not found")
+
+	// ErrInvalidProgressPhotoPose is returned when a progress photo names a pose other than front, side or back
+	ErrInvalidProgressPhotoPose = entity.NewValidationError("invalid_progress_photo_pose", "pose must be front, side or back")
+
+	// ErrPhotoLinkInvalid is returned when a progress photo link was not issued by the server or has expired
+	ErrPhotoLinkInvalid = entity.NewForbiddenError("photo_link_invalid", "photo link is invalid or has expired")
+
+	// ErrCoachNotFound is returned when revoking a coach the user has not authorised
+	ErrCoachNotFound = entity.NewNotFoundError("coach_not_found", "coach not found")
+
+	// ErrCoachUserNotFound is returned when authorising a coach whose username does not exist
+	ErrCoachUserNotFound = entity.NewNotFoundError("coach_user_not_found", "no user with this username")
+
+	// ErrCoachSelf is returned when users try to authorise themselves as their coach
+	ErrCoachSelf = entity.NewValidationError("coach_self", "you cannot be your own coach")
+
+	// ErrIdempotencyKeyReused is returned when an idempotency key is sent again with a different request
+	ErrIdempotencyKeyReused = entity.NewConflictError("idempotency_key_reused", "idempotency key was already used for a different request")
+
+	// ErrIdempotencyKeyInProgress is returned when the request that first used an idempotency key has not finished yet
+	ErrIdempotencyKeyInProgress = entity.NewConflictError("idempotency_key_in_progress", "a request with this idempotency key is still being processed")
 )
@@ -8,6 +8,7 @@ import (
 
 	"github.com/terrnit/rebound/backend/internal/entity"
 	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
 )
 
 // ExerciseUseCase represents the exercise use case
@@ -26,6 +27,9 @@ func NewExerciseUseCase(r repository.ExerciseRepository, config Config) *Exercis
 
 // CreateExercise creates a new exercise
 func (uc *ExerciseUseCase) CreateExercise(ctx context.Context, exercise *entity.Exercise) (*entity.Exercise, error) {
+	ctx, span := tracing.Start(ctx, "ExerciseUseCase.CreateExercise")
+	defer span.End()
+
 	exercise.ID = uuid.New().String()
 	exercise.CreatedAt = time.Now()
 	exercise.UpdatedAt = time.Now()
@@ -35,11 +39,24 @@ func (uc *ExerciseUseCase) CreateExercise(ctx context.Context, exercise *entity.
 
 // GetExercise retrieves an exercise by its ID
 func (uc *ExerciseUseCase) GetExercise(ctx context.Context, exerciseID string) (*entity.Exercise, error) {
-	return uc.repo.GetByID(ctx, exerciseID)
+	ctx, span := tracing.Start(ctx, "ExerciseUseCase.GetExercise")
+	defer span.End()
+
+	exercise, err := uc.repo.GetByID(ctx, exerciseID)
+	if err != nil {
+		return nil, err
+	}
+	if exercise == nil {
+		return nil, ErrExerciseNotFound
+	}
+	return exercise, nil
 }
 
-// ListExercises returns a paginated list of exercises matching the filters
-func (uc *ExerciseUseCase) ListExercises(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]*entity.Exercise, int64, error) {
+// ListExercises returns a paginated list of exercises matching the query spec
+func (uc *ExerciseUseCase) ListExercises(ctx context.Context, spec repository.QuerySpec, page, pageSize int) ([]*entity.Exercise, int64, error) {
+	ctx, span := tracing.Start(ctx, "ExerciseUseCase.ListExercises")
+	defer span.End()
+
 	// Validate page size
 	if pageSize <= 0 {
 		pageSize = uc.config.DefaultPageSize
@@ -49,13 +66,13 @@ func (uc *ExerciseUseCase) ListExercises(ctx context.Context, filters map[string
 	}
 
 	// Get total count
-	total, err := uc.repo.Count(ctx, filters)
+	total, err := uc.repo.Count(ctx, spec)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// Get exercises
-	items, err := uc.repo.List(ctx, filters, page, pageSize)
+	items, err := uc.repo.List(ctx, spec, page, pageSize)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -65,17 +82,26 @@ func (uc *ExerciseUseCase) ListExercises(ctx context.Context, filters map[string
 
 // UpdateExercise updates an existing exercise
 func (uc *ExerciseUseCase) UpdateExercise(ctx context.Context, exercise *entity.Exercise) error {
+	ctx, span := tracing.Start(ctx, "ExerciseUseCase.UpdateExercise")
+	defer span.End()
+
 	exercise.UpdatedAt = time.Now()
 	return uc.repo.Update(ctx, exercise)
 }
 
 // DeleteExercise deletes an exercise
 func (uc *ExerciseUseCase) DeleteExercise(ctx context.Context, exerciseID string) error {
+	ctx, span := tracing.Start(ctx, "ExerciseUseCase.DeleteExercise")
+	defer span.End()
+
 	return uc.repo.Delete(ctx, exerciseID)
 }
 
 // SearchExercises searches for exercises by name or description
 func (uc *ExerciseUseCase) SearchExercises(ctx context.Context, query string, page, pageSize int) ([]*entity.Exercise, int64, error) {
+	ctx, span := tracing.Start(ctx, "ExerciseUseCase.SearchExercises")
+	defer span.End()
+
 	// Validate page size
 	if pageSize <= 0 {
 		pageSize = uc.config.DefaultPageSize
@@ -85,8 +111,8 @@ func (uc *ExerciseUseCase) SearchExercises(ctx context.Context, query string, pa
 	}
 
 	// Get total count
-	total, err := uc.repo.Count(ctx, map[string]interface{}{
-		"exercise_name": query,
+	total, err := uc.repo.Count(ctx, repository.QuerySpec{
+		Filters: []repository.Filter{{Field: "name", Operator: repository.OpILike, Value: query}},
 	})
 	if err != nil {
 		return nil, 0, err
@@ -103,6 +129,9 @@ func (uc *ExerciseUseCase) SearchExercises(ctx context.Context, query string, pa
 
 // GetUserExercises retrieves exercises created by a specific user
 func (uc *ExerciseUseCase) GetUserExercises(ctx context.Context, userID string, page, pageSize int) ([]*entity.Exercise, error) {
+	ctx, span := tracing.Start(ctx, "ExerciseUseCase.GetUserExercises")
+	defer span.End()
+
 	// Validate page size
 	if pageSize <= 0 {
 		pageSize = uc.config.DefaultPageSize
@@ -0,0 +1,439 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/barcode"
+	"github.com/terrnit/rebound/backend/pkg/fooddata"
+	"github.com/terrnit/rebound/backend/pkg/openfoodfacts"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
+)
+
+const (
+	// foodImportSourceUSDA and foodImportSourceOpenFoodFacts prefix the source IDs of imported food items
+	foodImportSourceUSDA          = "usda"
+	foodImportSourceOpenFoodFacts = "off"
+
+	// defaultFoodImportBatchSize is used when no batch size is configured
+	defaultFoodImportBatchSize = 1000
+)
+
+// Column limits of food items and serving units
+const (
+	maxFoodItemNameLength     = 255
+	maxFoodItemBrandLength    = 150
+	maxServingUnitNameLength  = 50
+	maxServingUnitEquivalent  = 999999.9999
+	maxFoodItemGramsPer100g   = 100
+	maxFoodItemCaloriesPer100 = 900
+	maxFoodItemSmallAmount    = 999999.99
+	maxFoodItemLargeAmount    = 99999999.99
+)
+
+// usdaNutrients are the FoodData Central nutrients that food items hold
+var usdaNutrients = []int{
+	fooddata.NutrientEnergy, fooddata.NutrientEnergyAtwaterSpec, fooddata.NutrientEnergyAtwaterGen, fooddata.NutrientEnergyKJ,
+	fooddata.NutrientProtein, fooddata.NutrientFat, fooddata.NutrientCarbohydrate, fooddata.NutrientFiber,
+	fooddata.NutrientSugars, fooddata.NutrientSugarsTotal, fooddata.NutrientSaturatedFat, fooddata.NutrientTransFat,
+	fooddata.NutrientCholesterol, fooddata.NutrientSodium, fooddata.NutrientPotassium, fooddata.NutrientVitaminA,
+	fooddata.NutrientVitaminC, fooddata.NutrientCalcium, fooddata.NutrientIron,
+}
+
+// servingUnitAbbreviations abbreviates the household measures food databases use
+var servingUnitAbbreviations = map[string]string{
+	"cup": "cup", "cups": "cup", "tablespoon": "tbsp", "tbsp": "tbsp", "teaspoon": "tsp", "tsp": "tsp",
+	"ounce": "oz", "oz": "oz", "fl": "fl oz", "slice": "slice", "slices": "slice", "piece": "pc", "pieces": "pc",
+	"package": "pkg", "container": "cont", "bottle": "btl", "can": "can", "bar": "bar", "packet": "pkt",
+	"g": "g", "ml": "ml", "liter": "l", "pint": "pt", "quart": "qt", "gallon": "gal", "pound": "lb", "lb": "lb",
+}
+
+// FoodImportReporter is told how an import is going
+type FoodImportReporter interface {
+	// Progress is called after each batch with the totals so far
+	Progress(result entity.FoodImportResult)
+	// Reject is called for each record that is not imported, with the reason
+	Reject(err error)
+}
+
+// FoodImportUseCase fills the food catalogue from the data dumps of food databases.
+// Dumps have millions of rows: they are streamed and written in batches.
+type FoodImportUseCase struct {
+	repo      repository.FoodImportRepository
+	batchSize int
+}
+
+// NewFoodImportUseCase creates a new instance of FoodImportUseCase
+func NewFoodImportUseCase(r repository.FoodImportRepository, batchSize int) *FoodImportUseCase {
+	if batchSize <= 0 {
+		batchSize = defaultFoodImportBatchSize
+	}
+	return &FoodImportUseCase{repo: r, batchSize: batchSize}
+}
+
+// ImportUSDA imports the foods of a FoodData Central download, a directory of CSV files. dataTypes limits the
+// foods imported, to fooddata.DefaultDataTypes when empty. USDA data is imported as verified system food items.
+func (uc *FoodImportUseCase) ImportUSDA(ctx context.Context, dir string, dataTypes []string, reporter FoodImportReporter) (entity.FoodImportResult, error) {
+	ctx, span := tracing.Start(ctx, "FoodImportUseCase.ImportUSDA")
+	defer span.End()
+
+	batch := uc.newBatch(reporter)
+	err := fooddata.Read(ctx, fooddata.Config{
+		Dir:       dir,
+		DataTypes: dataTypes,
+		Nutrients: usdaNutrients,
+		// Rows of the other files are not records; their food is rejected if it lacks what they held
+		OnError: reporter.Reject,
+	}, func(food *fooddata.Food) error {
+		record, err := foodImportFromUSDA(food)
+		if err != nil {
+			batch.reject(err)
+			return nil
+		}
+		return batch.add(ctx, record)
+	})
+	if err == nil {
+		err = batch.flush(ctx)
+	}
+	return batch.result, err
+}
+
+// ImportOpenFoodFacts imports the products of an Open Food Facts dump in the JSON lines format. Products without
+// a valid barcode, a name or their energy are rejected. Open Food Facts data is imported as unverified api food items.
+func (uc *FoodImportUseCase) ImportOpenFoodFacts(ctx context.Context, r io.Reader, reporter FoodImportReporter) (entity.FoodImportResult, error) {
+	ctx, span := tracing.Start(ctx, "FoodImportUseCase.ImportOpenFoodFacts")
+	defer span.End()
+
+	batch := uc.newBatch(reporter)
+	scanner := openfoodfacts.NewScanner(r)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return batch.result, err
+		}
+		product, err := scanner.Product()
+		if err != nil {
+			batch.reject(err)
+			continue
+		}
+		record, err := foodImportFromOpenFoodFacts(product)
+		if err != nil {
+			batch.reject(fmt.Errorf("line %d: %w", scanner.Line(), err))
+			continue
+		}
+		if err := batch.add(ctx, record); err != nil {
+			return batch.result, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return batch.result, err
+	}
+	return batch.result, batch.flush(ctx)
+}
+
+// foodImportBatch collects records until there are enough to write
+type foodImportBatch struct {
+	uc        *FoodImportUseCase
+	reporter  FoodImportReporter
+	records   []*entity.FoodImportRecord
+	sourceIDs map[string]bool
+	barcodes  map[string]bool
+	result    entity.FoodImportResult
+}
+
+func (uc *FoodImportUseCase) newBatch(reporter FoodImportReporter) *foodImportBatch {
+	return &foodImportBatch{
+		uc:        uc,
+		reporter:  reporter,
+		sourceIDs: make(map[string]bool, uc.batchSize),
+		barcodes:  make(map[string]bool, uc.batchSize),
+	}
+}
+
+// add adds a record, writing the batch when it is full. Records of a batch cannot share a source ID or a barcode,
+// so a record that does is written with the next batch, and updates the item of the first.
+func (b *foodImportBatch) add(ctx context.Context, record *entity.FoodImportRecord) error {
+	code := record.Item.BarcodeUPC
+	if b.sourceIDs[record.SourceID] || (code != nil && b.barcodes[*code]) {
+		if err := b.flush(ctx); err != nil {
+			return err
+		}
+	}
+	b.records = append(b.records, record)
+	b.sourceIDs[record.SourceID] = true
+	if code != nil {
+		b.barcodes[*code] = true
+	}
+	if len(b.records) >= b.uc.batchSize {
+		return b.flush(ctx)
+	}
+	return nil
+}
+
+// reject counts a record that is not imported
+func (b *foodImportBatch) reject(err error) {
+	b.result.Read++
+	b.result.Rejected++
+	b.reporter.Reject(err)
+}
+
+// flush writes the records collected
+func (b *foodImportBatch) flush(ctx context.Context) error {
+	if len(b.records) == 0 {
+		return nil
+	}
+	result, err := b.uc.repo.Upsert(ctx, b.records)
+	if err != nil {
+		return fmt.Errorf("FoodImportUseCase - batch ending with %s: %w", b.records[len(b.records)-1].SourceID, err)
+	}
+	b.result.Add(result)
+	b.records = b.records[:0]
+	clear(b.sourceIDs)
+	clear(b.barcodes)
+	b.reporter.Progress(b.result)
+	return nil
+}
+
+// foodImportFromUSDA maps a FoodData Central food to a food item of 100 g
+func foodImportFromUSDA(food *fooddata.Food) (*entity.FoodImportRecord, error) {
+	sourceID := foodImportSourceUSDA + ":" + strconv.Itoa(food.FDCID)
+	nutrient := func(ids ...int) *float64 {
+		for _, id := range ids {
+			if v, ok := food.Nutrients[id]; ok {
+				return &v
+			}
+		}
+		return nil
+	}
+	value := func(id int) float64 {
+		if v, ok := food.Nutrients[id]; ok {
+			return v
+		}
+		return 0
+	}
+
+	calories := nutrient(fooddata.NutrientEnergy, fooddata.NutrientEnergyAtwaterSpec, fooddata.NutrientEnergyAtwaterGen)
+	if calories == nil {
+		if kj := nutrient(fooddata.NutrientEnergyKJ); kj != nil {
+			kcal := *kj / 4.184
+			calories = &kcal
+		}
+	}
+	if calories == nil {
+		return nil, fmt.Errorf("%s: no energy", sourceID)
+	}
+
+	now := time.Now()
+	item := &entity.FoodItem{
+		ID:                                 uuid.New().String(),
+		Name:                               food.Description,
+		ServingSizeDefaultQty:              100,
+		ServingSizeDefaultUnit:             "g",
+		CaloriesPerDefaultServing:          *calories,
+		ProteinGramsPerDefaultServing:      value(fooddata.NutrientProtein),
+		FatGramsPerDefaultServing:          value(fooddata.NutrientFat),
+		CarbsGramsPerDefaultServing:        value(fooddata.NutrientCarbohydrate),
+		FiberGramsPerDefaultServing:        nutrient(fooddata.NutrientFiber),
+		SugarGramsPerDefaultServing:        nutrient(fooddata.NutrientSugars, fooddata.NutrientSugarsTotal),
+		SaturatedFatGramsPerDefaultServing: nutrient(fooddata.NutrientSaturatedFat),
+		TransFatGramsPerDefaultServing:     nutrient(fooddata.NutrientTransFat),
+		CholesterolMgPerDefaultServing:     nutrient(fooddata.NutrientCholesterol),
+		SodiumMgPerDefaultServing:          nutrient(fooddata.NutrientSodium),
+		PotassiumMgPerDefaultServing:       nutrient(fooddata.NutrientPotassium),
+		VitaminAMcgPerDefaultServing:       nutrient(fooddata.NutrientVitaminA),
+		VitaminCMgPerDefaultServing:        nutrient(fooddata.NutrientVitaminC),
+		CalciumMgPerDefaultServing:         nutrient(fooddata.NutrientCalcium),
+		IronMgPerDefaultServing:            nutrient(fooddata.NutrientIron),
+		Source:                             entity.FoodItemSourceSystem,
+		IsVerified:                         true,
+		CreatedAt:                          now,
+		UpdatedAt:                          now,
+	}
+	if brand := food.BrandName; brand != "" {
+		item.BrandName = &brand
+	} else if brand := food.BrandOwner; brand != "" {
+		item.BrandName = &brand
+	}
+	// A food with an invalid GTIN is still identified by its FDC ID
+	if code, err := barcode.Normalize(food.GTINUPC); err == nil {
+		item.BarcodeUPC = &code
+	}
+
+	record := &entity.FoodImportRecord{SourceID: sourceID, Item: item}
+	if food.ServingSize > 0 {
+		name := food.HouseholdServing
+		if name == "" {
+			name = "serving"
+		}
+		switch food.ServingSizeUnit {
+		case "g":
+			record.ServingUnits = addServingUnit(record.ServingUnits, name, &food.ServingSize, nil)
+		case "ml":
+			record.ServingUnits = addServingUnit(record.ServingUnits, name, nil, &food.ServingSize)
+		}
+	}
+	for _, portion := range food.Portions {
+		// Survey foods describe whole portions, such as 1 cup; the others describe an amount of a unit
+		if portion.Description != "" {
+			grams := portion.GramWeight
+			record.ServingUnits = addServingUnit(record.ServingUnits, portion.Description, &grams, nil)
+			continue
+		}
+		if portion.Amount <= 0 {
+			continue
+		}
+		name := portion.Unit
+		switch {
+		case name == "":
+			name = portion.Modifier
+		case portion.Modifier != "":
+			name += ", " + portion.Modifier
+		}
+		grams := portion.GramWeight / portion.Amount
+		record.ServingUnits = addServingUnit(record.ServingUnits, name, &grams, nil)
+	}
+
+	if err := validateFoodImport(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// foodImportFromOpenFoodFacts maps an Open Food Facts product like barcode lookups do, with its serving size
+func foodImportFromOpenFoodFacts(product *openfoodfacts.Product) (*entity.FoodImportRecord, error) {
+	code, err := barcode.Normalize(product.Code)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%s: %w", foodImportSourceOpenFoodFacts, product.Code, err)
+	}
+	sourceID := foodImportSourceOpenFoodFacts + ":" + code
+	item := foodItemFromProduct(code, product)
+	if item == nil {
+		return nil, fmt.Errorf("%s: no name or energy", sourceID)
+	}
+
+	record := &entity.FoodImportRecord{SourceID: sourceID, Item: item}
+	if product.ServingQuantity != nil && *product.ServingQuantity > 0 {
+		name := strings.TrimSpace(product.ServingSize)
+		if name == "" {
+			name = "serving"
+		}
+		quantity := float64(*product.ServingQuantity)
+		if strings.EqualFold(product.ServingQuantityUnit, "ml") {
+			record.ServingUnits = addServingUnit(record.ServingUnits, name, nil, &quantity)
+		} else {
+			record.ServingUnits = addServingUnit(record.ServingUnits, name, &quantity, nil)
+		}
+	}
+
+	if err := validateFoodImport(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// addServingUnit adds a household measure to the serving units of a food, unless it has one of the same name
+// or its weight does not fit
+func addServingUnit(units []*entity.ServingUnit, name string, grams, ml *float64) []*entity.ServingUnit {
+	name = truncateRunes(strings.Join(strings.Fields(name), " "), maxServingUnitNameLength)
+	if name == "" {
+		return units
+	}
+	for _, equivalent := range []*float64{grams, ml} {
+		if equivalent != nil && (*equivalent <= 0 || *equivalent > maxServingUnitEquivalent) {
+			return units
+		}
+	}
+	for _, unit := range units {
+		if unit.UnitName == name {
+			return units
+		}
+	}
+	return append(units, &entity.ServingUnit{
+		UnitName:        name,
+		Abbreviation:    servingUnitAbbreviation(name),
+		GramsEquivalent: grams,
+		MlEquivalent:    ml,
+	})
+}
+
+// servingUnitAbbreviation abbreviates the first known measure in the name of a serving unit, such as tbsp for
+// 1 tablespoon, chopped. Other units are servings.
+func servingUnitAbbreviation(name string) string {
+	for _, word := range strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r == ' ' || r == ',' || r == '(' || r == ')' || r == '.'
+	}) {
+		if abbreviation, ok := servingUnitAbbreviations[word]; ok {
+			return abbreviation
+		}
+	}
+	return "srv"
+}
+
+// validateFoodImport checks that the amounts of a record are per 100 g and fit the food item columns,
+// shortening the names that do not
+func validateFoodImport(record *entity.FoodImportRecord) error {
+	item := record.Item
+	item.Name = truncateRunes(strings.Join(strings.Fields(item.Name), " "), maxFoodItemNameLength)
+	if item.Name == "" {
+		return fmt.Errorf("%s: no name", record.SourceID)
+	}
+	if item.BrandName != nil {
+		brand := truncateRunes(strings.TrimSpace(*item.BrandName), maxFoodItemBrandLength)
+		item.BrandName = &brand
+		if brand == "" {
+			item.BrandName = nil
+		}
+	}
+
+	optional := func(v *float64) float64 {
+		if v == nil {
+			return 0
+		}
+		return *v
+	}
+	amounts := []struct {
+		name  string
+		value float64
+		max   float64
+	}{
+		{"calories", item.CaloriesPerDefaultServing, maxFoodItemCaloriesPer100},
+		{"protein", item.ProteinGramsPerDefaultServing, maxFoodItemGramsPer100g},
+		{"fat", item.FatGramsPerDefaultServing, maxFoodItemGramsPer100g},
+		{"carbs", item.CarbsGramsPerDefaultServing, maxFoodItemGramsPer100g},
+		{"fiber", optional(item.FiberGramsPerDefaultServing), maxFoodItemGramsPer100g},
+		{"sugar", optional(item.SugarGramsPerDefaultServing), maxFoodItemGramsPer100g},
+		{"saturated fat", optional(item.SaturatedFatGramsPerDefaultServing), maxFoodItemGramsPer100g},
+		{"trans fat", optional(item.TransFatGramsPerDefaultServing), maxFoodItemGramsPer100g},
+		{"cholesterol", optional(item.CholesterolMgPerDefaultServing), maxFoodItemSmallAmount},
+		{"sodium", optional(item.SodiumMgPerDefaultServing), maxFoodItemSmallAmount},
+		{"potassium", optional(item.PotassiumMgPerDefaultServing), maxFoodItemSmallAmount},
+		{"vitamin A", optional(item.VitaminAMcgPerDefaultServing), maxFoodItemLargeAmount},
+		{"vitamin C", optional(item.VitaminCMgPerDefaultServing), maxFoodItemLargeAmount},
+		{"calcium", optional(item.CalciumMgPerDefaultServing), maxFoodItemLargeAmount},
+		{"iron", optional(item.IronMgPerDefaultServing), maxFoodItemLargeAmount},
+	}
+	for _, amount := range amounts {
+		// Amounts are rounded to two decimals when stored, so a rounding error in the source does not count
+		if math.IsNaN(amount.value) || amount.value < -0.005 || amount.value > amount.max+0.005 {
+			return fmt.Errorf("%s: %s of %g per 100 g is out of range", record.SourceID, amount.name, amount.value)
+		}
+	}
+	return nil
+}
+
+// truncateRunes shortens s to at most n characters
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return strings.TrimSpace(string([]rune(s)[:n]))
+}
@@ -2,12 +2,18 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/terrnit/rebound/backend/internal/entity"
 	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/barcode"
+	"github.com/terrnit/rebound/backend/pkg/openfoodfacts"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
 )
 
 type Config struct {
@@ -15,32 +21,221 @@ type Config struct {
 	MaxPageSize     int
 }
 
+// FoodCatalog is an external catalog of packaged food, implemented by *openfoodfacts.Client and
+// by *openfoodfacts.Static. Product returns nil when the catalog does not know a barcode.
+type FoodCatalog interface {
+	Product(ctx context.Context, code string) (*openfoodfacts.Product, error)
+}
+
+// FoodItemUseCase handles the shared food catalogue.
+// Items are edited by their creator or an admin, edits are recorded in the audit log.
 type FoodItemUseCase struct {
-	repo   repository.FoodItemRepository
-	config Config
+	repo     repository.FoodItemRepository
+	roleRepo repository.RoleRepository
+	catalog  FoodCatalog
+	audit    auditor
+	config   Config
 }
 
-// New creates a new instance of FoodItemUseCase
-func NewFoodItemUseCase(r repository.FoodItemRepository, config Config) *FoodItemUseCase {
+// New creates a new instance of FoodItemUseCase, a nil catalog limits barcode lookups to the local catalogue
+func NewFoodItemUseCase(r repository.FoodItemRepository, roleRepo repository.RoleRepository, catalog FoodCatalog, auditRepo repository.AuditLogRepository, config Config) *FoodItemUseCase {
 	return &FoodItemUseCase{
-		repo:   r,
-		config: config,
+		repo:     r,
+		roleRepo: roleRepo,
+		catalog:  catalog,
+		audit:    auditor{repo: auditRepo},
+		config:   config,
 	}
 }
 
-func (uc *FoodItemUseCase) CreateFoodItem(ctx context.Context, foodItem *entity.FoodItem) (*entity.FoodItem, error) {
+// CreateFoodItem adds a food item to the catalogue, created by userID
+func (uc *FoodItemUseCase) CreateFoodItem(ctx context.Context, userID string, foodItem *entity.FoodItem) (*entity.FoodItem, error) {
+	ctx, span := tracing.Start(ctx, "FoodItemUseCase.CreateFoodItem")
+	defer span.End()
+
 	foodItem.ID = uuid.New().String()
+	foodItem.CreatedByUserID = nil
+	if userID != "" {
+		foodItem.CreatedByUserID = &userID
+	}
 	foodItem.CreatedAt = time.Now()
 	foodItem.UpdatedAt = time.Now()
 
-	return uc.repo.Create(ctx, foodItem)
+	created, err := uc.repo.Create(ctx, foodItem)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := auditChanges(nil, created)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.audit.record(ctx, entity.AuditActionFoodItemCreate, entity.AuditTargetFoodItem, created.ID, changes); err != nil {
+		return nil, err
+	}
+	return created, nil
 }
 
 func (uc *FoodItemUseCase) GetFoodItem(ctx context.Context, foodItemID string) (*entity.FoodItem, error) {
-	return uc.repo.GetByID(ctx, foodItemID)
+	ctx, span := tracing.Start(ctx, "FoodItemUseCase.GetFoodItem")
+	defer span.End()
+
+	foodItem, err := uc.repo.GetByID(ctx, foodItemID)
+	if err != nil {
+		return nil, err
+	}
+	if foodItem == nil {
+		return nil, ErrFoodItemNotFound
+	}
+	return foodItem, nil
+}
+
+// GetFoodItemByBarcode looks up a food item by its UPC-A, EAN-13 or EAN-8 barcode. Barcodes missing from the
+// local catalogue are looked up in the external catalog, whose product is added to the local catalogue.
+func (uc *FoodItemUseCase) GetFoodItemByBarcode(ctx context.Context, code string) (*entity.FoodItem, error) {
+	ctx, span := tracing.Start(ctx, "FoodItemUseCase.GetFoodItemByBarcode")
+	defer span.End()
+
+	code, err := barcode.Normalize(code)
+	if errors.Is(err, barcode.ErrInvalidChecksum) {
+		return nil, ErrInvalidBarcode.WithField("code", "check digit does not match")
+	}
+	if err != nil {
+		return nil, ErrInvalidBarcode.WithField("code", "must be a UPC-A, EAN-13 or EAN-8 barcode")
+	}
+
+	foodItem, err := uc.localByBarcode(ctx, code)
+	if err != nil || foodItem != nil {
+		return foodItem, err
+	}
+	if uc.catalog == nil {
+		return nil, ErrFoodItemNotFound
+	}
+
+	product, err := uc.catalog.Product(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("FoodItemUseCase - GetFoodItemByBarcode - catalog.Product: %w: %w", ErrFoodCatalogUnavailable, err)
+	}
+	foodItem = foodItemFromProduct(code, product)
+	if foodItem == nil {
+		return nil, ErrFoodItemNotFound
+	}
+
+	created, err := uc.repo.Create(ctx, foodItem)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		// Another lookup of the same barcode added it first
+		existing, getErr := uc.localByBarcode(ctx, code)
+		if getErr != nil {
+			return nil, getErr
+		}
+		if existing != nil {
+			return existing, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := auditChanges(nil, created)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.audit.record(ctx, entity.AuditActionFoodItemCreate, entity.AuditTargetFoodItem, created.ID, changes); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// localByBarcode finds a food item by a normalised barcode, also under its UPC-A form
+func (uc *FoodItemUseCase) localByBarcode(ctx context.Context, code string) (*entity.FoodItem, error) {
+	foodItem, err := uc.repo.GetByBarcode(ctx, code)
+	if err != nil || foodItem != nil {
+		return foodItem, err
+	}
+	if upca, ok := barcode.UPCA(code); ok {
+		return uc.repo.GetByBarcode(ctx, upca)
+	}
+	return nil, nil
 }
 
-func (uc *FoodItemUseCase) ListFoodItems(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]*entity.FoodItem, int64, error) {
+// foodItemFromProduct maps an external product to a food item per 100 g. Products without a name or
+// an energy value are of no use for tracking and map to nil.
+func foodItemFromProduct(code string, product *openfoodfacts.Product) *entity.FoodItem {
+	if product == nil {
+		return nil
+	}
+	name := strings.TrimSpace(product.ProductName)
+	if name == "" {
+		name = strings.TrimSpace(product.GenericName)
+	}
+	if name == "" {
+		return nil
+	}
+
+	n := product.Nutriments
+	var calories float64
+	switch {
+	case n.EnergyKcal != nil:
+		calories = float64(*n.EnergyKcal)
+	case n.EnergyKJ != nil:
+		calories = float64(*n.EnergyKJ) / 4.184
+	default:
+		return nil
+	}
+
+	// Open Food Facts gives every amount in grams
+	amount := func(v *openfoodfacts.Number, scale float64) *float64 {
+		if v == nil {
+			return nil
+		}
+		scaled := float64(*v) * scale
+		return &scaled
+	}
+	value := func(v *openfoodfacts.Number) float64 {
+		if v == nil {
+			return 0
+		}
+		return float64(*v)
+	}
+
+	now := time.Now()
+	foodItem := &entity.FoodItem{
+		ID:                                 uuid.New().String(),
+		Name:                               name,
+		BarcodeUPC:                         &code,
+		ServingSizeDefaultQty:              100,
+		ServingSizeDefaultUnit:             "g",
+		CaloriesPerDefaultServing:          calories,
+		ProteinGramsPerDefaultServing:      value(n.Proteins),
+		FatGramsPerDefaultServing:          value(n.Fat),
+		CarbsGramsPerDefaultServing:        value(n.Carbohydrates),
+		FiberGramsPerDefaultServing:        amount(n.Fiber, 1),
+		SugarGramsPerDefaultServing:        amount(n.Sugars, 1),
+		SaturatedFatGramsPerDefaultServing: amount(n.SaturatedFat, 1),
+		TransFatGramsPerDefaultServing:     amount(n.TransFat, 1),
+		CholesterolMgPerDefaultServing:     amount(n.Cholesterol, 1e3),
+		SodiumMgPerDefaultServing:          amount(n.Sodium, 1e3),
+		PotassiumMgPerDefaultServing:       amount(n.Potassium, 1e3),
+		VitaminAMcgPerDefaultServing:       amount(n.VitaminA, 1e6),
+		VitaminCMgPerDefaultServing:        amount(n.VitaminC, 1e3),
+		CalciumMgPerDefaultServing:         amount(n.Calcium, 1e3),
+		IronMgPerDefaultServing:            amount(n.Iron, 1e3),
+		Source:                             entity.FoodItemSourceAPI,
+		CreatedAt:                          now,
+		UpdatedAt:                          now,
+	}
+	// The first brand is the one on the package
+	if brand, _, _ := strings.Cut(product.Brands, ","); strings.TrimSpace(brand) != "" {
+		brand = strings.TrimSpace(brand)
+		foodItem.BrandName = &brand
+	}
+	return foodItem
+}
+
+func (uc *FoodItemUseCase) ListFoodItems(ctx context.Context, spec repository.QuerySpec, page, pageSize int) ([]*entity.FoodItem, int64, error) {
+	ctx, span := tracing.Start(ctx, "FoodItemUseCase.ListFoodItems")
+	defer span.End()
+
 	// Validate page size
 	if pageSize <= 0 {
 		pageSize = uc.config.DefaultPageSize
@@ -50,13 +245,13 @@ func (uc *FoodItemUseCase) ListFoodItems(ctx context.Context, filters map[string
 	}
 
 	// Get total count
-	total, err := uc.repo.Count(ctx, filters)
+	total, err := uc.repo.Count(ctx, spec)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// Get food items
-	items, err := uc.repo.List(ctx, filters, page, pageSize)
+	items, err := uc.repo.List(ctx, spec, page, pageSize)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -64,16 +259,95 @@ func (uc *FoodItemUseCase) ListFoodItems(ctx context.Context, filters map[string
 	return items, total, nil
 }
 
-func (uc *FoodItemUseCase) UpdateFoodItem(ctx context.Context, foodItem *entity.FoodItem) error {
-	foodItem.UpdatedAt = time.Now()
-	return uc.repo.Update(ctx, foodItem)
+// ListFoodItemsPage returns a cursor paginated list of food items
+func (uc *FoodItemUseCase) ListFoodItemsPage(ctx context.Context, spec repository.QuerySpec, page repository.CursorPage) ([]*entity.FoodItem, repository.PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "FoodItemUseCase.ListFoodItemsPage")
+	defer span.End()
+
+	// Validate page size
+	if page.Limit <= 0 {
+		page.Limit = uc.config.DefaultPageSize
+	}
+	if page.Limit > uc.config.MaxPageSize {
+		page.Limit = uc.config.MaxPageSize
+	}
+
+	return uc.repo.ListPage(ctx, spec, page)
 }
 
-func (uc *FoodItemUseCase) DeleteFoodItem(ctx context.Context, foodItemID string) error {
-	return uc.repo.Delete(ctx, foodItemID)
+// UpdateFoodItem replaces a food item, on behalf of userID
+func (uc *FoodItemUseCase) UpdateFoodItem(ctx context.Context, userID string, foodItem *entity.FoodItem) error {
+	ctx, span := tracing.Start(ctx, "FoodItemUseCase.UpdateFoodItem")
+	defer span.End()
+
+	existing, err := uc.GetFoodItem(ctx, foodItem.ID)
+	if err != nil {
+		return err
+	}
+	if err := uc.checkEditor(ctx, userID, existing); err != nil {
+		return err
+	}
+	// The creator is who the trash, exports and account erasure attribute the item to
+	foodItem.CreatedByUserID = existing.CreatedByUserID
+
+	if err := uc.repo.Update(ctx, foodItem); err != nil {
+		return err
+	}
+
+	changes, err := auditChanges(existing, foodItem)
+	if err != nil {
+		return err
+	}
+	return uc.audit.record(ctx, entity.AuditActionFoodItemUpdate, entity.AuditTargetFoodItem, foodItem.ID, changes)
+}
+
+// DeleteFoodItem moves a food item to the trash, on behalf of userID
+func (uc *FoodItemUseCase) DeleteFoodItem(ctx context.Context, userID, foodItemID string) error {
+	ctx, span := tracing.Start(ctx, "FoodItemUseCase.DeleteFoodItem")
+	defer span.End()
+
+	existing, err := uc.GetFoodItem(ctx, foodItemID)
+	if err != nil {
+		return err
+	}
+	if err := uc.checkEditor(ctx, userID, existing); err != nil {
+		return err
+	}
+
+	if err := uc.repo.Delete(ctx, foodItemID); err != nil {
+		return err
+	}
+
+	changes, err := auditChanges(existing, nil)
+	if err != nil {
+		return err
+	}
+	return uc.audit.record(ctx, entity.AuditActionFoodItemDelete, entity.AuditTargetFoodItem, foodItemID, changes)
+}
+
+// checkEditor returns ErrForbidden unless userID created the food item or is an admin. Items without a creator,
+// imported or looked up in the external catalog, are edited by admins only.
+func (uc *FoodItemUseCase) checkEditor(ctx context.Context, userID string, foodItem *entity.FoodItem) error {
+	if userID == "" {
+		return ErrForbidden
+	}
+	if foodItem.CreatedByUserID != nil && *foodItem.CreatedByUserID == userID {
+		return nil
+	}
+	admin, err := hasRole(ctx, uc.roleRepo, userID, entity.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if !admin {
+		return ErrForbidden
+	}
+	return nil
 }
 
 func (uc *FoodItemUseCase) SearchFoodItems(ctx context.Context, query string, page, pageSize int) ([]*entity.FoodItem, int64, error) {
+	ctx, span := tracing.Start(ctx, "FoodItemUseCase.SearchFoodItems")
+	defer span.End()
+
 	// Validate page size
 	if pageSize <= 0 {
 		pageSize = uc.config.DefaultPageSize
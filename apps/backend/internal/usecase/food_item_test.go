@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/openfoodfacts"
+)
+
+// fakeFoodItemRepository keeps food items in memory
+type fakeFoodItemRepository struct {
+	repository.FoodItemRepository
+	items []*entity.FoodItem
+}
+
+func (r *fakeFoodItemRepository) Create(_ context.Context, foodItem *entity.FoodItem) (*entity.FoodItem, error) {
+	r.items = append(r.items, foodItem)
+	return foodItem, nil
+}
+
+func (r *fakeFoodItemRepository) GetByBarcode(_ context.Context, code string) (*entity.FoodItem, error) {
+	for _, item := range r.items {
+		if item.BarcodeUPC != nil && *item.BarcodeUPC == code {
+			return item, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeFoodItemRepository) GetByID(_ context.Context, id string) (*entity.FoodItem, error) {
+	for _, item := range r.items {
+		if item.ID == id {
+			clone := *item
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeFoodItemRepository) Update(_ context.Context, foodItem *entity.FoodItem) error {
+	for i, item := range r.items {
+		if item.ID == foodItem.ID {
+			clone := *foodItem
+			r.items[i] = &clone
+		}
+	}
+	return nil
+}
+
+// fakeFoodCatalog serves products by barcode and counts the lookups made
+type fakeFoodCatalog struct {
+	products map[string]*openfoodfacts.Product
+	err      error
+	lookups  int
+}
+
+func (c *fakeFoodCatalog) Product(_ context.Context, code string) (*openfoodfacts.Product, error) {
+	c.lookups++
+	return c.products[code], c.err
+}
+
+func TestFoodItemUseCase_GetFoodItemByBarcode(t *testing.T) {
+	local := "036000291452"
+	kcal := openfoodfacts.Number(539)
+	catalog := map[string]*openfoodfacts.Product{
+		"4006381333931": {Code: "4006381333931", ProductName: "Hazelnut spread", Nutriments: openfoodfacts.Nutriments{EnergyKcal: &kcal}},
+		// Products without an energy value are of no use for tracking
+		"5449000000996": {Code: "5449000000996", ProductName: "Soda"},
+	}
+
+	tests := []struct {
+		name        string
+		code        string
+		catalogErr  error
+		wantName    string
+		wantErr     error
+		wantLookups int
+		wantCreated bool
+	}{
+		{name: "cached under its UPC-A form", code: "0036000291452", wantName: "Peanut butter"},
+		{name: "cached, as a GTIN-14", code: "00036000291452", wantName: "Peanut butter"},
+		{name: "from the catalog", code: "4006381333931", wantName: "Hazelnut spread", wantLookups: 1, wantCreated: true},
+		{name: "unknown to the catalog", code: "96385074", wantErr: ErrFoodItemNotFound, wantLookups: 1},
+		{name: "not trackable", code: "5449000000996", wantErr: ErrFoodItemNotFound, wantLookups: 1},
+		{name: "catalog down", code: "96385074", catalogErr: errors.New("timeout"), wantErr: ErrFoodCatalogUnavailable, wantLookups: 1},
+		{name: "invalid check digit", code: "4006381333932", wantErr: ErrInvalidBarcode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeFoodItemRepository{items: []*entity.FoodItem{{ID: "1", Name: "Peanut butter", BarcodeUPC: &local}}}
+			catalog := &fakeFoodCatalog{products: catalog, err: tt.catalogErr}
+			audit := &fakeAuditLogRepository{}
+			uc := NewFoodItemUseCase(repo, nil, catalog, audit, Config{})
+
+			got, err := uc.GetFoodItemByBarcode(context.Background(), tt.code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetFoodItemByBarcode() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.Name != tt.wantName {
+				t.Errorf("GetFoodItemByBarcode() = %s, want %s", got.Name, tt.wantName)
+			}
+			if catalog.lookups != tt.wantLookups {
+				t.Errorf("catalog lookups = %d, want %d", catalog.lookups, tt.wantLookups)
+			}
+			if created := len(repo.items) == 2; created != tt.wantCreated || created != (len(audit.entries) == 1) {
+				t.Errorf("created = %v with %d audit entries, want %v", created, len(audit.entries), tt.wantCreated)
+			}
+		})
+	}
+}
+
+func TestFoodItemUseCase_GetFoodItemByBarcode_Caches(t *testing.T) {
+	kcal := openfoodfacts.Number(539)
+	repo := &fakeFoodItemRepository{}
+	catalog := &fakeFoodCatalog{products: map[string]*openfoodfacts.Product{
+		"0036000291452": {Code: "0036000291452", ProductName: "Peanut butter", Nutriments: openfoodfacts.Nutriments{EnergyKcal: &kcal}},
+	}}
+	uc := NewFoodItemUseCase(repo, nil, catalog, &fakeAuditLogRepository{}, Config{})
+
+	// The product is stored under the EAN-13 form, which the UPC-A form finds too
+	for _, code := range []string{"036000291452", "0036000291452"} {
+		got, err := uc.GetFoodItemByBarcode(context.Background(), code)
+		if err != nil {
+			t.Fatalf("GetFoodItemByBarcode(%s) error = %v", code, err)
+		}
+		if got.BarcodeUPC == nil || *got.BarcodeUPC != "0036000291452" {
+			t.Errorf("barcode = %v, want 0036000291452", got.BarcodeUPC)
+		}
+	}
+	if catalog.lookups != 1 || len(repo.items) != 1 {
+		t.Errorf("catalog lookups = %d, items = %d, want 1 of each", catalog.lookups, len(repo.items))
+	}
+
+	// Without a catalog, lookups stay local
+	uc = NewFoodItemUseCase(repo, nil, nil, &fakeAuditLogRepository{}, Config{})
+	if _, err := uc.GetFoodItemByBarcode(context.Background(), "4006381333931"); !errors.Is(err, ErrFoodItemNotFound) {
+		t.Errorf("GetFoodItemByBarcode() without a catalog error = %v, want %v", err, ErrFoodItemNotFound)
+	}
+}
+
+func TestFoodItemUseCase_Creator(t *testing.T) {
+	repo := &fakeFoodItemRepository{}
+	uc := NewFoodItemUseCase(repo, nil, nil, &fakeAuditLogRepository{}, Config{})
+	ctx := context.Background()
+
+	mallory := "mallory"
+	created, err := uc.CreateFoodItem(ctx, "alice", &entity.FoodItem{Name: "Granola", CreatedByUserID: &mallory})
+	if err != nil {
+		t.Fatalf("CreateFoodItem() error = %v", err)
+	}
+	if created.CreatedByUserID == nil || *created.CreatedByUserID != "alice" {
+		t.Fatalf("creator = %v, want alice", created.CreatedByUserID)
+	}
+
+	// Replacing the item, as PUT and PATCH do, keeps its creator
+	if err := uc.UpdateFoodItem(ctx, "alice", &entity.FoodItem{ID: created.ID, Name: "Crunchy granola", CreatedByUserID: &mallory}); err != nil {
+		t.Fatalf("UpdateFoodItem() error = %v", err)
+	}
+	got, _ := repo.GetByID(ctx, created.ID)
+	if got.Name != "Crunchy granola" || got.CreatedByUserID == nil || *got.CreatedByUserID != "alice" {
+		t.Errorf("updated item = %s by %v, want Crunchy granola by alice", got.Name, got.CreatedByUserID)
+	}
+}
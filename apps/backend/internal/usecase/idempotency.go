@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
+)
+
+type IdempotencyConfig struct {
+	TTL time.Duration
+}
+
+// IdempotencyUseCase handles replaying of requests sent with an Idempotency-Key header
+type IdempotencyUseCase struct {
+	repo   repository.IdempotencyRepository
+	config IdempotencyConfig
+}
+
+// NewIdempotencyUseCase creates a new instance of IdempotencyUseCase
+func NewIdempotencyUseCase(r repository.IdempotencyRepository, config IdempotencyConfig) *IdempotencyUseCase {
+	return &IdempotencyUseCase{
+		repo:   r,
+		config: config,
+	}
+}
+
+// Begin claims key, within the scope of the caller, for a request identified by requestHash.
+// It returns nil if the request should be executed, in which case Complete or Release must follow,
+// or the stored key whose response should be replayed.
+func (uc *IdempotencyUseCase) Begin(ctx context.Context, scope, key, requestHash string) (*entity.IdempotencyKey, error) {
+	ctx, span := tracing.Start(ctx, "IdempotencyUseCase.Begin")
+	defer span.End()
+
+	now := time.Now()
+	reserved, err := uc.repo.Reserve(ctx, &entity.IdempotencyKey{
+		Scope:       scope,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(uc.config.TTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	existing, err := uc.repo.GetByKey(ctx, scope, key)
+	if err != nil {
+		return nil, err
+	}
+	// The key was purged in between, the client may retry right away
+	if existing == nil {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	if existing.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if !existing.IsCompleted() {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	return existing, nil
+}
+
+// Complete stores the response to replay for key
+func (uc *IdempotencyUseCase) Complete(ctx context.Context, scope, key string, statusCode int, contentType string, body []byte) error {
+	ctx, span := tracing.Start(ctx, "IdempotencyUseCase.Complete")
+	defer span.End()
+
+	return uc.repo.Complete(ctx, &entity.IdempotencyKey{
+		Scope:        scope,
+		Key:          key,
+		StatusCode:   &statusCode,
+		ContentType:  contentType,
+		ResponseBody: body,
+	})
+}
+
+// Release frees key without storing a response, so that a retry executes the request again
+func (uc *IdempotencyUseCase) Release(ctx context.Context, scope, key string) error {
+	ctx, span := tracing.Start(ctx, "IdempotencyUseCase.Release")
+	defer span.End()
+
+	return uc.repo.Delete(ctx, scope, key)
+}
+
+// PurgeExpired removes expired keys and returns how many were removed
+func (uc *IdempotencyUseCase) PurgeExpired(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "IdempotencyUseCase.PurgeExpired")
+	defer span.End()
+
+	return uc.repo.DeleteExpired(ctx, time.Now())
+}
@@ -2,100 +2,203 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/metrics"
 	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
 )
 
-// MealUseCase handles meal-related business logic
+// MealUseCase handles meal-related business logic.
+// Meals are private: the meals of other users are reported as missing, so that their IDs reveal nothing.
 type MealUseCase struct {
 	mealRepo repository.MealRepository
+	config   Config
 }
 
 // NewMealUseCase creates a new instance of MealUseCase
-func NewMealUseCase(mealRepo repository.MealRepository) *MealUseCase {
+func NewMealUseCase(mealRepo repository.MealRepository, config Config) *MealUseCase {
 	return &MealUseCase{
 		mealRepo: mealRepo,
+		config:   config,
 	}
 }
 
-// CreateMeal creates a new meal
-func (uc *MealUseCase) CreateMeal(ctx context.Context, meal *entity.UserMeal) (*entity.UserMeal, error) {
+// CreateMeal creates a new meal for the user
+func (uc *MealUseCase) CreateMeal(ctx context.Context, userID string, meal *entity.UserMeal) (*entity.UserMeal, error) {
+	ctx, span := tracing.Start(ctx, "MealUseCase.CreateMeal")
+	defer span.End()
+
+	if meal.UserID != userID {
+		return nil, ErrForbidden
+	}
+
 	// Generate new ID and timestamps
 	meal.ID = uuid.New().String()
 	now := time.Now()
 	meal.CreatedAt = now
 	meal.UpdatedAt = now
 
-	return uc.mealRepo.Create(ctx, meal)
+	created, err := uc.mealRepo.Create(ctx, meal)
+	if err != nil {
+		return nil, err
+	}
+	metrics.MealsLogged.Inc()
+	return created, nil
 }
 
-// GetMeal retrieves a meal by its ID
-func (uc *MealUseCase) GetMeal(ctx context.Context, id string) (*entity.UserMeal, error) {
-	return uc.mealRepo.GetByID(ctx, id)
+// GetMeal retrieves a meal of the user by its ID
+func (uc *MealUseCase) GetMeal(ctx context.Context, userID, id string) (*entity.UserMeal, error) {
+	ctx, span := tracing.Start(ctx, "MealUseCase.GetMeal")
+	defer span.End()
+
+	meal, err := uc.mealRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if meal == nil || meal.UserID != userID {
+		return nil, ErrMealNotFound
+	}
+	return meal, nil
 }
 
 // ListMeals returns a paginated list of meals
-func (uc *MealUseCase) ListMeals(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]*entity.UserMeal, error) {
+func (uc *MealUseCase) ListMeals(ctx context.Context, spec repository.QuerySpec, page, pageSize int) ([]*entity.UserMeal, error) {
+	ctx, span := tracing.Start(ctx, "MealUseCase.ListMeals")
+	defer span.End()
+
 	// Validate page size
 	if pageSize <= 0 {
-		pageSize = 10 // Default page size
+		pageSize = uc.config.DefaultPageSize
 	}
-	if pageSize > 100 {
-		pageSize = 100 // Maximum page size
+	if pageSize > uc.config.MaxPageSize {
+		pageSize = uc.config.MaxPageSize
 	}
 
-	return uc.mealRepo.List(ctx, filters, page, pageSize)
+	return uc.mealRepo.List(ctx, spec, page, pageSize)
 }
 
-// UpdateMeal updates an existing meal
+// UpdateMeal updates an existing meal.
+// meal.UpdatedAt must hold the version that was read, repository.ErrVersionConflict is returned if it changed since.
 func (uc *MealUseCase) UpdateMeal(ctx context.Context, meal *entity.UserMeal) error {
-	meal.UpdatedAt = time.Now()
+	ctx, span := tracing.Start(ctx, "MealUseCase.UpdateMeal")
+	defer span.End()
+
 	return uc.mealRepo.Update(ctx, meal)
 }
 
-// DeleteMeal deletes a meal by its ID
-func (uc *MealUseCase) DeleteMeal(ctx context.Context, id string) error {
+// DeleteMeal moves a meal of the user to the trash
+func (uc *MealUseCase) DeleteMeal(ctx context.Context, userID, id string) error {
+	ctx, span := tracing.Start(ctx, "MealUseCase.DeleteMeal")
+	defer span.End()
+
+	if _, err := uc.GetMeal(ctx, userID, id); err != nil {
+		return err
+	}
+
 	return uc.mealRepo.Delete(ctx, id)
 }
 
 // GetUserMeals retrieves meals for a specific user
 func (uc *MealUseCase) GetUserMeals(ctx context.Context, userID string, page, pageSize int) ([]*entity.UserMeal, error) {
+	ctx, span := tracing.Start(ctx, "MealUseCase.GetUserMeals")
+	defer span.End()
+
 	// Validate page size
 	if pageSize <= 0 {
-		pageSize = 10 // Default page size
+		pageSize = uc.config.DefaultPageSize
 	}
-	if pageSize > 100 {
-		pageSize = 100 // Maximum page size
+	if pageSize > uc.config.MaxPageSize {
+		pageSize = uc.config.MaxPageSize
 	}
 
 	offset := (page - 1) * pageSize
 	return uc.mealRepo.GetByUserID(ctx, userID, pageSize, offset)
 }
 
+// ListUserMeals returns a cursor paginated list of a user's meals
+func (uc *MealUseCase) ListUserMeals(ctx context.Context, userID string, spec repository.QuerySpec, page repository.CursorPage) ([]*entity.UserMeal, repository.PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "MealUseCase.ListUserMeals")
+	defer span.End()
+
+	// Validate page size
+	if page.Limit <= 0 {
+		page.Limit = uc.config.DefaultPageSize
+	}
+	if page.Limit > uc.config.MaxPageSize {
+		page.Limit = uc.config.MaxPageSize
+	}
+
+	return uc.mealRepo.ListPage(ctx, spec.Where("user_id", userID), page)
+}
+
 // AddFoodItemToMeal adds a new food item to a meal
 func (uc *MealUseCase) AddFoodItemToMeal(ctx context.Context, foodItem *entity.MealFoodItem) error {
+	ctx, span := tracing.Start(ctx, "MealUseCase.AddFoodItemToMeal")
+	defer span.End()
+
 	// Generate new ID and timestamp
 	foodItem.ID = uuid.New().String()
 	foodItem.LoggedAt = time.Now()
 
-	return uc.mealRepo.AddFoodItem(ctx, foodItem)
+	if err := uc.mealRepo.AddFoodItem(ctx, foodItem); err != nil {
+		return err
+	}
+	metrics.MealFoodItemsLogged.Inc()
+	return nil
 }
 
 // GetMealFoodItems retrieves all food items for a meal
 func (uc *MealUseCase) GetMealFoodItems(ctx context.Context, mealID string) ([]*entity.MealFoodItem, error) {
+	ctx, span := tracing.Start(ctx, "MealUseCase.GetMealFoodItems")
+	defer span.End()
+
 	return uc.mealRepo.GetFoodItems(ctx, mealID)
 }
 
-// UpdateMealFoodItem updates an existing food item
-func (uc *MealUseCase) UpdateMealFoodItem(ctx context.Context, foodItem *entity.MealFoodItem) error {
+// UpdateMealFoodItem updates a food item in a meal of the user
+func (uc *MealUseCase) UpdateMealFoodItem(ctx context.Context, userID string, foodItem *entity.MealFoodItem) error {
+	ctx, span := tracing.Start(ctx, "MealUseCase.UpdateMealFoodItem")
+	defer span.End()
+
+	if _, err := uc.getMealFoodItem(ctx, userID, foodItem.ID); err != nil {
+		return err
+	}
 	return uc.mealRepo.UpdateFoodItem(ctx, foodItem)
 }
 
-// DeleteMealFoodItem deletes a food item
-func (uc *MealUseCase) DeleteMealFoodItem(ctx context.Context, foodItemID string) error {
+// DeleteMealFoodItem deletes a food item from a meal of the user
+func (uc *MealUseCase) DeleteMealFoodItem(ctx context.Context, userID, foodItemID string) error {
+	ctx, span := tracing.Start(ctx, "MealUseCase.DeleteMealFoodItem")
+	defer span.End()
+
+	if _, err := uc.getMealFoodItem(ctx, userID, foodItemID); err != nil {
+		return err
+	}
 	return uc.mealRepo.DeleteFoodItem(ctx, foodItemID)
 }
+
+// getMealFoodItem retrieves a food item in a meal of the user
+func (uc *MealUseCase) getMealFoodItem(ctx context.Context, userID, foodItemID string) (*entity.MealFoodItem, error) {
+	if _, err := uuid.Parse(foodItemID); err != nil {
+		return nil, ErrMealFoodItemNotFound
+	}
+	foodItem, err := uc.mealRepo.GetFoodItemByID(ctx, foodItemID)
+	if err != nil {
+		return nil, err
+	}
+	if foodItem == nil {
+		return nil, ErrMealFoodItemNotFound
+	}
+	if _, err := uc.GetMeal(ctx, userID, foodItem.MealID); err != nil {
+		if errors.Is(err, ErrMealNotFound) {
+			return nil, ErrMealFoodItemNotFound
+		}
+		return nil, err
+	}
+	return foodItem, nil
+}
@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+)
+
+func (r *fakeMealRepository) ListPage(_ context.Context, _ repository.QuerySpec, page repository.CursorPage) ([]*entity.UserMeal, repository.PageInfo, error) {
+	r.page = page
+	return nil, repository.PageInfo{}, nil
+}
+
+func (r *fakeMealRepository) Delete(_ context.Context, id string) error {
+	delete(r.meals, id)
+	return nil
+}
+
+func (r *fakeMealRepository) DeleteFoodItem(_ context.Context, id string) error {
+	delete(r.foodItems, id)
+	return nil
+}
+
+func TestMealUseCase_OwnerOnly(t *testing.T) {
+	const (
+		mealID     = "5b0c3c52-5d0c-4d8e-a8a4-7f2f0c1f6d01"
+		foodItemID = "5b0c3c52-5d0c-4d8e-a8a4-7f2f0c1f6d02"
+	)
+
+	tests := []struct {
+		name    string
+		call    func(uc *MealUseCase, userID string) error
+		wantErr error
+	}{
+		{"get meal", func(uc *MealUseCase, userID string) error {
+			_, err := uc.GetMeal(context.Background(), userID, mealID)
+			return err
+		}, ErrMealNotFound},
+		{"delete meal", func(uc *MealUseCase, userID string) error {
+			return uc.DeleteMeal(context.Background(), userID, mealID)
+		}, ErrMealNotFound},
+		{"update food item", func(uc *MealUseCase, userID string) error {
+			return uc.UpdateMealFoodItem(context.Background(), userID, &entity.MealFoodItem{ID: foodItemID, QuantityConsumed: 2})
+		}, ErrMealFoodItemNotFound},
+		{"delete food item", func(uc *MealUseCase, userID string) error {
+			return uc.DeleteMealFoodItem(context.Background(), userID, foodItemID)
+		}, ErrMealFoodItemNotFound},
+		{"create meal", func(uc *MealUseCase, userID string) error {
+			_, err := uc.CreateMeal(context.Background(), userID, &entity.UserMeal{UserID: "alice"})
+			return err
+		}, ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeMealRepository{
+				meals:     map[string]*entity.UserMeal{mealID: {ID: mealID, UserID: "alice"}},
+				foodItems: map[string]*entity.MealFoodItem{foodItemID: {ID: foodItemID, MealID: mealID, QuantityConsumed: 1}},
+			}
+			uc := NewMealUseCase(repo, Config{DefaultPageSize: 10, MaxPageSize: 100})
+
+			if err := tt.call(uc, "mallory"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("another user's error = %v, want %v", err, tt.wantErr)
+			}
+			if len(repo.meals) != 1 || len(repo.foodItems) != 1 || repo.foodItems[foodItemID].QuantityConsumed != 1 {
+				t.Errorf("another user changed alice's meal")
+			}
+		})
+	}
+}
+
+func TestMealUseCase_Owner(t *testing.T) {
+	const (
+		mealID     = "5b0c3c52-5d0c-4d8e-a8a4-7f2f0c1f6d01"
+		foodItemID = "5b0c3c52-5d0c-4d8e-a8a4-7f2f0c1f6d02"
+	)
+	repo := &fakeMealRepository{
+		meals:     map[string]*entity.UserMeal{mealID: {ID: mealID, UserID: "alice"}},
+		foodItems: map[string]*entity.MealFoodItem{foodItemID: {ID: foodItemID, MealID: mealID, QuantityConsumed: 1}},
+	}
+	uc := NewMealUseCase(repo, Config{DefaultPageSize: 10, MaxPageSize: 100})
+	ctx := context.Background()
+
+	if _, err := uc.GetMeal(ctx, "alice", mealID); err != nil {
+		t.Fatalf("GetMeal() error = %v", err)
+	}
+	if err := uc.UpdateMealFoodItem(ctx, "alice", &entity.MealFoodItem{ID: foodItemID, MealID: mealID, QuantityConsumed: 2}); err != nil {
+		t.Fatalf("UpdateMealFoodItem() error = %v", err)
+	}
+	if got := repo.foodItems[foodItemID].QuantityConsumed; got != 2 {
+		t.Errorf("quantity = %v, want 2", got)
+	}
+	if err := uc.DeleteMealFoodItem(ctx, "alice", foodItemID); err != nil {
+		t.Fatalf("DeleteMealFoodItem() error = %v", err)
+	}
+	if err := uc.DeleteMeal(ctx, "alice", mealID); err != nil {
+		t.Fatalf("DeleteMeal() error = %v", err)
+	}
+	if len(repo.meals) != 0 || len(repo.foodItems) != 0 {
+		t.Errorf("meal or food item left after deletion")
+	}
+}
+
+func TestMealUseCase_ListUserMeals_PageSize(t *testing.T) {
+	repo := &fakeMealRepository{}
+	uc := NewMealUseCase(repo, Config{DefaultPageSize: 20, MaxPageSize: 50})
+
+	for limit, want := range map[int]int{0: 20, 30: 30, 500: 50} {
+		if _, _, err := uc.ListUserMeals(context.Background(), "alice", repository.QuerySpec{}, repository.CursorPage{Limit: limit}); err != nil {
+			t.Fatalf("ListUserMeals() error = %v", err)
+		}
+		if repo.page.Limit != want {
+			t.Errorf("ListUserMeals() with limit %d asked for %d, want %d", limit, repo.page.Limit, want)
+		}
+	}
+}
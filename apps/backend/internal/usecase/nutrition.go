@@ -8,22 +8,33 @@ import (
 
 	"github.com/terrnit/rebound/backend/internal/entity"
 	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
 )
 
-// NutritionUseCase handles nutrition-related business logic
+// NutritionUseCase handles nutrition-related business logic.
+// Nutrition goals and biometrics are private: those of other users are reported as missing, so that their IDs reveal nothing.
 type NutritionUseCase struct {
 	nutritionRepo repository.NutritionRepository
+	config        Config
 }
 
 // NewNutritionUseCase creates a new instance of NutritionUseCase
-func NewNutritionUseCase(nutritionRepo repository.NutritionRepository) *NutritionUseCase {
+func NewNutritionUseCase(nutritionRepo repository.NutritionRepository, config Config) *NutritionUseCase {
 	return &NutritionUseCase{
 		nutritionRepo: nutritionRepo,
+		config:        config,
 	}
 }
 
-// CreateNutritionGoals creates new nutrition goals
-func (uc *NutritionUseCase) CreateNutritionGoals(ctx context.Context, goals *entity.UserNutritionGoal) (*entity.UserNutritionGoal, error) {
+// CreateNutritionGoals creates new nutrition goals for the user
+func (uc *NutritionUseCase) CreateNutritionGoals(ctx context.Context, userID string, goals *entity.UserNutritionGoal) (*entity.UserNutritionGoal, error) {
+	ctx, span := tracing.Start(ctx, "NutritionUseCase.CreateNutritionGoals")
+	defer span.End()
+
+	if goals.UserID != userID {
+		return nil, ErrForbidden
+	}
+
 	// Generate new ID and timestamps
 	goals.ID = uuid.New().String()
 	now := time.Now()
@@ -33,43 +44,83 @@ func (uc *NutritionUseCase) CreateNutritionGoals(ctx context.Context, goals *ent
 	return uc.nutritionRepo.CreateNutritionGoals(ctx, goals)
 }
 
-// GetNutritionGoals retrieves nutrition goals by ID
-func (uc *NutritionUseCase) GetNutritionGoals(ctx context.Context, id string) (*entity.UserNutritionGoal, error) {
-	return uc.nutritionRepo.GetNutritionGoalsByID(ctx, id)
+// GetNutritionGoals retrieves nutrition goals of the user by ID
+func (uc *NutritionUseCase) GetNutritionGoals(ctx context.Context, userID, id string) (*entity.UserNutritionGoal, error) {
+	ctx, span := tracing.Start(ctx, "NutritionUseCase.GetNutritionGoals")
+	defer span.End()
+
+	goals, err := uc.nutritionRepo.GetNutritionGoalsByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if goals == nil || goals.UserID != userID {
+		return nil, ErrNutritionGoalsNotFound
+	}
+	return goals, nil
 }
 
-// UpdateNutritionGoals updates existing nutrition goals
+// UpdateNutritionGoals updates existing nutrition goals.
+// goals.UpdatedAt must hold the version that was read, repository.ErrVersionConflict is returned if it changed since.
 func (uc *NutritionUseCase) UpdateNutritionGoals(ctx context.Context, goals *entity.UserNutritionGoal) error {
-	goals.UpdatedAt = time.Now()
+	ctx, span := tracing.Start(ctx, "NutritionUseCase.UpdateNutritionGoals")
+	defer span.End()
+
 	return uc.nutritionRepo.UpdateNutritionGoals(ctx, goals)
 }
 
-// DeleteNutritionGoals deletes nutrition goals
-func (uc *NutritionUseCase) DeleteNutritionGoals(ctx context.Context, id string) error {
+// DeleteNutritionGoals deletes nutrition goals of the user
+func (uc *NutritionUseCase) DeleteNutritionGoals(ctx context.Context, userID, id string) error {
+	ctx, span := tracing.Start(ctx, "NutritionUseCase.DeleteNutritionGoals")
+	defer span.End()
+
+	if _, err := uc.GetNutritionGoals(ctx, userID, id); err != nil {
+		return err
+	}
+
 	return uc.nutritionRepo.DeleteNutritionGoals(ctx, id)
 }
 
 // GetActiveNutritionGoals retrieves the active nutrition goals for a user
 func (uc *NutritionUseCase) GetActiveNutritionGoals(ctx context.Context, userID string) (*entity.UserNutritionGoal, error) {
-	return uc.nutritionRepo.GetActiveNutritionGoals(ctx, userID)
+	ctx, span := tracing.Start(ctx, "NutritionUseCase.GetActiveNutritionGoals")
+	defer span.End()
+
+	goals, err := uc.nutritionRepo.GetActiveNutritionGoals(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if goals == nil {
+		return nil, ErrNutritionGoalsNotFound
+	}
+	return goals, nil
 }
 
 // GetNutritionGoalsHistory retrieves nutrition goals history for a user
 func (uc *NutritionUseCase) GetNutritionGoalsHistory(ctx context.Context, userID string, page, pageSize int) ([]*entity.UserNutritionGoal, error) {
+	ctx, span := tracing.Start(ctx, "NutritionUseCase.GetNutritionGoalsHistory")
+	defer span.End()
+
 	// Validate page size
 	if pageSize <= 0 {
-		pageSize = 10 // Default page size
+		pageSize = uc.config.DefaultPageSize
 	}
-	if pageSize > 100 {
-		pageSize = 100 // Maximum page size
+	if pageSize > uc.config.MaxPageSize {
+		pageSize = uc.config.MaxPageSize
 	}
 
 	offset := (page - 1) * pageSize
 	return uc.nutritionRepo.GetNutritionGoalsHistory(ctx, userID, pageSize, offset)
 }
 
-// CreateBiometrics creates new biometrics entry
-func (uc *NutritionUseCase) CreateBiometrics(ctx context.Context, biometrics *entity.UserBiometric) (*entity.UserBiometric, error) {
+// CreateBiometrics creates new biometrics entry for the user
+func (uc *NutritionUseCase) CreateBiometrics(ctx context.Context, userID string, biometrics *entity.UserBiometric) (*entity.UserBiometric, error) {
+	ctx, span := tracing.Start(ctx, "NutritionUseCase.CreateBiometrics")
+	defer span.End()
+
+	if biometrics.UserID != userID {
+		return nil, ErrForbidden
+	}
+
 	// Generate new ID and timestamp
 	biometrics.ID = uuid.New().String()
 	biometrics.CreatedAt = time.Now()
@@ -77,36 +128,85 @@ func (uc *NutritionUseCase) CreateBiometrics(ctx context.Context, biometrics *en
 	return uc.nutritionRepo.CreateBiometrics(ctx, biometrics)
 }
 
-// GetBiometrics retrieves biometrics by ID
-func (uc *NutritionUseCase) GetBiometrics(ctx context.Context, id string) (*entity.UserBiometric, error) {
-	return uc.nutritionRepo.GetBiometricsByID(ctx, id)
+// GetBiometrics retrieves biometrics of the user by ID
+func (uc *NutritionUseCase) GetBiometrics(ctx context.Context, userID, id string) (*entity.UserBiometric, error) {
+	ctx, span := tracing.Start(ctx, "NutritionUseCase.GetBiometrics")
+	defer span.End()
+
+	biometrics, err := uc.nutritionRepo.GetBiometricsByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if biometrics == nil || biometrics.UserID != userID {
+		return nil, ErrBiometricsNotFound
+	}
+	return biometrics, nil
 }
 
 // UpdateBiometrics updates existing biometrics
 func (uc *NutritionUseCase) UpdateBiometrics(ctx context.Context, biometrics *entity.UserBiometric) error {
+	ctx, span := tracing.Start(ctx, "NutritionUseCase.UpdateBiometrics")
+	defer span.End()
+
 	return uc.nutritionRepo.UpdateBiometrics(ctx, biometrics)
 }
 
-// DeleteBiometrics deletes biometrics entry
-func (uc *NutritionUseCase) DeleteBiometrics(ctx context.Context, id string) error {
+// DeleteBiometrics moves a biometrics entry of the user to the trash
+func (uc *NutritionUseCase) DeleteBiometrics(ctx context.Context, userID, id string) error {
+	ctx, span := tracing.Start(ctx, "NutritionUseCase.DeleteBiometrics")
+	defer span.End()
+
+	if _, err := uc.GetBiometrics(ctx, userID, id); err != nil {
+		return err
+	}
+
 	return uc.nutritionRepo.DeleteBiometrics(ctx, id)
 }
 
 // GetUserBiometricsHistory retrieves biometrics history for a user
 func (uc *NutritionUseCase) GetUserBiometricsHistory(ctx context.Context, userID string, page, pageSize int) ([]*entity.UserBiometric, error) {
+	ctx, span := tracing.Start(ctx, "NutritionUseCase.GetUserBiometricsHistory")
+	defer span.End()
+
 	// Validate page size
 	if pageSize <= 0 {
-		pageSize = 10 // Default page size
+		pageSize = uc.config.DefaultPageSize
 	}
-	if pageSize > 100 {
-		pageSize = 100 // Maximum page size
+	if pageSize > uc.config.MaxPageSize {
+		pageSize = uc.config.MaxPageSize
 	}
 
 	offset := (page - 1) * pageSize
 	return uc.nutritionRepo.GetUserBiometricsHistory(ctx, userID, pageSize, offset)
 }
 
+// ListUserBiometrics returns a cursor paginated list of a user's biometrics
+func (uc *NutritionUseCase) ListUserBiometrics(ctx context.Context, userID string, spec repository.QuerySpec, page repository.CursorPage) ([]*entity.UserBiometric, repository.PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "NutritionUseCase.ListUserBiometrics")
+	defer span.End()
+
+	// Validate page size
+	if page.Limit <= 0 {
+		page.Limit = uc.config.DefaultPageSize
+	}
+	if page.Limit > uc.config.MaxPageSize {
+		page.Limit = uc.config.MaxPageSize
+	}
+
+	return uc.nutritionRepo.ListBiometricsPage(ctx, spec.Where("user_id", userID), page)
+}
+
 // GetLatestBiometrics retrieves the most recent biometrics for a user
 func (uc *NutritionUseCase) GetLatestBiometrics(ctx context.Context, userID string) (*entity.UserBiometric, error) {
-	return uc.nutritionRepo.GetLatestBiometrics(ctx, userID)
+	ctx, span := tracing.Start(ctx, "NutritionUseCase.GetLatestBiometrics")
+	defer span.End()
+
+	biometrics, err := uc.nutritionRepo.GetLatestBiometrics(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if biometrics == nil {
+		return nil, ErrBiometricsNotFound
+	}
+	return biometrics, nil
 }
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+)
+
+// fakeNutritionRepository keeps nutrition goals and biometrics in memory
+type fakeNutritionRepository struct {
+	repository.NutritionRepository
+	goals      map[string]*entity.UserNutritionGoal
+	biometrics map[string]*entity.UserBiometric
+	// page is the last page asked for
+	page repository.CursorPage
+}
+
+func (r *fakeNutritionRepository) ListBiometricsPage(_ context.Context, _ repository.QuerySpec, page repository.CursorPage) ([]*entity.UserBiometric, repository.PageInfo, error) {
+	r.page = page
+	return nil, repository.PageInfo{}, nil
+}
+
+func (r *fakeNutritionRepository) GetNutritionGoalsByID(_ context.Context, id string) (*entity.UserNutritionGoal, error) {
+	if g, ok := r.goals[id]; ok {
+		clone := *g
+		return &clone, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeNutritionRepository) DeleteNutritionGoals(_ context.Context, id string) error {
+	delete(r.goals, id)
+	return nil
+}
+
+func (r *fakeNutritionRepository) GetBiometricsByID(_ context.Context, id string) (*entity.UserBiometric, error) {
+	if b, ok := r.biometrics[id]; ok {
+		clone := *b
+		return &clone, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeNutritionRepository) DeleteBiometrics(_ context.Context, id string) error {
+	delete(r.biometrics, id)
+	return nil
+}
+
+func TestNutritionUseCase_OwnerOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		call    func(uc *NutritionUseCase, userID string) error
+		wantErr error
+	}{
+		{"get goals", func(uc *NutritionUseCase, userID string) error {
+			_, err := uc.GetNutritionGoals(context.Background(), userID, "goals-1")
+			return err
+		}, ErrNutritionGoalsNotFound},
+		{"delete goals", func(uc *NutritionUseCase, userID string) error {
+			return uc.DeleteNutritionGoals(context.Background(), userID, "goals-1")
+		}, ErrNutritionGoalsNotFound},
+		{"create goals", func(uc *NutritionUseCase, userID string) error {
+			_, err := uc.CreateNutritionGoals(context.Background(), userID, &entity.UserNutritionGoal{UserID: "alice"})
+			return err
+		}, ErrForbidden},
+		{"get biometrics", func(uc *NutritionUseCase, userID string) error {
+			_, err := uc.GetBiometrics(context.Background(), userID, "biometrics-1")
+			return err
+		}, ErrBiometricsNotFound},
+		{"delete biometrics", func(uc *NutritionUseCase, userID string) error {
+			return uc.DeleteBiometrics(context.Background(), userID, "biometrics-1")
+		}, ErrBiometricsNotFound},
+		{"create biometrics", func(uc *NutritionUseCase, userID string) error {
+			_, err := uc.CreateBiometrics(context.Background(), userID, &entity.UserBiometric{UserID: "alice"})
+			return err
+		}, ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeNutritionRepository{
+				goals:      map[string]*entity.UserNutritionGoal{"goals-1": {ID: "goals-1", UserID: "alice"}},
+				biometrics: map[string]*entity.UserBiometric{"biometrics-1": {ID: "biometrics-1", UserID: "alice"}},
+			}
+			uc := NewNutritionUseCase(repo, Config{DefaultPageSize: 10, MaxPageSize: 100})
+
+			if err := tt.call(uc, "mallory"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("another user's error = %v, want %v", err, tt.wantErr)
+			}
+			if len(repo.goals) != 1 || len(repo.biometrics) != 1 {
+				t.Errorf("another user deleted alice's data")
+			}
+		})
+	}
+}
+
+func TestNutritionUseCase_ListUserBiometrics_PageSize(t *testing.T) {
+	repo := &fakeNutritionRepository{}
+	uc := NewNutritionUseCase(repo, Config{DefaultPageSize: 20, MaxPageSize: 50})
+
+	for limit, want := range map[int]int{0: 20, 30: 30, 500: 50} {
+		if _, _, err := uc.ListUserBiometrics(context.Background(), "alice", repository.QuerySpec{}, repository.CursorPage{Limit: limit}); err != nil {
+			t.Fatalf("ListUserBiometrics() error = %v", err)
+		}
+		if repo.page.Limit != want {
+			t.Errorf("ListUserBiometrics() with limit %d asked for %d, want %d", limit, repo.page.Limit, want)
+		}
+	}
+}
@@ -0,0 +1,601 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
+)
+
+const (
+	// OAuthAccessTokenPrefix starts every access token issued to an OAuth client, which tells them apart from JWTs
+	OAuthAccessTokenPrefix = "rbo_"
+
+	OAuthGrantAuthorizationCode = "authorization_code"
+	OAuthGrantRefreshToken      = "refresh_token"
+
+	_oauthResponseTypeCode = "code"
+	_pkceMethodS256        = "S256"
+)
+
+// pkceVerifierPattern is the code verifier syntax of RFC 7636
+var pkceVerifierPattern = regexp.MustCompile(`^[A-Za-z0-9._~-]{43,128}$`)
+
+// privateURISchemePattern matches the private-use URI schemes of native apps: a domain name the app's
+// developer controls, reversed, such as com.example.app
+var privateURISchemePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*(\.[a-z][a-z0-9-]*)+$`)
+
+type OAuthConfig struct {
+	// CodeTTL is how long an authorization code can be exchanged
+	CodeTTL         time.Duration
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// OAuthUseCase is the OAuth 2.0 authorization server that lets partner apps access user data with consent.
+// Apps use the authorization code flow with PKCE, their tokens are stored with the session tokens.
+type OAuthUseCase struct {
+	userRepo  repository.UserRepository
+	oauthRepo repository.OAuthRepository
+	tokenRepo repository.AuthTokenRepository
+	audit     auditor
+	config    OAuthConfig
+}
+
+// NewOAuthUseCase creates a new instance of OAuthUseCase
+func NewOAuthUseCase(
+	userRepo repository.UserRepository,
+	oauthRepo repository.OAuthRepository,
+	tokenRepo repository.AuthTokenRepository,
+	auditRepo repository.AuditLogRepository,
+	config OAuthConfig,
+) *OAuthUseCase {
+	return &OAuthUseCase{
+		userRepo:  userRepo,
+		oauthRepo: oauthRepo,
+		tokenRepo: tokenRepo,
+		audit:     auditor{repo: auditRepo},
+		config:    config,
+	}
+}
+
+// RegisterClient registers a partner app owned by a user.
+// Confidential clients get a secret, which is only returned here.
+func (uc *OAuthUseCase) RegisterClient(ctx context.Context, ownerID, name string, redirectURIs []string, scopes []entity.Scope, confidential bool) (*entity.OAuthClient, error) {
+	ctx, span := tracing.Start(ctx, "OAuthUseCase.RegisterClient")
+	defer span.End()
+
+	for _, uri := range redirectURIs {
+		if err := checkRedirectURI(uri); err != nil {
+			return nil, err
+		}
+	}
+
+	client := &entity.OAuthClient{
+		ID:           uuid.New().String(),
+		OwnerID:      &ownerID,
+		Name:         strings.TrimSpace(name),
+		Confidential: confidential,
+		RedirectURIs: redirectURIs,
+		Scopes:       uniqueScopes(scopes),
+		CreatedAt:    time.Now(),
+	}
+	if confidential {
+		secret, err := randomToken()
+		if err != nil {
+			return nil, err
+		}
+		client.Secret = secret
+		client.SecretHash = hashToken(secret)
+	}
+
+	if err := uc.oauthRepo.CreateClient(ctx, client); err != nil {
+		return nil, err
+	}
+	if err := uc.audit.record(ctx, entity.AuditActionOAuthClientCreate, entity.AuditTargetUser, ownerID, map[string]entity.AuditChange{
+		"oauth_client": {After: client.ID},
+	}); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// ListClients returns the partner apps a user registered
+func (uc *OAuthUseCase) ListClients(ctx context.Context, ownerID string) ([]*entity.OAuthClient, error) {
+	ctx, span := tracing.Start(ctx, "OAuthUseCase.ListClients")
+	defer span.End()
+
+	return uc.oauthRepo.ListClientsByOwner(ctx, ownerID)
+}
+
+// DeleteClient deletes one of a user's partner apps, disconnecting it from every user
+func (uc *OAuthUseCase) DeleteClient(ctx context.Context, ownerID, clientID string) error {
+	ctx, span := tracing.Start(ctx, "OAuthUseCase.DeleteClient")
+	defer span.End()
+
+	if _, err := uuid.Parse(clientID); err != nil {
+		return ErrOAuthClientNotFound
+	}
+	deleted, err := uc.oauthRepo.DeleteClient(ctx, ownerID, clientID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrOAuthClientNotFound
+	}
+
+	return uc.audit.record(ctx, entity.AuditActionOAuthClientDelete, entity.AuditTargetUser, ownerID, map[string]entity.AuditChange{
+		"oauth_client": {Before: clientID},
+	})
+}
+
+// GetAuthorization validates an authorization request and returns what the consent screen shows
+func (uc *OAuthUseCase) GetAuthorization(ctx context.Context, userID string, req *entity.OAuthAuthorizeRequest) (*entity.OAuthAuthorization, error) {
+	ctx, span := tracing.Start(ctx, "OAuthUseCase.GetAuthorization")
+	defer span.End()
+
+	client, scopes, err := uc.checkAuthorizeRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	consent, err := uc.oauthRepo.GetConsent(ctx, userID, client.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.OAuthAuthorization{
+		Client:    client,
+		Scopes:    scopes,
+		Consented: consent != nil && containsScopes(consent.Scopes, scopes),
+	}, nil
+}
+
+// Authorize answers an authorization request with the user's decision. Approving records the consent
+// and redirects back to the client with a code, denying redirects back with access_denied.
+func (uc *OAuthUseCase) Authorize(ctx context.Context, userID string, req *entity.OAuthAuthorizeRequest, approve bool) (*entity.OAuthRedirect, error) {
+	ctx, span := tracing.Start(ctx, "OAuthUseCase.Authorize")
+	defer span.End()
+
+	client, scopes, err := uc.checkAuthorizeRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	if !approve {
+		params.Set("error", "access_denied")
+		return &entity.OAuthRedirect{RedirectTo: withQuery(req.RedirectURI, params)}, nil
+	}
+
+	if err := uc.oauthRepo.GrantConsent(ctx, userID, client.ID, scopes); err != nil {
+		return nil, err
+	}
+	if err := uc.audit.record(ctx, entity.AuditActionOAuthConsentGrant, entity.AuditTargetUser, userID, map[string]entity.AuditChange{
+		"oauth_client": {After: client.ID},
+		"scopes":       {After: scopes},
+	}); err != nil {
+		return nil, err
+	}
+
+	code, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	err = uc.oauthRepo.CreateCode(ctx, &entity.OAuthAuthorizationCode{
+		CodeHash:      hashToken(code),
+		ClientID:      client.ID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(uc.config.CodeTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	params.Set("code", code)
+	return &entity.OAuthRedirect{RedirectTo: withQuery(req.RedirectURI, params)}, nil
+}
+
+// Token is the token endpoint, it exchanges an authorization code or a refresh token for new tokens
+func (uc *OAuthUseCase) Token(ctx context.Context, req *entity.OAuthTokenRequest) (*entity.OAuthToken, error) {
+	ctx, span := tracing.Start(ctx, "OAuthUseCase.Token")
+	defer span.End()
+
+	switch req.GrantType {
+	case OAuthGrantAuthorizationCode, OAuthGrantRefreshToken:
+	default:
+		return nil, ErrOAuthUnsupportedGrantType
+	}
+
+	client, err := uc.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GrantType == OAuthGrantRefreshToken {
+		return uc.refresh(ctx, client, req.RefreshToken)
+	}
+	return uc.exchangeCode(ctx, client, req)
+}
+
+func (uc *OAuthUseCase) exchangeCode(ctx context.Context, client *entity.OAuthClient, req *entity.OAuthTokenRequest) (*entity.OAuthToken, error) {
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, ErrOAuthInvalidRequest
+	}
+
+	code, first, err := uc.oauthRepo.UseCode(ctx, hashToken(req.Code))
+	if err != nil {
+		return nil, err
+	}
+	if code == nil || code.ClientID != client.ID {
+		return nil, ErrOAuthInvalidGrant
+	}
+	if !first {
+		// A code used twice was intercepted, the tokens issued for it may be in the wrong hands
+		if err := uc.tokenRepo.RevokeClientTokens(ctx, code.UserID, client.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrOAuthInvalidGrant
+	}
+	if time.Now().After(code.ExpiresAt) || code.RedirectURI != req.RedirectURI || !verifyPKCE(req.CodeVerifier, code.CodeChallenge) {
+		return nil, ErrOAuthInvalidGrant
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, code.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		return nil, ErrOAuthInvalidGrant
+	}
+
+	return uc.issueTokens(ctx, code.UserID, client.ID, uuid.New().String(), code.Scopes, time.Now())
+}
+
+// refresh rotates a refresh token like sessions do, a reused one disconnects the client from the user
+func (uc *OAuthUseCase) refresh(ctx context.Context, client *entity.OAuthClient, refreshToken string) (*entity.OAuthToken, error) {
+	if refreshToken == "" {
+		return nil, ErrOAuthInvalidRequest
+	}
+
+	token, err := uc.tokenRepo.GetByHash(ctx, entity.AuthTokenTypeRefresh, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.ClientID != client.ID || time.Now().After(token.ExpiresAt) {
+		return nil, ErrOAuthInvalidGrant
+	}
+
+	rotated, err := uc.tokenRepo.Revoke(ctx, token.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// A reused token leaves the newer token of its grant live, a revoked grant has none
+		reused, err := uc.tokenRepo.RevokeSession(ctx, token.UserID, token.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		if reused {
+			if err := uc.tokenRepo.RevokeClientTokens(ctx, token.UserID, client.ID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrOAuthInvalidGrant
+	}
+
+	// Scopes withdrawn since are not refreshed
+	consent, err := uc.oauthRepo.GetConsent(ctx, token.UserID, client.ID)
+	if err != nil {
+		return nil, err
+	}
+	if consent == nil {
+		return nil, ErrOAuthInvalidGrant
+	}
+	scopes := intersectScopes(token.Scopes, consent.Scopes)
+	if len(scopes) == 0 {
+		return nil, ErrOAuthInvalidGrant
+	}
+
+	return uc.issueTokens(ctx, token.UserID, client.ID, token.SessionID, scopes, token.SignedInAt)
+}
+
+// Revoke is the revocation endpoint of RFC 7009. Revoking a refresh token revokes its whole grant.
+// Unknown tokens and tokens of other clients are ignored, so the endpoint reveals nothing about them.
+func (uc *OAuthUseCase) Revoke(ctx context.Context, clientID, clientSecret, token string) error {
+	ctx, span := tracing.Start(ctx, "OAuthUseCase.Revoke")
+	defer span.End()
+
+	client, err := uc.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+
+	hash := hashToken(token)
+	for _, tokenType := range []entity.AuthTokenType{entity.AuthTokenTypeRefresh, entity.AuthTokenTypeAccess} {
+		stored, err := uc.tokenRepo.GetByHash(ctx, tokenType, hash)
+		if err != nil {
+			return err
+		}
+		if stored == nil || stored.ClientID != client.ID {
+			continue
+		}
+		if tokenType == entity.AuthTokenTypeRefresh {
+			_, err = uc.tokenRepo.RevokeSession(ctx, stored.UserID, stored.SessionID)
+		} else {
+			_, err = uc.tokenRepo.Revoke(ctx, stored.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// AuthenticateAccessToken returns the principal of an access token issued to an OAuth client
+func (uc *OAuthUseCase) AuthenticateAccessToken(ctx context.Context, accessToken string) (*entity.Principal, error) {
+	ctx, span := tracing.Start(ctx, "OAuthUseCase.AuthenticateAccessToken")
+	defer span.End()
+
+	token, err := uc.tokenRepo.GetByHash(ctx, entity.AuthTokenTypeAccess, hashToken(accessToken))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.ClientID == "" || token.IsRevoked || time.Now().After(token.ExpiresAt) {
+		return nil, ErrInvalidAccessToken
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		return nil, ErrInvalidAccessToken
+	}
+
+	return &entity.Principal{UserID: token.UserID, ClientID: token.ClientID, Scopes: token.Scopes}, nil
+}
+
+// ListConnections returns the apps a user connected and the scopes granted to them
+func (uc *OAuthUseCase) ListConnections(ctx context.Context, userID string) ([]*entity.OAuthConsent, error) {
+	ctx, span := tracing.Start(ctx, "OAuthUseCase.ListConnections")
+	defer span.End()
+
+	return uc.oauthRepo.ListConsents(ctx, userID)
+}
+
+// RevokeConnection disconnects an app from a user, withdrawing the consent and revoking its tokens
+func (uc *OAuthUseCase) RevokeConnection(ctx context.Context, userID, clientID string) error {
+	ctx, span := tracing.Start(ctx, "OAuthUseCase.RevokeConnection")
+	defer span.End()
+
+	if _, err := uuid.Parse(clientID); err != nil {
+		return ErrOAuthConnectionNotFound
+	}
+	deleted, err := uc.oauthRepo.DeleteConsent(ctx, userID, clientID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrOAuthConnectionNotFound
+	}
+	if err := uc.tokenRepo.RevokeClientTokens(ctx, userID, clientID); err != nil {
+		return err
+	}
+
+	return uc.audit.record(ctx, entity.AuditActionOAuthConsentRevoke, entity.AuditTargetUser, userID, map[string]entity.AuditChange{
+		"oauth_client": {Before: clientID},
+	})
+}
+
+// PurgeExpiredCodes deletes expired authorization codes and returns how many were deleted
+func (uc *OAuthUseCase) PurgeExpiredCodes(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "OAuthUseCase.PurgeExpiredCodes")
+	defer span.End()
+
+	return uc.oauthRepo.PurgeCodes(ctx)
+}
+
+// checkAuthorizeRequest validates an authorization request and returns its client and the requested scopes
+func (uc *OAuthUseCase) checkAuthorizeRequest(ctx context.Context, req *entity.OAuthAuthorizeRequest) (*entity.OAuthClient, []entity.Scope, error) {
+	if req.ResponseType != _oauthResponseTypeCode {
+		return nil, nil, ErrOAuthUnsupportedResponseType
+	}
+	if _, err := uuid.Parse(req.ClientID); err != nil {
+		return nil, nil, ErrOAuthClientNotFound
+	}
+	client, err := uc.oauthRepo.GetClient(ctx, req.ClientID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if client == nil {
+		return nil, nil, ErrOAuthClientNotFound
+	}
+
+	registered := false
+	for _, uri := range client.RedirectURIs {
+		if uri == req.RedirectURI {
+			registered = true
+			break
+		}
+	}
+	if !registered {
+		return nil, nil, ErrOAuthInvalidRedirectURI
+	}
+
+	// Every client uses PKCE, confidential ones included
+	if req.CodeChallengeMethod != _pkceMethodS256 || req.CodeChallenge == "" {
+		return nil, nil, ErrOAuthInvalidRequest.WithField("code_challenge", "an S256 code challenge is required")
+	}
+
+	var scopes []entity.Scope
+	for _, name := range strings.Fields(req.Scope) {
+		scope := entity.Scope(name)
+		if !scope.IsValid() || !containsScopes(client.Scopes, []entity.Scope{scope}) {
+			return nil, nil, ErrOAuthInvalidScope.WithField("scope", name+" is not available to this client")
+		}
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 0 {
+		return nil, nil, ErrOAuthInvalidScope.WithField("scope", "at least one scope is required")
+	}
+	return client, uniqueScopes(scopes), nil
+}
+
+// authenticateClient checks the credentials of a client, public clients have no secret
+func (uc *OAuthUseCase) authenticateClient(ctx context.Context, clientID, clientSecret string) (*entity.OAuthClient, error) {
+	if _, err := uuid.Parse(clientID); err != nil {
+		return nil, ErrOAuthInvalidClient
+	}
+	client, err := uc.oauthRepo.GetClient(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, ErrOAuthInvalidClient
+	}
+
+	if !client.Confidential {
+		if clientSecret != "" {
+			return nil, ErrOAuthInvalidClient
+		}
+		return client, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(clientSecret)), []byte(client.SecretHash)) != 1 {
+		return nil, ErrOAuthInvalidClient
+	}
+	return client, nil
+}
+
+// issueTokens stores a new access and refresh token of a grant, whose ID is kept as their session ID
+func (uc *OAuthUseCase) issueTokens(ctx context.Context, userID, clientID, grantID string, scopes []entity.Scope, grantedAt time.Time) (*entity.OAuthToken, error) {
+	now := time.Now()
+	source := auditSourceFrom(ctx)
+
+	accessSecret, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	accessToken := OAuthAccessTokenPrefix + accessSecret
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, token := range []*entity.AuthToken{
+		{Type: entity.AuthTokenTypeAccess, TokenHash: hashToken(accessToken), ExpiresAt: now.Add(uc.config.AccessTokenTTL)},
+		{Type: entity.AuthTokenTypeRefresh, TokenHash: hashToken(refreshToken), ExpiresAt: now.Add(uc.config.RefreshTokenTTL)},
+	} {
+		token.ID = uuid.New().String()
+		token.UserID = userID
+		token.IssuedAt = now
+		token.SessionID = grantID
+		token.UserAgent = source.UserAgent
+		token.IPAddress = source.IPAddress
+		token.SignedInAt = grantedAt
+		token.LastUsedAt = now
+		token.ClientID = clientID
+		token.Scopes = scopes
+		if err := uc.tokenRepo.Create(ctx, token); err != nil {
+			return nil, err
+		}
+	}
+
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+	return &entity.OAuthToken{
+		AccessToken:  accessToken,
+		TokenType:    _tokenTypeBearer,
+		ExpiresIn:    int(uc.config.AccessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        strings.Join(names, " "),
+	}, nil
+}
+
+// checkRedirectURI accepts absolute URIs without a fragment, with a scheme a client can be redirected to: https,
+// plain http for the loopback addresses native apps listen on, and the private-use schemes of native apps, which
+// are in reverse domain name form (RFC 8252). Other schemes, such as javascript, data and file, are refused.
+func checkRedirectURI(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil || !u.IsAbs() || u.Fragment != "" {
+		return ErrInvalidInput.WithField("redirect_uris", uri+" must be an absolute URI without a fragment")
+	}
+	switch {
+	case u.Scheme == "https":
+		if u.Host == "" {
+			return ErrInvalidInput.WithField("redirect_uris", uri+" must have a host")
+		}
+	case u.Scheme == "http":
+		host := u.Hostname()
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return ErrInvalidInput.WithField("redirect_uris", uri+" must use https, plain http is only allowed for loopback addresses")
+		}
+	case !privateURISchemePattern.MatchString(u.Scheme):
+		return ErrInvalidInput.WithField("redirect_uris", uri+" must use https, or a private-use scheme in reverse domain name form such as com.example.app")
+	}
+	return nil
+}
+
+// verifyPKCE checks a code verifier against its S256 challenge
+func verifyPKCE(verifier, challenge string) bool {
+	if !pkceVerifierPattern.MatchString(verifier) {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// withQuery adds params to the query of uri, keeping the parameters it already has
+func withQuery(uri string, params url.Values) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// containsScopes reports whether granted holds every scope of requested
+func containsScopes(granted, requested []entity.Scope) bool {
+	for _, scope := range requested {
+		if len(intersectScopes(granted, []entity.Scope{scope})) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// intersectScopes returns the scopes of a that are also in b, in the order of a
+func intersectScopes(a, b []entity.Scope) []entity.Scope {
+	var both []entity.Scope
+	for _, scope := range a {
+		for _, other := range b {
+			if scope == other {
+				both = append(both, scope)
+				break
+			}
+		}
+	}
+	return both
+}
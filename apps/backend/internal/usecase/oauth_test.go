@@ -0,0 +1,397 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+)
+
+// fakeOAuthRepository keeps clients, consents and authorization codes in memory
+type fakeOAuthRepository struct {
+	repository.OAuthRepository
+	clients  map[string]*entity.OAuthClient
+	consents map[string]*entity.OAuthConsent
+	codes    map[string]*entity.OAuthAuthorizationCode
+}
+
+func (r *fakeOAuthRepository) GetClient(_ context.Context, id string) (*entity.OAuthClient, error) {
+	return r.clients[id], nil
+}
+
+func (r *fakeOAuthRepository) GetConsent(_ context.Context, userID, clientID string) (*entity.OAuthConsent, error) {
+	return r.consents[userID+"/"+clientID], nil
+}
+
+func (r *fakeOAuthRepository) GrantConsent(_ context.Context, userID, clientID string, scopes []entity.Scope) error {
+	consent := r.consents[userID+"/"+clientID]
+	if consent == nil {
+		consent = &entity.OAuthConsent{UserID: userID, ClientID: clientID}
+		r.consents[userID+"/"+clientID] = consent
+	}
+	consent.Scopes = uniqueScopes(append(consent.Scopes, scopes...))
+	return nil
+}
+
+func (r *fakeOAuthRepository) CreateCode(_ context.Context, code *entity.OAuthAuthorizationCode) error {
+	r.codes[code.CodeHash] = code
+	return nil
+}
+
+func (r *fakeOAuthRepository) UseCode(_ context.Context, codeHash string) (*entity.OAuthAuthorizationCode, bool, error) {
+	code := r.codes[codeHash]
+	if code == nil {
+		return nil, false, nil
+	}
+	if code.UsedAt != nil {
+		return code, false, nil
+	}
+	now := time.Now()
+	code.UsedAt = &now
+	return code, true, nil
+}
+
+// fakeOAuthTokenRepository keeps the tokens issued to clients in memory
+type fakeOAuthTokenRepository struct {
+	repository.AuthTokenRepository
+	tokens []*entity.AuthToken
+}
+
+func (r *fakeOAuthTokenRepository) Create(_ context.Context, token *entity.AuthToken) error {
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *fakeOAuthTokenRepository) GetByHash(_ context.Context, tokenType entity.AuthTokenType, hash string) (*entity.AuthToken, error) {
+	for _, token := range r.tokens {
+		if token.Type == tokenType && token.TokenHash == hash {
+			clone := *token
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeOAuthTokenRepository) Revoke(_ context.Context, id string) (bool, error) {
+	return r.revoke(func(token *entity.AuthToken) bool { return token.ID == id }), nil
+}
+
+func (r *fakeOAuthTokenRepository) RevokeSession(_ context.Context, userID, sessionID string) (bool, error) {
+	return r.revoke(func(token *entity.AuthToken) bool { return token.UserID == userID && token.SessionID == sessionID }), nil
+}
+
+func (r *fakeOAuthTokenRepository) RevokeClientTokens(_ context.Context, userID, clientID string) error {
+	r.revoke(func(token *entity.AuthToken) bool { return token.UserID == userID && token.ClientID == clientID })
+	return nil
+}
+
+// revoke revokes the live tokens that match and reports whether there were any
+func (r *fakeOAuthTokenRepository) revoke(match func(*entity.AuthToken) bool) bool {
+	revoked := false
+	for _, token := range r.tokens {
+		if !token.IsRevoked && match(token) {
+			token.IsRevoked = true
+			revoked = true
+		}
+	}
+	return revoked
+}
+
+// live counts the tokens of a type that are not revoked
+func (r *fakeOAuthTokenRepository) live(tokenType entity.AuthTokenType) int {
+	n := 0
+	for _, token := range r.tokens {
+		if token.Type == tokenType && !token.IsRevoked {
+			n++
+		}
+	}
+	return n
+}
+
+const (
+	testClientID    = "8a1f6b9e-3c2d-4e5f-9a0b-1c2d3e4f5a6b"
+	testRedirectURI = "com.example.app:/callback"
+	// testVerifier is the PKCE code verifier of the authorization requests in these tests
+	testVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+type oauthFixture struct {
+	uc     *OAuthUseCase
+	oauth  *fakeOAuthRepository
+	tokens *fakeOAuthTokenRepository
+}
+
+func newOAuthFixture() *oauthFixture {
+	oauthRepo := &fakeOAuthRepository{
+		clients: map[string]*entity.OAuthClient{testClientID: {
+			ID:           testClientID,
+			Name:         "Partner app",
+			RedirectURIs: []string{testRedirectURI, "https://partner.example.com/callback"},
+			Scopes:       []entity.Scope{entity.ScopeReadWorkouts, entity.ScopeReadMeals},
+		}},
+		consents: map[string]*entity.OAuthConsent{},
+		codes:    map[string]*entity.OAuthAuthorizationCode{},
+	}
+	tokens := &fakeOAuthTokenRepository{}
+	users := newFakeUserRepository(&entity.User{ID: "alice", Username: "alice", IsActive: true})
+	uc := NewOAuthUseCase(users, oauthRepo, tokens, &fakeAuditLogRepository{}, OAuthConfig{
+		CodeTTL:         time.Minute,
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+	})
+	return &oauthFixture{uc: uc, oauth: oauthRepo, tokens: tokens}
+}
+
+// authorizeRequest is an authorization request of the test client, with the S256 challenge of testVerifier
+func authorizeRequest(scope string) *entity.OAuthAuthorizeRequest {
+	sum := sha256.Sum256([]byte(testVerifier))
+	return &entity.OAuthAuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            testClientID,
+		RedirectURI:         testRedirectURI,
+		Scope:               scope,
+		State:               "xyz",
+		CodeChallenge:       base64.RawURLEncoding.EncodeToString(sum[:]),
+		CodeChallengeMethod: "S256",
+	}
+}
+
+// authorize has alice approve the request and returns the code sent back to the client
+func (f *oauthFixture) authorize(t *testing.T, req *entity.OAuthAuthorizeRequest) string {
+	t.Helper()
+	redirect, err := f.uc.Authorize(context.Background(), "alice", req, true)
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+	u, err := url.Parse(redirect.RedirectTo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Query().Get("state") != req.State {
+		t.Errorf("redirect %s lost the state", redirect.RedirectTo)
+	}
+	return u.Query().Get("code")
+}
+
+// exchange exchanges a code for tokens, as the test client
+func (f *oauthFixture) exchange(code, redirectURI, verifier string) (*entity.OAuthToken, error) {
+	return f.uc.Token(context.Background(), &entity.OAuthTokenRequest{
+		GrantType:    OAuthGrantAuthorizationCode,
+		ClientID:     testClientID,
+		Code:         code,
+		RedirectURI:  redirectURI,
+		CodeVerifier: verifier,
+	})
+}
+
+// refresh refreshes tokens, as the test client
+func (f *oauthFixture) refresh(refreshToken string) (*entity.OAuthToken, error) {
+	return f.uc.Token(context.Background(), &entity.OAuthTokenRequest{
+		GrantType:    OAuthGrantRefreshToken,
+		ClientID:     testClientID,
+		RefreshToken: refreshToken,
+	})
+}
+
+func TestCheckRedirectURI(t *testing.T) {
+	tests := []struct {
+		uri     string
+		wantErr bool
+	}{
+		{uri: "https://app.example.com/callback"},
+		{uri: "http://127.0.0.1:8765/callback"},
+		{uri: "http://[::1]/callback"},
+		{uri: "http://localhost:3000/callback"},
+		{uri: "com.example.app:/callback"},
+		{uri: "com.example.app://oauth/callback"},
+		{uri: "http://app.example.com/callback", wantErr: true},
+		{uri: "https:/callback", wantErr: true},
+		{uri: "https://app.example.com/callback#token", wantErr: true},
+		{uri: "/callback", wantErr: true},
+		{uri: "javascript:alert(document.cookie)", wantErr: true},
+		{uri: "data:text/html,<script>alert(1)</script>", wantErr: true},
+		{uri: "file:///etc/passwd", wantErr: true},
+		{uri: "ftp://example.com/callback", wantErr: true},
+		{uri: "myapp://callback", wantErr: true},
+		{uri: "com..example:/callback", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := checkRedirectURI(tt.uri); (err != nil) != tt.wantErr {
+			t.Errorf("checkRedirectURI(%q) error = %v, want error %v", tt.uri, err, tt.wantErr)
+		}
+	}
+}
+
+func TestOAuthUseCase_Authorize(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*entity.OAuthAuthorizeRequest)
+		wantErr error
+	}{
+		{name: "registered redirect URI", change: func(*entity.OAuthAuthorizeRequest) {}},
+		{name: "another registered redirect URI", change: func(r *entity.OAuthAuthorizeRequest) { r.RedirectURI = "https://partner.example.com/callback" }},
+		{name: "redirect URI with a trailing slash", change: func(r *entity.OAuthAuthorizeRequest) { r.RedirectURI += "/" }, wantErr: ErrOAuthInvalidRedirectURI},
+		{name: "redirect URI with a query", change: func(r *entity.OAuthAuthorizeRequest) { r.RedirectURI += "?next=/admin" }, wantErr: ErrOAuthInvalidRedirectURI},
+		{name: "unregistered redirect URI", change: func(r *entity.OAuthAuthorizeRequest) { r.RedirectURI = "https://attacker.example.com/callback" }, wantErr: ErrOAuthInvalidRedirectURI},
+		{name: "scope not available to the client", change: func(r *entity.OAuthAuthorizeRequest) { r.Scope = "read:workouts write:workouts" }, wantErr: ErrOAuthInvalidScope},
+		{name: "unknown scope", change: func(r *entity.OAuthAuthorizeRequest) { r.Scope = "admin" }, wantErr: ErrOAuthInvalidScope},
+		{name: "no scope", change: func(r *entity.OAuthAuthorizeRequest) { r.Scope = "" }, wantErr: ErrOAuthInvalidScope},
+		{name: "plain PKCE", change: func(r *entity.OAuthAuthorizeRequest) { r.CodeChallengeMethod = "plain" }, wantErr: ErrOAuthInvalidRequest},
+		{name: "no PKCE", change: func(r *entity.OAuthAuthorizeRequest) { r.CodeChallenge, r.CodeChallengeMethod = "", "" }, wantErr: ErrOAuthInvalidRequest},
+		{name: "unknown client", change: func(r *entity.OAuthAuthorizeRequest) { r.ClientID = "0b7c2f4e-9d8a-4b1c-8e2f-3a4b5c6d7e8f" }, wantErr: ErrOAuthClientNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOAuthFixture()
+			req := authorizeRequest("read:workouts")
+			tt.change(req)
+
+			redirect, err := f.uc.Authorize(context.Background(), "alice", req, true)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authorize() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(f.oauth.codes) != 0 || len(f.oauth.consents) != 0 {
+					t.Errorf("rejected request issued a code or recorded consent")
+				}
+				return
+			}
+			if !strings.HasPrefix(redirect.RedirectTo, req.RedirectURI+"?") || len(f.oauth.codes) != 1 {
+				t.Errorf("Authorize() redirects to %s with %d codes", redirect.RedirectTo, len(f.oauth.codes))
+			}
+		})
+	}
+}
+
+func TestOAuthUseCase_ExchangeCode(t *testing.T) {
+	tests := []struct {
+		name        string
+		redirectURI string
+		verifier    string
+		wantErr     error
+	}{
+		{name: "matching verifier", redirectURI: testRedirectURI, verifier: testVerifier},
+		{name: "wrong verifier", redirectURI: testRedirectURI, verifier: strings.Repeat("a", 43), wantErr: ErrOAuthInvalidGrant},
+		{name: "challenge as the verifier", redirectURI: testRedirectURI, verifier: "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", wantErr: ErrOAuthInvalidGrant},
+		{name: "short verifier", redirectURI: testRedirectURI, verifier: "short", wantErr: ErrOAuthInvalidGrant},
+		{name: "no verifier", redirectURI: testRedirectURI, wantErr: ErrOAuthInvalidRequest},
+		{name: "another redirect URI", redirectURI: "https://partner.example.com/callback", verifier: testVerifier, wantErr: ErrOAuthInvalidGrant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOAuthFixture()
+			code := f.authorize(t, authorizeRequest("read:workouts read:meals"))
+
+			token, err := f.exchange(code, tt.redirectURI, tt.verifier)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Token() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(f.tokens.tokens) != 0 {
+					t.Errorf("rejected exchange issued %d tokens", len(f.tokens.tokens))
+				}
+				return
+			}
+			if token.Scope != "read:workouts read:meals" || !strings.HasPrefix(token.AccessToken, OAuthAccessTokenPrefix) {
+				t.Errorf("Token() = %+v", token)
+			}
+			principal, err := f.uc.AuthenticateAccessToken(context.Background(), token.AccessToken)
+			if err != nil || principal.UserID != "alice" || principal.ClientID != testClientID {
+				t.Errorf("AuthenticateAccessToken() = %+v, %v", principal, err)
+			}
+		})
+	}
+}
+
+func TestOAuthUseCase_ExchangeCode_Reused(t *testing.T) {
+	f := newOAuthFixture()
+	code := f.authorize(t, authorizeRequest("read:workouts"))
+
+	token, err := f.exchange(code, testRedirectURI, testVerifier)
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+
+	// The code was intercepted, so the tokens it was exchanged for go as well
+	if _, err := f.exchange(code, testRedirectURI, testVerifier); !errors.Is(err, ErrOAuthInvalidGrant) {
+		t.Fatalf("second Token() error = %v, want %v", err, ErrOAuthInvalidGrant)
+	}
+	if f.tokens.live(entity.AuthTokenTypeAccess) != 0 || f.tokens.live(entity.AuthTokenTypeRefresh) != 0 {
+		t.Errorf("tokens of the reused code are still live")
+	}
+	if _, err := f.uc.AuthenticateAccessToken(context.Background(), token.AccessToken); !errors.Is(err, ErrInvalidAccessToken) {
+		t.Errorf("AuthenticateAccessToken() error = %v, want %v", err, ErrInvalidAccessToken)
+	}
+}
+
+func TestOAuthUseCase_Refresh(t *testing.T) {
+	f := newOAuthFixture()
+	first, err := f.exchange(f.authorize(t, authorizeRequest("read:workouts")), testRedirectURI, testVerifier)
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+
+	second, err := f.refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh Token() error = %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == first.AccessToken {
+		t.Fatalf("refresh did not rotate the tokens")
+	}
+	if n := f.tokens.live(entity.AuthTokenTypeRefresh); n != 1 {
+		t.Fatalf("live refresh tokens = %d, want the rotated one", n)
+	}
+
+	// Reusing the rotated token means one of the two copies was stolen, the whole grant goes
+	if _, err := f.refresh(first.RefreshToken); !errors.Is(err, ErrOAuthInvalidGrant) {
+		t.Fatalf("reused refresh Token() error = %v, want %v", err, ErrOAuthInvalidGrant)
+	}
+	if f.tokens.live(entity.AuthTokenTypeAccess) != 0 || f.tokens.live(entity.AuthTokenTypeRefresh) != 0 {
+		t.Errorf("tokens of the grant are still live after the reuse")
+	}
+	if _, err := f.refresh(second.RefreshToken); !errors.Is(err, ErrOAuthInvalidGrant) {
+		t.Errorf("refresh Token() with the newer token error = %v, want %v", err, ErrOAuthInvalidGrant)
+	}
+}
+
+func TestOAuthUseCase_Refresh_NarrowsScopes(t *testing.T) {
+	f := newOAuthFixture()
+	token, err := f.exchange(f.authorize(t, authorizeRequest("read:workouts read:meals")), testRedirectURI, testVerifier)
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+
+	// Scopes withdrawn from the consent since are left out of the refreshed tokens
+	consent := f.oauth.consents["alice/"+testClientID]
+	consent.Scopes = []entity.Scope{entity.ScopeReadMeals}
+	token, err = f.refresh(token.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh Token() error = %v", err)
+	}
+	if token.Scope != "read:meals" {
+		t.Errorf("refreshed scope = %q, want read:meals", token.Scope)
+	}
+
+	// Scopes granted to the consent since are not added to them
+	consent.Scopes = []entity.Scope{entity.ScopeReadMeals, entity.ScopeReadWorkouts}
+	token, err = f.refresh(token.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh Token() error = %v", err)
+	}
+	if token.Scope != "read:meals" {
+		t.Errorf("refreshed scope = %q, want read:meals", token.Scope)
+	}
+
+	// Nothing left to refresh
+	consent.Scopes = []entity.Scope{entity.ScopeReadWorkouts}
+	if _, err := f.refresh(token.RefreshToken); !errors.Is(err, ErrOAuthInvalidGrant) {
+		t.Errorf("refresh Token() error = %v, want %v", err, ErrOAuthInvalidGrant)
+	}
+}
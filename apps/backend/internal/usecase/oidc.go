@@ -0,0 +1,353 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/metrics"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/oidc"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
+)
+
+const (
+	// _usernameAttempts is how many generated usernames are tried for a new account
+	_usernameAttempts = 5
+	_usernameMinLen   = 3
+	_usernameMaxLen   = 50
+)
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// OIDCProvider is an OpenID Connect provider users sign in with, implemented by *oidc.Provider
+type OIDCProvider interface {
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*oidc.Claims, error)
+}
+
+type OIDCConfig struct {
+	// StateTTL is how long a user has to sign in at the provider
+	StateTTL time.Duration
+}
+
+// OIDCUseCase signs users in with OpenID Connect providers. Provider accounts are linked to users by
+// their subject; the first sign in links to the account with the same verified email or creates one.
+type OIDCUseCase struct {
+	auth         *AuthUseCase
+	userRepo     repository.UserRepository
+	identityRepo repository.IdentityRepository
+	providers    map[string]OIDCProvider
+	audit        auditor
+	config       OIDCConfig
+}
+
+// NewOIDCUseCase creates a new instance of OIDCUseCase, providers are keyed by the name used in the API
+func NewOIDCUseCase(
+	auth *AuthUseCase,
+	userRepo repository.UserRepository,
+	identityRepo repository.IdentityRepository,
+	auditRepo repository.AuditLogRepository,
+	providers map[string]OIDCProvider,
+	config OIDCConfig,
+) *OIDCUseCase {
+	return &OIDCUseCase{
+		auth:         auth,
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		providers:    providers,
+		audit:        auditor{repo: auditRepo},
+		config:       config,
+	}
+}
+
+// Providers returns the providers users can sign in with, by name
+func (uc *OIDCUseCase) Providers() []*entity.OIDCProvider {
+	names := make([]string, 0, len(uc.providers))
+	for name := range uc.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	providers := make([]*entity.OIDCProvider, len(names))
+	for i, name := range names {
+		providers[i] = &entity.OIDCProvider{Name: name}
+	}
+	return providers
+}
+
+// Begin starts a sign in with a provider and returns the provider URL to send the user to
+func (uc *OIDCUseCase) Begin(ctx context.Context, providerName, deviceName string) (*entity.OAuthRedirect, error) {
+	ctx, span := tracing.Start(ctx, "OIDCUseCase.Begin")
+	defer span.End()
+
+	provider, ok := uc.providers[providerName]
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := oidc.GenerateVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	redirectTo, err := provider.AuthCodeURL(ctx, state, nonce, oidc.S256Challenge(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("OIDCUseCase - Begin - provider.AuthCodeURL: %w", err)
+	}
+
+	err = uc.identityRepo.CreateState(ctx, &entity.OIDCLoginState{
+		StateHash:    hashToken(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		DeviceName:   deviceName,
+		ExpiresAt:    time.Now().Add(uc.config.StateTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &entity.OAuthRedirect{RedirectTo: redirectTo}, nil
+}
+
+// Complete finishes a sign in with the code and state the provider sent back. It returns tokens like a
+// password sign in does, or a challenge for users with two-factor authentication.
+func (uc *OIDCUseCase) Complete(ctx context.Context, providerName, code, state string) (*entity.LoginResult, error) {
+	ctx, span := tracing.Start(ctx, "OIDCUseCase.Complete")
+	defer span.End()
+
+	provider, ok := uc.providers[providerName]
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+
+	// A state is taken once, whatever the outcome, so a code cannot be replayed against it
+	login, err := uc.identityRepo.TakeState(ctx, hashToken(state))
+	if err != nil {
+		return nil, err
+	}
+	if login == nil || login.Provider != providerName || time.Now().After(login.ExpiresAt) {
+		return nil, ErrOIDCInvalidState
+	}
+
+	claims, err := provider.Exchange(ctx, code, login.CodeVerifier, login.Nonce)
+	if errors.Is(err, oidc.ErrExchange) || errors.Is(err, oidc.ErrInvalidIDToken) {
+		return nil, ErrOIDCLoginFailed
+	}
+	if err != nil {
+		return nil, fmt.Errorf("OIDCUseCase - Complete - provider.Exchange: %w", err)
+	}
+
+	user, err := uc.resolveUser(ctx, providerName, claims)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		if user.DeletionScheduledAt != nil {
+			return nil, ErrAccountDeletionPending
+		}
+		return nil, ErrAccountInactive
+	}
+
+	return uc.auth.completeLogin(ctx, user, login.DeviceName)
+}
+
+// resolveUser returns the user of a provider account. An account signing in for the first time is linked
+// to the user with the same email when the provider and the user have both verified it, or signs up.
+func (uc *OIDCUseCase) resolveUser(ctx context.Context, providerName string, claims *oidc.Claims) (*entity.User, error) {
+	identity, err := uc.identityRepo.GetByProviderSubject(ctx, providerName, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		if err := uc.identityRepo.TouchLogin(ctx, identity.ID); err != nil {
+			return nil, err
+		}
+		user, err := uc.userRepo.GetByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, ErrOIDCLoginFailed
+		}
+		return user, nil
+	}
+
+	// Emails the provider has not verified could belong to anyone
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	now := time.Now()
+	identity = &entity.LinkedIdentity{
+		ID:          uuid.New().String(),
+		Provider:    providerName,
+		Subject:     claims.Subject,
+		Email:       claims.Email,
+		CreatedAt:   now,
+		LastLoginAt: &now,
+	}
+
+	user, err := uc.userRepo.GetByEmail(ctx, claims.Email)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		// Whoever registered an unverified email may not own it, linking would hand them the provider account
+		if !user.IsEmailVerified {
+			return nil, ErrOIDCAccountNotLinkable
+		}
+		identity.UserID = user.ID
+		if err := uc.identityRepo.Create(ctx, identity); err != nil {
+			return nil, err
+		}
+	} else {
+		user, err = uc.signUp(ctx, claims, identity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.audit.record(ctx, entity.AuditActionIdentityLink, entity.AuditTargetUser, user.ID, map[string]entity.AuditChange{
+		"identity": {After: providerName},
+	}); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// signUp creates the account of a provider account without one. It has no password, its email is verified by the provider.
+func (uc *OIDCUseCase) signUp(ctx context.Context, claims *oidc.Claims, identity *entity.LinkedIdentity) (*entity.User, error) {
+	username, err := uc.availableUsername(ctx, claims.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &entity.User{
+		ID:              uuid.New().String(),
+		Username:        username,
+		Email:           claims.Email,
+		FirstName:       truncate(claims.GivenName, 100),
+		LastName:        truncate(claims.FamilyName, 100),
+		IsActive:        true,
+		IsEmailVerified: true,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	identity.UserID = user.ID
+	if err := uc.identityRepo.CreateWithUser(ctx, user, identity); err != nil {
+		return nil, err
+	}
+	metrics.UsersRegistered.Inc()
+	return user, nil
+}
+
+// availableUsername derives a username from the local part of an email, adding a number when it is taken
+func (uc *OIDCUseCase) availableUsername(ctx context.Context, email string) (string, error) {
+	local, _, _ := strings.Cut(email, "@")
+	base := truncate(usernameInvalidChars.ReplaceAllString(local, ""), _usernameMaxLen-5)
+	for len(base) < _usernameMinLen {
+		base += "_"
+	}
+
+	candidate := base
+	for range _usernameAttempts {
+		existing, err := uc.userRepo.GetByUsername(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return candidate, nil
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s%04d", base, n.Int64())
+	}
+	return "", ErrUsernameTaken
+}
+
+// ListIdentities returns the provider accounts linked to a user
+func (uc *OIDCUseCase) ListIdentities(ctx context.Context, userID string) ([]*entity.LinkedIdentity, error) {
+	ctx, span := tracing.Start(ctx, "OIDCUseCase.ListIdentities")
+	defer span.End()
+
+	return uc.identityRepo.ListByUserID(ctx, userID)
+}
+
+// Unlink removes a provider account from a user. Users without a password keep at least one.
+func (uc *OIDCUseCase) Unlink(ctx context.Context, userID, id string) error {
+	ctx, span := tracing.Start(ctx, "OIDCUseCase.Unlink")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrIdentityNotFound
+	}
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	identities, err := uc.identityRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var identity *entity.LinkedIdentity
+	for _, candidate := range identities {
+		if candidate.ID == id {
+			identity = candidate
+		}
+	}
+	if identity == nil {
+		return ErrIdentityNotFound
+	}
+	if user.PasswordHash == "" && len(identities) == 1 {
+		return ErrLastSignInMethod
+	}
+
+	if _, err := uc.identityRepo.Delete(ctx, userID, id); err != nil {
+		return err
+	}
+	return uc.audit.record(ctx, entity.AuditActionIdentityUnlink, entity.AuditTargetUser, userID, map[string]entity.AuditChange{
+		"identity": {Before: identity.Provider},
+	})
+}
+
+// PurgeExpiredStates deletes sign ins that were never completed and returns how many were deleted
+func (uc *OIDCUseCase) PurgeExpiredStates(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "OIDCUseCase.PurgeExpiredStates")
+	defer span.End()
+
+	return uc.identityRepo.PurgeStates(ctx)
+}
+
+// truncate cuts s to at most n runes
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
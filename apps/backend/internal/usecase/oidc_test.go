@@ -0,0 +1,241 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/oidc"
+	"github.com/terrnit/rebound/backend/pkg/oidc/oidctest"
+)
+
+func (r *fakeUserRepository) UpdateLastLogin(context.Context, string) error {
+	return nil
+}
+
+// fakeIdentityRepository keeps linked identities and sign in states in memory, creating users in users
+type fakeIdentityRepository struct {
+	repository.IdentityRepository
+	users      *fakeUserRepository
+	identities []*entity.LinkedIdentity
+	states     map[string]*entity.OIDCLoginState
+}
+
+func (r *fakeIdentityRepository) GetByProviderSubject(_ context.Context, provider, subject string) (*entity.LinkedIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeIdentityRepository) Create(_ context.Context, identity *entity.LinkedIdentity) error {
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *fakeIdentityRepository) CreateWithUser(_ context.Context, user *entity.User, identity *entity.LinkedIdentity) error {
+	r.users.users[user.ID] = user
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *fakeIdentityRepository) TouchLogin(context.Context, string) error {
+	return nil
+}
+
+func (r *fakeIdentityRepository) CreateState(_ context.Context, state *entity.OIDCLoginState) error {
+	r.states[state.StateHash] = state
+	return nil
+}
+
+func (r *fakeIdentityRepository) TakeState(_ context.Context, stateHash string) (*entity.OIDCLoginState, error) {
+	state := r.states[stateHash]
+	delete(r.states, stateHash)
+	return state, nil
+}
+
+// fakeTwoFactorRepository has no user with two-factor authentication
+type fakeTwoFactorRepository struct {
+	repository.TwoFactorRepository
+}
+
+func (fakeTwoFactorRepository) GetByUserID(context.Context, string) (*entity.TwoFactor, error) {
+	return nil, nil
+}
+
+// fakeAuthTokenRepository accepts the refresh tokens of new sessions
+type fakeAuthTokenRepository struct {
+	repository.AuthTokenRepository
+}
+
+func (fakeAuthTokenRepository) Create(context.Context, *entity.AuthToken) error {
+	return nil
+}
+
+type oidcFixture struct {
+	uc         *OIDCUseCase
+	issuer     *oidctest.Issuer
+	users      *fakeUserRepository
+	identities *fakeIdentityRepository
+}
+
+func newOIDCFixture(t *testing.T, users ...*entity.User) *oidcFixture {
+	t.Helper()
+	issuer, err := oidctest.NewIssuer("rebound", "client-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(issuer.Close)
+
+	userRepo := newFakeUserRepository(users...)
+	identities := &fakeIdentityRepository{users: userRepo, states: map[string]*entity.OIDCLoginState{}}
+	audit := &fakeAuditLogRepository{}
+	auth := NewAuthUseCase(userRepo, fakeAuthTokenRepository{}, fakeTwoFactorRepository{}, audit, nil, AuthConfig{
+		JWTSecret:       []byte("secret"),
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+	})
+	providers := map[string]OIDCProvider{
+		"test": oidc.New(issuer.Config("https://app.example.com/api/auth/oidc/test/callback")),
+	}
+	uc := NewOIDCUseCase(auth, userRepo, identities, audit, providers, OIDCConfig{StateTTL: time.Minute})
+	return &oidcFixture{uc: uc, issuer: issuer, users: userRepo, identities: identities}
+}
+
+// signIn signs in at the issuer as user and completes the sign in with the code it sends back
+func (f *oidcFixture) signIn(t *testing.T, user oidctest.User) (*entity.LoginResult, error) {
+	t.Helper()
+	f.issuer.SetUser(user)
+	ctx := context.Background()
+
+	redirect, err := f.uc.Begin(ctx, "test", "phone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(redirect.RedirectTo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return f.uc.Complete(ctx, "test", callback.Query().Get("code"), callback.Query().Get("state"))
+}
+
+func TestOIDCUseCase_SignUp(t *testing.T) {
+	f := newOIDCFixture(t)
+	provider := oidctest.User{Subject: "sub-1", Email: "alice@example.com", EmailVerified: true}
+
+	result, err := f.signIn(t, provider)
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if result.TokenPair == nil {
+		t.Fatalf("Complete() issued no tokens")
+	}
+	if len(f.users.users) != 1 || len(f.identities.identities) != 1 {
+		t.Fatalf("users = %d, identities = %d, want 1 of each", len(f.users.users), len(f.identities.identities))
+	}
+	user := f.users.users[f.identities.identities[0].UserID]
+	if user == nil || user.Email != "alice@example.com" || user.Username != "alice" || !user.IsEmailVerified {
+		t.Errorf("signed up user = %+v", user)
+	}
+
+	// Signing in again finds the account by its subject, whatever the email says now
+	provider.Email = "alice@another.example.com"
+	if _, err := f.signIn(t, provider); err != nil {
+		t.Fatalf("second Complete() error = %v", err)
+	}
+	if len(f.users.users) != 1 || len(f.identities.identities) != 1 {
+		t.Errorf("second sign in created an account")
+	}
+}
+
+func TestOIDCUseCase_Link(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     *entity.User
+		provider oidctest.User
+		wantErr  error
+		wantLink bool
+	}{
+		{
+			name:     "verified email",
+			user:     &entity.User{ID: "1", Username: "alice", Email: "alice@example.com", IsActive: true, IsEmailVerified: true},
+			provider: oidctest.User{Subject: "sub-1", Email: "alice@example.com", EmailVerified: true},
+			wantLink: true,
+		},
+		{
+			name:     "email not verified by the user",
+			user:     &entity.User{ID: "1", Username: "alice", Email: "alice@example.com", IsActive: true},
+			provider: oidctest.User{Subject: "sub-1", Email: "alice@example.com", EmailVerified: true},
+			wantErr:  ErrOIDCAccountNotLinkable,
+		},
+		{
+			name:     "email not verified by the provider",
+			user:     &entity.User{ID: "1", Username: "alice", Email: "alice@example.com", IsActive: true, IsEmailVerified: true},
+			provider: oidctest.User{Subject: "sub-1", Email: "alice@example.com"},
+			wantErr:  ErrOIDCEmailNotVerified,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOIDCFixture(t, tt.user)
+
+			_, err := f.signIn(t, tt.provider)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Complete() error = %v, want %v", err, tt.wantErr)
+			}
+			linked := len(f.identities.identities) == 1 && f.identities.identities[0].UserID == tt.user.ID
+			if linked != tt.wantLink || len(f.users.users) != 1 {
+				t.Errorf("linked = %v with %d users, want %v with 1", linked, len(f.users.users), tt.wantLink)
+			}
+		})
+	}
+}
+
+// TestOIDCUseCase_ChangedEmailIsNotLinked checks that a user cannot claim the address of someone else and
+// have the provider account of that address linked to theirs
+func TestOIDCUseCase_ChangedEmailIsNotLinked(t *testing.T) {
+	f := newOIDCFixture(t, &entity.User{ID: "1", Username: "mallory", Email: "mallory@example.com", IsActive: true, IsEmailVerified: true})
+	users := NewUserUseCase(f.users, nil, nil, nil, nil, UserConfig{})
+
+	mallory, err := users.GetUser(context.Background(), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mallory.Email = "alice@example.com"
+	if err := users.UpdateUser(context.Background(), mallory); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+
+	_, err = f.signIn(t, oidctest.User{Subject: "sub-alice", Email: "alice@example.com", EmailVerified: true})
+	if !errors.Is(err, ErrOIDCAccountNotLinkable) {
+		t.Fatalf("Complete() error = %v, want %v", err, ErrOIDCAccountNotLinkable)
+	}
+	if len(f.identities.identities) != 0 {
+		t.Errorf("alice's provider account was linked to mallory")
+	}
+}
+
+func TestOIDCUseCase_CompleteRefused(t *testing.T) {
+	f := newOIDCFixture(t)
+
+	if _, err := f.uc.Complete(context.Background(), "test", "code", "unknown-state"); !errors.Is(err, ErrOIDCInvalidState) {
+		t.Errorf("Complete() with an unknown state error = %v, want %v", err, ErrOIDCInvalidState)
+	}
+	if _, err := f.uc.Complete(context.Background(), "other", "code", "state"); !errors.Is(err, ErrOIDCProviderNotFound) {
+		t.Errorf("Complete() with an unknown provider error = %v, want %v", err, ErrOIDCProviderNotFound)
+	}
+}
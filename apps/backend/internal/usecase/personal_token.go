@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
+)
+
+const (
+	// PersonalTokenPrefix starts every personal access token, which tells them apart from JWTs
+	PersonalTokenPrefix = "rbd_"
+
+	// _personalTokenDisplayLen is how much of a token is kept to tell tokens apart
+	_personalTokenDisplayLen = len(PersonalTokenPrefix) + 6
+
+	// _personalTokenTouchInterval is how often the last use of a token is written
+	_personalTokenTouchInterval = time.Minute
+)
+
+type PersonalTokenConfig struct {
+	// MaxPerUser is how many active tokens a user can have
+	MaxPerUser int
+}
+
+// PersonalTokenUseCase manages personal access tokens and authenticates the requests that carry them
+type PersonalTokenUseCase struct {
+	userRepo  repository.UserRepository
+	tokenRepo repository.PersonalTokenRepository
+	audit     auditor
+	config    PersonalTokenConfig
+}
+
+// NewPersonalTokenUseCase creates a new instance of PersonalTokenUseCase
+func NewPersonalTokenUseCase(
+	userRepo repository.UserRepository,
+	tokenRepo repository.PersonalTokenRepository,
+	auditRepo repository.AuditLogRepository,
+	config PersonalTokenConfig,
+) *PersonalTokenUseCase {
+	return &PersonalTokenUseCase{
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+		audit:     auditor{repo: auditRepo},
+		config:    config,
+	}
+}
+
+// Create issues a token with scopes to a user, the returned token is the only time it is shown.
+// A nil expiresAt makes a token that does not expire.
+func (uc *PersonalTokenUseCase) Create(ctx context.Context, userID, name string, scopes []entity.Scope, expiresAt *time.Time) (*entity.PersonalToken, error) {
+	ctx, span := tracing.Start(ctx, "PersonalTokenUseCase.Create")
+	defer span.End()
+
+	now := time.Now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, ErrInvalidInput.WithField("expires_at", "must be in the future")
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	active, err := uc.tokenRepo.ListActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(active) >= uc.config.MaxPerUser {
+		return nil, ErrPersonalTokenLimit
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	plain := PersonalTokenPrefix + secret
+
+	token := &entity.PersonalToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Name:      strings.TrimSpace(name),
+		Prefix:    plain[:_personalTokenDisplayLen],
+		TokenHash: hashToken(plain),
+		Scopes:    uniqueScopes(scopes),
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+	if err := uc.tokenRepo.Create(ctx, token); err != nil {
+		return nil, err
+	}
+
+	if err := uc.audit.record(ctx, entity.AuditActionPersonalTokenCreate, entity.AuditTargetUser, userID, map[string]entity.AuditChange{
+		"personal_token": {After: token.ID},
+	}); err != nil {
+		return nil, err
+	}
+
+	token.Token = plain
+	return token, nil
+}
+
+// List returns a user's tokens that are neither revoked nor expired, newest first
+func (uc *PersonalTokenUseCase) List(ctx context.Context, userID string) ([]*entity.PersonalToken, error) {
+	ctx, span := tracing.Start(ctx, "PersonalTokenUseCase.List")
+	defer span.End()
+
+	return uc.tokenRepo.ListActiveByUserID(ctx, userID)
+}
+
+// Revoke revokes one of a user's tokens
+func (uc *PersonalTokenUseCase) Revoke(ctx context.Context, userID, id string) error {
+	ctx, span := tracing.Start(ctx, "PersonalTokenUseCase.Revoke")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrPersonalTokenNotFound
+	}
+	revoked, err := uc.tokenRepo.Revoke(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrPersonalTokenNotFound
+	}
+
+	return uc.audit.record(ctx, entity.AuditActionPersonalTokenRevoke, entity.AuditTargetUser, userID, map[string]entity.AuditChange{
+		"personal_token": {Before: id},
+	})
+}
+
+// Authenticate returns the principal of a personal access token and records its use.
+// Tokens of deactivated accounts are refused along with revoked and expired ones.
+func (uc *PersonalTokenUseCase) Authenticate(ctx context.Context, plain string) (*entity.Principal, error) {
+	ctx, span := tracing.Start(ctx, "PersonalTokenUseCase.Authenticate")
+	defer span.End()
+
+	token, err := uc.tokenRepo.GetByHash(ctx, hashToken(plain))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.RevokedAt != nil || (token.ExpiresAt != nil && time.Now().After(*token.ExpiresAt)) {
+		return nil, ErrInvalidPersonalToken
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		return nil, ErrInvalidPersonalToken
+	}
+
+	if err := uc.tokenRepo.Touch(ctx, token.ID, _personalTokenTouchInterval); err != nil {
+		return nil, err
+	}
+	return &entity.Principal{UserID: token.UserID, PersonalToken: token, Scopes: token.Scopes}, nil
+}
+
+// uniqueScopes drops repeated scopes, keeping their order
+func uniqueScopes(scopes []entity.Scope) []entity.Scope {
+	seen := make(map[entity.Scope]bool, len(scopes))
+	unique := make([]entity.Scope, 0, len(scopes))
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,381 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/storage"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
+)
+
+const (
+	// ProgressPhotoFull names the full size file of a progress photo in its links
+	ProgressPhotoFull = "full"
+	// ProgressPhotoThumbnail names the thumbnail of a progress photo in its links
+	ProgressPhotoThumbnail = "thumbnail"
+)
+
+type ProgressPhotoConfig struct {
+	// MaxBytes is the largest file accepted
+	MaxBytes int64
+	// MaxDimension is the width and height photos are scaled down to fit
+	MaxDimension int
+	// ThumbnailSize is the width and height thumbnails are scaled down to fit
+	ThumbnailSize int
+	// LinkSecret signs the links photos are viewed through
+	LinkSecret []byte
+	// LinkTTL is how long a link to a photo is valid, and the URLs it redirects to for storages that sign them
+	LinkTTL time.Duration
+}
+
+// ProgressPhotoUseCase stores front, side and back photos attached to biometrics entries.
+// Photos are private: only their owner and the coaches the owner authorised see them, and their files
+// are only reachable through short-lived signed links.
+type ProgressPhotoUseCase struct {
+	nutritionRepo repository.NutritionRepository
+	photoRepo     repository.ProgressPhotoRepository
+	coachRepo     repository.CoachRepository
+	files         storage.Storage
+	config        ProgressPhotoConfig
+}
+
+// NewProgressPhotoUseCase creates a new instance of ProgressPhotoUseCase
+func NewProgressPhotoUseCase(
+	nutritionRepo repository.NutritionRepository,
+	photoRepo repository.ProgressPhotoRepository,
+	coachRepo repository.CoachRepository,
+	files storage.Storage,
+	config ProgressPhotoConfig,
+) *ProgressPhotoUseCase {
+	return &ProgressPhotoUseCase{
+		nutritionRepo: nutritionRepo,
+		photoRepo:     photoRepo,
+		coachRepo:     coachRepo,
+		files:         files,
+		config:        config,
+	}
+}
+
+// MaxBytes returns the largest file accepted
+func (uc *ProgressPhotoUseCase) MaxBytes() int64 {
+	return uc.config.MaxBytes
+}
+
+// Upload attaches a photo to one of the user's biometrics entries, replacing the photo of the same pose
+func (uc *ProgressPhotoUseCase) Upload(ctx context.Context, userID, biometricsID string, pose entity.ProgressPhotoPose, data []byte) (*entity.ProgressPhoto, error) {
+	ctx, span := tracing.Start(ctx, "ProgressPhotoUseCase.Upload")
+	defer span.End()
+
+	if !pose.IsValid() {
+		return nil, ErrInvalidProgressPhotoPose
+	}
+	if int64(len(data)) > uc.config.MaxBytes {
+		return nil, ErrUploadTooLarge
+	}
+	if _, err := uuid.Parse(biometricsID); err != nil {
+		return nil, ErrBiometricsNotFound
+	}
+	biometrics, err := uc.nutritionRepo.GetBiometricsByID(ctx, biometricsID)
+	if err != nil {
+		return nil, err
+	}
+	if biometrics == nil || biometrics.UserID != userID {
+		return nil, ErrBiometricsNotFound
+	}
+
+	img, err := processImage(data, uc.config.MaxDimension, uc.config.ThumbnailSize)
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.New().String()
+	photo := &entity.ProgressPhoto{
+		ID:                id,
+		UserID:            userID,
+		BiometricsID:      biometricsID,
+		LogDate:           biometrics.LogDate,
+		Pose:              pose,
+		FileName:          id + extension(img.contentType),
+		ThumbnailFileName: id + "_thumb" + extension(img.thumbnailType),
+		ContentType:       img.contentType,
+		Size:              int64(img.main.Len()),
+		Width:             img.width,
+		Height:            img.height,
+		CreatedAt:         time.Now(),
+	}
+
+	if err := storeFile(uc.files, photo.FileName, &img.main); err != nil {
+		return nil, err
+	}
+	if err := storeFile(uc.files, photo.ThumbnailFileName, &img.thumbnail); err != nil {
+		uc.removeFiles(photo)
+		return nil, err
+	}
+
+	replaced, err := uc.photoRepo.Attach(ctx, photo)
+	if err != nil {
+		uc.removeFiles(photo)
+		return nil, err
+	}
+	if replaced != nil {
+		uc.removeFiles(replaced)
+	}
+	return photo, nil
+}
+
+// Get retrieves a photo the viewer may see
+func (uc *ProgressPhotoUseCase) Get(ctx context.Context, viewerID, id string) (*entity.ProgressPhoto, error) {
+	ctx, span := tracing.Start(ctx, "ProgressPhotoUseCase.Get")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrProgressPhotoNotFound
+	}
+	photo, err := uc.photoRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if photo == nil {
+		return nil, ErrProgressPhotoNotFound
+	}
+	// Photos the viewer may not see are reported as missing, so that their IDs reveal nothing
+	allowed, err := uc.canView(ctx, viewerID, photo.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrProgressPhotoNotFound
+	}
+	return photo, nil
+}
+
+// List retrieves a user's photos of biometrics entries logged between two dates, inclusive
+func (uc *ProgressPhotoUseCase) List(ctx context.Context, viewerID, userID string, from, to time.Time) ([]*entity.ProgressPhoto, error) {
+	ctx, span := tracing.Start(ctx, "ProgressPhotoUseCase.List")
+	defer span.End()
+
+	if from.After(to) {
+		return nil, ErrInvalidInput.WithField("from", "must not be after to")
+	}
+	if err := uc.checkView(ctx, viewerID, userID); err != nil {
+		return nil, err
+	}
+	return uc.photoRepo.ListByUser(ctx, userID, from, to)
+}
+
+// Compare puts a user's progress at two dates side by side: the biometrics entry logged on or closest before
+// each date, its photos and how much each measurement changed between the two entries
+func (uc *ProgressPhotoUseCase) Compare(ctx context.Context, viewerID, userID string, from, to time.Time) (*entity.ProgressComparison, error) {
+	ctx, span := tracing.Start(ctx, "ProgressPhotoUseCase.Compare")
+	defer span.End()
+
+	if from.After(to) {
+		return nil, ErrInvalidInput.WithField("from", "must not be after to")
+	}
+	if err := uc.checkView(ctx, viewerID, userID); err != nil {
+		return nil, err
+	}
+
+	comparison := &entity.ProgressComparison{}
+	var err error
+	if comparison.From, err = uc.snapshot(ctx, userID, from); err != nil {
+		return nil, err
+	}
+	if comparison.To, err = uc.snapshot(ctx, userID, to); err != nil {
+		return nil, err
+	}
+	if comparison.From.Biometrics != nil && comparison.To.Biometrics != nil {
+		comparison.Changes = biometricChanges(comparison.From.Biometrics, comparison.To.Biometrics)
+	}
+	return comparison, nil
+}
+
+func (uc *ProgressPhotoUseCase) snapshot(ctx context.Context, userID string, date time.Time) (*entity.ProgressSnapshot, error) {
+	snapshot := &entity.ProgressSnapshot{Date: date, Photos: []*entity.ProgressPhoto{}}
+
+	biometrics, err := uc.nutritionRepo.GetBiometricsOnOrBefore(ctx, userID, date)
+	if err != nil || biometrics == nil {
+		return snapshot, err
+	}
+	snapshot.Biometrics = biometrics
+
+	photos, err := uc.photoRepo.ListByBiometrics(ctx, biometrics.ID)
+	if err != nil {
+		return nil, err
+	}
+	if photos != nil {
+		snapshot.Photos = photos
+	}
+	return snapshot, nil
+}
+
+// biometricChanges subtracts the measurements of one entry from those of a later one
+func biometricChanges(from, to *entity.UserBiometric) *entity.BiometricChanges {
+	delta := func(a, b *float64) *float64 {
+		if a == nil || b == nil {
+			return nil
+		}
+		d := *b - *a
+		return &d
+	}
+
+	changes := &entity.BiometricChanges{
+		WeightKg:             delta(from.WeightKg, to.WeightKg),
+		BodyFatPercentage:    delta(from.BodyFatPercentage, to.BodyFatPercentage),
+		WaistCircumferenceCm: delta(from.WaistCircumferenceCm, to.WaistCircumferenceCm),
+		HipCircumferenceCm:   delta(from.HipCircumferenceCm, to.HipCircumferenceCm),
+		ChestCircumferenceCm: delta(from.ChestCircumferenceCm, to.ChestCircumferenceCm),
+	}
+	if from.RestingHeartRateBpm != nil && to.RestingHeartRateBpm != nil {
+		d := *to.RestingHeartRateBpm - *from.RestingHeartRateBpm
+		changes.RestingHeartRateBpm = &d
+	}
+	return changes
+}
+
+// Delete deletes one of the user's photos
+func (uc *ProgressPhotoUseCase) Delete(ctx context.Context, userID, id string) error {
+	ctx, span := tracing.Start(ctx, "ProgressPhotoUseCase.Delete")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrProgressPhotoNotFound
+	}
+	photo, err := uc.photoRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	// Coaches see photos but only their owner deletes them
+	if photo == nil || photo.UserID != userID {
+		return ErrProgressPhotoNotFound
+	}
+
+	if err := uc.photoRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	uc.removeFiles(photo)
+	return nil
+}
+
+// canView reports whether a viewer may see a user's photos: the user themselves and the coaches they authorised
+func (uc *ProgressPhotoUseCase) canView(ctx context.Context, viewerID, userID string) (bool, error) {
+	if viewerID == "" {
+		return false, nil
+	}
+	if viewerID == userID {
+		return true, nil
+	}
+	return uc.coachRepo.IsCoach(ctx, userID, viewerID)
+}
+
+func (uc *ProgressPhotoUseCase) checkView(ctx context.Context, viewerID, userID string) error {
+	allowed, err := uc.canView(ctx, viewerID, userID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// LinkSignature returns when a link to a file of a photo expires and its signature.
+// Viewers who can get a photo can view its files until the link expires.
+func (uc *ProgressPhotoUseCase) LinkSignature(photo *entity.ProgressPhoto, variant string) (int64, string) {
+	expires := time.Now().Add(uc.config.LinkTTL).Unix()
+	return expires, uc.sign(photo.ID, variant, expires)
+}
+
+// OpenFile checks a link to a file of a photo and opens the file. For storages that sign URLs it returns
+// a URL to redirect to instead of the file.
+func (uc *ProgressPhotoUseCase) OpenFile(ctx context.Context, id, variant string, expires int64, signature string) (io.ReadCloser, string, string, error) {
+	ctx, span := tracing.Start(ctx, "ProgressPhotoUseCase.OpenFile")
+	defer span.End()
+
+	if variant != ProgressPhotoFull && variant != ProgressPhotoThumbnail {
+		return nil, "", "", ErrPhotoLinkInvalid
+	}
+	if !hmac.Equal([]byte(signature), []byte(uc.sign(id, variant, expires))) {
+		return nil, "", "", ErrPhotoLinkInvalid
+	}
+	if time.Now().Unix() >= expires {
+		return nil, "", "", ErrPhotoLinkInvalid
+	}
+
+	photo, err := uc.photoRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if photo == nil {
+		return nil, "", "", ErrProgressPhotoNotFound
+	}
+	name := photo.FileName
+	if variant == ProgressPhotoThumbnail {
+		name = photo.ThumbnailFileName
+	}
+
+	if signer, ok := uc.files.(storage.Signer); ok {
+		signedURL, err := signer.SignedURL(name, uc.config.LinkTTL)
+		return nil, "", signedURL, err
+	}
+	f, err := uc.files.Open(name)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, "", "", ErrProgressPhotoNotFound
+	}
+	if err != nil {
+		return nil, "", "", err
+	}
+	return f, imageContentType(name), "", nil
+}
+
+func (uc *ProgressPhotoUseCase) sign(id, variant string, expires int64) string {
+	mac := hmac.New(sha256.New, uc.config.LinkSecret)
+	mac.Write([]byte(id + "." + variant + "." + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// removeFiles removes the files of photos whose records are gone, files that cannot be removed are left behind
+func (uc *ProgressPhotoUseCase) removeFiles(photo *entity.ProgressPhoto) {
+	_ = uc.files.Remove(photo.FileName)
+	_ = uc.files.Remove(photo.ThumbnailFileName)
+}
+
+// PurgeOrphans deletes the photos of biometrics entries that no longer exist, after the trash was emptied or
+// the account erased, and returns how many were deleted
+func (uc *ProgressPhotoUseCase) PurgeOrphans(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "ProgressPhotoUseCase.PurgeOrphans")
+	defer span.End()
+
+	var purged int64
+	for ctx.Err() == nil {
+		photos, err := uc.photoRepo.ListOrphans(ctx, 100)
+		if err != nil || len(photos) == 0 {
+			return purged, err
+		}
+
+		for _, photo := range photos {
+			// Files go first, so that a failure leaves the record to retry with
+			if err := uc.files.Remove(photo.FileName); err != nil {
+				return purged, err
+			}
+			if err := uc.files.Remove(photo.ThumbnailFileName); err != nil {
+				return purged, err
+			}
+			if err := uc.photoRepo.Delete(ctx, photo.ID); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+	}
+	return purged, nil
+}
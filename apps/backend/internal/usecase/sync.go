@@ -0,0 +1,447 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/metrics"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
+)
+
+// SyncUseCase implements offline sync of workout sessions, session logs, meals,
+// meal food items and biometrics on top of their repositories.
+//
+// Conflicts are resolved per record, the last writer wins. Every pushed change carries the
+// time the client made it. The change is applied unless the server's copy of the record
+// changed after that time, in which case the push result is a conflict that carries the
+// server's record and the client adopts it. Deletes follow the same rule: a delete made after
+// the last server change removes the record, an edit made after a server side delete brings
+// it back. Logs and meal food items removed along with their session or meal get no tombstone
+// of their own, the client removes them when it receives the parent's tombstone.
+type SyncUseCase struct {
+	userRepo      repository.UserRepository
+	sessionRepo   repository.WorkoutSessionRepository
+	mealRepo      repository.MealRepository
+	nutritionRepo repository.NutritionRepository
+	syncRepo      repository.SyncRepository
+	trashRepo     repository.TrashRepository
+	config        Config
+}
+
+// NewSyncUseCase creates a new instance of SyncUseCase
+func NewSyncUseCase(
+	userRepo repository.UserRepository,
+	sessionRepo repository.WorkoutSessionRepository,
+	mealRepo repository.MealRepository,
+	nutritionRepo repository.NutritionRepository,
+	syncRepo repository.SyncRepository,
+	trashRepo repository.TrashRepository,
+	config Config,
+) *SyncUseCase {
+	return &SyncUseCase{
+		userRepo:      userRepo,
+		sessionRepo:   sessionRepo,
+		mealRepo:      mealRepo,
+		nutritionRepo: nutritionRepo,
+		syncRepo:      syncRepo,
+		trashRepo:     trashRepo,
+		config:        config,
+	}
+}
+
+// Push applies changes a user made offline in the order they are given.
+// Parents must come before their children. Invalid changes are rejected one by one,
+// only failures of the server abort the push.
+func (uc *SyncUseCase) Push(ctx context.Context, userID string, changes []*entity.SyncPushChange) ([]*entity.SyncPushResult, error) {
+	ctx, span := tracing.Start(ctx, "SyncUseCase.Push")
+	defer span.End()
+
+	if err := uc.checkUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	results := make([]*entity.SyncPushResult, 0, len(changes))
+	for _, change := range changes {
+		result, err := uc.push(ctx, userID, change)
+
+		var domainErr *entity.Error
+		if errors.As(err, &domainErr) && domainErr.Kind != entity.ErrorKindInternal {
+			result = &entity.SyncPushResult{
+				EntityType: change.EntityType,
+				EntityID:   change.EntityID,
+				Status:     entity.SyncPushRejected,
+				Error:      domainErr,
+			}
+		} else if err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// Pull returns the changes of a user's records since token, the empty token starts from the beginning.
+// Upserts carry the current record, deletes are tombstones.
+func (uc *SyncUseCase) Pull(ctx context.Context, userID, token string, limit int) ([]*entity.SyncChange, repository.SyncPosition, error) {
+	ctx, span := tracing.Start(ctx, "SyncUseCase.Pull")
+	defer span.End()
+
+	if err := uc.checkUser(ctx, userID); err != nil {
+		return nil, repository.SyncPosition{}, err
+	}
+
+	// Validate page size
+	if limit <= 0 {
+		limit = uc.config.DefaultPageSize
+	}
+	if limit > uc.config.MaxPageSize {
+		limit = uc.config.MaxPageSize
+	}
+
+	changes, position, err := uc.syncRepo.ListChanges(ctx, userID, token, limit)
+	if err != nil {
+		return nil, repository.SyncPosition{}, err
+	}
+
+	pulled := make([]*entity.SyncChange, 0, len(changes))
+	for _, change := range changes {
+		if change.Operation == entity.SyncOperationUpsert {
+			change.Record, err = uc.load(ctx, change.EntityType, change.EntityID)
+			if err != nil {
+				return nil, repository.SyncPosition{}, err
+			}
+			// Deleted after the change was listed, its tombstone comes with a later pull
+			if change.Record == nil {
+				continue
+			}
+		}
+		pulled = append(pulled, change)
+	}
+
+	return pulled, position, nil
+}
+
+func (uc *SyncUseCase) checkUser(ctx context.Context, userID string) error {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// push applies a single change and reports the outcome
+func (uc *SyncUseCase) push(ctx context.Context, userID string, change *entity.SyncPushChange) (*entity.SyncPushResult, error) {
+	result := &entity.SyncPushResult{
+		EntityType: change.EntityType,
+		EntityID:   change.EntityID,
+		Status:     entity.SyncPushApplied,
+	}
+
+	current, err := uc.load(ctx, change.EntityType, change.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	lastChange, err := uc.syncRepo.GetChange(ctx, change.EntityType, change.EntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Records of other users, including deleted ones, are off limits
+	if lastChange != nil && lastChange.UserID != userID {
+		return nil, ErrForbidden
+	}
+	if current != nil {
+		ownerID, err := uc.ownerOf(ctx, current)
+		if err != nil {
+			return nil, err
+		}
+		if ownerID != userID {
+			return nil, ErrForbidden
+		}
+	}
+
+	// Last writer wins
+	var serverChangedAt time.Time
+	switch {
+	case lastChange != nil:
+		serverChangedAt = lastChange.ChangedAt
+	case current != nil:
+		serverChangedAt = modifiedAt(current)
+	}
+	if change.ModifiedAt.Before(serverChangedAt) {
+		result.Status = entity.SyncPushConflict
+		result.Current = current
+		return result, nil
+	}
+
+	if change.Operation == entity.SyncOperationDelete {
+		if current != nil {
+			if err := uc.remove(ctx, change.EntityType, change.EntityID); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+
+	// A record deleted on the server is still in the trash, an edit takes it out again
+	if current == nil && lastChange != nil && lastChange.Operation == entity.SyncOperationDelete {
+		if current, err = uc.restore(ctx, userID, change.EntityType, change.EntityID); err != nil {
+			return nil, err
+		}
+	}
+
+	err = uc.write(ctx, userID, change, current)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		// Changed between reading and writing, the concurrent writer is the later one
+		result.Status = entity.SyncPushConflict
+		result.Current, err = uc.load(ctx, change.EntityType, change.EntityID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// write creates the record of change, or updates current if the record exists
+func (uc *SyncUseCase) write(ctx context.Context, userID string, change *entity.SyncPushChange, current interface{}) error {
+	now := time.Now()
+
+	switch record := change.Record.(type) {
+	case *entity.UserWorkoutSession:
+		record.ID = change.EntityID
+		record.UserID = userID
+		if current == nil {
+			record.CreatedAt = now
+			record.UpdatedAt = now
+			if _, err := uc.sessionRepo.Create(ctx, record); err != nil {
+				return err
+			}
+			if completedNow(nil, record) {
+				metrics.WorkoutSessionsCompleted.Inc()
+			}
+			return nil
+		}
+		existing := current.(*entity.UserWorkoutSession)
+		record.CreatedAt = existing.CreatedAt
+		record.UpdatedAt = existing.UpdatedAt
+		if err := uc.sessionRepo.Update(ctx, record); err != nil {
+			return err
+		}
+		if completedNow(existing, record) {
+			metrics.WorkoutSessionsCompleted.Inc()
+		}
+		return nil
+
+	case *entity.UserWorkoutSessionLog:
+		record.ID = change.EntityID
+		// An update can move the log to another session, which must be the user's as well
+		if err := uc.checkSession(ctx, userID, record.SessionID); err != nil {
+			return err
+		}
+		if current == nil {
+			record.LoggedAt = change.ModifiedAt
+			return uc.sessionRepo.AddLog(ctx, record)
+		}
+		return uc.sessionRepo.UpdateLog(ctx, record)
+
+	case *entity.UserMeal:
+		record.ID = change.EntityID
+		record.UserID = userID
+		if current == nil {
+			record.CreatedAt = now
+			record.UpdatedAt = now
+			if _, err := uc.mealRepo.Create(ctx, record); err != nil {
+				return err
+			}
+			metrics.MealsLogged.Inc()
+			return nil
+		}
+		// Totals are maintained by the server
+		existing := current.(*entity.UserMeal)
+		record.TotalCaloriesConsumed = existing.TotalCaloriesConsumed
+		record.TotalProteinConsumed = existing.TotalProteinConsumed
+		record.TotalFatConsumed = existing.TotalFatConsumed
+		record.TotalCarbsConsumed = existing.TotalCarbsConsumed
+		record.CreatedAt = existing.CreatedAt
+		record.UpdatedAt = existing.UpdatedAt
+		return uc.mealRepo.Update(ctx, record)
+
+	case *entity.MealFoodItem:
+		record.ID = change.EntityID
+		// An update can move the food item to another meal, which must be the user's as well
+		if err := uc.checkMeal(ctx, userID, record.MealID); err != nil {
+			return err
+		}
+		if current == nil {
+			record.LoggedAt = change.ModifiedAt
+			if err := uc.mealRepo.AddFoodItem(ctx, record); err != nil {
+				return err
+			}
+			metrics.MealFoodItemsLogged.Inc()
+			return nil
+		}
+		return uc.mealRepo.UpdateFoodItem(ctx, record)
+
+	case *entity.UserBiometric:
+		record.ID = change.EntityID
+		record.UserID = userID
+		if current == nil {
+			record.CreatedAt = now
+			_, err := uc.nutritionRepo.CreateBiometrics(ctx, record)
+			return err
+		}
+		record.CreatedAt = current.(*entity.UserBiometric).CreatedAt
+		return uc.nutritionRepo.UpdateBiometrics(ctx, record)
+	}
+
+	return ErrInvalidInput
+}
+
+// remove deletes a record
+func (uc *SyncUseCase) remove(ctx context.Context, entityType entity.SyncEntityType, id string) error {
+	switch entityType {
+	case entity.SyncEntityWorkoutSession:
+		return uc.sessionRepo.Delete(ctx, id)
+	case entity.SyncEntitySessionLog:
+		return uc.sessionRepo.DeleteLog(ctx, id)
+	case entity.SyncEntityMeal:
+		return uc.mealRepo.Delete(ctx, id)
+	case entity.SyncEntityMealFoodItem:
+		return uc.mealRepo.DeleteFoodItem(ctx, id)
+	case entity.SyncEntityBiometrics:
+		return uc.nutritionRepo.DeleteBiometrics(ctx, id)
+	}
+	return ErrInvalidInput
+}
+
+// restore takes a deleted record out of the trash and returns it, nil if it was purged
+// or its type is not kept in the trash
+func (uc *SyncUseCase) restore(ctx context.Context, userID string, entityType entity.SyncEntityType, id string) (interface{}, error) {
+	itemType := entity.TrashItemType(entityType)
+	if !itemType.IsValid() {
+		return nil, nil
+	}
+
+	restored, err := uc.trashRepo.Restore(ctx, userID, itemType, id, time.Time{})
+	if err != nil || !restored {
+		return nil, err
+	}
+	return uc.load(ctx, entityType, id)
+}
+
+// load retrieves the current record, nil if it does not exist
+func (uc *SyncUseCase) load(ctx context.Context, entityType entity.SyncEntityType, id string) (interface{}, error) {
+	switch entityType {
+	case entity.SyncEntityWorkoutSession:
+		session, err := uc.sessionRepo.GetByID(ctx, id)
+		if err != nil || session == nil {
+			return nil, err
+		}
+		return session, nil
+	case entity.SyncEntitySessionLog:
+		log, err := uc.sessionRepo.GetLogByID(ctx, id)
+		if err != nil || log == nil {
+			return nil, err
+		}
+		return log, nil
+	case entity.SyncEntityMeal:
+		meal, err := uc.mealRepo.GetByID(ctx, id)
+		if err != nil || meal == nil {
+			return nil, err
+		}
+		return meal, nil
+	case entity.SyncEntityMealFoodItem:
+		foodItem, err := uc.mealRepo.GetFoodItemByID(ctx, id)
+		if err != nil || foodItem == nil {
+			return nil, err
+		}
+		return foodItem, nil
+	case entity.SyncEntityBiometrics:
+		biometrics, err := uc.nutritionRepo.GetBiometricsByID(ctx, id)
+		if err != nil || biometrics == nil {
+			return nil, err
+		}
+		return biometrics, nil
+	}
+	return nil, ErrInvalidInput
+}
+
+// ownerOf returns the ID of the user a record belongs to
+func (uc *SyncUseCase) ownerOf(ctx context.Context, record interface{}) (string, error) {
+	switch record := record.(type) {
+	case *entity.UserWorkoutSession:
+		return record.UserID, nil
+	case *entity.UserMeal:
+		return record.UserID, nil
+	case *entity.UserBiometric:
+		return record.UserID, nil
+	case *entity.UserWorkoutSessionLog:
+		session, err := uc.sessionRepo.GetByID(ctx, record.SessionID)
+		if err != nil || session == nil {
+			return "", err
+		}
+		return session.UserID, nil
+	case *entity.MealFoodItem:
+		meal, err := uc.mealRepo.GetByID(ctx, record.MealID)
+		if err != nil || meal == nil {
+			return "", err
+		}
+		return meal.UserID, nil
+	}
+	return "", ErrInvalidInput
+}
+
+// checkSession verifies that a workout session exists and belongs to the user
+func (uc *SyncUseCase) checkSession(ctx context.Context, userID, sessionID string) error {
+	session, err := uc.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return ErrWorkoutSessionNotFound
+	}
+	if session.UserID != userID {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// checkMeal verifies that a meal exists and belongs to the user
+func (uc *SyncUseCase) checkMeal(ctx context.Context, userID, mealID string) error {
+	meal, err := uc.mealRepo.GetByID(ctx, mealID)
+	if err != nil {
+		return err
+	}
+	if meal == nil {
+		return ErrMealNotFound
+	}
+	if meal.UserID != userID {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// modifiedAt is the last modification time of a record that predates the change feed
+func modifiedAt(record interface{}) time.Time {
+	switch record := record.(type) {
+	case *entity.UserWorkoutSession:
+		return record.UpdatedAt
+	case *entity.UserMeal:
+		return record.UpdatedAt
+	case *entity.UserWorkoutSessionLog:
+		return record.LoggedAt
+	case *entity.MealFoodItem:
+		return record.LoggedAt
+	case *entity.UserBiometric:
+		return record.CreatedAt
+	}
+	return time.Time{}
+}
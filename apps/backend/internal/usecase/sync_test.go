@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+)
+
+type fakeWorkoutSessionRepository struct {
+	repository.WorkoutSessionRepository
+	sessions map[string]*entity.UserWorkoutSession
+	logs     map[string]*entity.UserWorkoutSessionLog
+}
+
+func (r *fakeWorkoutSessionRepository) GetByID(_ context.Context, id string) (*entity.UserWorkoutSession, error) {
+	if s, ok := r.sessions[id]; ok {
+		clone := *s
+		return &clone, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeWorkoutSessionRepository) GetLogByID(_ context.Context, id string) (*entity.UserWorkoutSessionLog, error) {
+	if l, ok := r.logs[id]; ok {
+		clone := *l
+		return &clone, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeWorkoutSessionRepository) UpdateLog(_ context.Context, log *entity.UserWorkoutSessionLog) error {
+	clone := *log
+	r.logs[log.ID] = &clone
+	return nil
+}
+
+type fakeMealRepository struct {
+	repository.MealRepository
+	meals     map[string]*entity.UserMeal
+	foodItems map[string]*entity.MealFoodItem
+	// page is the last page asked for
+	page repository.CursorPage
+}
+
+func (r *fakeMealRepository) GetByID(_ context.Context, id string) (*entity.UserMeal, error) {
+	if m, ok := r.meals[id]; ok {
+		clone := *m
+		return &clone, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeMealRepository) GetFoodItemByID(_ context.Context, id string) (*entity.MealFoodItem, error) {
+	if f, ok := r.foodItems[id]; ok {
+		clone := *f
+		return &clone, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeMealRepository) UpdateFoodItem(_ context.Context, item *entity.MealFoodItem) error {
+	clone := *item
+	r.foodItems[item.ID] = &clone
+	return nil
+}
+
+// fakeSyncRepository has no change feed, records are as old as their own timestamps
+type fakeSyncRepository struct {
+	repository.SyncRepository
+}
+
+func (fakeSyncRepository) GetChange(context.Context, entity.SyncEntityType, string) (*entity.SyncChange, error) {
+	return nil, nil
+}
+
+func TestSyncUseCase_PushMovesChildren(t *testing.T) {
+	logged := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		change     *entity.SyncPushChange
+		wantStatus entity.SyncPushStatus
+	}{
+		{
+			name: "log to own session",
+			change: &entity.SyncPushChange{EntityType: entity.SyncEntitySessionLog, EntityID: "log-1",
+				Record: &entity.UserWorkoutSessionLog{SessionID: "session-alice-2"}},
+			wantStatus: entity.SyncPushApplied,
+		},
+		{
+			name: "log to another user's session",
+			change: &entity.SyncPushChange{EntityType: entity.SyncEntitySessionLog, EntityID: "log-1",
+				Record: &entity.UserWorkoutSessionLog{SessionID: "session-bob"}},
+			wantStatus: entity.SyncPushRejected,
+		},
+		{
+			name: "food item to own meal",
+			change: &entity.SyncPushChange{EntityType: entity.SyncEntityMealFoodItem, EntityID: "food-1",
+				Record: &entity.MealFoodItem{MealID: "meal-alice-2"}},
+			wantStatus: entity.SyncPushApplied,
+		},
+		{
+			name: "food item to another user's meal",
+			change: &entity.SyncPushChange{EntityType: entity.SyncEntityMealFoodItem, EntityID: "food-1",
+				Record: &entity.MealFoodItem{MealID: "meal-bob"}},
+			wantStatus: entity.SyncPushRejected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := &fakeWorkoutSessionRepository{
+				sessions: map[string]*entity.UserWorkoutSession{
+					"session-alice-1": {ID: "session-alice-1", UserID: "alice"},
+					"session-alice-2": {ID: "session-alice-2", UserID: "alice"},
+					"session-bob":     {ID: "session-bob", UserID: "bob"},
+				},
+				logs: map[string]*entity.UserWorkoutSessionLog{
+					"log-1": {ID: "log-1", SessionID: "session-alice-1", LoggedAt: logged},
+				},
+			}
+			meals := &fakeMealRepository{
+				meals: map[string]*entity.UserMeal{
+					"meal-alice-1": {ID: "meal-alice-1", UserID: "alice"},
+					"meal-alice-2": {ID: "meal-alice-2", UserID: "alice"},
+					"meal-bob":     {ID: "meal-bob", UserID: "bob"},
+				},
+				foodItems: map[string]*entity.MealFoodItem{
+					"food-1": {ID: "food-1", MealID: "meal-alice-1", LoggedAt: logged},
+				},
+			}
+			uc := NewSyncUseCase(newFakeUserRepository(&entity.User{ID: "alice"}), sessions, meals, nil, fakeSyncRepository{}, nil, Config{})
+
+			tt.change.Operation = entity.SyncOperationUpsert
+			tt.change.ModifiedAt = logged.Add(time.Minute)
+			results, err := uc.Push(context.Background(), "alice", []*entity.SyncPushChange{tt.change})
+			if err != nil {
+				t.Fatalf("Push() error = %v", err)
+			}
+			if got := results[0].Status; got != tt.wantStatus {
+				t.Fatalf("Push() status = %s (%v), want %s", got, results[0].Error, tt.wantStatus)
+			}
+
+			if tt.wantStatus == entity.SyncPushRejected {
+				if sessions.logs["log-1"].SessionID != "session-alice-1" || meals.foodItems["food-1"].MealID != "meal-alice-1" {
+					t.Errorf("a rejected change moved the record")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
+)
+
+type TrashConfig struct {
+	// Retention is how long deleted records can be restored before they are purged
+	Retention time.Duration
+}
+
+// TrashUseCase handles restoring and purging of deleted records
+type TrashUseCase struct {
+	userRepo  repository.UserRepository
+	trashRepo repository.TrashRepository
+	config    TrashConfig
+}
+
+// NewTrashUseCase creates a new instance of TrashUseCase
+func NewTrashUseCase(userRepo repository.UserRepository, trashRepo repository.TrashRepository, config TrashConfig) *TrashUseCase {
+	return &TrashUseCase{
+		userRepo:  userRepo,
+		trashRepo: trashRepo,
+		config:    config,
+	}
+}
+
+// List returns the records a user deleted within the retention period, most recently deleted first
+func (uc *TrashUseCase) List(ctx context.Context, userID string) ([]*entity.TrashItem, error) {
+	ctx, span := tracing.Start(ctx, "TrashUseCase.List")
+	defer span.End()
+
+	if err := uc.checkUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	items, err := uc.trashRepo.List(ctx, userID, time.Now().Add(-uc.config.Retention))
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		item.RestoreUntil = item.DeletedAt.Add(uc.config.Retention)
+	}
+	return items, nil
+}
+
+// Restore takes a record out of the trash
+func (uc *TrashUseCase) Restore(ctx context.Context, userID string, itemType entity.TrashItemType, id string) error {
+	ctx, span := tracing.Start(ctx, "TrashUseCase.Restore")
+	defer span.End()
+
+	if err := uc.checkUser(ctx, userID); err != nil {
+		return err
+	}
+
+	restored, err := uc.trashRepo.Restore(ctx, userID, itemType, id, time.Now().Add(-uc.config.Retention))
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return ErrTrashItemNotFound
+	}
+	return nil
+}
+
+// PurgeExpired permanently deletes records whose retention period has passed and returns how many were deleted
+func (uc *TrashUseCase) PurgeExpired(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "TrashUseCase.PurgeExpired")
+	defer span.End()
+
+	return uc.trashRepo.Purge(ctx, time.Now().Add(-uc.config.Retention))
+}
+
+func (uc *TrashUseCase) checkUser(ctx context.Context, userID string) error {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	return nil
+}
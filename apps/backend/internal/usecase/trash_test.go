@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+)
+
+// trashedRecord is a soft deleted record of a user
+type trashedRecord struct {
+	userID string
+	item   entity.TrashItem
+}
+
+// fakeTrashRepository keeps soft deleted records in memory
+type fakeTrashRepository struct {
+	repository.TrashRepository
+	records []*trashedRecord
+}
+
+func (r *fakeTrashRepository) List(_ context.Context, userID string, since time.Time) ([]*entity.TrashItem, error) {
+	var items []*entity.TrashItem
+	for _, record := range r.records {
+		if record.userID == userID && record.item.DeletedAt.After(since) {
+			item := record.item
+			items = append(items, &item)
+		}
+	}
+	return items, nil
+}
+
+func (r *fakeTrashRepository) Restore(_ context.Context, userID string, itemType entity.TrashItemType, id string, since time.Time) (bool, error) {
+	for i, record := range r.records {
+		if record.userID == userID && record.item.Type == itemType && record.item.ID == id && record.item.DeletedAt.After(since) {
+			r.records = append(r.records[:i], r.records[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeTrashRepository) Purge(_ context.Context, before time.Time) (int64, error) {
+	var kept []*trashedRecord
+	for _, record := range r.records {
+		if record.item.DeletedAt.After(before) {
+			kept = append(kept, record)
+		}
+	}
+	purged := int64(len(r.records) - len(kept))
+	r.records = kept
+	return purged, nil
+}
+
+const testRetention = 30 * 24 * time.Hour
+
+// newTrashFixture has alice delete a meal yesterday and a food item past the retention period, and bob a meal
+func newTrashFixture() (*TrashUseCase, *fakeTrashRepository, time.Time) {
+	yesterday := time.Now().Add(-24 * time.Hour)
+	repo := &fakeTrashRepository{records: []*trashedRecord{
+		{userID: "alice", item: entity.TrashItem{Type: entity.TrashItemMeal, ID: "m1", Title: "Breakfast", DeletedAt: yesterday}},
+		{userID: "alice", item: entity.TrashItem{Type: entity.TrashItemFoodItem, ID: "f1", Title: "Granola", DeletedAt: time.Now().Add(-testRetention - time.Hour)}},
+		{userID: "bob", item: entity.TrashItem{Type: entity.TrashItemMeal, ID: "m2", Title: "Lunch", DeletedAt: yesterday}},
+	}}
+	users := newFakeUserRepository(&entity.User{ID: "alice", IsActive: true}, &entity.User{ID: "bob", IsActive: true})
+	return NewTrashUseCase(users, repo, TrashConfig{Retention: testRetention}), repo, yesterday
+}
+
+func TestTrashUseCase_List(t *testing.T) {
+	uc, _, yesterday := newTrashFixture()
+
+	items, err := uc.List(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	// The food item is past the retention period, bob's meal is his
+	if len(items) != 1 || items[0].ID != "m1" {
+		t.Fatalf("List() = %+v, want the meal m1", items)
+	}
+	if want := yesterday.Add(testRetention); !items[0].RestoreUntil.Equal(want) {
+		t.Errorf("RestoreUntil = %v, want %v", items[0].RestoreUntil, want)
+	}
+
+	if _, err := uc.List(context.Background(), "mallory"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("List() of an unknown user error = %v, want %v", err, ErrUserNotFound)
+	}
+}
+
+func TestTrashUseCase_Restore(t *testing.T) {
+	tests := []struct {
+		name     string
+		itemType entity.TrashItemType
+		id       string
+		wantErr  error
+	}{
+		{name: "within retention", itemType: entity.TrashItemMeal, id: "m1"},
+		{name: "past retention", itemType: entity.TrashItemFoodItem, id: "f1", wantErr: ErrTrashItemNotFound},
+		{name: "another user's", itemType: entity.TrashItemMeal, id: "m2", wantErr: ErrTrashItemNotFound},
+		{name: "another type", itemType: entity.TrashItemWorkoutSession, id: "m1", wantErr: ErrTrashItemNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, repo, _ := newTrashFixture()
+
+			err := uc.Restore(context.Background(), "alice", tt.itemType, tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Restore() error = %v, want %v", err, tt.wantErr)
+			}
+			if restored := len(repo.records) == 2; restored != (tt.wantErr == nil) {
+				t.Errorf("records left = %d", len(repo.records))
+			}
+		})
+	}
+}
+
+func TestTrashUseCase_PurgeExpired(t *testing.T) {
+	uc, repo, _ := newTrashFixture()
+
+	purged, err := uc.PurgeExpired(context.Background())
+	if err != nil {
+		t.Fatalf("PurgeExpired() error = %v", err)
+	}
+	if purged != 1 || len(repo.records) != 2 {
+		t.Fatalf("PurgeExpired() = %d leaving %d records, want 1 leaving 2", purged, len(repo.records))
+	}
+	for _, record := range repo.records {
+		if record.item.ID == "f1" {
+			t.Errorf("the food item past retention was kept")
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/imaging"
+	"github.com/terrnit/rebound/backend/pkg/storage"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
+)
+
+// _uploadMaxPixels bounds the memory an upload takes to decode, 40 megapixels is about 160 MB
+const _uploadMaxPixels = 40_000_000
+
+// uploadFileName matches the names of the files uploads are stored under
+var uploadFileName = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}(_thumb)?\.(jpg|png)$`)
+
+type UploadConfig struct {
+	// MaxBytes is the largest file accepted
+	MaxBytes int64
+	// MaxDimension is the width and height images are scaled down to fit
+	MaxDimension int
+	// ThumbnailSize is the width and height thumbnails are scaled down to fit
+	ThumbnailSize int
+	// PublicURL is the URL uploads are served under, file names are appended to it
+	PublicURL string
+	// SignedURLTTL is how long the URLs that serving redirects to are valid, for storages that sign them
+	SignedURLTTL time.Duration
+}
+
+// UploadUseCase stores uploaded images and attaches them to profile pictures, exercise images and workout plan covers.
+// Images are re-encoded, which drops EXIF and any other metadata, and stored with a thumbnail.
+type UploadUseCase struct {
+	userRepo        repository.UserRepository
+	exerciseRepo    repository.ExerciseRepository
+	workoutPlanRepo repository.WorkoutPlanRepository
+	uploadRepo      repository.UploadRepository
+	files           storage.Storage
+	config          UploadConfig
+}
+
+// NewUploadUseCase creates a new instance of UploadUseCase
+func NewUploadUseCase(
+	userRepo repository.UserRepository,
+	exerciseRepo repository.ExerciseRepository,
+	workoutPlanRepo repository.WorkoutPlanRepository,
+	uploadRepo repository.UploadRepository,
+	files storage.Storage,
+	config UploadConfig,
+) *UploadUseCase {
+	return &UploadUseCase{
+		userRepo:        userRepo,
+		exerciseRepo:    exerciseRepo,
+		workoutPlanRepo: workoutPlanRepo,
+		uploadRepo:      uploadRepo,
+		files:           files,
+		config:          config,
+	}
+}
+
+// MaxBytes returns the largest file accepted
+func (uc *UploadUseCase) MaxBytes() int64 {
+	return uc.config.MaxBytes
+}
+
+// UploadImage stores an image and writes its URL to the target field, replacing the image uploaded there before.
+// Users upload their own profile picture and images for the exercises and workout plans they created.
+func (uc *UploadUseCase) UploadImage(ctx context.Context, userID string, target entity.UploadTarget, targetID string, data []byte) (*entity.Upload, error) {
+	ctx, span := tracing.Start(ctx, "UploadUseCase.UploadImage")
+	defer span.End()
+
+	if !target.IsValid() {
+		return nil, ErrInvalidUploadTarget
+	}
+	if int64(len(data)) > uc.config.MaxBytes {
+		return nil, ErrUploadTooLarge
+	}
+	if err := uc.checkTarget(ctx, userID, target, targetID); err != nil {
+		return nil, err
+	}
+
+	img, err := processImage(data, uc.config.MaxDimension, uc.config.ThumbnailSize)
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.New().String()
+	upload := &entity.Upload{
+		ID:                id,
+		Target:            target,
+		TargetID:          targetID,
+		FileName:          id + extension(img.contentType),
+		ThumbnailFileName: id + "_thumb" + extension(img.thumbnailType),
+		ContentType:       img.contentType,
+		Size:              int64(img.main.Len()),
+		Width:             img.width,
+		Height:            img.height,
+		UploadedBy:        &userID,
+		CreatedAt:         time.Now(),
+	}
+	upload.URL = uc.fileURL(upload.FileName)
+	upload.ThumbnailURL = uc.fileURL(upload.ThumbnailFileName)
+
+	if err := storeFile(uc.files, upload.FileName, &img.main); err != nil {
+		return nil, err
+	}
+	if err := storeFile(uc.files, upload.ThumbnailFileName, &img.thumbnail); err != nil {
+		uc.removeFiles(upload)
+		return nil, err
+	}
+
+	replaced, err := uc.uploadRepo.Attach(ctx, upload)
+	if err != nil {
+		uc.removeFiles(upload)
+		return nil, err
+	}
+	uc.removeFiles(replaced...)
+	return upload, nil
+}
+
+// checkTarget checks that the target exists and that the user may change its image
+func (uc *UploadUseCase) checkTarget(ctx context.Context, userID string, target entity.UploadTarget, targetID string) error {
+	switch target {
+	case entity.UploadTargetProfilePicture:
+		if _, err := uuid.Parse(targetID); err != nil {
+			return ErrUserNotFound
+		}
+		if targetID != userID {
+			return ErrForbidden
+		}
+		user, err := uc.userRepo.GetByID(ctx, targetID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return ErrUserNotFound
+		}
+	case entity.UploadTargetExerciseImage:
+		if _, err := uuid.Parse(targetID); err != nil {
+			return ErrExerciseNotFound
+		}
+		exercise, err := uc.exerciseRepo.GetByID(ctx, targetID)
+		if err != nil {
+			return err
+		}
+		if exercise == nil {
+			return ErrExerciseNotFound
+		}
+		if exercise.CreatedByUserID == nil || *exercise.CreatedByUserID != userID {
+			return ErrForbidden
+		}
+	case entity.UploadTargetWorkoutPlanCover:
+		if _, err := uuid.Parse(targetID); err != nil {
+			return ErrWorkoutPlanNotFound
+		}
+		plan, err := uc.workoutPlanRepo.GetByID(ctx, targetID)
+		if err != nil {
+			return err
+		}
+		if plan == nil {
+			return ErrWorkoutPlanNotFound
+		}
+		if plan.UserID == nil || *plan.UserID != userID {
+			return ErrForbidden
+		}
+	}
+	return nil
+}
+
+// removeFiles removes the files of uploads whose records are gone. Files that cannot be removed are left behind
+// rather than failing an upload that has already replaced them.
+func (uc *UploadUseCase) removeFiles(uploads ...*entity.Upload) {
+	for _, upload := range uploads {
+		_ = uc.files.Remove(upload.FileName)
+		_ = uc.files.Remove(upload.ThumbnailFileName)
+	}
+}
+
+func (uc *UploadUseCase) fileURL(name string) string {
+	return strings.TrimSuffix(uc.config.PublicURL, "/") + "/" + name
+}
+
+// SignedURL returns a URL that reads an uploaded file straight from storage, for storages that sign URLs.
+// It returns an empty URL when files are served by the API instead.
+func (uc *UploadUseCase) SignedURL(name string) (string, error) {
+	if !uploadFileName.MatchString(name) {
+		return "", ErrUploadNotFound
+	}
+	signer, ok := uc.files.(storage.Signer)
+	if !ok {
+		return "", nil
+	}
+	return signer.SignedURL(name, uc.config.SignedURLTTL)
+}
+
+// Open returns a reader for an uploaded file and its content type
+func (uc *UploadUseCase) Open(name string) (io.ReadCloser, string, error) {
+	if !uploadFileName.MatchString(name) {
+		return nil, "", ErrUploadNotFound
+	}
+	f, err := uc.files.Open(name)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, "", ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return f, imageContentType(name), nil
+}
+
+// PurgeOrphans deletes the uploads of profiles, exercises and workout plans that no longer exist,
+// such as erased accounts, and returns how many were deleted
+func (uc *UploadUseCase) PurgeOrphans(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "UploadUseCase.PurgeOrphans")
+	defer span.End()
+
+	var purged int64
+	for ctx.Err() == nil {
+		uploads, err := uc.uploadRepo.ListOrphans(ctx, 100)
+		if err != nil || len(uploads) == 0 {
+			return purged, err
+		}
+
+		for _, upload := range uploads {
+			// Files go first, so that a failure leaves the record to retry with
+			if err := uc.files.Remove(upload.FileName); err != nil {
+				return purged, err
+			}
+			if err := uc.files.Remove(upload.ThumbnailFileName); err != nil {
+				return purged, err
+			}
+			if err := uc.uploadRepo.Delete(ctx, upload.ID); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// processedImage is an uploaded image scaled down, re-encoded and with a thumbnail
+type processedImage struct {
+	main, thumbnail            bytes.Buffer
+	contentType, thumbnailType string
+	width, height              int
+}
+
+// processImage decodes an uploaded image and encodes it scaled down to fit maxDimension and to fit thumbnailSize.
+// Re-encoding drops EXIF and any other metadata.
+func processImage(data []byte, maxDimension, thumbnailSize int) (*processedImage, error) {
+	img, err := imaging.Decode(data, _uploadMaxPixels)
+	if errors.Is(err, imaging.ErrUnsupportedFormat) {
+		return nil, ErrUnsupportedImageType
+	}
+	if errors.Is(err, imaging.ErrTooManyPixels) {
+		return nil, ErrImageTooLarge
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var processed processedImage
+	main := img.Fit(maxDimension)
+	processed.width, processed.height = main.Bounds().Dx(), main.Bounds().Dy()
+	if processed.contentType, err = img.Encode(&processed.main, main); err != nil {
+		return nil, fmt.Errorf("usecase - processImage - img.Encode: %w", err)
+	}
+	if processed.thumbnailType, err = img.Encode(&processed.thumbnail, img.Fit(thumbnailSize)); err != nil {
+		return nil, fmt.Errorf("usecase - processImage - img.Encode: %w", err)
+	}
+	return &processed, nil
+}
+
+// storeFile writes a file to storage, nothing is left behind when writing fails
+func storeFile(files storage.Storage, name string, data io.Reader) error {
+	w, err := files.Create(name)
+	if err != nil {
+		return fmt.Errorf("usecase - storeFile - files.Create: %w", err)
+	}
+	if _, err := io.Copy(w, data); err != nil {
+		w.Close()
+		_ = files.Remove(name)
+		return fmt.Errorf("usecase - storeFile - io.Copy: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("usecase - storeFile - Close: %w", err)
+	}
+	return nil
+}
+
+// imageContentType returns the content type of an image file by its extension
+func imageContentType(name string) string {
+	if strings.HasSuffix(name, ".png") {
+		return imaging.PNG
+	}
+	return imaging.JPEG
+}
+
+// extension returns the file extension of an image content type
+func extension(contentType string) string {
+	if contentType == imaging.PNG {
+		return ".png"
+	}
+	return ".jpg"
+}
@@ -8,30 +8,56 @@ import (
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/metrics"
 	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/storage"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
 )
 
 type UserConfig struct {
 	DefaultPageSize int
 	MaxPageSize     int
 	MinPasswordLen  int
+	// DeletionGracePeriod is how long a deleted account can be restored before it is erased
+	DeletionGracePeriod time.Duration
+	// Lockout locks the account after wrong current passwords as after failed sign ins
+	Lockout repository.Lockout
 }
 
 type UserUseCase struct {
-	repo   repository.UserRepository
-	config UserConfig
+	repo      repository.UserRepository
+	roleRepo  repository.RoleRepository
+	tokenRepo repository.AuthTokenRepository
+	audit     auditor
+	// exportFiles holds the data export archives that are erased along with an account
+	exportFiles storage.Storage
+	config      UserConfig
 }
 
 // NewUserUseCase creates a new instance of UserUseCase
-func NewUserUseCase(r repository.UserRepository, config UserConfig) *UserUseCase {
+func NewUserUseCase(
+	r repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	tokenRepo repository.AuthTokenRepository,
+	auditRepo repository.AuditLogRepository,
+	exportFiles storage.Storage,
+	config UserConfig,
+) *UserUseCase {
 	return &UserUseCase{
-		repo:   r,
-		config: config,
+		repo:        r,
+		roleRepo:    roleRepo,
+		tokenRepo:   tokenRepo,
+		audit:       auditor{repo: auditRepo},
+		exportFiles: exportFiles,
+		config:      config,
 	}
 }
 
 // CreateUser creates a new user
 func (uc *UserUseCase) CreateUser(ctx context.Context, user *entity.User, password string) (*entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.CreateUser")
+	defer span.End()
+
 	// Validate password
 	if len(password) < uc.config.MinPasswordLen {
 		return nil, ErrInvalidPassword
@@ -69,26 +95,65 @@ func (uc *UserUseCase) CreateUser(ctx context.Context, user *entity.User, passwo
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
-	return uc.repo.Create(ctx, user)
+	created, err := uc.repo.Create(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	metrics.UsersRegistered.Inc()
+	return created, nil
 }
 
 // GetUser retrieves a user by ID
 func (uc *UserUseCase) GetUser(ctx context.Context, id string) (*entity.User, error) {
-	return uc.repo.GetByID(ctx, id)
+	ctx, span := tracing.Start(ctx, "UserUseCase.GetUser")
+	defer span.End()
+
+	user, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
 }
 
 // GetUserByEmail retrieves a user by email
 func (uc *UserUseCase) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
-	return uc.repo.GetByEmail(ctx, email)
+	ctx, span := tracing.Start(ctx, "UserUseCase.GetUserByEmail")
+	defer span.End()
+
+	user, err := uc.repo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
 }
 
 // GetUserByUsername retrieves a user by username
 func (uc *UserUseCase) GetUserByUsername(ctx context.Context, username string) (*entity.User, error) {
-	return uc.repo.GetByUsername(ctx, username)
+	ctx, span := tracing.Start(ctx, "UserUseCase.GetUserByUsername")
+	defer span.End()
+
+	user, err := uc.repo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
 }
 
-// UpdateUser updates an existing user
+// UpdateUser updates an existing user.
+// user.UpdatedAt must hold the version that was read, repository.ErrVersionConflict is returned if it changed since.
 func (uc *UserUseCase) UpdateUser(ctx context.Context, user *entity.User) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.UpdateUser")
+	defer span.End()
+
 	// Check if user exists
 	existingUser, err := uc.repo.GetByID(ctx, user.ID)
 	if err != nil {
@@ -100,46 +165,125 @@ func (uc *UserUseCase) UpdateUser(ctx context.Context, user *entity.User) error
 
 	// If username is being changed, check if it's already taken
 	if user.Username != existingUser.Username {
-		existingUser, err = uc.repo.GetByUsername(ctx, user.Username)
+		byUsername, err := uc.repo.GetByUsername(ctx, user.Username)
 		if err != nil {
 			return err
 		}
-		if existingUser != nil {
+		if byUsername != nil {
 			return ErrUsernameTaken
 		}
 	}
 
 	// If email is being changed, check if it's already taken
 	if user.Email != existingUser.Email {
-		existingUser, err = uc.repo.GetByEmail(ctx, user.Email)
+		byEmail, err := uc.repo.GetByEmail(ctx, user.Email)
 		if err != nil {
 			return err
 		}
-		if existingUser != nil {
+		if byEmail != nil {
 			return ErrEmailTaken
 		}
+		// The new address is not verified yet. Keeping the flag would let anyone claim an address they
+		// do not own, which sign in with a provider then links to their account.
+		user.IsEmailVerified = false
 	}
 
-	user.UpdatedAt = time.Now()
 	return uc.repo.Update(ctx, user)
 }
 
-// DeleteUser deletes a user
-func (uc *UserUseCase) DeleteUser(ctx context.Context, id string) error {
+// DeleteUser deactivates a user, revokes their tokens and schedules the account to be erased
+// once the grace period has passed. Deleting an account that is already scheduled keeps the original date.
+func (uc *UserUseCase) DeleteUser(ctx context.Context, id string) (*entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.DeleteUser")
+	defer span.End()
+
 	// Check if user exists
-	existingUser, err := uc.repo.GetByID(ctx, id)
+	user, err := uc.repo.GetByID(ctx, id)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if existingUser == nil {
-		return ErrUserNotFound
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.DeletionScheduledAt != nil {
+		return user, nil
+	}
+
+	scheduledAt := time.Now().Add(uc.config.DeletionGracePeriod)
+	if err := uc.repo.ScheduleDeletion(ctx, id, scheduledAt); err != nil {
+		return nil, err
+	}
+	if err := uc.tokenRepo.RevokeAllByUserID(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := uc.audit.record(ctx, entity.AuditActionAccountDeletionRequest, entity.AuditTargetUser, id, nil); err != nil {
+		return nil, err
+	}
+
+	return uc.GetUser(ctx, id)
+}
+
+// CancelUserDeletion reactivates a user whose account is scheduled for deletion
+func (uc *UserUseCase) CancelUserDeletion(ctx context.Context, id string) (*entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.CancelUserDeletion")
+	defer span.End()
+
+	if _, err := uc.GetUser(ctx, id); err != nil {
+		return nil, err
+	}
+
+	cancelled, err := uc.repo.CancelDeletion(ctx, id)
+	if err != nil {
+		return nil, err
 	}
+	if !cancelled {
+		return nil, ErrAccountDeletionNotScheduled
+	}
+	if err := uc.audit.record(ctx, entity.AuditActionAccountDeletionCancel, entity.AuditTargetUser, id, nil); err != nil {
+		return nil, err
+	}
+
+	return uc.GetUser(ctx, id)
+}
+
+// EraseDueAccounts erases the accounts whose grace period has passed and returns how many were erased
+func (uc *UserUseCase) EraseDueAccounts(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.EraseDueAccounts")
+	defer span.End()
 
-	return uc.repo.Delete(ctx, id)
+	var erased int64
+	for ctx.Err() == nil {
+		ids, err := uc.repo.ListDueDeletions(ctx, time.Now(), 100)
+		if err != nil || len(ids) == 0 {
+			return erased, err
+		}
+
+		for _, id := range ids {
+			files, err := uc.repo.Erase(ctx, id)
+			if err != nil {
+				return erased, err
+			}
+			for _, file := range files {
+				if err := uc.exportFiles.Remove(file); err != nil {
+					return erased, err
+				}
+			}
+
+			// The erased account's data is not copied into the audit log
+			if err := uc.audit.record(ctx, entity.AuditActionAccountDelete, entity.AuditTargetUser, id, nil); err != nil {
+				return erased, err
+			}
+			erased++
+		}
+	}
+	return erased, nil
 }
 
 // ListUsers returns a paginated list of users
-func (uc *UserUseCase) ListUsers(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]*entity.User, int64, error) {
+func (uc *UserUseCase) ListUsers(ctx context.Context, spec repository.QuerySpec, page, pageSize int) ([]*entity.User, int64, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.ListUsers")
+	defer span.End()
+
 	// Validate page size
 	if pageSize <= 0 {
 		pageSize = uc.config.DefaultPageSize
@@ -149,13 +293,13 @@ func (uc *UserUseCase) ListUsers(ctx context.Context, filters map[string]interfa
 	}
 
 	// Get total count
-	total, err := uc.repo.Count(ctx, filters)
+	total, err := uc.repo.Count(ctx, spec)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// Get users
-	users, err := uc.repo.List(ctx, filters, page, pageSize)
+	users, err := uc.repo.List(ctx, spec, page, pageSize)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -163,8 +307,12 @@ func (uc *UserUseCase) ListUsers(ctx context.Context, filters map[string]interfa
 	return users, total, nil
 }
 
-// UpdatePassword updates a user's password
-func (uc *UserUseCase) UpdatePassword(ctx context.Context, id string, currentPassword, newPassword string) error {
+// UpdatePassword updates a user's password.
+// With revokeOtherSessions every session but currentSessionID is signed out, all of them when it is empty.
+func (uc *UserUseCase) UpdatePassword(ctx context.Context, id string, currentPassword, newPassword string, revokeOtherSessions bool, currentSessionID string) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.UpdatePassword")
+	defer span.End()
+
 	// Validate new password
 	if len(newPassword) < uc.config.MinPasswordLen {
 		return ErrInvalidPassword
@@ -179,9 +327,20 @@ func (uc *UserUseCase) UpdatePassword(ctx context.Context, id string, currentPas
 		return ErrUserNotFound
 	}
 
+	// The current password is as good as a sign in, guessing it is subject to the same lockout
+	now := time.Now()
+	if user.LockedUntil != nil && user.LockedUntil.After(now) {
+		return ErrAccountLocked.WithRetryAfter(user.LockedUntil.Sub(now))
+	}
+
 	// Verify current password
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword))
 	if err != nil {
+		if uc.config.Lockout.Threshold > 0 {
+			if _, err := uc.repo.RecordLoginFailure(ctx, user.ID, uc.config.Lockout); err != nil {
+				return err
+			}
+		}
 		return ErrInvalidPassword
 	}
 
@@ -193,22 +352,67 @@ func (uc *UserUseCase) UpdatePassword(ctx context.Context, id string, currentPas
 
 	// Update password
 	user.PasswordHash = string(hashedPassword)
-	user.UpdatedAt = time.Now()
-	return uc.repo.Update(ctx, user)
+	if err := uc.repo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	if err := uc.audit.record(ctx, entity.AuditActionPasswordChange, entity.AuditTargetUser, id, nil); err != nil {
+		return err
+	}
+	if !revokeOtherSessions {
+		return nil
+	}
+
+	// Sign every other device out, the current session is kept
+	revoked, err := uc.tokenRepo.RevokeOtherSessions(ctx, id, currentSessionID)
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return nil
+	}
+	return uc.audit.record(ctx, entity.AuditActionLogout, entity.AuditTargetUser, id, nil)
 }
 
 // UpdateLastLogin updates the last login timestamp for a user
 func (uc *UserUseCase) UpdateLastLogin(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.UpdateLastLogin")
+	defer span.End()
+
 	return uc.repo.UpdateLastLogin(ctx, id)
 }
 
 // UpdateEmailVerification updates the email verification status for a user
 func (uc *UserUseCase) UpdateEmailVerification(ctx context.Context, id string, isVerified bool) error {
-	return uc.repo.UpdateEmailVerification(ctx, id, isVerified)
+	ctx, span := tracing.Start(ctx, "UserUseCase.UpdateEmailVerification")
+	defer span.End()
+
+	user, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	if err := uc.repo.UpdateEmailVerification(ctx, id, isVerified); err != nil {
+		return err
+	}
+
+	var changes map[string]entity.AuditChange
+	if user.IsEmailVerified != isVerified {
+		changes = map[string]entity.AuditChange{
+			"is_email_verified": {Before: user.IsEmailVerified, After: isVerified},
+		}
+	}
+	return uc.audit.record(ctx, entity.AuditActionEmailVerificationChange, entity.AuditTargetUser, id, changes)
 }
 
 // VerifyPassword verifies a user's password
 func (uc *UserUseCase) VerifyPassword(ctx context.Context, email, password string) (*entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.VerifyPassword")
+	defer span.End()
+
 	user, err := uc.repo.GetByEmail(ctx, email)
 	if err != nil {
 		return nil, err
@@ -224,3 +428,97 @@ func (uc *UserUseCase) VerifyPassword(ctx context.Context, email, password strin
 
 	return user, nil
 }
+
+// GetUserRoles returns the roles assigned to a user
+func (uc *UserUseCase) GetUserRoles(ctx context.Context, userID string) ([]*entity.Role, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.GetUserRoles")
+	defer span.End()
+
+	if _, err := uc.GetUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return uc.roleRepo.ListByUserID(ctx, userID)
+}
+
+// HasRole reports whether a user holds the named role
+func (uc *UserUseCase) HasRole(ctx context.Context, userID, roleName string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.HasRole")
+	defer span.End()
+
+	return hasRole(ctx, uc.roleRepo, userID, roleName)
+}
+
+// hasRole reports whether a user holds the named role
+func hasRole(ctx context.Context, roleRepo repository.RoleRepository, userID, roleName string) (bool, error) {
+	roles, err := roleRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, role := range roles {
+		if role.Name == roleName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// AssignRole assigns the named role to a user, assigning a role the user already has is a no-op
+func (uc *UserUseCase) AssignRole(ctx context.Context, userID, roleName string) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.AssignRole")
+	defer span.End()
+
+	role, err := uc.getRole(ctx, userID, roleName)
+	if err != nil {
+		return err
+	}
+
+	assigned, err := uc.roleRepo.Assign(ctx, &entity.UserRole{
+		UserID:     userID,
+		RoleID:     role.ID,
+		AssignedAt: time.Now(),
+	})
+	if err != nil || !assigned {
+		return err
+	}
+
+	return uc.audit.record(ctx, entity.AuditActionRoleAssign, entity.AuditTargetUser, userID, map[string]entity.AuditChange{
+		"role": {After: role.Name},
+	})
+}
+
+// RevokeRole removes the named role from a user, revoking a role the user does not have is a no-op
+func (uc *UserUseCase) RevokeRole(ctx context.Context, userID, roleName string) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.RevokeRole")
+	defer span.End()
+
+	role, err := uc.getRole(ctx, userID, roleName)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := uc.roleRepo.Revoke(ctx, userID, role.ID)
+	if err != nil || !revoked {
+		return err
+	}
+
+	return uc.audit.record(ctx, entity.AuditActionRoleRevoke, entity.AuditTargetUser, userID, map[string]entity.AuditChange{
+		"role": {Before: role.Name},
+	})
+}
+
+// getRole checks that the user exists and looks up the named role
+func (uc *UserUseCase) getRole(ctx context.Context, userID, roleName string) (*entity.Role, error) {
+	if _, err := uc.GetUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	role, err := uc.roleRepo.GetByName(ctx, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+	return role, nil
+}
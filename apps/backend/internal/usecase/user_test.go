@@ -0,0 +1,296 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/storage"
+)
+
+// fakeUserRepository keeps users in memory. Methods a test does not need panic through the nil interface.
+type fakeUserRepository struct {
+	repository.UserRepository
+	users   map[string]*entity.User
+	updated []*entity.User
+	// failures counts the failed sign in attempts per user
+	failures map[string]int
+}
+
+func newFakeUserRepository(users ...*entity.User) *fakeUserRepository {
+	r := &fakeUserRepository{users: map[string]*entity.User{}, failures: map[string]int{}}
+	for _, u := range users {
+		r.users[u.ID] = u
+	}
+	return r
+}
+
+func (r *fakeUserRepository) GetByID(_ context.Context, id string) (*entity.User, error) {
+	if u, ok := r.users[id]; ok {
+		clone := *u
+		return &clone, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepository) GetByEmail(_ context.Context, email string) (*entity.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			clone := *u
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepository) GetByUsername(_ context.Context, username string) (*entity.User, error) {
+	for _, u := range r.users {
+		if u.Username == username {
+			clone := *u
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepository) Update(_ context.Context, user *entity.User) error {
+	clone := *user
+	r.users[user.ID] = &clone
+	r.updated = append(r.updated, &clone)
+	return nil
+}
+
+func (r *fakeUserRepository) RecordLoginFailure(_ context.Context, id string, lockout repository.Lockout) (*time.Time, error) {
+	u := r.users[id]
+	r.failures[id]++
+	if r.failures[id] >= lockout.Threshold {
+		lockedUntil := time.Now().Add(lockout.BaseDelay)
+		u.LockedUntil = &lockedUntil
+	}
+	return u.LockedUntil, nil
+}
+
+// fakeAuditLogRepository collects the entries appended to the audit log
+type fakeAuditLogRepository struct {
+	repository.AuditLogRepository
+	entries []*entity.AuditLog
+}
+
+func (r *fakeAuditLogRepository) Create(_ context.Context, entry *entity.AuditLog) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func TestUserUseCase_UpdateUser(t *testing.T) {
+	alice := &entity.User{ID: "1", Username: "alice", Email: "alice@example.com"}
+	bob := &entity.User{ID: "2", Username: "bob", Email: "bob@example.com"}
+
+	tests := []struct {
+		name     string
+		username string
+		email    string
+		wantErr  error
+	}{
+		{name: "unchanged", username: "alice", email: "alice@example.com"},
+		{name: "new username", username: "alicia", email: "alice@example.com"},
+		{name: "new email", username: "alice", email: "alicia@example.com"},
+		{name: "new username and email", username: "alicia", email: "alicia@example.com"},
+		{name: "username taken", username: "bob", email: "alice@example.com", wantErr: ErrUsernameTaken},
+		{name: "email taken", username: "alice", email: "bob@example.com", wantErr: ErrEmailTaken},
+		{name: "new username, email taken", username: "alicia", email: "bob@example.com", wantErr: ErrEmailTaken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeUserRepository(alice, bob)
+			uc := NewUserUseCase(repo, nil, nil, nil, nil, UserConfig{})
+
+			err := uc.UpdateUser(context.Background(), &entity.User{ID: "1", Username: tt.username, Email: tt.email, IsEmailVerified: true})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateUser() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.updated) != 0 {
+					t.Errorf("UpdateUser() wrote the user after %v", err)
+				}
+				return
+			}
+			got := repo.users["1"]
+			if got.Username != tt.username || got.Email != tt.email {
+				t.Errorf("stored user = %s <%s>, want %s <%s>", got.Username, got.Email, tt.username, tt.email)
+			}
+			// A new address has to be verified again
+			if wantVerified := tt.email == alice.Email; got.IsEmailVerified != wantVerified {
+				t.Errorf("stored user verified = %v, want %v", got.IsEmailVerified, wantVerified)
+			}
+		})
+	}
+}
+
+func TestUserUseCase_UpdateUser_NotFound(t *testing.T) {
+	uc := NewUserUseCase(newFakeUserRepository(), nil, nil, nil, nil, UserConfig{})
+	err := uc.UpdateUser(context.Background(), &entity.User{ID: "1", Username: "alice"})
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("UpdateUser() error = %v, want %v", err, ErrUserNotFound)
+	}
+}
+
+func TestUserUseCase_UpdatePassword_Lockout(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newFakeUserRepository(&entity.User{ID: "1", PasswordHash: string(hash)})
+	uc := NewUserUseCase(repo, nil, nil, &fakeAuditLogRepository{}, nil, UserConfig{
+		MinPasswordLen: 8,
+		Lockout:        repository.Lockout{Threshold: 3, BaseDelay: time.Minute, MaxDelay: time.Hour},
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := uc.UpdatePassword(ctx, "1", "guess", "new password", false, ""); !errors.Is(err, ErrInvalidPassword) {
+			t.Fatalf("attempt %d: UpdatePassword() error = %v, want %v", i+1, err, ErrInvalidPassword)
+		}
+	}
+	if got := repo.failures["1"]; got != 3 {
+		t.Errorf("failed attempts = %d, want 3", got)
+	}
+
+	// Once locked, even the right password is refused
+	err = uc.UpdatePassword(ctx, "1", "correct horse", "new password", false, "")
+	var domainErr *entity.Error
+	if !errors.Is(err, ErrAccountLocked) || !errors.As(err, &domainErr) || domainErr.RetryAfter <= 0 {
+		t.Fatalf("locked UpdatePassword() error = %v, want %v with a retry delay", err, ErrAccountLocked)
+	}
+	if len(repo.updated) != 0 {
+		t.Errorf("UpdatePassword() changed the password of a locked account")
+	}
+
+	repo.users["1"].LockedUntil = nil
+	if err := uc.UpdatePassword(ctx, "1", "correct horse", "new password", false, ""); err != nil {
+		t.Fatalf("UpdatePassword() error = %v", err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(repo.users["1"].PasswordHash), []byte("new password")) != nil {
+		t.Errorf("UpdatePassword() did not store the new password")
+	}
+}
+
+func (r *fakeUserRepository) ScheduleDeletion(_ context.Context, id string, at time.Time) error {
+	u := r.users[id]
+	u.IsActive = false
+	u.DeletionScheduledAt = &at
+	return nil
+}
+
+func (r *fakeUserRepository) CancelDeletion(_ context.Context, id string) (bool, error) {
+	u := r.users[id]
+	if u == nil || u.DeletionScheduledAt == nil {
+		return false, nil
+	}
+	u.IsActive = true
+	u.DeletionScheduledAt = nil
+	return true, nil
+}
+
+func (r *fakeUserRepository) ListDueDeletions(_ context.Context, now time.Time, limit int) ([]string, error) {
+	var ids []string
+	for id, u := range r.users {
+		if u.DeletionScheduledAt != nil && !u.DeletionScheduledAt.After(now) && len(ids) < limit {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *fakeUserRepository) Erase(_ context.Context, id string) ([]string, error) {
+	delete(r.users, id)
+	return []string{id + ".zip"}, nil
+}
+
+// fakeRevokingTokenRepository records the users whose tokens were all revoked
+type fakeRevokingTokenRepository struct {
+	repository.AuthTokenRepository
+	revoked []string
+}
+
+func (r *fakeRevokingTokenRepository) RevokeAllByUserID(_ context.Context, userID string) error {
+	r.revoked = append(r.revoked, userID)
+	return nil
+}
+
+func TestUserUseCase_DeleteUser(t *testing.T) {
+	repo := newFakeUserRepository(&entity.User{ID: "1", Username: "alice", IsActive: true})
+	tokens := &fakeRevokingTokenRepository{}
+	audit := &fakeAuditLogRepository{}
+	uc := NewUserUseCase(repo, nil, tokens, audit, nil, UserConfig{DeletionGracePeriod: 30 * 24 * time.Hour})
+	ctx := context.Background()
+
+	user, err := uc.DeleteUser(ctx, "1")
+	if err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	if user.IsActive || user.DeletionScheduledAt == nil {
+		t.Fatalf("deleted user = %+v, want inactive and scheduled", user)
+	}
+	if len(tokens.revoked) != 1 || tokens.revoked[0] != "1" {
+		t.Errorf("revoked tokens of %v, want the user's", tokens.revoked)
+	}
+
+	// Deleting again keeps the original date
+	scheduledAt := *user.DeletionScheduledAt
+	if user, err = uc.DeleteUser(ctx, "1"); err != nil || !user.DeletionScheduledAt.Equal(scheduledAt) {
+		t.Errorf("second DeleteUser() = %v, %v, want the date kept", user.DeletionScheduledAt, err)
+	}
+
+	// Cancelling within the grace period restores the account
+	user, err = uc.CancelUserDeletion(ctx, "1")
+	if err != nil {
+		t.Fatalf("CancelUserDeletion() error = %v", err)
+	}
+	if !user.IsActive || user.DeletionScheduledAt != nil {
+		t.Errorf("restored user = %+v, want active and not scheduled", user)
+	}
+	if _, err := uc.CancelUserDeletion(ctx, "1"); !errors.Is(err, ErrAccountDeletionNotScheduled) {
+		t.Errorf("second CancelUserDeletion() error = %v, want %v", err, ErrAccountDeletionNotScheduled)
+	}
+	if len(audit.entries) != 2 {
+		t.Errorf("audit entries = %d, want the request and the cancellation", len(audit.entries))
+	}
+}
+
+func TestUserUseCase_EraseDueAccounts(t *testing.T) {
+	files, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := files.Create("1.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	repo := newFakeUserRepository(
+		&entity.User{ID: "1", Username: "alice", DeletionScheduledAt: &past},
+		&entity.User{ID: "2", Username: "bob", DeletionScheduledAt: &future},
+		&entity.User{ID: "3", Username: "carol", IsActive: true},
+	)
+	uc := NewUserUseCase(repo, nil, nil, &fakeAuditLogRepository{}, files, UserConfig{})
+
+	erased, err := uc.EraseDueAccounts(context.Background())
+	if err != nil {
+		t.Fatalf("EraseDueAccounts() error = %v", err)
+	}
+	if erased != 1 || repo.users["1"] != nil || repo.users["2"] == nil || repo.users["3"] == nil {
+		t.Errorf("EraseDueAccounts() = %d, users left %v, want alice erased", erased, repo.users)
+	}
+	if _, err := files.Open("1.zip"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("export archive of the erased account: %v, want %v", err, storage.ErrNotFound)
+	}
+}
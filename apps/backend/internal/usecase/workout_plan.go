@@ -8,6 +8,7 @@ import (
 
 	"github.com/terrnit/rebound/backend/internal/entity"
 	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
 )
 
 // WorkoutPlanUseCase represents the workout plan use case
@@ -26,6 +27,9 @@ func NewWorkoutPlanUseCase(r repository.WorkoutPlanRepository, config Config) *W
 
 // CreateWorkoutPlan creates a new workout plan
 func (uc *WorkoutPlanUseCase) CreateWorkoutPlan(ctx context.Context, plan *entity.WorkoutPlan) (*entity.WorkoutPlan, error) {
+	ctx, span := tracing.Start(ctx, "WorkoutPlanUseCase.CreateWorkoutPlan")
+	defer span.End()
+
 	plan.ID = uuid.New().String()
 	plan.CreatedAt = time.Now()
 	plan.UpdatedAt = time.Now()
@@ -35,11 +39,24 @@ func (uc *WorkoutPlanUseCase) CreateWorkoutPlan(ctx context.Context, plan *entit
 
 // GetWorkoutPlan retrieves a workout plan by its ID
 func (uc *WorkoutPlanUseCase) GetWorkoutPlan(ctx context.Context, planID string) (*entity.WorkoutPlan, error) {
-	return uc.repo.GetByID(ctx, planID)
+	ctx, span := tracing.Start(ctx, "WorkoutPlanUseCase.GetWorkoutPlan")
+	defer span.End()
+
+	plan, err := uc.repo.GetByID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return nil, ErrWorkoutPlanNotFound
+	}
+	return plan, nil
 }
 
-// ListWorkoutPlans returns a paginated list of workout plans matching the filters
-func (uc *WorkoutPlanUseCase) ListWorkoutPlans(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]*entity.WorkoutPlan, int64, error) {
+// ListWorkoutPlans returns a paginated list of workout plans matching the query spec
+func (uc *WorkoutPlanUseCase) ListWorkoutPlans(ctx context.Context, spec repository.QuerySpec, page, pageSize int) ([]*entity.WorkoutPlan, int64, error) {
+	ctx, span := tracing.Start(ctx, "WorkoutPlanUseCase.ListWorkoutPlans")
+	defer span.End()
+
 	// Validate page size
 	if pageSize <= 0 {
 		pageSize = uc.config.DefaultPageSize
@@ -49,13 +66,13 @@ func (uc *WorkoutPlanUseCase) ListWorkoutPlans(ctx context.Context, filters map[
 	}
 
 	// Get total count
-	total, err := uc.repo.Count(ctx, filters)
+	total, err := uc.repo.Count(ctx, spec)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// Get workout plans
-	items, err := uc.repo.List(ctx, filters, page, pageSize)
+	items, err := uc.repo.List(ctx, spec, page, pageSize)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -65,17 +82,26 @@ func (uc *WorkoutPlanUseCase) ListWorkoutPlans(ctx context.Context, filters map[
 
 // UpdateWorkoutPlan updates an existing workout plan
 func (uc *WorkoutPlanUseCase) UpdateWorkoutPlan(ctx context.Context, plan *entity.WorkoutPlan) error {
+	ctx, span := tracing.Start(ctx, "WorkoutPlanUseCase.UpdateWorkoutPlan")
+	defer span.End()
+
 	plan.UpdatedAt = time.Now()
 	return uc.repo.Update(ctx, plan)
 }
 
-// DeleteWorkoutPlan deletes a workout plan
+// DeleteWorkoutPlan moves a workout plan to the trash
 func (uc *WorkoutPlanUseCase) DeleteWorkoutPlan(ctx context.Context, planID string) error {
+	ctx, span := tracing.Start(ctx, "WorkoutPlanUseCase.DeleteWorkoutPlan")
+	defer span.End()
+
 	return uc.repo.Delete(ctx, planID)
 }
 
 // GetUserWorkoutPlans retrieves workout plans created by a specific user
 func (uc *WorkoutPlanUseCase) GetUserWorkoutPlans(ctx context.Context, userID string, page, pageSize int) ([]*entity.WorkoutPlan, error) {
+	ctx, span := tracing.Start(ctx, "WorkoutPlanUseCase.GetUserWorkoutPlans")
+	defer span.End()
+
 	// Validate page size
 	if pageSize <= 0 {
 		pageSize = uc.config.DefaultPageSize
@@ -89,16 +115,25 @@ func (uc *WorkoutPlanUseCase) GetUserWorkoutPlans(ctx context.Context, userID st
 
 // AddExerciseToPlan adds an exercise to a workout plan
 func (uc *WorkoutPlanUseCase) AddExerciseToPlan(ctx context.Context, planExercise *entity.WorkoutPlanExercise) error {
+	ctx, span := tracing.Start(ctx, "WorkoutPlanUseCase.AddExerciseToPlan")
+	defer span.End()
+
 	planExercise.ID = uuid.New().String()
 	return uc.repo.AddExercise(ctx, planExercise)
 }
 
 // RemoveExerciseFromPlan removes an exercise from a workout plan
 func (uc *WorkoutPlanUseCase) RemoveExerciseFromPlan(ctx context.Context, planID, exerciseID string) error {
+	ctx, span := tracing.Start(ctx, "WorkoutPlanUseCase.RemoveExerciseFromPlan")
+	defer span.End()
+
 	return uc.repo.RemoveExercise(ctx, planID, exerciseID)
 }
 
 // GetPlanExercises retrieves all exercises in a workout plan
 func (uc *WorkoutPlanUseCase) GetPlanExercises(ctx context.Context, planID string) ([]*entity.WorkoutPlanExercise, error) {
+	ctx, span := tracing.Start(ctx, "WorkoutPlanUseCase.GetPlanExercises")
+	defer span.End()
+
 	return uc.repo.GetExercises(ctx, planID)
 }
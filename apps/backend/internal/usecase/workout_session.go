@@ -2,15 +2,19 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/metrics"
 	"github.com/terrnit/rebound/backend/internal/repository"
+	"github.com/terrnit/rebound/backend/pkg/tracing"
 )
 
-// WorkoutSessionUseCase represents the workout session use case
+// WorkoutSessionUseCase represents the workout session use case.
+// Workout sessions are private: the sessions of other users are reported as missing, so that their IDs reveal nothing.
 type WorkoutSessionUseCase struct {
 	repo   repository.WorkoutSessionRepository
 	config Config
@@ -24,22 +28,49 @@ func NewWorkoutSessionUseCase(r repository.WorkoutSessionRepository, config Conf
 	}
 }
 
-// CreateWorkoutSession creates a new workout session
-func (uc *WorkoutSessionUseCase) CreateWorkoutSession(ctx context.Context, session *entity.UserWorkoutSession) (*entity.UserWorkoutSession, error) {
+// CreateWorkoutSession creates a new workout session for the user
+func (uc *WorkoutSessionUseCase) CreateWorkoutSession(ctx context.Context, userID string, session *entity.UserWorkoutSession) (*entity.UserWorkoutSession, error) {
+	ctx, span := tracing.Start(ctx, "WorkoutSessionUseCase.CreateWorkoutSession")
+	defer span.End()
+
+	if session.UserID != userID {
+		return nil, ErrForbidden
+	}
+
 	session.ID = uuid.New().String()
 	session.CreatedAt = time.Now()
 	session.UpdatedAt = time.Now()
 
-	return uc.repo.Create(ctx, session)
+	created, err := uc.repo.Create(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status == entity.WorkoutSessionStatusCompleted {
+		metrics.WorkoutSessionsCompleted.Inc()
+	}
+	return created, nil
 }
 
-// GetWorkoutSession retrieves a workout session by its ID
-func (uc *WorkoutSessionUseCase) GetWorkoutSession(ctx context.Context, sessionID string) (*entity.UserWorkoutSession, error) {
-	return uc.repo.GetByID(ctx, sessionID)
+// GetWorkoutSession retrieves a workout session of the user by its ID
+func (uc *WorkoutSessionUseCase) GetWorkoutSession(ctx context.Context, userID, sessionID string) (*entity.UserWorkoutSession, error) {
+	ctx, span := tracing.Start(ctx, "WorkoutSessionUseCase.GetWorkoutSession")
+	defer span.End()
+
+	session, err := uc.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.UserID != userID {
+		return nil, ErrWorkoutSessionNotFound
+	}
+	return session, nil
 }
 
-// ListWorkoutSessions returns a paginated list of workout sessions matching the filters
-func (uc *WorkoutSessionUseCase) ListWorkoutSessions(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]*entity.UserWorkoutSession, int64, error) {
+// ListWorkoutSessions returns a paginated list of workout sessions matching the query spec
+func (uc *WorkoutSessionUseCase) ListWorkoutSessions(ctx context.Context, spec repository.QuerySpec, page, pageSize int) ([]*entity.UserWorkoutSession, int64, error) {
+	ctx, span := tracing.Start(ctx, "WorkoutSessionUseCase.ListWorkoutSessions")
+	defer span.End()
+
 	// Validate page size
 	if pageSize <= 0 {
 		pageSize = uc.config.DefaultPageSize
@@ -49,13 +80,13 @@ func (uc *WorkoutSessionUseCase) ListWorkoutSessions(ctx context.Context, filter
 	}
 
 	// Get total count
-	total, err := uc.repo.Count(ctx, filters)
+	total, err := uc.repo.Count(ctx, spec)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// Get workout sessions
-	items, err := uc.repo.List(ctx, filters, page, pageSize)
+	items, err := uc.repo.List(ctx, spec, page, pageSize)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -63,19 +94,52 @@ func (uc *WorkoutSessionUseCase) ListWorkoutSessions(ctx context.Context, filter
 	return items, total, nil
 }
 
-// UpdateWorkoutSession updates an existing workout session
+// UpdateWorkoutSession updates an existing workout session.
+// session.UpdatedAt must hold the version that was read, repository.ErrVersionConflict is returned if it changed since.
 func (uc *WorkoutSessionUseCase) UpdateWorkoutSession(ctx context.Context, session *entity.UserWorkoutSession) error {
-	session.UpdatedAt = time.Now()
-	return uc.repo.Update(ctx, session)
+	ctx, span := tracing.Start(ctx, "WorkoutSessionUseCase.UpdateWorkoutSession")
+	defer span.End()
+
+	existing, err := uc.repo.GetByID(ctx, session.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrWorkoutSessionNotFound
+	}
+
+	if err := uc.repo.Update(ctx, session); err != nil {
+		return err
+	}
+	if completedNow(existing, session) {
+		metrics.WorkoutSessionsCompleted.Inc()
+	}
+	return nil
+}
+
+// completedNow reports whether an update moves a workout session to the completed status
+func completedNow(before, after *entity.UserWorkoutSession) bool {
+	return after.Status == entity.WorkoutSessionStatusCompleted &&
+		(before == nil || before.Status != entity.WorkoutSessionStatusCompleted)
 }
 
-// DeleteWorkoutSession deletes a workout session
-func (uc *WorkoutSessionUseCase) DeleteWorkoutSession(ctx context.Context, sessionID string) error {
+// DeleteWorkoutSession moves a workout session of the user to the trash
+func (uc *WorkoutSessionUseCase) DeleteWorkoutSession(ctx context.Context, userID, sessionID string) error {
+	ctx, span := tracing.Start(ctx, "WorkoutSessionUseCase.DeleteWorkoutSession")
+	defer span.End()
+
+	if _, err := uc.GetWorkoutSession(ctx, userID, sessionID); err != nil {
+		return err
+	}
+
 	return uc.repo.Delete(ctx, sessionID)
 }
 
 // GetUserWorkoutSessions retrieves workout sessions for a specific user
 func (uc *WorkoutSessionUseCase) GetUserWorkoutSessions(ctx context.Context, userID string, page, pageSize int) ([]*entity.UserWorkoutSession, error) {
+	ctx, span := tracing.Start(ctx, "WorkoutSessionUseCase.GetUserWorkoutSessions")
+	defer span.End()
+
 	// Validate page size
 	if pageSize <= 0 {
 		pageSize = uc.config.DefaultPageSize
@@ -87,8 +151,27 @@ func (uc *WorkoutSessionUseCase) GetUserWorkoutSessions(ctx context.Context, use
 	return uc.repo.GetByUserID(ctx, userID, pageSize, (page-1)*pageSize)
 }
 
+// ListUserWorkoutSessions returns a cursor paginated list of a user's workout sessions
+func (uc *WorkoutSessionUseCase) ListUserWorkoutSessions(ctx context.Context, userID string, spec repository.QuerySpec, page repository.CursorPage) ([]*entity.UserWorkoutSession, repository.PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "WorkoutSessionUseCase.ListUserWorkoutSessions")
+	defer span.End()
+
+	// Validate page size
+	if page.Limit <= 0 {
+		page.Limit = uc.config.DefaultPageSize
+	}
+	if page.Limit > uc.config.MaxPageSize {
+		page.Limit = uc.config.MaxPageSize
+	}
+
+	return uc.repo.ListPage(ctx, spec.Where("user_id", userID), page)
+}
+
 // AddSessionLog adds a new log entry to a workout session
 func (uc *WorkoutSessionUseCase) AddSessionLog(ctx context.Context, log *entity.UserWorkoutSessionLog) error {
+	ctx, span := tracing.Start(ctx, "WorkoutSessionUseCase.AddSessionLog")
+	defer span.End()
+
 	log.ID = uuid.New().String()
 	log.LoggedAt = time.Now()
 	return uc.repo.AddLog(ctx, log)
@@ -96,15 +179,72 @@ func (uc *WorkoutSessionUseCase) AddSessionLog(ctx context.Context, log *entity.
 
 // GetSessionLogs retrieves all logs for a workout session
 func (uc *WorkoutSessionUseCase) GetSessionLogs(ctx context.Context, sessionID string) ([]*entity.UserWorkoutSessionLog, error) {
+	ctx, span := tracing.Start(ctx, "WorkoutSessionUseCase.GetSessionLogs")
+	defer span.End()
+
 	return uc.repo.GetLogs(ctx, sessionID)
 }
 
-// UpdateSessionLog updates an existing log entry
-func (uc *WorkoutSessionUseCase) UpdateSessionLog(ctx context.Context, log *entity.UserWorkoutSessionLog) error {
+// ListSessionLogs returns a cursor paginated list of the logs of a workout session of the user
+func (uc *WorkoutSessionUseCase) ListSessionLogs(ctx context.Context, userID, sessionID string, page repository.CursorPage) ([]*entity.UserWorkoutSessionLog, repository.PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "WorkoutSessionUseCase.ListSessionLogs")
+	defer span.End()
+
+	if _, err := uc.GetWorkoutSession(ctx, userID, sessionID); err != nil {
+		return nil, repository.PageInfo{}, err
+	}
+
+	// Validate page size
+	if page.Limit <= 0 {
+		page.Limit = uc.config.DefaultPageSize
+	}
+	if page.Limit > uc.config.MaxPageSize {
+		page.Limit = uc.config.MaxPageSize
+	}
+
+	spec := repository.QuerySpec{}.Where("session_id", sessionID)
+	return uc.repo.ListLogsPage(ctx, spec, page)
+}
+
+// UpdateSessionLog updates a log entry of a workout session of the user
+func (uc *WorkoutSessionUseCase) UpdateSessionLog(ctx context.Context, userID string, log *entity.UserWorkoutSessionLog) error {
+	ctx, span := tracing.Start(ctx, "WorkoutSessionUseCase.UpdateSessionLog")
+	defer span.End()
+
+	if _, err := uc.getSessionLog(ctx, userID, log.ID); err != nil {
+		return err
+	}
 	return uc.repo.UpdateLog(ctx, log)
 }
 
-// DeleteSessionLog deletes a log entry
-func (uc *WorkoutSessionUseCase) DeleteSessionLog(ctx context.Context, logID string) error {
+// DeleteSessionLog moves a log entry of a workout session of the user to the trash
+func (uc *WorkoutSessionUseCase) DeleteSessionLog(ctx context.Context, userID, logID string) error {
+	ctx, span := tracing.Start(ctx, "WorkoutSessionUseCase.DeleteSessionLog")
+	defer span.End()
+
+	if _, err := uc.getSessionLog(ctx, userID, logID); err != nil {
+		return err
+	}
 	return uc.repo.DeleteLog(ctx, logID)
 }
+
+// getSessionLog retrieves a log entry of a workout session of the user
+func (uc *WorkoutSessionUseCase) getSessionLog(ctx context.Context, userID, logID string) (*entity.UserWorkoutSessionLog, error) {
+	if _, err := uuid.Parse(logID); err != nil {
+		return nil, ErrSessionLogNotFound
+	}
+	log, err := uc.repo.GetLogByID(ctx, logID)
+	if err != nil {
+		return nil, err
+	}
+	if log == nil {
+		return nil, ErrSessionLogNotFound
+	}
+	if _, err := uc.GetWorkoutSession(ctx, userID, log.SessionID); err != nil {
+		if errors.Is(err, ErrWorkoutSessionNotFound) {
+			return nil, ErrSessionLogNotFound
+		}
+		return nil, err
+	}
+	return log, nil
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/terrnit/rebound/backend/internal/entity"
+	"github.com/terrnit/rebound/backend/internal/repository"
+)
+
+func (r *fakeWorkoutSessionRepository) Create(_ context.Context, session *entity.UserWorkoutSession) (*entity.UserWorkoutSession, error) {
+	clone := *session
+	r.sessions[session.ID] = &clone
+	return session, nil
+}
+
+func (r *fakeWorkoutSessionRepository) Delete(_ context.Context, id string) error {
+	delete(r.sessions, id)
+	return nil
+}
+
+func (r *fakeWorkoutSessionRepository) DeleteLog(_ context.Context, id string) error {
+	delete(r.logs, id)
+	return nil
+}
+
+func (r *fakeWorkoutSessionRepository) ListLogsPage(context.Context, repository.QuerySpec, repository.CursorPage) ([]*entity.UserWorkoutSessionLog, repository.PageInfo, error) {
+	logs := make([]*entity.UserWorkoutSessionLog, 0, len(r.logs))
+	for _, l := range r.logs {
+		logs = append(logs, l)
+	}
+	return logs, repository.PageInfo{}, nil
+}
+
+func TestWorkoutSessionUseCase_OwnerOnly(t *testing.T) {
+	const (
+		sessionID = "0f6b7d1e-3b0a-4c55-9d3f-2a1e5c7b9a01"
+		logID     = "0f6b7d1e-3b0a-4c55-9d3f-2a1e5c7b9a02"
+	)
+
+	tests := []struct {
+		name    string
+		call    func(uc *WorkoutSessionUseCase, userID string) error
+		wantErr error
+	}{
+		{"get session", func(uc *WorkoutSessionUseCase, userID string) error {
+			_, err := uc.GetWorkoutSession(context.Background(), userID, sessionID)
+			return err
+		}, ErrWorkoutSessionNotFound},
+		{"delete session", func(uc *WorkoutSessionUseCase, userID string) error {
+			return uc.DeleteWorkoutSession(context.Background(), userID, sessionID)
+		}, ErrWorkoutSessionNotFound},
+		{"list exercises", func(uc *WorkoutSessionUseCase, userID string) error {
+			_, _, err := uc.ListSessionLogs(context.Background(), userID, sessionID, repository.CursorPage{})
+			return err
+		}, ErrWorkoutSessionNotFound},
+		{"update exercise", func(uc *WorkoutSessionUseCase, userID string) error {
+			return uc.UpdateSessionLog(context.Background(), userID, &entity.UserWorkoutSessionLog{ID: logID, SessionID: sessionID})
+		}, ErrSessionLogNotFound},
+		{"delete exercise", func(uc *WorkoutSessionUseCase, userID string) error {
+			return uc.DeleteSessionLog(context.Background(), userID, logID)
+		}, ErrSessionLogNotFound},
+		{"create session", func(uc *WorkoutSessionUseCase, userID string) error {
+			_, err := uc.CreateWorkoutSession(context.Background(), userID, &entity.UserWorkoutSession{UserID: "alice"})
+			return err
+		}, ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeWorkoutSessionRepository{
+				sessions: map[string]*entity.UserWorkoutSession{sessionID: {ID: sessionID, UserID: "alice"}},
+				logs:     map[string]*entity.UserWorkoutSessionLog{logID: {ID: logID, SessionID: sessionID}},
+			}
+			uc := NewWorkoutSessionUseCase(repo, Config{DefaultPageSize: 10, MaxPageSize: 100})
+
+			if err := tt.call(uc, "mallory"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("another user's error = %v, want %v", err, tt.wantErr)
+			}
+			if err := tt.call(uc, "alice"); errors.Is(err, tt.wantErr) {
+				t.Fatalf("owner's error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_user_biometrics_sync ON user_biometrics;
+DROP TRIGGER IF EXISTS trg_meal_food_items_sync ON meal_food_items;
+DROP TRIGGER IF EXISTS trg_user_meals_sync ON user_meals;
+DROP TRIGGER IF EXISTS trg_user_workout_session_logs_sync ON user_workout_session_logs;
+DROP TRIGGER IF EXISTS trg_user_workout_sessions_sync ON user_workout_sessions;
+DROP FUNCTION IF EXISTS record_sync_change();
+DROP INDEX IF EXISTS idx_sync_changes_user_id_position;
+DROP TABLE IF EXISTS sync_changes;
+DROP SEQUENCE IF EXISTS sync_change_seq;
+
+COMMIT;
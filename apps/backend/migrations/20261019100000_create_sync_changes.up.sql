@@ -0,0 +1,76 @@
+-- Change feed for offline sync.
+-- Every write to a synced table leaves one row per record, a delete leaves a tombstone.
+
+BEGIN;
+
+CREATE SEQUENCE sync_change_seq;
+
+CREATE TABLE sync_changes (
+    entity_type VARCHAR(32) NOT NULL, -- 'workout_session', 'session_log', 'meal', 'meal_food_item', 'biometrics'
+    entity_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    operation VARCHAR(10) NOT NULL CHECK (operation IN ('upsert', 'delete')),
+    -- Transaction of the latest change. Sync tokens advance by transaction so that
+    -- changes of transactions that commit late are not skipped.
+    txid BIGINT NOT NULL,
+    change_id BIGINT NOT NULL DEFAULT nextval('sync_change_seq'),
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (entity_type, entity_id)
+);
+
+CREATE INDEX idx_sync_changes_user_id_position ON sync_changes(user_id, txid, change_id);
+
+-- record_sync_change(entity_type, id_column) records the change of a row in sync_changes.
+-- Logs and meal food items are owned through their parent. When they are removed along
+-- with the parent, the parent's tombstone covers them and no tombstone of their own is kept.
+CREATE FUNCTION record_sync_change() RETURNS trigger AS $$
+DECLARE
+    row_data JSONB;
+    owner_id UUID;
+    op VARCHAR(10);
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        row_data := to_jsonb(OLD);
+        op := 'delete';
+    ELSE
+        row_data := to_jsonb(NEW);
+        op := 'upsert';
+    END IF;
+
+    IF TG_ARGV[0] = 'session_log' THEN
+        SELECT user_id INTO owner_id FROM user_workout_sessions WHERE session_id = (row_data->>'session_id')::UUID;
+    ELSIF TG_ARGV[0] = 'meal_food_item' THEN
+        SELECT user_id INTO owner_id FROM user_meals WHERE meal_id = (row_data->>'meal_id')::UUID;
+    ELSE
+        owner_id := (row_data->>'user_id')::UUID;
+    END IF;
+
+    IF owner_id IS NULL THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO sync_changes (entity_type, entity_id, user_id, operation, txid)
+    VALUES (TG_ARGV[0], (row_data->>TG_ARGV[1])::UUID, owner_id, op, pg_current_xact_id()::TEXT::BIGINT)
+    ON CONFLICT (entity_type, entity_id) DO UPDATE SET
+        user_id = EXCLUDED.user_id,
+        operation = EXCLUDED.operation,
+        txid = EXCLUDED.txid,
+        change_id = nextval('sync_change_seq'),
+        changed_at = CURRENT_TIMESTAMP;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_user_workout_sessions_sync AFTER INSERT OR UPDATE OR DELETE ON user_workout_sessions
+    FOR EACH ROW EXECUTE FUNCTION record_sync_change('workout_session', 'session_id');
+CREATE TRIGGER trg_user_workout_session_logs_sync AFTER INSERT OR UPDATE OR DELETE ON user_workout_session_logs
+    FOR EACH ROW EXECUTE FUNCTION record_sync_change('session_log', 'log_id');
+CREATE TRIGGER trg_user_meals_sync AFTER INSERT OR UPDATE OR DELETE ON user_meals
+    FOR EACH ROW EXECUTE FUNCTION record_sync_change('meal', 'meal_id');
+CREATE TRIGGER trg_meal_food_items_sync AFTER INSERT OR UPDATE OR DELETE ON meal_food_items
+    FOR EACH ROW EXECUTE FUNCTION record_sync_change('meal_food_item', 'meal_food_item_id');
+CREATE TRIGGER trg_user_biometrics_sync AFTER INSERT OR UPDATE OR DELETE ON user_biometrics
+    FOR EACH ROW EXECUTE FUNCTION record_sync_change('biometrics', 'biometrics_id');
+
+COMMIT;
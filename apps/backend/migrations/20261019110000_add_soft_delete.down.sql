@@ -0,0 +1,64 @@
+BEGIN;
+
+CREATE OR REPLACE FUNCTION record_sync_change() RETURNS trigger AS $$
+DECLARE
+    row_data JSONB;
+    owner_id UUID;
+    op VARCHAR(10);
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        row_data := to_jsonb(OLD);
+        op := 'delete';
+    ELSE
+        row_data := to_jsonb(NEW);
+        op := 'upsert';
+    END IF;
+
+    IF TG_ARGV[0] = 'session_log' THEN
+        SELECT user_id INTO owner_id FROM user_workout_sessions WHERE session_id = (row_data->>'session_id')::UUID;
+    ELSIF TG_ARGV[0] = 'meal_food_item' THEN
+        SELECT user_id INTO owner_id FROM user_meals WHERE meal_id = (row_data->>'meal_id')::UUID;
+    ELSE
+        owner_id := (row_data->>'user_id')::UUID;
+    END IF;
+
+    IF owner_id IS NULL THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO sync_changes (entity_type, entity_id, user_id, operation, txid)
+    VALUES (TG_ARGV[0], (row_data->>TG_ARGV[1])::UUID, owner_id, op, pg_current_xact_id()::TEXT::BIGINT)
+    ON CONFLICT (entity_type, entity_id) DO UPDATE SET
+        user_id = EXCLUDED.user_id,
+        operation = EXCLUDED.operation,
+        txid = EXCLUDED.txid,
+        change_id = nextval('sync_change_seq'),
+        changed_at = CURRENT_TIMESTAMP;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS idx_user_biometrics_deleted_at;
+DROP INDEX IF EXISTS idx_workout_plans_deleted_at;
+DROP INDEX IF EXISTS idx_food_items_deleted_at;
+DROP INDEX IF EXISTS idx_user_meals_deleted_at;
+DROP INDEX IF EXISTS idx_uwsl_deleted_at;
+DROP INDEX IF EXISTS idx_uws_deleted_at;
+
+-- Rows in the trash are gone for good once the column is dropped
+DELETE FROM user_workout_session_logs WHERE deleted_at IS NOT NULL;
+DELETE FROM user_workout_sessions WHERE deleted_at IS NOT NULL;
+DELETE FROM user_meals WHERE deleted_at IS NOT NULL;
+DELETE FROM food_items WHERE deleted_at IS NOT NULL AND NOT EXISTS (SELECT 1 FROM meal_food_items WHERE meal_food_items.food_item_id = food_items.id);
+DELETE FROM workout_plans WHERE deleted_at IS NOT NULL;
+DELETE FROM user_biometrics WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE user_biometrics DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE workout_plans DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE food_items DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE user_meals DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE user_workout_session_logs DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE user_workout_sessions DROP COLUMN IF EXISTS deleted_at;
+
+COMMIT;
@@ -0,0 +1,65 @@
+-- Soft delete for user generated records.
+-- Deleted rows stay in the trash until the retention period has passed and they are purged.
+
+BEGIN;
+
+ALTER TABLE user_workout_sessions ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE user_workout_session_logs ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE user_meals ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE food_items ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE workout_plans ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE user_biometrics ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- The trash and the purge only look at deleted rows
+CREATE INDEX idx_uws_deleted_at ON user_workout_sessions(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_uwsl_deleted_at ON user_workout_session_logs(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_user_meals_deleted_at ON user_meals(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_food_items_deleted_at ON food_items(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_workout_plans_deleted_at ON workout_plans(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_user_biometrics_deleted_at ON user_biometrics(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- A soft deleted row is a tombstone in the sync change feed, restoring it is an upsert
+CREATE OR REPLACE FUNCTION record_sync_change() RETURNS trigger AS $$
+DECLARE
+    row_data JSONB;
+    owner_id UUID;
+    op VARCHAR(10);
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        row_data := to_jsonb(OLD);
+        op := 'delete';
+    ELSE
+        row_data := to_jsonb(NEW);
+        IF row_data->>'deleted_at' IS NOT NULL THEN
+            op := 'delete';
+        ELSE
+            op := 'upsert';
+        END IF;
+    END IF;
+
+    IF TG_ARGV[0] = 'session_log' THEN
+        SELECT user_id INTO owner_id FROM user_workout_sessions WHERE session_id = (row_data->>'session_id')::UUID;
+    ELSIF TG_ARGV[0] = 'meal_food_item' THEN
+        SELECT user_id INTO owner_id FROM user_meals WHERE meal_id = (row_data->>'meal_id')::UUID;
+    ELSE
+        owner_id := (row_data->>'user_id')::UUID;
+    END IF;
+
+    IF owner_id IS NULL THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO sync_changes (entity_type, entity_id, user_id, operation, txid)
+    VALUES (TG_ARGV[0], (row_data->>TG_ARGV[1])::UUID, owner_id, op, pg_current_xact_id()::TEXT::BIGINT)
+    ON CONFLICT (entity_type, entity_id) DO UPDATE SET
+        user_id = EXCLUDED.user_id,
+        operation = EXCLUDED.operation,
+        txid = EXCLUDED.txid,
+        change_id = nextval('sync_change_seq'),
+        changed_at = CURRENT_TIMESTAMP;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
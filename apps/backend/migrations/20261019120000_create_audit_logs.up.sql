@@ -0,0 +1,21 @@
+-- Audit log of security and data sensitive actions
+
+BEGIN;
+
+CREATE TABLE audit_logs (
+    audit_log_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_user_id UUID, -- NULL when the actor is anonymous or the system; no foreign key so entries outlive users
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(50) NOT NULL,
+    target_id VARCHAR(255) NOT NULL,
+    changes JSONB, -- {"field": {"before": ..., "after": ...}}
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at, audit_log_id);
+CREATE INDEX idx_audit_logs_actor_user_id ON audit_logs(actor_user_id, created_at);
+CREATE INDEX idx_audit_logs_target ON audit_logs(target_type, target_id, created_at);
+
+COMMIT;
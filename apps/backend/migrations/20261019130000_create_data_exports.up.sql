@@ -0,0 +1,20 @@
+-- GDPR data exports of user accounts
+
+BEGIN;
+
+CREATE TABLE data_exports (
+    export_id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, running, completed, failed, expired
+    file_name VARCHAR(255), -- name of the ZIP archive in file storage once completed
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ -- the archive and its download link expire at this time
+);
+
+CREATE INDEX idx_data_exports_user_id ON data_exports(user_id, created_at);
+CREATE INDEX idx_data_exports_status ON data_exports(status, created_at);
+
+COMMIT;
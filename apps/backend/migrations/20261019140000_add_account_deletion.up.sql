@@ -0,0 +1,10 @@
+-- Account deletion with a grace period.
+-- A user asking for deletion is deactivated and erased once deletion_scheduled_at has passed.
+
+BEGIN;
+
+ALTER TABLE users ADD COLUMN deletion_scheduled_at TIMESTAMPTZ;
+
+CREATE INDEX idx_users_deletion_scheduled_at ON users(deletion_scheduled_at) WHERE deletion_scheduled_at IS NOT NULL;
+
+COMMIT;
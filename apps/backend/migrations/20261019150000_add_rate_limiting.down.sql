@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE users DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE users DROP COLUMN IF EXISTS failed_login_attempts;
+
+DROP TABLE IF EXISTS rate_limit_buckets;
+
+COMMIT;
@@ -0,0 +1,19 @@
+-- Rate limiting and login lockout.
+-- rate_limit_buckets backs the token buckets shared by all instances, idle buckets are purged periodically.
+-- A user is locked out for an exponentially growing time once failed_login_attempts reaches the threshold.
+
+BEGIN;
+
+CREATE TABLE rate_limit_buckets (
+    bucket_key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
+
+ALTER TABLE users ADD COLUMN failed_login_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN locked_until TIMESTAMPTZ;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS user_recovery_codes;
+DROP TABLE IF EXISTS user_two_factor;
+
+COMMIT;
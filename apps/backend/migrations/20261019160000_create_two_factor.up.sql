@@ -0,0 +1,26 @@
+-- TOTP two-factor authentication.
+-- A row in user_two_factor without enabled_at is an enrollment waiting for its confirmation code.
+-- The secret is encrypted by the application, recovery codes are stored as SHA-256 hashes.
+
+BEGIN;
+
+CREATE TABLE user_two_factor (
+    user_id UUID PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    enabled_at TIMESTAMPTZ,
+    -- last_used_step is the time step of the last accepted code, a code is never accepted twice
+    last_used_step BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE user_recovery_codes (
+    recovery_code_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_user_recovery_codes_user_id ON user_recovery_codes(user_id);
+
+COMMIT;
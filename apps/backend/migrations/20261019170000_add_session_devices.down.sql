@@ -0,0 +1,12 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_auth_tokens_session_id;
+
+ALTER TABLE auth_tokens DROP COLUMN IF EXISTS last_used_at;
+ALTER TABLE auth_tokens DROP COLUMN IF EXISTS signed_in_at;
+ALTER TABLE auth_tokens DROP COLUMN IF EXISTS ip_address;
+ALTER TABLE auth_tokens DROP COLUMN IF EXISTS user_agent;
+ALTER TABLE auth_tokens DROP COLUMN IF EXISTS device_name;
+ALTER TABLE auth_tokens DROP COLUMN IF EXISTS session_id;
+
+COMMIT;
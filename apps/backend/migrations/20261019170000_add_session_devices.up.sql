@@ -0,0 +1,23 @@
+-- Active sessions.
+-- A session is the chain of refresh tokens that rotation issues after one sign in, all sharing its session_id.
+-- Device details are refreshed on every rotation, so they describe where the session was last used from.
+
+BEGIN;
+
+ALTER TABLE auth_tokens ADD COLUMN session_id UUID;
+ALTER TABLE auth_tokens ADD COLUMN device_name VARCHAR(100);
+ALTER TABLE auth_tokens ADD COLUMN user_agent TEXT;
+ALTER TABLE auth_tokens ADD COLUMN ip_address VARCHAR(45);
+ALTER TABLE auth_tokens ADD COLUMN signed_in_at TIMESTAMPTZ;
+ALTER TABLE auth_tokens ADD COLUMN last_used_at TIMESTAMPTZ;
+
+-- Tokens issued before sessions existed each start their own
+UPDATE auth_tokens SET session_id = token_id, signed_in_at = issued_at, last_used_at = issued_at;
+
+ALTER TABLE auth_tokens ALTER COLUMN session_id SET NOT NULL;
+ALTER TABLE auth_tokens ALTER COLUMN signed_in_at SET NOT NULL;
+ALTER TABLE auth_tokens ALTER COLUMN last_used_at SET NOT NULL;
+
+CREATE INDEX idx_auth_tokens_session_id ON auth_tokens(session_id);
+
+COMMIT;
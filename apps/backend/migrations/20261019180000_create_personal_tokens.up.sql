@@ -0,0 +1,21 @@
+-- Personal access tokens for scripts and integrations.
+-- Only a SHA-256 hash of each token is stored, token_prefix keeps the start of it to tell tokens apart.
+
+BEGIN;
+
+CREATE TABLE personal_tokens (
+    token_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    token_prefix VARCHAR(20) NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_personal_tokens_user_id ON personal_tokens(user_id);
+
+COMMIT;
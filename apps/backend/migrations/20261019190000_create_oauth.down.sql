@@ -0,0 +1,13 @@
+BEGIN;
+
+DELETE FROM auth_tokens WHERE client_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_auth_tokens_client_id;
+ALTER TABLE auth_tokens DROP COLUMN IF EXISTS scopes;
+ALTER TABLE auth_tokens DROP COLUMN IF EXISTS client_id;
+
+DROP TABLE IF EXISTS oauth_authorization_codes;
+DROP TABLE IF EXISTS oauth_consents;
+DROP TABLE IF EXISTS oauth_clients;
+
+COMMIT;
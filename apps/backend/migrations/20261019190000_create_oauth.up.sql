@@ -0,0 +1,47 @@
+-- OAuth 2.0 authorization server for partner apps.
+-- Apps get authorization codes bound to a PKCE challenge, exchanged for tokens stored in auth_tokens
+-- with the client and the granted scopes. Secrets, codes and tokens are stored as SHA-256 hashes.
+
+BEGIN;
+
+CREATE TABLE oauth_clients (
+    client_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_user_id UUID REFERENCES users(user_id) ON DELETE SET NULL,
+    name VARCHAR(100) NOT NULL,
+    -- secret_hash is NULL for public clients, such as mobile apps, which rely on PKCE alone
+    secret_hash TEXT,
+    redirect_uris TEXT[] NOT NULL,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_oauth_clients_owner_user_id ON oauth_clients(owner_user_id);
+
+CREATE TABLE oauth_consents (
+    user_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    client_id UUID NOT NULL REFERENCES oauth_clients(client_id) ON DELETE CASCADE,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, client_id)
+);
+
+CREATE TABLE oauth_authorization_codes (
+    code_hash TEXT PRIMARY KEY,
+    client_id UUID NOT NULL REFERENCES oauth_clients(client_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    redirect_uri TEXT NOT NULL,
+    scopes TEXT[] NOT NULL,
+    code_challenge TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_oauth_authorization_codes_expires_at ON oauth_authorization_codes(expires_at);
+
+ALTER TABLE auth_tokens ADD COLUMN client_id UUID REFERENCES oauth_clients(client_id) ON DELETE CASCADE;
+ALTER TABLE auth_tokens ADD COLUMN scopes TEXT[];
+
+CREATE INDEX idx_auth_tokens_client_id ON auth_tokens(client_id);
+
+COMMIT;
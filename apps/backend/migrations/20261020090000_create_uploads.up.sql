@@ -0,0 +1,23 @@
+-- Uploaded images.
+-- Each row is an image attached to a field of a user, exercise or workout plan, with the names of its
+-- files in upload storage. Rows whose target no longer exists are purged together with their files.
+
+BEGIN;
+
+CREATE TABLE uploads (
+    upload_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    target VARCHAR(50) NOT NULL,
+    target_id UUID NOT NULL,
+    file_name VARCHAR(100) NOT NULL,
+    thumbnail_file_name VARCHAR(100) NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    uploaded_by UUID REFERENCES users(user_id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_uploads_target ON uploads(target, target_id);
+
+COMMIT;
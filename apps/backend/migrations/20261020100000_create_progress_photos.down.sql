@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS coach_grants;
+DROP TABLE IF EXISTS progress_photos;
+
+COMMIT;
@@ -0,0 +1,35 @@
+-- Progress photos and coach access.
+-- progress_photos holds a front, side or back photo per biometrics entry, stored in private storage. Photos
+-- follow their entry into the trash and are purged with their files once the entry is gone. coach_grants
+-- lists the coaches each user has authorised to see their progress.
+
+BEGIN;
+
+CREATE TABLE progress_photos (
+    photo_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    biometrics_id UUID NOT NULL,
+    pose VARCHAR(10) NOT NULL CHECK (pose IN ('front', 'side', 'back')),
+    file_name VARCHAR(100) NOT NULL,
+    thumbnail_file_name VARCHAR(100) NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (biometrics_id, pose)
+);
+
+CREATE INDEX idx_progress_photos_user_id ON progress_photos(user_id);
+
+CREATE TABLE coach_grants (
+    user_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    coach_user_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, coach_user_id),
+    CHECK (user_id <> coach_user_id)
+);
+
+CREATE INDEX idx_coach_grants_coach_user_id ON coach_grants(coach_user_id);
+
+COMMIT;
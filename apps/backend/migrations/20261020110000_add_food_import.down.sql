@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS serving_units;
+DROP INDEX IF EXISTS idx_food_items_source_id;
+ALTER TABLE food_items DROP COLUMN IF EXISTS source_id;
+
+COMMIT;
@@ -0,0 +1,21 @@
+-- Bulk food imports.
+-- source_id identifies a food item in the database it was imported from, such as usda:171688 for a FoodData Central
+-- food or off:3017620422003 for an Open Food Facts product, so that importing a newer dump updates the items of the
+-- previous one. serving_units holds the household measures of food items, such as 1 cup, chopped, with their weight.
+
+BEGIN;
+
+ALTER TABLE food_items ADD COLUMN source_id VARCHAR(100);
+CREATE UNIQUE INDEX idx_food_items_source_id ON food_items(source_id) WHERE source_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS serving_units (
+    unit_id SERIAL PRIMARY KEY,
+    food_item_id UUID REFERENCES food_items(id) ON DELETE CASCADE, -- NULL for generic units such as g and ml
+    unit_name VARCHAR(50) NOT NULL,
+    abbreviation VARCHAR(10) NOT NULL,
+    grams_equivalent DECIMAL(10,4),
+    ml_equivalent DECIMAL(10,4)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_serving_units_food_item_unit ON serving_units(food_item_id, unit_name);
+
+COMMIT;
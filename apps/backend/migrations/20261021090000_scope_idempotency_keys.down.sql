@@ -0,0 +1,9 @@
+BEGIN;
+
+DELETE FROM idempotency_keys;
+
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys DROP COLUMN scope;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (idempotency_key);
+
+COMMIT;
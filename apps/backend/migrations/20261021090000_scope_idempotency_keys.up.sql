@@ -0,0 +1,12 @@
+-- Idempotency keys belong to the caller that sent them, they were global before
+
+BEGIN;
+
+-- Stored responses cannot be attributed to a caller, retries after the upgrade execute again
+DELETE FROM idempotency_keys;
+
+ALTER TABLE idempotency_keys ADD COLUMN scope VARCHAR(255) NOT NULL; -- user:<id> or ip:<address>
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (scope, idempotency_key);
+
+COMMIT;
@@ -0,0 +1 @@
+-- The role may have existed before the up migration and may be assigned, it is left in place
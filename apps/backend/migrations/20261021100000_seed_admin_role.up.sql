@@ -0,0 +1,6 @@
+-- The admin role manages roles and reads the audit log. The first admin is assigned in the database:
+-- INSERT INTO user_roles (user_id, role_id) SELECT '<user id>', role_id FROM roles WHERE role_name = 'admin';
+
+INSERT INTO roles (role_name, description)
+VALUES ('admin', 'Manages user roles and reads the audit log')
+ON CONFLICT (role_name) DO NOTHING;
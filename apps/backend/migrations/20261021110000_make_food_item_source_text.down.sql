@@ -0,0 +1,6 @@
+BEGIN;
+
+-- The column stays text, which every version of the app writes
+ALTER TABLE food_items DROP CONSTRAINT chk_food_items_source;
+
+COMMIT;
@@ -0,0 +1,9 @@
+-- The source of food items was an enum in some databases and text in others, so writes had to look its type up.
+-- It is text everywhere now, limited to the sources the app knows.
+
+BEGIN;
+
+ALTER TABLE food_items ALTER COLUMN source TYPE VARCHAR(20) USING source::text;
+ALTER TABLE food_items ADD CONSTRAINT chk_food_items_source CHECK (source IN ('user_created', 'system', 'api'));
+
+COMMIT;
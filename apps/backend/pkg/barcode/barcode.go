@@ -0,0 +1,75 @@
+// Package barcode validates and normalises the retail barcodes printed on food packaging: UPC-A, EAN-13 and EAN-8,
+// also when written as a 14 digit GTIN.
+package barcode
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrInvalidFormat is returned for a code that is not 8, 12, 13 or 14 digits, or a GTIN-14 of a trade unit
+	ErrInvalidFormat = errors.New("barcode: must be 8, 12, 13 or 14 digits")
+	// ErrInvalidChecksum is returned for a code whose check digit does not match
+	ErrInvalidChecksum = errors.New("barcode: invalid check digit")
+)
+
+// Normalize validates a UPC-A, EAN-13 or EAN-8 code and returns its canonical form.
+// Spaces and hyphens are ignored. UPC-A codes are returned as EAN-13, with a leading zero,
+// so that both forms of the same product compare equal. EAN-8 codes are returned as they are.
+// GTIN-14 codes are returned as EAN-13 without their leading zero. Those with another packaging
+// indicator digit identify cases of a product rather than the product and are refused.
+func Normalize(code string) (string, error) {
+	code = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, code)
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidFormat
+		}
+	}
+
+	switch len(code) {
+	case 8, 13:
+	case 12:
+		code = "0" + code
+	case 14:
+		if code[0] != '0' {
+			return "", ErrInvalidFormat
+		}
+		code = code[1:]
+	default:
+		return "", ErrInvalidFormat
+	}
+
+	if checkDigit(code[:len(code)-1]) != code[len(code)-1] {
+		return "", ErrInvalidChecksum
+	}
+	return code, nil
+}
+
+// UPCA returns the UPC-A form of a normalised code, which is the EAN-13 form without its leading zero.
+// It returns false for codes that have no UPC-A form.
+func UPCA(code string) (string, bool) {
+	if len(code) != 13 || code[0] != '0' {
+		return "", false
+	}
+	return code[1:], true
+}
+
+// checkDigit computes the GS1 check digit of the digits before it: counting from the right,
+// digits are weighted 3 and 1 in turn and the check digit brings the sum to a multiple of 10
+func checkDigit(digits string) byte {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
@@ -0,0 +1,61 @@
+package barcode
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		want    string
+		wantErr error
+	}{
+		{name: "EAN-8", code: "96385074", want: "96385074"},
+		{name: "UPC-A", code: "036000291452", want: "0036000291452"},
+		{name: "EAN-13", code: "4006381333931", want: "4006381333931"},
+		{name: "GTIN-14 of a retail product", code: "00036000291452", want: "0036000291452"},
+		{name: "GTIN-14 of an EAN-13", code: "04006381333931", want: "4006381333931"},
+		{name: "spaces and hyphens", code: "4 006381-333931", want: "4006381333931"},
+		{name: "EAN-8 check digit", code: "96385075", wantErr: ErrInvalidChecksum},
+		{name: "UPC-A check digit", code: "036000291453", wantErr: ErrInvalidChecksum},
+		{name: "EAN-13 check digit", code: "4006381333932", wantErr: ErrInvalidChecksum},
+		{name: "GTIN-14 check digit", code: "00036000291453", wantErr: ErrInvalidChecksum},
+		{name: "GTIN-14 of a case", code: "10036000291459", wantErr: ErrInvalidFormat},
+		{name: "too short", code: "1234567", wantErr: ErrInvalidFormat},
+		{name: "between lengths", code: "1234567890", wantErr: ErrInvalidFormat},
+		{name: "too long", code: "123456789012345", wantErr: ErrInvalidFormat},
+		{name: "letters", code: "40063813339a1", wantErr: ErrInvalidFormat},
+		{name: "empty", code: "", wantErr: ErrInvalidFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Normalize(%q) error = %v, want %v", tt.code, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUPCA(t *testing.T) {
+	tests := []struct {
+		code   string
+		want   string
+		wantOK bool
+	}{
+		{code: "0036000291452", want: "036000291452", wantOK: true},
+		{code: "4006381333931"},
+		{code: "96385074"},
+	}
+	for _, tt := range tests {
+		got, ok := UPCA(tt.code)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("UPCA(%q) = %q, %v, want %q, %v", tt.code, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
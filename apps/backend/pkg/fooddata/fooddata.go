@@ -0,0 +1,485 @@
+// Package fooddata reads the CSV downloads of USDA FoodData Central, https://fdc.nal.usda.gov/download-datasets.
+//
+// A download is a directory of CSV files linked by the fdc_id of foods. Read joins food.csv with the nutrient amounts
+// in food_nutrient.csv and, when they are present, the label data in branded_food.csv and the household portions in
+// food_portion.csv. The files are merged as they are streamed, which needs each of them to list its rows by ascending
+// fdc_id, and only the food being read is held in memory.
+package fooddata
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Nutrient IDs of FoodData Central. Amounts are per 100 g, in the unit noted.
+const (
+	NutrientProtein           = 1003 // g
+	NutrientFat               = 1004 // g
+	NutrientCarbohydrate      = 1005 // g, by difference
+	NutrientEnergy            = 1008 // kcal
+	NutrientEnergyKJ          = 1062 // kJ
+	NutrientSugarsTotal       = 1063 // g, in foundation foods
+	NutrientFiber             = 1079 // g
+	NutrientCalcium           = 1087 // mg
+	NutrientIron              = 1089 // mg
+	NutrientPotassium         = 1092 // mg
+	NutrientSodium            = 1093 // mg
+	NutrientVitaminA          = 1106 // µg, retinol activity equivalents
+	NutrientVitaminC          = 1162 // mg
+	NutrientCholesterol       = 1253 // mg
+	NutrientTransFat          = 1257 // g
+	NutrientSaturatedFat      = 1258 // g
+	NutrientSugars            = 2000 // g
+	NutrientEnergyAtwaterGen  = 2047 // kcal, in foundation foods
+	NutrientEnergyAtwaterSpec = 2048 // kcal, in foundation foods
+)
+
+// measureUnitUndetermined is the measure unit of portions described only by their modifier, such as 1 large
+const measureUnitUndetermined = "undetermined"
+
+// DefaultDataTypes are the data types of foods people eat. The other types describe lab samples.
+var DefaultDataTypes = []string{"foundation_food", "sr_legacy_food", "survey_fndds_food", "branded_food"}
+
+// Food is a food of FoodData Central
+type Food struct {
+	FDCID       int
+	DataType    string
+	Description string
+	// Nutrients holds the amount per 100 g of each nutrient read, by nutrient ID
+	Nutrients map[int]float64
+	// Portions are household measures of the food, from food_portion.csv
+	Portions []Portion
+
+	// The label data of branded foods, from branded_food.csv
+	BrandOwner       string
+	BrandName        string
+	GTINUPC          string
+	ServingSize      float64
+	ServingSizeUnit  string
+	HouseholdServing string
+}
+
+// Portion is a household measure of a food, such as 1 cup, chopped
+type Portion struct {
+	Amount      float64
+	Unit        string
+	Modifier    string
+	Description string
+	GramWeight  float64
+}
+
+// ErrUnsorted is returned for a file that does not list its rows by ascending fdc_id. Such a file can be sorted,
+// keeping its header first, before it is read.
+var ErrUnsorted = errors.New("fooddata: rows are not sorted by fdc_id")
+
+// RowError reports a row that could not be read. The row is skipped.
+type RowError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("%s line %d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Config configures Read
+type Config struct {
+	// Dir holds the CSV files of a download
+	Dir string
+	// DataTypes lists the data types of the foods to read, DefaultDataTypes when empty
+	DataTypes []string
+	// Nutrients lists the IDs of the nutrients to read, all when empty
+	Nutrients []int
+	// OnError is told about each row that cannot be read
+	OnError func(err error)
+}
+
+// Read calls fn with each food of a download, in the order of food.csv. It stops at the first error returned by fn.
+func Read(ctx context.Context, config Config, fn func(*Food) error) error {
+	dataTypes := config.DataTypes
+	if len(dataTypes) == 0 {
+		dataTypes = DefaultDataTypes
+	}
+	wantedTypes := make(map[string]bool, len(dataTypes))
+	for _, t := range dataTypes {
+		wantedTypes[t] = true
+	}
+	wantedNutrients := make(map[int32]bool, len(config.Nutrients))
+	for _, id := range config.Nutrients {
+		wantedNutrients[int32(id)] = true //nolint:gosec // nutrient IDs are small
+	}
+	onError := config.OnError
+	if onError == nil {
+		onError = func(error) {}
+	}
+	r := reader{dir: config.Dir, onError: onError}
+
+	// Measure units are few, and looked up by the portions of any food
+	units := make(map[int32]string)
+	err := r.each(ctx, "measure_unit.csv", []string{"id", "name"}, func(row row) error {
+		id, err := row.id("id")
+		if err != nil {
+			return err
+		}
+		units[id] = row.get("name")
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	foods, err := r.merge(ctx, "food.csv", []string{"fdc_id", "data_type", "description"})
+	if err != nil {
+		return err
+	}
+	defer foods.close()
+	nutrients, err := r.merge(ctx, "food_nutrient.csv", []string{"fdc_id", "nutrient_id", "amount"})
+	if err != nil {
+		return err
+	}
+	defer nutrients.close()
+	// Label data and portions are in some downloads only
+	branded, err := r.merge(ctx, "branded_food.csv", []string{"fdc_id"})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	defer branded.close()
+	portions, err := r.merge(ctx, "food_portion.csv", []string{"fdc_id", "gram_weight"})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	defer portions.close()
+
+	// next stops the loop at the end of food.csv and at an error
+	var last int32
+	for err = nil; foods.ok; err = foods.next(ctx) {
+		id := foods.id
+		if id == last {
+			r.onError(&RowError{File: foods.t.name, Line: foods.row.line, Err: fmt.Errorf("duplicate fdc_id %d", id)})
+			continue
+		}
+		last = id
+		if !wantedTypes[foods.row.get("data_type")] {
+			continue
+		}
+
+		food := &Food{
+			FDCID:       int(id),
+			DataType:    foods.row.get("data_type"),
+			Description: foods.row.get("description"),
+			Nutrients:   make(map[int]float64),
+		}
+		err = nutrients.rows(ctx, id, func(row row) error {
+			nutrientID, err := row.id("nutrient_id")
+			if err != nil {
+				return err
+			}
+			if len(wantedNutrients) > 0 && !wantedNutrients[nutrientID] {
+				return nil
+			}
+			amount, ok, err := row.float("amount")
+			if err != nil || !ok {
+				return err
+			}
+			food.Nutrients[int(nutrientID)] = amount
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		err = branded.rows(ctx, id, func(row row) error {
+			servingSize, _, err := row.float("serving_size")
+			if err != nil {
+				return err
+			}
+			food.BrandOwner = row.get("brand_owner")
+			food.BrandName = row.get("brand_name")
+			food.GTINUPC = row.get("gtin_upc")
+			food.ServingSize = servingSize
+			food.ServingSizeUnit = brandedServingUnit(row.get("serving_size_unit"))
+			food.HouseholdServing = row.get("household_serving_fulltext")
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		err = portions.rows(ctx, id, func(row row) error {
+			gramWeight, ok, err := row.float("gram_weight")
+			if err != nil || !ok {
+				return err
+			}
+			amount, _, err := row.float("amount")
+			if err != nil {
+				return err
+			}
+			portion := Portion{
+				Amount:      amount,
+				Modifier:    row.get("modifier"),
+				Description: row.get("portion_description"),
+				GramWeight:  gramWeight,
+			}
+			if unitID, err := row.id("measure_unit_id"); err == nil && units[unitID] != measureUnitUndetermined {
+				portion.Unit = units[unitID]
+			}
+			food.Portions = append(food.Portions, portion)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := fn(food); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// brandedServingUnit maps the units of branded_food.csv, which mixes abbreviations and UN/CEFACT codes, to g or ml
+func brandedServingUnit(unit string) string {
+	switch strings.ToLower(unit) {
+	case "g", "grm":
+		return "g"
+	case "ml", "mlt":
+		return "ml"
+	}
+	return strings.ToLower(unit)
+}
+
+// invalidValueError reports a value that cannot be read, which makes its row skipped
+type invalidValueError struct {
+	column, value string
+}
+
+func (e *invalidValueError) Error() string {
+	return fmt.Sprintf("invalid %s %q", e.column, e.value)
+}
+
+type reader struct {
+	dir     string
+	onError func(error)
+}
+
+// each calls fn with each row of a file. Rows with values that cannot be read are reported and skipped;
+// any other error returned by fn stops reading. It returns an error wrapping os.ErrNotExist when the file is missing.
+func (r reader) each(ctx context.Context, name string, required []string, fn func(row) error) error {
+	t, err := r.open(name, required)
+	if err != nil {
+		return err
+	}
+	defer t.close()
+
+	for {
+		row, ok, err := t.next(ctx)
+		if err != nil || !ok {
+			return err
+		}
+		if err := t.call(fn, row); err != nil {
+			return err
+		}
+	}
+}
+
+// merge opens a file to be read along food.csv, and reads its first row
+func (r reader) merge(ctx context.Context, name string, required []string) (*sortedTable, error) {
+	t, err := r.open(name, required)
+	if err != nil {
+		return nil, err
+	}
+	s := &sortedTable{t: t}
+	if err := s.next(ctx); err != nil {
+		t.close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r reader) open(name string, required []string) (*table, error) {
+	f, err := os.Open(filepath.Join(r.dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("fooddata - Read: %w", err)
+	}
+
+	// Some downloads start with a byte order mark
+	br := bufio.NewReader(f)
+	if bom, err := br.Peek(3); err == nil && string(bom) == "\ufeff" {
+		_, _ = br.Discard(3)
+	}
+	cr := csv.NewReader(br)
+	cr.ReuseRecord = true
+	cr.LazyQuotes = true
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("fooddata - Read: %s: %w", name, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[column] = i
+	}
+	for _, column := range required {
+		if _, ok := columns[column]; !ok {
+			f.Close()
+			return nil, fmt.Errorf("fooddata - Read: %s: missing column %s", name, column)
+		}
+	}
+	return &table{name: name, f: f, cr: cr, columns: columns, onError: r.onError}, nil
+}
+
+// table reads the rows of a CSV file
+type table struct {
+	name    string
+	f       *os.File
+	cr      *csv.Reader
+	columns map[string]int
+	onError func(error)
+	n       int
+}
+
+// next returns the next row of the file, and false at its end. Rows that are not valid CSV are reported and skipped.
+func (t *table) next(ctx context.Context) (row, bool, error) {
+	for {
+		// Checking every row would cost more than reading it
+		if t.n%10000 == 0 && ctx.Err() != nil {
+			return row{}, false, ctx.Err()
+		}
+		t.n++
+		record, err := t.cr.Read()
+		if errors.Is(err, io.EOF) {
+			return row{}, false, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			t.onError(&RowError{File: t.name, Line: parseErr.StartLine, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return row{}, false, fmt.Errorf("fooddata - Read: %s: %w", t.name, err)
+		}
+		line, _ := t.cr.FieldPos(0)
+		return row{columns: t.columns, record: record, line: line}, true, nil
+	}
+}
+
+// call calls fn with a row, reporting the row when fn finds a value that cannot be read
+func (t *table) call(fn func(row) error, row row) error {
+	err := fn(row)
+	var invalid *invalidValueError
+	if errors.As(err, &invalid) {
+		t.onError(&RowError{File: t.name, Line: row.line, Err: err})
+		return nil
+	}
+	return err
+}
+
+func (t *table) close() {
+	t.f.Close()
+}
+
+// sortedTable reads a file in step with food.csv. Both list rows by ascending fdc_id, so the rows of a food
+// are read when the food is, and only one food is held in memory at a time.
+type sortedTable struct {
+	t *table
+	// row is the row read last, of food id, when ok
+	row row
+	id  int32
+	ok  bool
+}
+
+// next reads the next row with a valid fdc_id
+func (s *sortedTable) next(ctx context.Context) error {
+	for {
+		row, ok, err := s.t.next(ctx)
+		if err != nil || !ok {
+			s.ok = false
+			return err
+		}
+		id, err := row.id("fdc_id")
+		if err != nil {
+			s.t.onError(&RowError{File: s.t.name, Line: row.line, Err: err})
+			continue
+		}
+		if s.ok && id < s.id {
+			s.ok = false
+			return fmt.Errorf("fooddata - Read: %s line %d: %w", s.t.name, row.line, ErrUnsorted)
+		}
+		s.row, s.id, s.ok = row, id, true
+		return nil
+	}
+}
+
+// rows calls fn with the rows of food id, skipping those of the foods before it. A nil table has no rows.
+func (s *sortedTable) rows(ctx context.Context, id int32, fn func(row) error) error {
+	if s == nil {
+		return nil
+	}
+	for s.ok && s.id <= id {
+		if s.id == id {
+			if err := s.t.call(fn, s.row); err != nil {
+				return err
+			}
+		}
+		if err := s.next(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sortedTable) close() {
+	if s != nil {
+		s.t.close()
+	}
+}
+
+type row struct {
+	columns map[string]int
+	record  []string
+	line    int
+}
+
+// get returns the value of a column, empty when the row is too short to have it
+func (r row) get(column string) string {
+	i, ok := r.columns[column]
+	if !ok || i >= len(r.record) {
+		return ""
+	}
+	return strings.TrimSpace(r.record[i])
+}
+
+func (r row) id(column string) (int32, error) {
+	id, err := strconv.ParseInt(r.get(column), 10, 32)
+	if err != nil {
+		return 0, &invalidValueError{column: column, value: r.get(column)}
+	}
+	return int32(id), nil
+}
+
+// float returns the number in a column and whether there is one
+func (r row) float(column string) (float64, bool, error) {
+	value := r.get(column)
+	if value == "" {
+		return 0, false, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false, &invalidValueError{column: column, value: value}
+	}
+	return f, true, nil
+}
@@ -0,0 +1,226 @@
+package fooddata
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeDownload writes the files of a download to a temporary directory
+func writeDownload(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// download is a small download in the layout of FoodData Central, with a byte order mark, quoted values and
+// rows of foods that are not read
+var download = map[string]string{
+	"food.csv": "\ufeff" + `"fdc_id","data_type","description","food_category_id","publication_date"
+"100","sr_legacy_food","Egg, whole, raw","1","2019-04-01"
+"101","sample_food","Egg, lab sample","1","2019-04-01"
+"102","branded_food","PEANUT BUTTER, CREAMY","","2021-10-28"
+"103","foundation_food","Apples, raw","9","2020-10-30"
+`,
+	"food_nutrient.csv": `"id","fdc_id","nutrient_id","amount"
+"1","100","1003","12.6"
+"2","100","1008","143"
+"3","100","1093","142"
+"4","101","1003","13"
+"5","102","1003","22.5"
+"6","102","1008","594"
+"7","103","1008",""
+"8","103","2047","61.9"
+`,
+	"branded_food.csv": `"fdc_id","brand_owner","brand_name","gtin_upc","serving_size","serving_size_unit","household_serving_fulltext"
+"102","Acme Foods","Acme","00036000291452","32","GRM","2 Tbsp"
+`,
+	"measure_unit.csv": `"id","name"
+"1000","cup"
+"9999","undetermined"
+`,
+	"food_portion.csv": `"id","fdc_id","seq_num","amount","measure_unit_id","portion_description","modifier","gram_weight"
+"1","100","1","1","9999","","large","50"
+"2","100","2","1","1000","","chopped","136"
+"3","101","1","1","1000","","","100"
+`,
+}
+
+func readAll(t *testing.T, config Config) ([]*Food, []error) {
+	t.Helper()
+	var foods []*Food
+	var rowErrors []error
+	config.OnError = func(err error) { rowErrors = append(rowErrors, err) }
+	err := Read(context.Background(), config, func(food *Food) error {
+		foods = append(foods, food)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	return foods, rowErrors
+}
+
+func TestRead(t *testing.T) {
+	foods, rowErrors := readAll(t, Config{Dir: writeDownload(t, download)})
+
+	want := []*Food{
+		{
+			FDCID: 100, DataType: "sr_legacy_food", Description: "Egg, whole, raw",
+			Nutrients: map[int]float64{NutrientProtein: 12.6, NutrientEnergy: 143, NutrientSodium: 142},
+			Portions: []Portion{
+				{Amount: 1, Modifier: "large", GramWeight: 50},
+				{Amount: 1, Unit: "cup", Modifier: "chopped", GramWeight: 136},
+			},
+		},
+		{
+			FDCID: 102, DataType: "branded_food", Description: "PEANUT BUTTER, CREAMY",
+			Nutrients:  map[int]float64{NutrientProtein: 22.5, NutrientEnergy: 594},
+			BrandOwner: "Acme Foods", BrandName: "Acme", GTINUPC: "00036000291452",
+			ServingSize: 32, ServingSizeUnit: "g", HouseholdServing: "2 Tbsp",
+		},
+		{
+			FDCID: 103, DataType: "foundation_food", Description: "Apples, raw",
+			Nutrients: map[int]float64{NutrientEnergyAtwaterGen: 61.9},
+		},
+	}
+	if !reflect.DeepEqual(foods, want) {
+		for i := range foods {
+			t.Logf("food %d: %+v", i, foods[i])
+		}
+		t.Errorf("Read() read %d foods, not the %d expected", len(foods), len(want))
+	}
+	if len(rowErrors) != 0 {
+		t.Errorf("row errors = %v", rowErrors)
+	}
+}
+
+func TestRead_Filters(t *testing.T) {
+	foods, _ := readAll(t, Config{
+		Dir:       writeDownload(t, download),
+		DataTypes: []string{"sr_legacy_food", "sample_food"},
+		Nutrients: []int{NutrientProtein},
+	})
+
+	if len(foods) != 2 || foods[0].FDCID != 100 || foods[1].FDCID != 101 {
+		t.Fatalf("Read() = %d foods, want 100 and 101", len(foods))
+	}
+	if want := map[int]float64{NutrientProtein: 12.6}; !reflect.DeepEqual(foods[0].Nutrients, want) {
+		t.Errorf("nutrients = %v, want %v", foods[0].Nutrients, want)
+	}
+	if len(foods[1].Portions) != 1 || foods[1].Portions[0].Unit != "cup" {
+		t.Errorf("portions = %+v, want 1 cup", foods[1].Portions)
+	}
+}
+
+func TestRead_OptionalFiles(t *testing.T) {
+	files := map[string]string{"food.csv": download["food.csv"], "food_nutrient.csv": download["food_nutrient.csv"]}
+	foods, _ := readAll(t, Config{Dir: writeDownload(t, files)})
+
+	if len(foods) != 3 {
+		t.Fatalf("Read() = %d foods, want 3", len(foods))
+	}
+	if foods[0].Portions != nil || foods[1].BrandOwner != "" {
+		t.Errorf("foods have portions or label data without their files")
+	}
+
+	delete(files, "food_nutrient.csv")
+	err := Read(context.Background(), Config{Dir: writeDownload(t, files)}, func(*Food) error { return nil })
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Read() without food_nutrient.csv error = %v, want %v", err, os.ErrNotExist)
+	}
+}
+
+func TestRead_InvalidRows(t *testing.T) {
+	files := map[string]string{
+		"food.csv": `fdc_id,data_type,description
+100,sr_legacy_food,Egg
+x,sr_legacy_food,Not a food
+`,
+		"food_nutrient.csv": `fdc_id,nutrient_id,amount
+100,1003,12.6
+100,1008,lots
+100,abc,1
+`,
+	}
+	foods, rowErrors := readAll(t, Config{Dir: writeDownload(t, files)})
+
+	if len(foods) != 1 || !reflect.DeepEqual(foods[0].Nutrients, map[int]float64{NutrientProtein: 12.6}) {
+		t.Fatalf("Read() = %+v, want the egg with its protein", foods)
+	}
+	// food.csv: x, food_nutrient.csv: lots and abc
+	want := map[string]int{"food.csv": 1, "food_nutrient.csv": 2}
+	for _, err := range rowErrors {
+		var rowErr *RowError
+		if !errors.As(err, &rowErr) {
+			t.Fatalf("row error = %v, want a *RowError", err)
+		}
+		want[rowErr.File]--
+		if rowErr.Line < 3 {
+			t.Errorf("row error %v is not on its line", err)
+		}
+	}
+	if want["food.csv"] != 0 || want["food_nutrient.csv"] != 0 {
+		t.Errorf("row errors = %v", rowErrors)
+	}
+}
+
+func TestRead_Unsorted(t *testing.T) {
+	files := map[string]string{
+		"food.csv": download["food.csv"],
+		"food_nutrient.csv": `fdc_id,nutrient_id,amount
+102,1003,22.5
+100,1003,12.6
+`,
+	}
+	err := Read(context.Background(), Config{Dir: writeDownload(t, files)}, func(*Food) error { return nil })
+	if !errors.Is(err, ErrUnsorted) {
+		t.Errorf("Read() error = %v, want %v", err, ErrUnsorted)
+	}
+}
+
+func TestRead_DuplicateFood(t *testing.T) {
+	files := map[string]string{
+		"food.csv": `fdc_id,data_type,description
+100,sr_legacy_food,Egg
+100,sr_legacy_food,Egg again
+`,
+		"food_nutrient.csv": "fdc_id,nutrient_id,amount\n",
+	}
+	foods, rowErrors := readAll(t, Config{Dir: writeDownload(t, files)})
+
+	if len(foods) != 1 || foods[0].Description != "Egg" {
+		t.Errorf("Read() = %+v, want the first egg", foods)
+	}
+	if len(rowErrors) != 1 {
+		t.Errorf("row errors = %v, want the duplicate", rowErrors)
+	}
+}
+
+func TestRead_Stops(t *testing.T) {
+	dir := writeDownload(t, download)
+	stop := errors.New("stop")
+
+	read := 0
+	err := Read(context.Background(), Config{Dir: dir}, func(*Food) error {
+		read++
+		return stop
+	})
+	if !errors.Is(err, stop) || read != 1 {
+		t.Errorf("Read() error = %v after %d foods, want %v after 1", err, read, stop)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Read(ctx, Config{Dir: dir}, func(*Food) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Read() with a cancelled context error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,124 @@
+// Package health implements readiness checks of the application's dependencies.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	_defaultTimeout = 2 * time.Second
+)
+
+// ErrShuttingDown is reported once the server started shutting down
+var ErrShuttingDown = errors.New("health: shutting down")
+
+// Check reports whether a dependency can be used
+type Check func(ctx context.Context) error
+
+// Result is the outcome of a single check.
+// Error is not serialised: it can name hosts and credentials of the dependency, callers log it instead.
+type Result struct {
+	Status     string `json:"status"`
+	Error      string `json:"-"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// Report is the readiness of the application with a breakdown per dependency
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Health -.
+type Health struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks map[string]Check
+
+	shuttingDown atomic.Bool
+}
+
+// New -.
+func New(opts ...Option) *Health {
+	h := &Health{
+		timeout: _defaultTimeout,
+		checks:  make(map[string]Check),
+	}
+
+	// Custom options
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// Register adds a dependency check, replacing the check of the same name
+func (h *Health) Register(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = check
+}
+
+// ShutDown makes the application unready for good, so that load balancers stop sending it traffic
+func (h *Health) ShutDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Ready runs all checks concurrently, each within the timeout.
+// The application is ready when every check passes and it is not shutting down.
+func (h *Health) Ready(ctx context.Context) Report {
+	report := Report{Status: StatusUp, Checks: make(map[string]Result)}
+	if h.shuttingDown.Load() {
+		report.Status = StatusDown
+		report.Checks["server"] = Result{Status: StatusDown, Error: ErrShuttingDown.Error()}
+		return report
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for name, check := range h.checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			result := h.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status == StatusDown {
+				report.Status = StatusDown
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (h *Health) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := Result{Status: StatusUp, DurationMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
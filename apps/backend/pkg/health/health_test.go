@@ -0,0 +1,72 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func up(context.Context) error { return nil }
+
+func TestHealth_Ready(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks map[string]Check
+		want   string
+		down   []string
+	}{
+		{name: "no checks", want: StatusUp},
+		{name: "all up", checks: map[string]Check{"postgres": up, "storage": up}, want: StatusUp},
+		{
+			name: "one down",
+			checks: map[string]Check{
+				"postgres": func(context.Context) error { return errors.New("dial tcp db.internal:5432: connection refused") },
+				"storage":  up,
+			},
+			want: StatusDown,
+			down: []string{"postgres"},
+		},
+		{
+			name: "timeout",
+			checks: map[string]Check{"slow": func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}},
+			want: StatusDown,
+			down: []string{"slow"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(Timeout(10 * time.Millisecond))
+			for name, check := range tt.checks {
+				h.Register(name, check)
+			}
+
+			report := h.Ready(context.Background())
+			if report.Status != tt.want {
+				t.Errorf("Status = %s, want %s", report.Status, tt.want)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Errorf("Checks = %v, want one per check", report.Checks)
+			}
+			for _, name := range tt.down {
+				if result := report.Checks[name]; result.Status != StatusDown || result.Error == "" {
+					t.Errorf("Checks[%s] = %+v, want down with the error", name, result)
+				}
+			}
+		})
+	}
+}
+
+func TestHealth_ShutDown(t *testing.T) {
+	h := New()
+	h.Register("postgres", up)
+	h.ShutDown()
+
+	report := h.Ready(context.Background())
+	if report.Status != StatusDown || report.Checks["server"].Status != StatusDown {
+		t.Errorf("Ready() after ShutDown = %+v, want down", report)
+	}
+}
@@ -13,6 +13,7 @@ const (
 	_defaultReadTimeout     = 5 * time.Second
 	_defaultWriteTimeout    = 5 * time.Second
 	_defaultShutdownTimeout = 3 * time.Second
+	_defaultBodyLimit       = 4 * 1024 * 1024
 )
 
 // Server -.
@@ -25,6 +26,10 @@ type Server struct {
 	readTimeout     time.Duration
 	writeTimeout    time.Duration
 	shutdownTimeout time.Duration
+	bodyLimit       int
+	drainDelay      time.Duration
+	onShutdown      []func()
+	errorHandler    fiber.ErrorHandler
 }
 
 // New -.
@@ -36,6 +41,12 @@ func New(opts ...Option) *Server {
 		readTimeout:     _defaultReadTimeout,
 		writeTimeout:    _defaultWriteTimeout,
 		shutdownTimeout: _defaultShutdownTimeout,
+		bodyLimit:       _defaultBodyLimit,
+		errorHandler: func(c *fiber.Ctx, err error) error {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		},
 	}
 
 	// Custom options
@@ -49,13 +60,10 @@ func New(opts ...Option) *Server {
 		Prefork:      s.prefork,
 		ReadTimeout:  s.readTimeout,
 		WriteTimeout: s.writeTimeout,
+		BodyLimit:    s.bodyLimit,
 		JSONDecoder:  json.Unmarshal,
 		JSONEncoder:  json.Marshal,
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		},
+		ErrorHandler: s.errorHandler,
 	})
 
 	s.App = app
@@ -76,7 +84,13 @@ func (s *Server) Notify() <-chan error {
 	return s.notify
 }
 
-// Shutdown -.
+// Shutdown runs the OnShutdown hooks, keeps serving for the drain delay, so that load balancers
+// notice the failing readiness probe, and then stops the server.
 func (s *Server) Shutdown() error {
+	for _, hook := range s.onShutdown {
+		hook()
+	}
+	time.Sleep(s.drainDelay)
+
 	return s.App.ShutdownWithTimeout(s.shutdownTimeout)
 }
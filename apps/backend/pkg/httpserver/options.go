@@ -3,6 +3,8 @@ package httpserver
 import (
 	"net"
 	"time"
+
+	"github.com/gofiber/fiber/v2"
 )
 
 // Option -.
@@ -42,3 +44,31 @@ func ShutdownTimeout(timeout time.Duration) Option {
 		s.shutdownTimeout = timeout
 	}
 }
+
+// BodyLimit sets the maximum request body size in bytes
+func BodyLimit(limit int) Option {
+	return func(s *Server) {
+		s.bodyLimit = limit
+	}
+}
+
+// ErrorHandler -.
+func ErrorHandler(handler fiber.ErrorHandler) Option {
+	return func(s *Server) {
+		s.errorHandler = handler
+	}
+}
+
+// DrainDelay -.
+func DrainDelay(delay time.Duration) Option {
+	return func(s *Server) {
+		s.drainDelay = delay
+	}
+}
+
+// OnShutdown adds a hook that runs as soon as Shutdown is called
+func OnShutdown(hook func()) Option {
+	return func(s *Server) {
+		s.onShutdown = append(s.onShutdown, hook)
+	}
+}
@@ -0,0 +1,156 @@
+// Package imaging decodes uploaded images, resizes them and encodes them again.
+// Encoding writes pixels only, so metadata of the upload such as EXIF is not carried over.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// Content types of the formats that are decoded
+const (
+	JPEG = "image/jpeg"
+	PNG  = "image/png"
+	WebP = "image/webp"
+)
+
+const _jpegQuality = 85
+
+var (
+	// ErrUnsupportedFormat is returned for data that is not a JPEG, PNG or WebP image
+	ErrUnsupportedFormat = errors.New("imaging: unsupported image format")
+	// ErrTooManyPixels is returned for images larger than the pixel limit, before they are decoded
+	ErrTooManyPixels = errors.New("imaging: image has too many pixels")
+)
+
+// Image is a decoded image
+type Image struct {
+	image.Image
+	// ContentType is the format the image was decoded from, sniffed from the data
+	ContentType string
+	// orientation is the EXIF orientation of a JPEG, 1 when upright
+	orientation int
+}
+
+// Decode decodes an image, detecting its format from the data rather than trusting a declared content type.
+// Images with more than maxPixels pixels are rejected from their header, so that they are never allocated.
+func Decode(data []byte, maxPixels int) (*Image, error) {
+	contentType := http.DetectContentType(data)
+
+	var decodeConfig func(io.Reader) (image.Config, error)
+	var decode func(io.Reader) (image.Image, error)
+	switch contentType {
+	case JPEG:
+		decodeConfig, decode = jpeg.DecodeConfig, jpeg.Decode
+	case PNG:
+		decodeConfig, decode = png.DecodeConfig, png.Decode
+	case WebP:
+		decodeConfig, decode = webp.DecodeConfig, webp.Decode
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	config, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width > maxPixels/config.Height {
+		return nil, ErrTooManyPixels
+	}
+
+	img, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+
+	orientation := 1
+	if contentType == JPEG {
+		orientation = jpegOrientation(data)
+	}
+	return &Image{Image: img, ContentType: contentType, orientation: orientation}, nil
+}
+
+// Fit scales the image down to fit in a size by size square, keeping its aspect ratio, and turns it upright.
+// Images that already fit are not scaled up.
+func (img *Image) Fit(size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, max(1, height*size/width)
+		} else {
+			width, height = max(1, width*size/height), size
+		}
+	}
+
+	scaled := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img.Image, bounds, draw.Src, nil)
+	return orient(scaled, img.orientation)
+}
+
+// Encode writes an image in the format it is stored in and returns that format's content type.
+// PNG stays PNG, as do images with transparency, anything else becomes JPEG.
+func (img *Image) Encode(w io.Writer, fitted image.Image) (string, error) {
+	if img.ContentType == PNG || !opaque(fitted) {
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		return PNG, encoder.Encode(w, fitted)
+	}
+	return JPEG, jpeg.Encode(w, fitted, &jpeg.Options{Quality: _jpegQuality})
+}
+
+func opaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// orient applies an EXIF orientation, which says how the stored pixels are turned and mirrored
+func orient(src *image.NRGBA, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	// Orientations 5 to 8 swap width and height
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = w-1-x, y
+			case 3: // rotated 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90° clockwise
+				dx, dy = h-1-y, x
+			case 7: // transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 90° counter-clockwise
+				dx, dy = y, w-1-x
+			}
+			si := src.PixOffset(x, y)
+			di := dst.PixOffset(dx, dy)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
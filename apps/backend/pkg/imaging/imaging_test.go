@@ -0,0 +1,192 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+const testCamera = "Rebound Test Camera"
+
+// exifSegment builds an APP1 segment whose EXIF holds an orientation and the camera make
+func exifSegment(order binary.ByteOrder, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	if order == binary.LittleEndian {
+		tiff.WriteString("II")
+	} else {
+		tiff.WriteString("MM")
+	}
+	write := func(v interface{}) { _ = binary.Write(&tiff, order, v) }
+	write(uint16(42))
+	write(uint32(8))
+
+	// One IFD of two entries, followed by the make it points to
+	const makeOffset = 8 + 2 + 2*12 + 4
+	write(uint16(2))
+	write([]uint16{_exifOrientationTag, 3})
+	write(uint32(1))
+	write([]uint16{orientation, 0})
+	write([]uint16{0x010F, 2})
+	write(uint32(len(testCamera) + 1))
+	write(uint32(makeOffset))
+	write(uint32(0))
+	tiff.WriteString(testCamera + "\x00")
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+	return append(app1, segment...)
+}
+
+// testJPEG encodes a width by height JPEG, with an EXIF segment right after the start of image when exif is set
+func testJPEG(t *testing.T, width, height int, exif []byte) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 255 / width), G: uint8(y * 255 / height), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	return append(append(data[:2:2], exif...), data[2:]...)
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{name: "no EXIF", data: testJPEG(t, 4, 2, nil), want: 1},
+		{name: "little endian", data: testJPEG(t, 4, 2, exifSegment(binary.LittleEndian, 6)), want: 6},
+		{name: "big endian", data: testJPEG(t, 4, 2, exifSegment(binary.BigEndian, 8)), want: 8},
+		{name: "out of range", data: testJPEG(t, 4, 2, exifSegment(binary.LittleEndian, 9)), want: 1},
+		{name: "truncated", data: testJPEG(t, 4, 2, exifSegment(binary.LittleEndian, 6))[:30], want: 1},
+		{name: "not a JPEG", data: []byte("\x89PNG\r\n\x1a\n"), want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jpegOrientation(tt.data); got != tt.want {
+				t.Errorf("jpegOrientation() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode_StripsMetadata(t *testing.T) {
+	data := testJPEG(t, 64, 32, exifSegment(binary.LittleEndian, 6))
+	if !bytes.Contains(data, []byte(testCamera)) {
+		t.Fatal("test image carries no EXIF")
+	}
+
+	img, err := Decode(data, 1<<20)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	var out bytes.Buffer
+	contentType, err := img.Encode(&out, img.Fit(16))
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	if contentType != JPEG {
+		t.Errorf("content type = %s, want %s", contentType, JPEG)
+	}
+	if bytes.Contains(out.Bytes(), []byte("Exif\x00\x00")) || bytes.Contains(out.Bytes(), []byte(testCamera)) {
+		t.Errorf("encoded image kept the EXIF of the upload")
+	}
+	// The orientation is applied to the pixels instead: 64x32 turned 90° fits 16 as 8x16
+	encoded, err := jpeg.DecodeConfig(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encoded.Width != 8 || encoded.Height != 16 {
+		t.Errorf("encoded size = %dx%d, want 8x16", encoded.Width, encoded.Height)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// A 2x1 image, red on the left and blue on the right
+	red, blue := color.NRGBA{R: 255, A: 255}, color.NRGBA{B: 255, A: 255}
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, red)
+	src.Set(1, 0, blue)
+
+	tests := []struct {
+		orientation int
+		// want lists the pixels row by row
+		want [][]color.NRGBA
+	}{
+		{1, [][]color.NRGBA{{red, blue}}},
+		{2, [][]color.NRGBA{{blue, red}}},
+		{3, [][]color.NRGBA{{blue, red}}},
+		{4, [][]color.NRGBA{{red, blue}}},
+		{5, [][]color.NRGBA{{red}, {blue}}},
+		{6, [][]color.NRGBA{{red}, {blue}}},
+		{7, [][]color.NRGBA{{blue}, {red}}},
+		{8, [][]color.NRGBA{{blue}, {red}}},
+	}
+	for _, tt := range tests {
+		got := orient(src, tt.orientation)
+		if got.Bounds().Dy() != len(tt.want) || got.Bounds().Dx() != len(tt.want[0]) {
+			t.Errorf("orient(%d) size = %v, want %dx%d", tt.orientation, got.Bounds().Size(), len(tt.want[0]), len(tt.want))
+			continue
+		}
+		for y, row := range tt.want {
+			for x, want := range row {
+				if c := color.NRGBAModel.Convert(got.At(x, y)); c != want {
+					t.Errorf("orient(%d) pixel (%d, %d) = %v, want %v", tt.orientation, x, y, c, want)
+				}
+			}
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var transparent bytes.Buffer
+	if err := png.Encode(&transparent, image.NewNRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		data      []byte
+		maxPixels int
+		wantErr   error
+		wantType  string
+	}{
+		{name: "JPEG", data: testJPEG(t, 4, 2, nil), maxPixels: 8, wantType: JPEG},
+		{name: "PNG with transparency", data: transparent.Bytes(), maxPixels: 16, wantType: PNG},
+		{name: "too many pixels", data: testJPEG(t, 4, 2, nil), maxPixels: 7, wantErr: ErrTooManyPixels},
+		{name: "not an image", data: []byte("GIF89a, or rather not"), maxPixels: 16, wantErr: ErrUnsupportedFormat},
+		{name: "truncated", data: testJPEG(t, 4, 2, nil)[:20], maxPixels: 16, wantErr: ErrUnsupportedFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Decode(tt.data, tt.maxPixels)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decode() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if img.ContentType != tt.wantType {
+				t.Errorf("ContentType = %s, want %s", img.ContentType, tt.wantType)
+			}
+			// Transparent images stay PNG, opaque ones become JPEG
+			contentType, err := img.Encode(&bytes.Buffer{}, img.Fit(2))
+			if err != nil || contentType != tt.wantType {
+				t.Errorf("Encode() = %s, %v, want %s", contentType, err, tt.wantType)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package logger
+
+import "context"
+
+// contextKey is the type of ContextKey
+type contextKey struct{}
+
+// ContextKey is the key under which a context holds the Fielder of its child loggers.
+// Contexts that cannot be derived from, such as a request's locals, set it directly.
+var ContextKey = contextKey{}
+
+// Fielder provides the fields of the child loggers of a context.
+// Fields are read each time a child logger is created, so they can change during a request.
+type Fielder interface {
+	LogFields() []interface{}
+}
+
+// fields is a fixed list of key/value pairs
+type fields []interface{}
+
+// LogFields implements Fielder
+func (f fields) LogFields() []interface{} {
+	return f
+}
+
+// ContextWith returns a copy of ctx whose child loggers add fields to those of ctx
+func ContextWith(ctx context.Context, kv ...interface{}) context.Context {
+	all := append(FieldsFromContext(ctx), kv...)
+	return context.WithValue(ctx, ContextKey, fields(all))
+}
+
+// FieldsFromContext returns the fields of the child loggers of ctx
+func FieldsFromContext(ctx context.Context) []interface{} {
+	f, ok := ctx.Value(ContextKey).(Fielder)
+	if !ok {
+		return nil
+	}
+	// Copy, so that appending never writes into the parent's fields
+	return append([]interface{}(nil), f.LogFields()...)
+}
@@ -1,31 +1,63 @@
+// Package logger implements structured, leveled logging.
 package logger
 
 import (
-	"fmt"
+	"context"
+	"io"
 	"os"
 	"strings"
 
 	"github.com/rs/zerolog"
 )
 
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+
+	_defaultFormat = FormatJSON
+)
+
 // Interface -.
+// Fields are key/value pairs, such as l.Error("uc.Complete", "error", err, "key", key).
 type Interface interface {
-	Debug(message interface{}, args ...interface{})
-	Info(message string, args ...interface{})
-	Warn(message string, args ...interface{})
-	Error(message interface{}, args ...interface{})
-	Fatal(message interface{}, args ...interface{})
+	Debug(message string, fields ...interface{})
+	Info(message string, fields ...interface{})
+	Warn(message string, fields ...interface{})
+	Error(message string, fields ...interface{})
+	Fatal(message string, fields ...interface{})
+
+	// With returns a child logger that adds fields to every message
+	With(fields ...interface{}) Interface
+	// WithContext returns a child logger that adds the fields ctx holds under ContextKey
+	WithContext(ctx context.Context) Interface
 }
 
 // Logger -.
 type Logger struct {
-	logger *zerolog.Logger
+	logger zerolog.Logger
 }
 
 var _ Interface = (*Logger)(nil)
 
+// options collects the settings of New
+type options struct {
+	format        string
+	output        io.Writer
+	debugSampling uint32
+}
+
 // New -.
-func New(level string) *Logger {
+func New(level string, opts ...Option) *Logger {
+	o := options{
+		format: _defaultFormat,
+		output: os.Stdout,
+	}
+
+	// Custom options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	var l zerolog.Level
 
 	switch strings.ToLower(level) {
@@ -41,62 +73,70 @@ func New(level string) *Logger {
 		l = zerolog.InfoLevel
 	}
 
-	zerolog.SetGlobalLevel(l)
+	output := o.output
+	if o.format == FormatConsole {
+		output = zerolog.ConsoleWriter{Out: o.output}
+	}
 
-	skipFrameCount := 3
-	logger := zerolog.New(os.Stdout).With().Timestamp().CallerWithSkipFrameCount(zerolog.CallerSkipFrameCount + skipFrameCount).Logger()
+	// Skip the frames of Logger's own methods, so that the caller is the code that logs
+	skipFrameCount := 2
+	logger := zerolog.New(output).Level(l).With().Timestamp().CallerWithSkipFrameCount(zerolog.CallerSkipFrameCount + skipFrameCount).Logger()
+
+	if o.debugSampling > 1 {
+		logger = logger.Sample(zerolog.LevelSampler{DebugSampler: &zerolog.BasicSampler{N: o.debugSampling}})
+	}
 
 	return &Logger{
-		logger: &logger,
+		logger: logger,
 	}
 }
 
 // Debug -.
-func (l *Logger) Debug(message interface{}, args ...interface{}) {
-	l.msg("debug", message, args...)
+func (l *Logger) Debug(message string, fields ...interface{}) {
+	l.log(l.logger.Debug(), message, fields)
 }
 
 // Info -.
-func (l *Logger) Info(message string, args ...interface{}) {
-	l.log(message, args...)
+func (l *Logger) Info(message string, fields ...interface{}) {
+	l.log(l.logger.Info(), message, fields)
 }
 
 // Warn -.
-func (l *Logger) Warn(message string, args ...interface{}) {
-	l.log(message, args...)
+func (l *Logger) Warn(message string, fields ...interface{}) {
+	l.log(l.logger.Warn(), message, fields)
 }
 
 // Error -.
-func (l *Logger) Error(message interface{}, args ...interface{}) {
-	if l.logger.GetLevel() == zerolog.DebugLevel {
-		l.Debug(message, args...)
-	}
-
-	l.msg("error", message, args...)
+func (l *Logger) Error(message string, fields ...interface{}) {
+	l.log(l.logger.Error(), message, fields)
 }
 
-// Fatal -.
-func (l *Logger) Fatal(message interface{}, args ...interface{}) {
-	l.msg("fatal", message, args...)
+// Fatal logs at fatal level and exits the process.
+func (l *Logger) Fatal(message string, fields ...interface{}) {
+	l.log(l.logger.Fatal(), message, fields)
 
 	os.Exit(1)
 }
 
-func (l *Logger) log(message string, args ...interface{}) {
-	if len(args) == 0 {
-		l.logger.Info().Msg(message)
-	} else {
-		l.logger.Info().Msgf(message, args...)
+// With -.
+func (l *Logger) With(fields ...interface{}) Interface {
+	return &Logger{
+		logger: l.logger.With().Fields(fields).Logger(),
 	}
 }
 
-func (l *Logger) msg(level string, message interface{}, args ...interface{}) {
-	switch msg := message.(type) {
-	case error:
-		l.log(msg.Error(), args...)
-	case string:
-		l.log(msg, args...)
-	default:
-		l.log(fmt.Sprintf("%s message %v has unknown type %v", level, message, msg), args...)
+// WithContext -.
+func (l *Logger) WithContext(ctx context.Context) Interface {
+	fields := FieldsFromContext(ctx)
+	if len(fields) == 0 {
+		return l
+	}
+	return l.With(fields...)
+}
+
+func (l *Logger) log(event *zerolog.Event, message string, fields []interface{}) {
+	if len(fields) > 0 {
+		event = event.Fields(fields)
 	}
+	event.Msg(message)
 }
@@ -0,0 +1,27 @@
+package logger
+
+import "io"
+
+// Option -.
+type Option func(*options)
+
+// Format sets the output format, json for machines or console for humans
+func Format(format string) Option {
+	return func(o *options) {
+		o.format = format
+	}
+}
+
+// Output -.
+func Output(w io.Writer) Option {
+	return func(o *options) {
+		o.output = w
+	}
+}
+
+// DebugSampling logs only one of every n debug messages, 0 and 1 log them all
+func DebugSampling(n uint32) Option {
+	return func(o *options) {
+		o.debugSampling = n
+	}
+}
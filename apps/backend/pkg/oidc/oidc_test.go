@@ -0,0 +1,152 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/terrnit/rebound/backend/pkg/oidc"
+	"github.com/terrnit/rebound/backend/pkg/oidc/oidctest"
+)
+
+const redirectURL = "https://app.example.com/api/auth/oidc/test/callback"
+
+func newIssuer(t *testing.T) *oidctest.Issuer {
+	t.Helper()
+	issuer, err := oidctest.NewIssuer("rebound", "client-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+// authorize runs the authorization request at the issuer and returns the code it redirects back with
+func authorize(t *testing.T, provider *oidc.Provider, state, nonce, verifier string) string {
+	t.Helper()
+	authURL, err := provider.AuthCodeURL(context.Background(), state, nonce, oidc.S256Challenge(verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorization status = %d, want %d", resp.StatusCode, http.StatusFound)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := location.Query().Get("state"); got != state {
+		t.Fatalf("state = %q, want %q", got, state)
+	}
+	return location.Query().Get("code")
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	issuer := newIssuer(t)
+	issuer.SetUser(oidctest.User{Subject: "sub-1", Email: "alice@example.com", EmailVerified: true, Name: "Alice"})
+	provider := oidc.New(issuer.Config(redirectURL))
+	ctx := context.Background()
+
+	verifier, err := oidc.GenerateVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := authorize(t, provider, "state-1", "nonce-1", verifier)
+
+	claims, err := provider.Exchange(ctx, code, verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	want := oidc.Claims{Subject: "sub-1", Email: "alice@example.com", EmailVerified: true, Name: "Alice"}
+	if *claims != want {
+		t.Errorf("Exchange() = %+v, want %+v", *claims, want)
+	}
+
+	// Codes are redeemed once
+	if _, err := provider.Exchange(ctx, code, verifier, "nonce-1"); !errors.Is(err, oidc.ErrExchange) {
+		t.Errorf("second Exchange() error = %v, want %v", err, oidc.ErrExchange)
+	}
+}
+
+func TestProvider_ExchangeRefused(t *testing.T) {
+	issuer := newIssuer(t)
+	issuer.SetUser(oidctest.User{Subject: "sub-1", Email: "alice@example.com", EmailVerified: true})
+	provider := oidc.New(issuer.Config(redirectURL))
+
+	tests := []struct {
+		name     string
+		verifier string
+		nonce    string
+		wantErr  error
+	}{
+		{name: "wrong code verifier", verifier: "another-verifier", nonce: "nonce-1", wantErr: oidc.ErrExchange},
+		{name: "wrong nonce", nonce: "nonce-2", wantErr: oidc.ErrInvalidIDToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := oidc.GenerateVerifier()
+			if err != nil {
+				t.Fatal(err)
+			}
+			code := authorize(t, provider, "state-1", "nonce-1", verifier)
+			if tt.verifier != "" {
+				verifier = tt.verifier
+			}
+
+			if _, err := provider.Exchange(context.Background(), code, verifier, tt.nonce); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Exchange() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProvider_Verify(t *testing.T) {
+	issuer := newIssuer(t)
+	provider := oidc.New(issuer.Config(redirectURL))
+	user := oidctest.User{Subject: "sub-1", Email: "alice@example.com"}
+
+	tests := []struct {
+		name      string
+		user      oidctest.User
+		audience  string
+		expiresAt time.Time
+		wantErr   bool
+	}{
+		{name: "valid", user: user, audience: "rebound", expiresAt: time.Now().Add(time.Hour)},
+		{name: "expired", user: user, audience: "rebound", expiresAt: time.Now().Add(-time.Hour), wantErr: true},
+		{name: "another client", user: user, audience: "another-app", expiresAt: time.Now().Add(time.Hour), wantErr: true},
+		{name: "no subject", user: oidctest.User{Email: "alice@example.com"}, audience: "rebound", expiresAt: time.Now().Add(time.Hour), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := issuer.IDToken(tt.user, tt.audience, "nonce", tt.expiresAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = provider.Verify(context.Background(), token, "nonce")
+			if tt.wantErr != errors.Is(err, oidc.ErrInvalidIDToken) {
+				t.Errorf("Verify() error = %v, want invalid: %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// A token signed by another issuer's key is refused, whatever its claims
+	other := newIssuer(t)
+	token, err := other.IDToken(user, "rebound", "nonce", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Verify(context.Background(), token, "nonce"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("Verify() of a foreign token error = %v, want %v", err, oidc.ErrInvalidIDToken)
+	}
+}
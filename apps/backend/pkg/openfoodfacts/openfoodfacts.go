@@ -0,0 +1,203 @@
+// Package openfoodfacts looks up products by barcode in Open Food Facts, https://world.openfoodfacts.org,
+// and in local catalogs shaped like it.
+package openfoodfacts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultBaseURL is the public Open Food Facts server
+	DefaultBaseURL = "https://world.openfoodfacts.org"
+
+	// maxResponseSize bounds the product documents read from the server
+	maxResponseSize = 1 << 20
+
+	// fields limits responses to what Product holds
+	fields = "code,product_name,generic_name,brands,serving_size,serving_quantity,serving_quantity_unit,nutriments"
+)
+
+// Product is the part of an Open Food Facts product that describes its nutrition
+type Product struct {
+	Code        string     `json:"code"`
+	ProductName string     `json:"product_name"`
+	GenericName string     `json:"generic_name"`
+	Brands      string     `json:"brands"`
+	Nutriments  Nutriments `json:"nutriments"`
+
+	// ServingSize is the serving printed on the package, such as "1 biscuit (12.5 g)"
+	ServingSize string `json:"serving_size"`
+	// ServingQuantity is the amount of a serving in ServingQuantityUnit
+	ServingQuantity *Number `json:"serving_quantity"`
+	// ServingQuantityUnit is g or ml. Products that predate it are in grams.
+	ServingQuantityUnit string `json:"serving_quantity_unit"`
+}
+
+// Nutriments are the nutrition facts per 100 g or 100 ml. Like in Open Food Facts, amounts are in grams,
+// vitamins and minerals included, and energy is in kilocalories or kilojoules. Missing facts are nil.
+type Nutriments struct {
+	EnergyKcal    *Number `json:"energy-kcal_100g"`
+	EnergyKJ      *Number `json:"energy-kj_100g"`
+	Proteins      *Number `json:"proteins_100g"`
+	Fat           *Number `json:"fat_100g"`
+	Carbohydrates *Number `json:"carbohydrates_100g"`
+	Fiber         *Number `json:"fiber_100g"`
+	Sugars        *Number `json:"sugars_100g"`
+	SaturatedFat  *Number `json:"saturated-fat_100g"`
+	TransFat      *Number `json:"trans-fat_100g"`
+	Cholesterol   *Number `json:"cholesterol_100g"`
+	Sodium        *Number `json:"sodium_100g"`
+	Potassium     *Number `json:"potassium_100g"`
+	VitaminA      *Number `json:"vitamin-a_100g"`
+	VitaminC      *Number `json:"vitamin-c_100g"`
+	Calcium       *Number `json:"calcium_100g"`
+	Iron          *Number `json:"iron_100g"`
+}
+
+// Number is a nutrition fact. Open Food Facts sends most as JSON numbers and some as strings.
+type Number float64
+
+// UnmarshalJSON accepts a number or a string holding one
+func (n *Number) UnmarshalJSON(data []byte) error {
+	raw := strings.Trim(string(data), `"`)
+	if raw == "" || raw == "null" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return fmt.Errorf("openfoodfacts: invalid number %s", data)
+	}
+	*n = Number(v)
+	return nil
+}
+
+// Config configures a client
+type Config struct {
+	// BaseURL defaults to DefaultBaseURL
+	BaseURL string
+	// UserAgent identifies the application, as Open Food Facts asks of API users
+	UserAgent string
+	// HTTPClient defaults to a client with a 5 second timeout
+	HTTPClient *http.Client
+}
+
+// Client reads products from the Open Food Facts API
+type Client struct {
+	config Config
+	client *http.Client
+}
+
+// New returns a client
+func New(config Config) *Client {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &Client{config: config, client: client}
+}
+
+// Product returns the product with a barcode, nil when Open Food Facts does not know it
+func (c *Client) Product(ctx context.Context, code string) (*Product, error) {
+	rawURL := strings.TrimSuffix(c.config.BaseURL, "/") + "/api/v2/product/" + url.PathEscape(code) + ".json?fields=" + fields
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.config.UserAgent != "" {
+		req.Header.Set("User-Agent", c.config.UserAgent)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("openfoodfacts - Product: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Unknown products are a 404 with a status of 0 in the body
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("openfoodfacts - Product: GET %s returned %s", rawURL, resp.Status)
+	}
+
+	var body struct {
+		Status  int      `json:"status"`
+		Product *Product `json:"product"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("openfoodfacts - Product: %w", err)
+	}
+	if body.Status != 1 || body.Product == nil {
+		return nil, nil
+	}
+	if body.Product.Code == "" {
+		body.Product.Code = code
+	}
+	return body.Product, nil
+}
+
+// Static is a catalog held in memory, for development and tests that must not reach Open Food Facts
+type Static struct {
+	products map[string]*Product
+}
+
+// NewStatic returns a catalog of products, keyed by their code
+func NewStatic(products ...*Product) *Static {
+	s := &Static{products: make(map[string]*Product, len(products))}
+	for _, p := range products {
+		s.products[p.Code] = p
+	}
+	return s
+}
+
+// LoadStatic reads a catalog from a file of products in the JSON lines format of the Open Food Facts data dumps
+func LoadStatic(path string) (*Static, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var products []*Product
+	scanner := NewScanner(f)
+	for scanner.Scan() {
+		p, err := scanner.Product()
+		if err != nil {
+			return nil, fmt.Errorf("openfoodfacts - LoadStatic: %w", err)
+		}
+		if p.Code == "" {
+			return nil, fmt.Errorf("openfoodfacts - LoadStatic: line %d: missing code", scanner.Line())
+		}
+		products = append(products, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("openfoodfacts - LoadStatic: %w", err)
+	}
+	return NewStatic(products...), nil
+}
+
+// Product returns the product with a barcode, nil when the catalog does not hold it.
+// Like Open Food Facts, it finds products listed under their UPC-A code by their EAN-13 code.
+func (s *Static) Product(_ context.Context, code string) (*Product, error) {
+	if p, ok := s.products[code]; ok {
+		return p, nil
+	}
+	if len(code) == 13 && code[0] == '0' {
+		return s.products[code[1:]], nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,87 @@
+package openfoodfacts
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// LineError reports a line of a data dump that does not hold a product
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// Scanner reads products from the JSON lines data dumps of Open Food Facts, one line at a time, so that dumps of
+// millions of products can be streamed. Lines are not limited in length: some products have very long documents.
+type Scanner struct {
+	r       *bufio.Reader
+	line    int
+	product *Product
+	lineErr error
+	err     error
+}
+
+// NewScanner returns a scanner reading from r
+func NewScanner(r io.Reader) *Scanner {
+	return &Scanner{r: bufio.NewReaderSize(r, 64*1024)}
+}
+
+// Scan advances to the next product, skipping blank lines. It returns false at the end of the input
+// or when reading fails, after which Err returns the failure.
+func (s *Scanner) Scan() bool {
+	for s.err == nil {
+		data, err := s.r.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			s.err = err
+			return false
+		}
+		if len(data) == 0 && err != nil {
+			return false
+		}
+		s.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			if err != nil {
+				return false
+			}
+			continue
+		}
+		var p Product
+		if jsonErr := json.Unmarshal(data, &p); jsonErr != nil {
+			s.product, s.lineErr = nil, &LineError{Line: s.line, Err: jsonErr}
+		} else {
+			s.product, s.lineErr = &p, nil
+		}
+		return true
+	}
+	return false
+}
+
+// Product returns the product read by the last call to Scan, or a *LineError when its line is not a product.
+// Scanning can carry on past such lines.
+func (s *Scanner) Product() (*Product, error) {
+	return s.product, s.lineErr
+}
+
+// Line returns the number of the line read by the last call to Scan
+func (s *Scanner) Line() int {
+	return s.line
+}
+
+// Err returns the error that stopped scanning, nil at the end of the input
+func (s *Scanner) Err() error {
+	return s.err
+}
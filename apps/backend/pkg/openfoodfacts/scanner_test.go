@@ -0,0 +1,76 @@
+package openfoodfacts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestScanner(t *testing.T) {
+	long := strings.Repeat("x", 200*1024)
+	dump := `{"code":"3017620422003","product_name":"Nutella","nutriments":{"energy-kcal_100g":539,"proteins_100g":"6.3"}}
+
+not json
+{"code":"0036000291452","generic_name":"` + long + `"}
+{"code":"96385074"}`
+
+	type line struct {
+		line    int
+		code    string
+		invalid bool
+	}
+	want := []line{{line: 1, code: "3017620422003"}, {line: 3, invalid: true}, {line: 4, code: "0036000291452"}, {line: 5, code: "96385074"}}
+
+	s := NewScanner(strings.NewReader(dump))
+	var got []line
+	for s.Scan() {
+		product, err := s.Product()
+		var lineErr *LineError
+		switch {
+		case errors.As(err, &lineErr):
+			if lineErr.Line != s.Line() || product != nil {
+				t.Errorf("line %d: error = %v with product %v", s.Line(), err, product)
+			}
+			got = append(got, line{line: s.Line(), invalid: true})
+		case err != nil:
+			t.Fatalf("line %d: error = %v", s.Line(), err)
+		default:
+			got = append(got, line{line: s.Line(), code: product.Code})
+			switch product.Code {
+			case "3017620422003":
+				n := product.Nutriments
+				if product.ProductName != "Nutella" || n.EnergyKcal == nil || *n.EnergyKcal != 539 || n.Proteins == nil || *n.Proteins != 6.3 {
+					t.Errorf("product = %+v", product)
+				}
+			case "0036000291452":
+				if product.GenericName != long {
+					t.Errorf("long line read as %d bytes", len(product.GenericName))
+				}
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("scanned %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("scan %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestScanner_ReadError(t *testing.T) {
+	readErr := errors.New("disk on fire")
+	s := NewScanner(iotest.ErrReader(readErr))
+
+	if s.Scan() {
+		t.Fatalf("Scan() = true on a failing reader")
+	}
+	if !errors.Is(s.Err(), readErr) {
+		t.Errorf("Err() = %v, want %v", s.Err(), readErr)
+	}
+}
@@ -48,6 +48,7 @@ func New(url string, opts ...Option) (*Postgres, error) {
 	}
 
 	poolConfig.MaxConns = int32(pg.maxPoolSize) //nolint:gosec // skip integer overflow conversion int -> int32
+	poolConfig.ConnConfig.Tracer = queryTracer{}
 
 	for pg.connAttempts > 0 {
 		pg.Pool, err = pgxpool.NewWithConfig(context.Background(), poolConfig)
@@ -69,6 +70,15 @@ func New(url string, opts ...Option) (*Postgres, error) {
 	return pg, nil
 }
 
+// MigrationVersion returns the version of the last applied migration and whether it failed half way
+func (p *Postgres) MigrationVersion(ctx context.Context) (version uint, dirty bool, err error) {
+	err = p.Pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		return 0, false, fmt.Errorf("postgres - MigrationVersion: %w", err)
+	}
+	return version, dirty, nil
+}
+
 // Close -.
 func (p *Postgres) Close() {
 	if p.Pool != nil {
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/terrnit/rebound/backend/pkg/tracing"
+)
+
+// queryTracer records a span for each query, batch and COPY.
+// Statements are recorded without their arguments, which may hold personal data.
+type queryTracer struct{}
+
+var (
+	_ pgx.QueryTracer    = queryTracer{}
+	_ pgx.BatchTracer    = queryTracer{}
+	_ pgx.CopyFromTracer = queryTracer{}
+)
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) context.Context {
+	attrs = append(attrs, semconv.DBSystemPostgreSQL)
+	ctx, _ = tracing.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return ctx
+}
+
+// operation returns the SQL command of a statement, such as SELECT, to name its span
+func operation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "query"
+	}
+	return strings.ToUpper(fields[0])
+}
+
+func endSpan(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceQueryStart implements pgx.QueryTracer
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	op := operation(data.SQL)
+	return startSpan(ctx, "postgres "+op, semconv.DBOperationName(op), semconv.DBQueryText(data.SQL))
+}
+
+// TraceQueryEnd implements pgx.QueryTracer
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	endSpan(ctx, data.Err)
+}
+
+// TraceBatchStart implements pgx.BatchTracer
+func (queryTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	return startSpan(ctx, "postgres.batch", attribute.Int("db.batch.size", data.Batch.Len()))
+}
+
+// TraceBatchQuery implements pgx.BatchTracer
+func (queryTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	trace.SpanFromContext(ctx).AddEvent("query", trace.WithAttributes(semconv.DBQueryText(data.SQL)))
+}
+
+// TraceBatchEnd implements pgx.BatchTracer
+func (queryTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	endSpan(ctx, data.Err)
+}
+
+// TraceCopyFromStart implements pgx.CopyFromTracer
+func (queryTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	return startSpan(ctx, "postgres.copy_from", semconv.DBCollectionName(data.TableName.Sanitize()))
+}
+
+// TraceCopyFromEnd implements pgx.CopyFromTracer
+func (queryTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	endSpan(ctx, data.Err)
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Memory keeps buckets in memory, limits are only enforced per instance
+type Memory struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+var _ Store = (*Memory)(nil)
+
+// NewMemory -.
+func NewMemory() *Memory {
+	return &Memory{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take -.
+func (m *Memory) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	if limit.Unlimited() {
+		return Result{Allowed: true}, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Count), updated: now}
+		m.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(limit.Count), b.tokens+now.Sub(b.updated).Seconds()*limit.rate())
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return limit.result(allowed, b.tokens), nil
+}
+
+// Purge -.
+func (m *Memory) Purge(_ context.Context, idleSince time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var purged int64
+	for key, b := range m.buckets {
+		if b.updated.Before(idleSince) {
+			delete(m.buckets, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/terrnit/rebound/backend/pkg/postgres"
+)
+
+// takeQuery refills the bucket for the time since it was last used and takes a token if there is one.
+// The SET expressions all see the row as it was, so the refilled amount is spelled out in each of them.
+const takeQuery = `
+INSERT INTO rate_limit_buckets AS b (bucket_key, tokens, allowed, updated_at)
+VALUES ($1, $2::DOUBLE PRECISION - 1, TRUE, now())
+ON CONFLICT (bucket_key) DO UPDATE SET
+	allowed = LEAST($2, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::DOUBLE PRECISION * $3::DOUBLE PRECISION) >= 1,
+	tokens = LEAST($2, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::DOUBLE PRECISION * $3::DOUBLE PRECISION)
+		- CASE WHEN LEAST($2, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::DOUBLE PRECISION * $3::DOUBLE PRECISION) >= 1 THEN 1 ELSE 0 END,
+	updated_at = now()
+RETURNING tokens, allowed`
+
+// Postgres keeps buckets in the rate_limit_buckets table, limits are shared by all instances
+type Postgres struct {
+	db *postgres.Postgres
+}
+
+var _ Store = (*Postgres)(nil)
+
+// NewPostgres -.
+func NewPostgres(db *postgres.Postgres) *Postgres {
+	return &Postgres{db: db}
+}
+
+// Take -.
+func (p *Postgres) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	if limit.Unlimited() {
+		return Result{Allowed: true}, nil
+	}
+
+	var (
+		tokens  float64
+		allowed bool
+	)
+	err := p.db.Pool.QueryRow(ctx, takeQuery, key, float64(limit.Count), limit.rate()).Scan(&tokens, &allowed)
+	if err != nil {
+		return Result{}, err
+	}
+	return limit.result(allowed, tokens), nil
+}
+
+// Purge -.
+func (p *Postgres) Purge(ctx context.Context, idleSince time.Time) (int64, error) {
+	tag, err := p.db.Pool.Exec(ctx, "DELETE FROM rate_limit_buckets WHERE updated_at < $1", idleSince)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,92 @@
+// Package ratelimit implements token bucket rate limiting with pluggable stores.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Count requests per Period, in bursts of up to Count.
+// The zero Limit is unlimited.
+type Limit struct {
+	Count  int
+	Period time.Duration
+}
+
+// ParseLimit parses a limit written as count/period, such as 100/1m. An empty string or 0 is unlimited.
+func ParseLimit(s string) (Limit, error) {
+	if s == "" || s == "0" {
+		return Limit{}, nil
+	}
+	count, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("ratelimit: limit %q is not count/period", s)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return Limit{}, fmt.Errorf("ratelimit: invalid count in limit %q", s)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("ratelimit: invalid period in limit %q", s)
+	}
+	return Limit{Count: n, Period: d}, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, so limits can be read from the environment
+func (l *Limit) UnmarshalText(text []byte) error {
+	parsed, err := ParseLimit(string(text))
+	if err != nil {
+		return err
+	}
+	*l = parsed
+	return nil
+}
+
+// String -.
+func (l Limit) String() string {
+	if l.Unlimited() {
+		return "0"
+	}
+	return strconv.Itoa(l.Count) + "/" + l.Period.String()
+}
+
+// Unlimited reports whether the limit lets every request through
+func (l Limit) Unlimited() bool {
+	return l.Count <= 0 || l.Period <= 0
+}
+
+// rate is the number of tokens added to a bucket per second
+func (l Limit) rate() float64 {
+	return float64(l.Count) / l.Period.Seconds()
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left in the bucket
+	Remaining int
+	// RetryAfter is how long until the next token is available, when the request is not allowed
+	RetryAfter time.Duration
+}
+
+// result derives the Result from the tokens left in a bucket after taking one
+func (l Limit) result(allowed bool, tokens float64) Result {
+	r := Result{Allowed: allowed, Remaining: int(tokens)}
+	if !allowed {
+		r.RetryAfter = time.Duration((1 - tokens) / l.rate() * float64(time.Second))
+	}
+	return r
+}
+
+// Store keeps token buckets by key
+type Store interface {
+	// Take takes a token from the bucket of key, which refills at the rate of limit
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	// Purge drops the buckets that were not used since idleSince and returns how many were dropped.
+	// A bucket idle for longer than its limit's period is full, so dropping it changes nothing.
+	Purge(ctx context.Context, idleSince time.Time) (int64, error)
+}
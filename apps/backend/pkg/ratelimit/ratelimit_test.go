@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{in: "", want: Limit{}},
+		{in: "0", want: Limit{}},
+		{in: "100/1m", want: Limit{Count: 100, Period: time.Minute}},
+		{in: "5/15m", want: Limit{Count: 5, Period: 15 * time.Minute}},
+		{in: "100", wantErr: true},
+		{in: "x/1m", wantErr: true},
+		{in: "-1/1m", wantErr: true},
+		{in: "10/forever", wantErr: true},
+		{in: "10/0s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseLimit(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLimit(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLimit(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+			if !tt.wantErr && tt.in != "" {
+				if again, err := ParseLimit(got.String()); err != nil || again != got {
+					t.Errorf("ParseLimit(%q.String()) = %v, %v, want %v", tt.in, again, err, got)
+				}
+			}
+		})
+	}
+}
+
+func TestMemory_Take(t *testing.T) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	limit := Limit{Count: 3, Period: 3 * time.Second}
+	ctx := context.Background()
+
+	// A full bucket allows a burst of Count requests
+	for i := 0; i < 3; i++ {
+		result, _ := m.Take(ctx, "ip:1", limit)
+		if !result.Allowed || result.Remaining != 2-i {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining", i+1, result, 2-i)
+		}
+	}
+	result, _ := m.Take(ctx, "ip:1", limit)
+	if result.Allowed || result.RetryAfter != time.Second {
+		t.Fatalf("request over the burst = %+v, want refused for 1s", result)
+	}
+
+	// Buckets are kept by key
+	if result, _ := m.Take(ctx, "ip:2", limit); !result.Allowed {
+		t.Errorf("another key = %+v, want allowed", result)
+	}
+
+	// Tokens come back at Count per Period
+	now = now.Add(time.Second)
+	if result, _ := m.Take(ctx, "ip:1", limit); !result.Allowed {
+		t.Errorf("after refill = %+v, want allowed", result)
+	}
+	if result, _ := m.Take(ctx, "ip:1", limit); result.Allowed {
+		t.Errorf("after using the refill = %+v, want refused", result)
+	}
+
+	// The zero limit lets everything through
+	for i := 0; i < 10; i++ {
+		if result, _ := m.Take(ctx, "ip:1", Limit{}); !result.Allowed {
+			t.Fatalf("unlimited request %d refused", i+1)
+		}
+	}
+}
+
+func TestMemory_Purge(t *testing.T) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	limit := Limit{Count: 1, Period: time.Minute}
+	ctx := context.Background()
+
+	m.Take(ctx, "old", limit) //nolint:errcheck // the memory store does not fail
+	now = now.Add(time.Hour)
+	m.Take(ctx, "recent", limit) //nolint:errcheck // the memory store does not fail
+
+	purged, err := m.Purge(ctx, now.Add(-time.Minute))
+	if err != nil || purged != 1 {
+		t.Fatalf("Purge() = %d, %v, want 1", purged, err)
+	}
+	if result, _ := m.Take(ctx, "recent", limit); result.Allowed {
+		t.Errorf("Purge() dropped a bucket in use")
+	}
+}
@@ -0,0 +1,56 @@
+package secretbox
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestBox(t *testing.T) {
+	box, err := New("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := box.Seal("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := box.Seal("JBSWY3DPEHPK3PXP"); again == sealed {
+		t.Errorf("Seal() is deterministic, nonces are reused")
+	}
+	if opened, err := box.Open(sealed); err != nil || opened != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("Open() = %q, %v, want the plaintext", opened, err)
+	}
+
+	other, err := New("other key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := base64.RawStdEncoding.DecodeString(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw[len(raw)-1] ^= 1
+	tampered := base64.RawStdEncoding.EncodeToString(raw)
+	for name, value := range map[string]string{
+		"other key":  "",
+		"tampered":   tampered,
+		"not base64": "%%%",
+		"too short":  "AAAA",
+	} {
+		b := box
+		if name == "other key" {
+			b, value = other, sealed
+		}
+		if _, err := b.Open(value); !errors.Is(err, ErrDecrypt) {
+			t.Errorf("Open() %s error = %v, want %v", name, err, ErrDecrypt)
+		}
+	}
+}
+
+func TestNew_EmptyKey(t *testing.T) {
+	if _, err := New(""); err == nil {
+		t.Error("New(\"\") succeeded, want an error")
+	}
+}
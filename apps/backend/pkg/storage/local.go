@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Local stores files in a directory of the local file system
+type Local struct {
+	dir string
+}
+
+var _ Storage = (*Local)(nil)
+
+// NewLocal creates the directory if needed and returns a storage backed by it
+func NewLocal(dir string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("storage - NewLocal - os.MkdirAll: %w", err)
+	}
+	return &Local{dir: dir}, nil
+}
+
+// path maps a file name into the directory, names must not contain path separators
+func (s *Local) path(name string) (string, error) {
+	if err := validName(name); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, name), nil
+}
+
+// Create returns a writer for a new file.
+// Data goes to a temporary file that is renamed into place on Close.
+func (s *Local) Create(name string) (io.WriteCloser, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(s.dir, "."+name+".*")
+	if err != nil {
+		return nil, err
+	}
+	return &localFile{File: f, path: path}, nil
+}
+
+// Open returns a reader for a file
+func (s *Local) Open(name string) (io.ReadCloser, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Remove deletes a file
+func (s *Local) Remove(name string) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Check verifies that files can be written to the directory
+func (s *Local) Check(_ context.Context) error {
+	f, err := os.CreateTemp(s.dir, ".check.*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// localFile renames its temporary file into place when it is closed
+type localFile struct {
+	*os.File
+	path string
+}
+
+func (f *localFile) Close() error {
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.File.Name())
+		return err
+	}
+	if err := os.Rename(f.File.Name(), f.path); err != nil {
+		os.Remove(f.File.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,356 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	_s3Algorithm = "AWS4-HMAC-SHA256"
+	_s3Service   = "s3"
+	// _s3MaxPresignTTL is the longest expiry S3 accepts for a presigned URL
+	_s3MaxPresignTTL = 7 * 24 * time.Hour
+)
+
+// S3Config configures an S3-compatible bucket
+type S3Config struct {
+	// Endpoint is the service URL, such as https://s3.eu-central-1.amazonaws.com or http://minio:9000
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// Prefix is prepended to every file name, to share a bucket
+	Prefix string
+	// PathStyle addresses the bucket in the path instead of the host name, which most self-hosted services need
+	PathStyle bool
+	// HTTPClient defaults to a client with a 30 second timeout
+	HTTPClient *http.Client
+}
+
+// S3 stores files in a bucket of an S3-compatible object storage, requests are signed with AWS Signature Version 4
+type S3 struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+var (
+	_ Storage = (*S3)(nil)
+	_ Signer  = (*S3)(nil)
+)
+
+// NewS3 returns a storage backed by a bucket, it does not contact the service until it is used
+func NewS3(config S3Config) (*S3, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("storage - NewS3: invalid endpoint %q", config.Endpoint)
+	}
+	if config.Bucket == "" || config.Region == "" {
+		return nil, errors.New("storage - NewS3: bucket and region are required")
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &S3{config: config, endpoint: endpoint, client: client}, nil
+}
+
+// objectURL returns the URL of a file, or of the bucket for an empty name
+func (s *S3) objectURL(name string) *url.URL {
+	u := *s.endpoint
+	key := ""
+	if name != "" {
+		key = s.config.Prefix + name
+	}
+	if s.config.PathStyle {
+		u.Path = path.Join("/", u.Path, s.config.Bucket, key)
+	} else {
+		u.Host = s.config.Bucket + "." + u.Host
+		u.Path = path.Join("/", u.Path, key)
+	}
+	return &u
+}
+
+// Create returns a writer for a new file.
+// Data goes to a temporary file that is uploaded on Close, so that the upload carries its length and checksum.
+func (s *S3) Create(name string) (io.WriteCloser, error) {
+	if err := validName(name); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp("", "s3-upload-*")
+	if err != nil {
+		return nil, err
+	}
+	return &s3File{File: f, hash: sha256.New(), storage: s, name: name}, nil
+}
+
+// Open returns a reader for a file
+func (s *S3) Open(name string) (io.ReadCloser, error) {
+	if err := validName(name); err != nil {
+		return nil, err
+	}
+	resp, err := s.do(context.Background(), http.MethodGet, s.objectURL(name), nil, 0, emptyPayloadHash, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, responseError("GET", name, resp)
+	}
+	return resp.Body, nil
+}
+
+// Remove deletes a file
+func (s *S3) Remove(name string) error {
+	if err := validName(name); err != nil {
+		return err
+	}
+	resp, err := s.do(context.Background(), http.MethodDelete, s.objectURL(name), nil, 0, emptyPayloadHash, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Deleting a missing key succeeds on S3, some compatible services answer 404
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return responseError("DELETE", name, resp)
+	}
+	return nil
+}
+
+// Check verifies that the bucket exists and the credentials can access it
+func (s *S3) Check(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodHead, s.objectURL(""), nil, 0, emptyPayloadHash, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("storage: HEAD bucket %s returned %s", s.config.Bucket, resp.Status)
+	}
+	return nil
+}
+
+// SignedURL returns a presigned URL that reads a file until it expires
+func (s *S3) SignedURL(name string, ttl time.Duration) (string, error) {
+	if err := validName(name); err != nil {
+		return "", err
+	}
+	if ttl <= 0 || ttl > _s3MaxPresignTTL {
+		return "", fmt.Errorf("storage: presigned URL expiry %s out of range", ttl)
+	}
+
+	u := s.objectURL(name)
+	now := time.Now().UTC()
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", _s3Algorithm)
+	query.Set("X-Amz-Credential", s.config.AccessKeyID+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(_amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		canonicalPath(u),
+		canonicalQuery(query),
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	query.Set("X-Amz-Signature", s.signature(now, canonical))
+	u.RawQuery = canonicalQuery(query)
+	return u.String(), nil
+}
+
+// do sends a request signed in the Authorization header
+func (s *S3) do(ctx context.Context, method string, u *url.URL, body io.Reader, length int64, payloadHash, contentType string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = length
+
+	now := time.Now().UTC()
+	headers := map[string]string{
+		"host":                 u.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           now.Format(_amzDateFormat),
+	}
+	if contentType != "" {
+		headers["content-type"] = contentType
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+		if name != "host" {
+			req.Header.Set(name, headers[name])
+		}
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		method,
+		canonicalPath(u),
+		canonicalQuery(u.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		_s3Algorithm, s.config.AccessKeyID, s.scope(now), signedHeaders, s.signature(now, canonical)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("storage - S3 %s: %w", method, err)
+	}
+	return resp, nil
+}
+
+const _amzDateFormat = "20060102T150405Z"
+
+// emptyPayloadHash is the SHA-256 of an empty body
+var emptyPayloadHash = hex.EncodeToString(sha256.New().Sum(nil))
+
+func (s *S3) scope(t time.Time) string {
+	return t.Format("20060102") + "/" + s.config.Region + "/" + _s3Service + "/aws4_request"
+}
+
+// signature signs a canonical request with the key derived for the day, region and service
+func (s *S3) signature(t time.Time, canonicalRequest string) string {
+	sum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		_s3Algorithm,
+		t.Format(_amzDateFormat),
+		s.scope(t),
+		hex.EncodeToString(sum[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), t.Format("20060102"))
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, _s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalPath URI-encodes each segment of the path once, as S3 expects
+func canonicalPath(u *url.URL) string {
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery encodes parameters sorted by name, with spaces as %20
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var pairs []string
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(name)+"="+uriEncode(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything but the unreserved characters of RFC 3986
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func responseError(method, name string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("storage: %s %s returned %s: %s", method, name, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// s3File buffers a file in a temporary file and uploads it when it is closed
+type s3File struct {
+	*os.File
+	hash    hash.Hash
+	storage *S3
+	name    string
+	size    int64
+}
+
+func (f *s3File) Write(p []byte) (int, error) {
+	n, err := f.File.Write(p)
+	f.hash.Write(p[:n])
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *s3File) Close() error {
+	defer os.Remove(f.File.Name())
+	defer f.File.Close()
+
+	if _, err := f.File.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(f.name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	resp, err := f.storage.do(context.Background(), http.MethodPut, f.storage.objectURL(f.name), io.NopCloser(f.File), f.size,
+		hex.EncodeToString(f.hash.Sum(nil)), contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError("PUT", f.name, resp)
+	}
+	return nil
+}
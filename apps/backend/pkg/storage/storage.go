@@ -0,0 +1,41 @@
+// Package storage implements file storage.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a file does not exist
+var ErrNotFound = errors.New("storage: file not found")
+
+// Storage stores files by name
+type Storage interface {
+	// Create returns a writer for a new file, the file becomes visible once the writer is closed
+	Create(name string) (io.WriteCloser, error)
+	// Open returns a reader for a file, ErrNotFound if it does not exist
+	Open(name string) (io.ReadCloser, error)
+	// Remove deletes a file, removing a file that does not exist is not an error
+	Remove(name string) error
+	// Check reports whether the backend can be used
+	Check(ctx context.Context) error
+}
+
+// Signer is implemented by storages that hand out URLs to read a file directly from the backend
+type Signer interface {
+	// SignedURL returns a URL that reads a file until the TTL has passed
+	SignedURL(name string, ttl time.Duration) (string, error)
+}
+
+// validName checks that a file name has no path separators
+func validName(name string) error {
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." || strings.Contains(name, "\\") {
+		return fmt.Errorf("storage: invalid file name %q", name)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package totp
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors, "12345678901234567890" in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestCode runs the SHA-1 test vectors of RFC 6238, appendix B, truncated to six digits
+func TestCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code() error = %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCode_InvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("Code() error = %v, want %v", err, ErrInvalidSecret)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code := func(at time.Time) string {
+		c, err := Code(rfcSecret, Step(at))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name   string
+		code   string
+		wantOK bool
+		want   int64
+	}{
+		{name: "current step", code: code(now), wantOK: true, want: Step(now)},
+		{name: "previous step", code: code(now.Add(-Period)), wantOK: true, want: Step(now) - 1},
+		{name: "next step", code: code(now.Add(Period)), wantOK: true, want: Step(now) + 1},
+		{name: "beyond the skew", code: code(now.Add(-2 * Period))},
+		{name: "wrong code", code: "000000"},
+		{name: "too short", code: "12345"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok, err := Validate(rfcSecret, tt.code, now, 1)
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if ok != tt.wantOK || step != tt.want {
+				t.Errorf("Validate() = %d, %v, want %d, %v", step, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Errorf("GenerateSecret() returned %s twice", a)
+	}
+	if _, err := Code(a, 1); err != nil {
+		t.Errorf("Code() with a generated secret error = %v", err)
+	}
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("Rebound", "alice@example.com", rfcSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Rebound:alice@example.com" {
+		t.Errorf("URI() = %s, want otpauth://totp/Rebound:alice@example.com", u)
+	}
+	q := u.Query()
+	for key, want := range map[string]string{"secret": rfcSecret, "issuer": "Rebound", "algorithm": "SHA1", "digits": "6", "period": "30"} {
+		if got := q.Get(key); got != want {
+			t.Errorf("URI() %s = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package tracing
+
+// Option -.
+type Option func(*Tracing)
+
+// Exporter sets where spans are exported, one of none, stdout or otlp
+func Exporter(name string) Option {
+	return func(t *Tracing) {
+		t.exporter = name
+	}
+}
+
+// Endpoint sets the host and port of the OTLP HTTP collector
+func Endpoint(endpoint string) Option {
+	return func(t *Tracing) {
+		t.endpoint = endpoint
+	}
+}
+
+// Insecure disables TLS towards the OTLP collector
+func Insecure(insecure bool) Option {
+	return func(t *Tracing) {
+		t.insecure = insecure
+	}
+}
+
+// SampleRatio sets the share of traces that are recorded, from 0 to 1
+func SampleRatio(ratio float64) Option {
+	return func(t *Tracing) {
+		t.sampleRatio = ratio
+	}
+}
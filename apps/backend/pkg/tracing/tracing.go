@@ -0,0 +1,118 @@
+// Package tracing implements OpenTelemetry tracing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+
+	_defaultExporter    = ExporterNone
+	_defaultEndpoint    = "localhost:4318"
+	_defaultSampleRatio = 1
+
+	instrumentationName = "github.com/terrnit/rebound/backend"
+)
+
+// contextKey is the type of ContextKey
+type contextKey struct{}
+
+// ContextKey is the key under which contexts that cannot be derived from, such as a request's locals, hold the current span
+var ContextKey = contextKey{}
+
+// Tracing -.
+type Tracing struct {
+	exporter    string
+	endpoint    string
+	insecure    bool
+	sampleRatio float64
+
+	provider *sdktrace.TracerProvider
+}
+
+// New installs the global tracer provider and the W3C trace context propagator.
+// With the none exporter spans are not recorded, but trace context is still propagated.
+func New(serviceName, version string, opts ...Option) (*Tracing, error) {
+	t := &Tracing{
+		exporter:    _defaultExporter,
+		endpoint:    _defaultEndpoint,
+		sampleRatio: _defaultSampleRatio,
+	}
+
+	// Custom options
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch t.exporter {
+	case ExporterNone:
+		return t, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		httpOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(t.endpoint)}
+		if t.insecure {
+			httpOpts = append(httpOpts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(context.Background(), httpOpts...)
+	default:
+		return nil, fmt.Errorf("tracing - New - unknown exporter %q", t.exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tracing - New - %s exporter: %w", t.exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("tracing - New - resource.Merge: %w", err)
+	}
+
+	t.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(t.sampleRatio))),
+	)
+	otel.SetTracerProvider(t.provider)
+
+	return t, nil
+}
+
+// Shutdown flushes the spans that were not exported yet
+func (t *Tracing) Shutdown(ctx context.Context) error {
+	if t.provider == nil {
+		return nil
+	}
+	return t.provider.Shutdown(ctx)
+}
+
+// Start starts a span that is a child of the current span of ctx, which is looked up under ContextKey too
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		if span, ok := ctx.Value(ContextKey).(trace.Span); ok {
+			ctx = trace.ContextWithSpan(ctx, span)
+		}
+	}
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}